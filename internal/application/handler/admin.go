@@ -0,0 +1,511 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/rayhan889/neatspace/internal/application/constants"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	"github.com/rayhan889/neatspace/internal/application/middlewares"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/internal/audit"
+	userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+type AdminHandlerInterface interface {
+	SearchUsers(c *fiber.Ctx) error
+	GetUser(c *fiber.Ctx) error
+	UpdateUserRole(c *fiber.Ctx) error
+	VerifyUserEmail(c *fiber.Ctx) error
+	DeactivateUser(c *fiber.Ctx) error
+	ReactivateUser(c *fiber.Ctx) error
+	ForcePasswordReset(c *fiber.Ctx) error
+	RevokeUserSessions(c *fiber.Ctx) error
+	StartImpersonation(c *fiber.Ctx) error
+	EndImpersonation(c *fiber.Ctx) error
+	UserLoginHistory(c *fiber.Ctx) error
+	AuditEvents(c *fiber.Ctx) error
+}
+
+var _ AdminHandlerInterface = (*AdminHandler)(nil)
+
+type AdminHandler struct {
+	userService  services.UserServiceInterface
+	authService  services.AuthServiceInterface
+	adminService services.AdminServiceInterface
+}
+
+type AdminHandlerOpts struct {
+	RouteGroup   fiber.Router
+	UserService  services.UserServiceInterface
+	AuthService  services.AuthServiceInterface
+	AdminService services.AdminServiceInterface
+	JWTSecretKey []byte
+	SigningAlg   jwa.SignatureAlgorithm
+	Sessions     middlewares.SessionValidator
+}
+
+func NewAdminHandler(opts AdminHandlerOpts) {
+	h := &AdminHandler{
+		userService:  opts.UserService,
+		authService:  opts.AuthService,
+		adminService: opts.AdminService,
+	}
+
+	g := opts.RouteGroup.Group("/admin",
+		middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg, opts.Sessions),
+		middlewares.RequireRole(opts.UserService, userEntity.RoleAdmin),
+	)
+	g.Get("/users", h.SearchUsers)
+	g.Get("/users/:userId", h.GetUser)
+	g.Patch("/users/:userId/role", middlewares.ValidateRequestJSON[dto.UpdateUserRoleRequest](), h.UpdateUserRole)
+	g.Post("/users/:userId/verify-email", h.VerifyUserEmail)
+	g.Post("/users/:userId/deactivate", h.DeactivateUser)
+	g.Post("/users/:userId/reactivate", h.ReactivateUser)
+	g.Post("/users/:userId/password-reset", h.ForcePasswordReset)
+	g.Delete("/users/:userId/sessions", h.RevokeUserSessions)
+	g.Get("/users/:userId/login-history", h.UserLoginHistory)
+	g.Post("/users/:userId/impersonate", h.StartImpersonation)
+	g.Get("/audit-events", h.AuditEvents)
+
+	// Called with the impersonation token itself, whose subject is the impersonated user
+	opts.RouteGroup.Delete("/impersonation", middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg, opts.Sessions), h.EndImpersonation)
+}
+
+// SearchUsers godoc
+// @Summary 		Search Users
+// @Description 	Paginate through all user accounts, newest first (admin only)
+// @Tags 			Admin
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			page		query	int		false	"Page number (default: 1, min: 1)"				default(1)		minimum(1)
+// @Param			per_page	query	int		false	"Items per page (default: 10, max: 100)"		default(10)		minimum(1)	maximum(100)
+// @Param			search		query	string	false	"Search by display name, username or email"
+// @Param			role		query	string	false	"Filter by role"								Enums(user, admin)
+// @Param			status		query	string	false	"Filter by account status"						Enums(active, deactivated, pending_deletion, unverified)
+// @Success      	200   {object}  apputils.PaginationResponse[dto.AdminUserItem]
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	422   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/admin/users [get]
+func (h *AdminHandler) SearchUsers(c *fiber.Ctx) error {
+	p := apputils.Paginate(c)
+
+	filter := &userEntity.AdminUserFilter{
+		Search: c.Query("search"),
+		Role:   c.Query("role"),
+		Status: c.Query("status"),
+	}
+
+	data, total, err := h.adminService.SearchUsers(c.Context(), filter, p)
+	if err != nil {
+		return err
+	}
+
+	meta := apputils.PaginationMetaBuilder(c, total)
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(apputils.PaginationBuilder(data, *meta)))
+}
+
+// GetUser godoc
+// @Summary 		Get User
+// @Description 	Get a user account with its sessions and note count (admin only, audited)
+// @Tags 			Admin
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			userId		path	string	true	"User ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.AdminUserDetail}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/admin/users/{userId} [get]
+func (h *AdminHandler) GetUser(c *fiber.Ctx) error {
+	actorID, userID, err := h.actorAndTarget(c)
+	if err != nil {
+		return err
+	}
+
+	detail, err := h.adminService.GetUserDetail(clientContext(c), actorID, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(detail))
+}
+
+// UpdateUserRole godoc
+// @Summary 		Update User Role
+// @Description 	Change the role of a user. Admins can't change their own role (admin only, audited)
+// @Tags 			Admin
+// @Accept 			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			userId		path	string						true	"User ID (UUID)"
+// @Param			request		body	dto.UpdateUserRoleRequest	true	"New role"
+// @Success      	200   {object}  apputils.BaseResponse
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/admin/users/{userId}/role [patch]
+func (h *AdminHandler) UpdateUserRole(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.UpdateUserRoleRequest)
+
+	actorID, userID, err := h.actorAndTarget(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.adminService.UpdateUserRole(clientContext(c), actorID, userID, req.Role); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "User role updated successfully",
+	}))
+}
+
+// VerifyUserEmail godoc
+// @Summary 		Verify User Email
+// @Description 	Mark the email address of a user as verified without the verification link (admin only, audited)
+// @Tags 			Admin
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			userId		path	string	true	"User ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/admin/users/{userId}/verify-email [post]
+func (h *AdminHandler) VerifyUserEmail(c *fiber.Ctx) error {
+	actorID, userID, err := h.actorAndTarget(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.adminService.VerifyUserEmail(clientContext(c), actorID, userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "User email verified successfully",
+	}))
+}
+
+// DeactivateUser godoc
+// @Summary 		Deactivate User
+// @Description 	Block sign in for a user and revoke all of their sessions (admin only, audited)
+// @Tags 			Admin
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			userId		path	string	true	"User ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/admin/users/{userId}/deactivate [post]
+func (h *AdminHandler) DeactivateUser(c *fiber.Ctx) error {
+	actorID, userID, err := h.actorAndTarget(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.adminService.DeactivateUser(clientContext(c), actorID, userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "User deactivated successfully",
+	}))
+}
+
+// ReactivateUser godoc
+// @Summary 		Reactivate User
+// @Description 	Allow a deactivated user to sign in again, cancelling any pending account deletion (admin only, audited)
+// @Tags 			Admin
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			userId		path	string	true	"User ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/admin/users/{userId}/reactivate [post]
+func (h *AdminHandler) ReactivateUser(c *fiber.Ctx) error {
+	actorID, userID, err := h.actorAndTarget(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.adminService.ReactivateUser(clientContext(c), actorID, userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "User reactivated successfully",
+	}))
+}
+
+// ForcePasswordReset godoc
+// @Summary 		Force Password Reset
+// @Description 	Block the current password of a user, revoke all of their sessions and email them a reset link (admin only, audited)
+// @Tags 			Admin
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			userId		path	string	true	"User ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/admin/users/{userId}/password-reset [post]
+func (h *AdminHandler) ForcePasswordReset(c *fiber.Ctx) error {
+	actorID, userID, err := h.actorAndTarget(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.adminService.ForcePasswordReset(clientContext(c), actorID, userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "Password reset email sent successfully",
+	}))
+}
+
+// RevokeUserSessions godoc
+// @Summary 		Revoke User Sessions
+// @Description 	Sign a user out of every device by revoking all of their sessions (admin only, audited)
+// @Tags 			Admin
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			userId		path	string	true	"User ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/admin/users/{userId}/sessions [delete]
+func (h *AdminHandler) RevokeUserSessions(c *fiber.Ctx) error {
+	actorID, userID, err := h.actorAndTarget(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.adminService.RevokeUserSessions(clientContext(c), actorID, userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "User sessions revoked successfully",
+	}))
+}
+
+// UserLoginHistory godoc
+// @Summary 		User Login History
+// @Description 	Paginate through the sign-in attempts of a user, newest first (admin only)
+// @Tags 			Admin
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			userId		path	string	true	"User ID (UUID)"
+// @Param			page		query	int		false	"Page number (default: 1, min: 1)"				default(1)		minimum(1)
+// @Param			per_page	query	int		false	"Items per page (default: 10, max: 100)"		default(10)		minimum(1)	maximum(100)
+// @Success      	200   {object}  apputils.PaginationResponse[dto.LoginHistoryItem]
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/admin/users/{userId}/login-history [get]
+func (h *AdminHandler) UserLoginHistory(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid user id")
+	}
+
+	if !h.userService.IsUserExistsByID(c.Context(), userID) {
+		return fiber.NewError(fiber.StatusNotFound, "user not found")
+	}
+
+	p := apputils.Paginate(c)
+
+	data, total, err := h.authService.PaginationLoginHistory(c.Context(), userID, p)
+	if err != nil {
+		return err
+	}
+
+	meta := apputils.PaginationMetaBuilder(c, total)
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(apputils.PaginationBuilder(data, *meta)))
+}
+
+// StartImpersonation godoc
+// @Summary 		Start Impersonation
+// @Description 	Issue a short-lived access token to act as the user. The token can't change the password or email, or delete the account, and no refresh token is issued (admin only, audited)
+// @Tags 			Admin
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			userId		path	string	true	"User ID (UUID)"
+// @Success      	201   {object}  apputils.BaseResponse{data=dto.ImpersonationResponse}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/admin/users/{userId}/impersonate [post]
+func (h *AdminHandler) StartImpersonation(c *fiber.Ctx) error {
+	actorID, userID, err := h.actorAndTarget(c)
+	if err != nil {
+		return err
+	}
+
+	impersonation, err := h.adminService.StartImpersonation(clientContext(c), actorID, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(apputils.SuccessResponse(impersonation))
+}
+
+// EndImpersonation godoc
+// @Summary 		End Impersonation
+// @Description 	Revoke the impersonation session of the token used for this request (audited)
+// @Tags 			Admin
+// @Produce 		json
+// @Security		BearerAuth
+// @Success      	200   {object}  apputils.BaseResponse
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/impersonation [delete]
+func (h *AdminHandler) EndImpersonation(c *fiber.Ctx) error {
+	impersonatorID, ok := c.Locals("impersonator_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusBadRequest, "token is not an impersonation token")
+	}
+
+	actorID, err := uuid.Parse(impersonatorID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid actor in token")
+	}
+
+	sid, _ := c.Locals("session_id").(string)
+	sessionID, err := uuid.Parse(sid)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "missing session in token")
+	}
+
+	if err := h.adminService.EndImpersonation(clientContext(c), actorID, sessionID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "Impersonation ended successfully",
+	}))
+}
+
+// AuditEvents godoc
+// @Summary 		Audit Events
+// @Description 	Paginate through the audit trail of sign-ins, credential changes, admin actions and deletions, newest first (admin only)
+// @Tags 			Admin
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			page		query	int		false	"Page number (default: 1, min: 1)"				default(1)		minimum(1)
+// @Param			per_page	query	int		false	"Items per page (default: 10, max: 100)"		default(10)		minimum(1)	maximum(100)
+// @Param			actor_id	query	string	false	"Filter by actor user ID (UUID)"
+// @Param			action		query	string	false	"Filter by action, e.g. auth.sign_in or user.deactivate"
+// @Param			target_type	query	string	false	"Filter by target type"							Enums(user, note, workspace)
+// @Param			target_id	query	string	false	"Filter by target ID (UUID)"
+// @Param			from		query	string	false	"Only events at or after this time (RFC 3339)"
+// @Param			to			query	string	false	"Only events before this time (RFC 3339)"
+// @Success      	200   {object}  apputils.PaginationResponse[dto.AuditEventItem]
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/admin/audit-events [get]
+func (h *AdminHandler) AuditEvents(c *fiber.Ctx) error {
+	filter := &audit.Filter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+	}
+
+	if v := c.Query("actor_id"); v != "" {
+		actorID, err := uuid.Parse(v)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid actor id")
+		}
+		filter.ActorID = &actorID
+	}
+	if v := c.Query("target_id"); v != "" {
+		targetID, err := uuid.Parse(v)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid target id")
+		}
+		filter.TargetID = &targetID
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid from time, expected RFC 3339")
+		}
+		filter.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid to time, expected RFC 3339")
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return fiber.NewError(fiber.StatusBadRequest, "from must be before to")
+	}
+
+	p := apputils.Paginate(c)
+
+	data, total, err := h.adminService.PaginationAuditEvents(c.Context(), filter, p)
+	if err != nil {
+		return err
+	}
+
+	meta := apputils.PaginationMetaBuilder(c, total)
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(apputils.PaginationBuilder(data, *meta)))
+}
+
+// actorAndTarget returns the signed-in admin and the user from the :userId path param
+func (h *AdminHandler) actorAndTarget(c *fiber.Ctx) (actorID, userID uuid.UUID, err error) {
+	userID, err = uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "invalid user id")
+	}
+
+	actorID = apputils.UUIDChecker(c.Locals("user_id").(string))
+
+	return actorID, userID, nil
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/rayhan889/neatspace/internal/application/middlewares"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+type AttachmentHandlerInterface interface {
+	UploadAttachment(c *fiber.Ctx) error
+	ListNoteAttachments(c *fiber.Ctx) error
+	GetAttachmentUsage(c *fiber.Ctx) error
+	GetAttachment(c *fiber.Ctx) error
+	DownloadAttachment(c *fiber.Ctx) error
+}
+
+var _ AttachmentHandlerInterface = (*AttachmentHandler)(nil)
+
+type AttachmentHandler struct {
+	attachmentService services.AttachmentServiceInterface
+}
+
+type AttachmentHandlerOpts struct {
+	RouteGroup        fiber.Router
+	AttachmentService services.AttachmentServiceInterface
+	JWTSecretKey      []byte
+	SigningAlg        jwa.SignatureAlgorithm
+	Sessions          middlewares.SessionValidator
+}
+
+func NewAttachmentHandler(opts AttachmentHandlerOpts) {
+	h := &AttachmentHandler{
+		attachmentService: opts.AttachmentService,
+	}
+
+	// Authorized by the signed link, not by a session. Registered ahead of the group below
+	// so its JWT middleware never runs for it, images can't send an Authorization header.
+	opts.RouteGroup.Get("/attachments/:attachmentId/content", h.DownloadAttachment)
+
+	privateGroup := opts.RouteGroup.Group("/attachments", middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg, opts.Sessions))
+	privateGroup.Post("", h.UploadAttachment)
+	privateGroup.Get("", h.ListNoteAttachments)
+	privateGroup.Get("/usage", h.GetAttachmentUsage)
+	privateGroup.Get("/:attachmentId", h.GetAttachment)
+}
+
+// UploadAttachment godoc
+// @Summary 		Upload Attachment
+// @Description 	Upload a file to a note, requires the edit permission on it. The type is sniffed from the content and limited to common images, PDF, ZIP, plain text, audio and video. Put the returned src in an image node to show the file in the note
+// @Tags 			Attachments
+// @Accept			multipart/form-data
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			note_id	formData	string	true	"Note ID (UUID)"
+// @Param			file	formData	file	true	"File to upload"
+// @Success      	201   {object}  apputils.BaseResponse{data=dto.AttachmentItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	413   {object}  apputils.BaseResponse
+// @Failure      	415   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/attachments [post]
+func (h *AttachmentHandler) UploadAttachment(c *fiber.Ctx) error {
+	noteID, err := uuid.Parse(c.FormValue("note_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "expected a multipart form with a file field")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "can't read the uploaded file")
+	}
+	defer file.Close()
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	attachment, err := h.attachmentService.UploadAttachment(c.Context(), userIDUUID, noteID, services.AttachmentUpload{
+		FileName: header.Filename,
+		Size:     header.Size,
+		Content:  file,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(apputils.SuccessResponse(attachment))
+}
+
+// ListNoteAttachments godoc
+// @Summary 		List Note Attachments
+// @Description 	List the files uploaded to a note, oldest first, each with a fresh signed download link
+// @Tags 			Attachments
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			note_id	query	string	true	"Note ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse{data=[]dto.AttachmentItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/attachments [get]
+func (h *AttachmentHandler) ListNoteAttachments(c *fiber.Ctx) error {
+	noteID, err := uuid.Parse(c.Query("note_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	attachments, err := h.attachmentService.ListNoteAttachments(c.Context(), userIDUUID, noteID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(attachments))
+}
+
+// GetAttachmentUsage godoc
+// @Summary 		Get Attachment Usage
+// @Description 	Get how much of the attachment storage quota the signed-in user's uploads take up
+// @Tags 			Attachments
+// @Produce 		json
+// @Security		BearerAuth
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.AttachmentUsage}
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/attachments/usage [get]
+func (h *AttachmentHandler) GetAttachmentUsage(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	usage, err := h.attachmentService.GetUsage(c.Context(), userIDUUID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(usage))
+}
+
+// GetAttachment godoc
+// @Summary 		Get Attachment
+// @Description 	Get an attachment with a fresh signed download link. Anyone who can view the note it was uploaded to, or a note showing it, has access
+// @Tags 			Attachments
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			attachmentId	path	string	true	"Attachment ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.AttachmentItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/attachments/{attachmentId} [get]
+func (h *AttachmentHandler) GetAttachment(c *fiber.Ctx) error {
+	attachmentID, err := uuid.Parse(c.Params("attachmentId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid attachment id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	attachment, err := h.attachmentService.GetAttachment(c.Context(), userIDUUID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(attachment))
+}
+
+// DownloadAttachment godoc
+// @Summary 		Download Attachment
+// @Description 	Download an attachment using a signed link. Images are served inline, other files as downloads
+// @Tags 			Attachments
+// @Produce 		octet-stream
+// @Param			attachmentId	path	string	true	"Attachment ID (UUID)"
+// @Param			expires			query	string	true	"Link expiry (unix seconds)"
+// @Param			signature		query	string	true	"Link signature"
+// @Success      	200   {file}    file
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	410   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/attachments/{attachmentId}/content [get]
+func (h *AttachmentHandler) DownloadAttachment(c *fiber.Ctx) error {
+	attachmentID, err := uuid.Parse(c.Params("attachmentId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid attachment id")
+	}
+
+	download, err := h.attachmentService.OpenAttachment(c.Context(), attachmentID, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		return err
+	}
+
+	disposition := "attachment"
+	if strings.HasPrefix(download.ContentType, "image/") {
+		disposition = "inline"
+	}
+
+	if header := mime.FormatMediaType(disposition, map[string]string{"filename": download.FileName}); header != "" {
+		disposition = header
+	}
+
+	c.Set(fiber.HeaderContentType, download.ContentType)
+	c.Set(fiber.HeaderContentDisposition, disposition)
+	// Files are never changed, only the signature in the link does
+	c.Set(fiber.HeaderCacheControl, "private, max-age=3600, immutable")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'none'; sandbox")
+	return c.Status(fiber.StatusOK).SendStream(download.Content, int(download.Size))
+}
@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/rayhan889/neatspace/internal/application/constants"
 	"github.com/rayhan889/neatspace/internal/application/handler/dto"
@@ -19,6 +21,12 @@ type AuthHandlerInterface interface {
 	SignInWithEmail(c *fiber.Ctx) error
 	SetUserPassword(c *fiber.Ctx) error
 	UpdateUserPassword(c *fiber.Ctx) error
+	ConfirmEmailChange(c *fiber.Ctx) error
+	CancelEmailChange(c *fiber.Ctx) error
+	SendReauthenticationOTP(c *fiber.Ctx) error
+	Reauthenticate(c *fiber.Ctx) error
+	CancelAccountDeletion(c *fiber.Ctx) error
+	ResetPassword(c *fiber.Ctx) error
 }
 
 var _ AuthHandlerInterface = (*AuthHandler)(nil)
@@ -32,6 +40,8 @@ type AuthHandlerOpts struct {
 	AuthService  services.AuthServiceInterface
 	JWTSecretKey []byte
 	SigningAlg   jwa.SignatureAlgorithm
+	Sessions     middlewares.SessionValidator
+	ReauthWindow time.Duration
 }
 
 func NewAuthHandler(opts AuthHandlerOpts) {
@@ -43,10 +53,16 @@ func NewAuthHandler(opts AuthHandlerOpts) {
 	publicGroup.Post("/verification/email/initiate", middlewares.ValidateRequestJSON[dto.InitiateEmailVerificationRequest](), h.InitiateEmailVerification)
 	publicGroup.Post("/verification/email/validate", middlewares.ValidateRequestJSON[dto.ValidateEmailVerificationRequest](), h.ValidateEmailVerification)
 	publicGroup.Post("/signin/email", middlewares.ValidateRequestJSON[dto.SignInWithEmailRequest](), h.SignInWithEmail)
+	publicGroup.Post("/email/change/confirm", middlewares.ValidateRequestJSON[dto.EmailChangeTokenRequest](), h.ConfirmEmailChange)
+	publicGroup.Post("/email/change/cancel", middlewares.ValidateRequestJSON[dto.EmailChangeTokenRequest](), h.CancelEmailChange)
+	publicGroup.Post("/account/deletion/cancel", middlewares.ValidateRequestJSON[dto.CancelAccountDeletionRequest](), h.CancelAccountDeletion)
+	publicGroup.Post("/password/reset", middlewares.ValidateRequestJSON[dto.ResetPasswordRequest](), h.ResetPassword)
 
-	privateGroup := publicGroup.Group("", middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg))
-	privateGroup.Post("/password", middlewares.ValidateRequestJSON[dto.SetUserPasswordRequest](), h.SetUserPassword)
-	privateGroup.Patch("/password/:userId", middlewares.ValidateRequestJSON[dto.UpdatePasswordRequest](), h.UpdateUserPassword)
+	privateGroup := publicGroup.Group("", middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg, opts.Sessions))
+	privateGroup.Post("/password", middlewares.RejectImpersonation(), middlewares.ValidateRequestJSON[dto.SetUserPasswordRequest](), h.SetUserPassword)
+	privateGroup.Patch("/password/:userId", middlewares.RequireRecentAuth(opts.AuthService, opts.ReauthWindow), middlewares.ValidateRequestJSON[dto.UpdatePasswordRequest](), h.UpdateUserPassword)
+	privateGroup.Post("/reauthenticate", middlewares.RejectImpersonation(), middlewares.ValidateRequestJSON[dto.ReauthenticateRequest](), h.Reauthenticate)
+	privateGroup.Post("/reauthenticate/otp", middlewares.RejectImpersonation(), h.SendReauthenticationOTP)
 }
 
 // InitiateEmailVerification godoc
@@ -89,7 +105,7 @@ func (h *AuthHandler) InitiateEmailVerification(c *fiber.Ctx) error {
 func (h *AuthHandler) ValidateEmailVerification(c *fiber.Ctx) error {
 	req := c.Locals(constants.RequestBodyJSONKey).(*dto.ValidateEmailVerificationRequest)
 
-	err := h.authService.ValidateEmailVerification(c.Context(), req)
+	err := h.authService.ValidateEmailVerification(clientContext(c), req)
 	if err != nil {
 		return err
 	}
@@ -114,7 +130,7 @@ func (h *AuthHandler) ValidateEmailVerification(c *fiber.Ctx) error {
 func (h *AuthHandler) SignInWithEmail(c *fiber.Ctx) error {
 	req := c.Locals(constants.RequestBodyJSONKey).(*dto.SignInWithEmailRequest)
 
-	authUser, err := h.authService.SignInWithEmail(c.Context(), req)
+	authUser, err := h.authService.SignInWithEmail(clientContext(c), req)
 	if err != nil {
 		return err
 	}
@@ -127,7 +143,7 @@ func (h *AuthHandler) SignInWithEmail(c *fiber.Ctx) error {
 
 // SetUserPassword godoc
 // @Summary		Set User Password
-// @Description	Set password for a user account (requires authentication)
+// @Description	Set the first password of the signed-in user's account, user_id must be the signed-in user. An existing password is changed with the update route instead
 // @Tags			Authentication
 // @Accept			json
 // @Produce			json
@@ -136,20 +152,26 @@ func (h *AuthHandler) SignInWithEmail(c *fiber.Ctx) error {
 // @Success		200	{object}	apputils.BaseResponse
 // @Failure		400	{object}	apputils.BaseResponse
 // @Failure		401	{object}	apputils.BaseResponse
+// @Failure		403	{object}	apputils.BaseResponse
 // @Failure		404	{object}	apputils.BaseResponse
+// @Failure		409	{object}	apputils.BaseResponse
+// @Failure		422	{object}	apputils.BaseResponse
 // @Failure		500	{object}	apputils.BaseResponse
 // @Router			/api/v1/auth/password [post]
 func (h *AuthHandler) SetUserPassword(c *fiber.Ctx) error {
 	req := c.Locals(constants.RequestBodyJSONKey).(*dto.SetUserPasswordRequest)
 
-	userID := apputils.UUIDChecker(req.UserID)
+	userID, err := tokenUserMatches(c, req.UserID)
+	if err != nil {
+		return err
+	}
 	userPassword := &authEntity.UserPasswordEntity{
 		UserID:       userID,
 		PasswordHash: []byte(req.Password),
 		CreatedAt:    time.Now(),
 	}
 
-	err := h.authService.SetUserPassword(c.Context(), userPassword)
+	err = h.authService.SetUserPassword(clientContext(c), userPassword)
 	if err != nil {
 		return err
 	}
@@ -161,7 +183,7 @@ func (h *AuthHandler) SetUserPassword(c *fiber.Ctx) error {
 
 // UpdateUserPassword godoc
 // @Summary		Update User Password
-// @Description	Update password for a specific user (requires authentication)
+// @Description	Update the password of the signed-in user, userId must be the signed-in user (requires recent authentication)
 // @Tags			Authentication
 // @Accept			json
 // @Produce			json
@@ -171,16 +193,20 @@ func (h *AuthHandler) SetUserPassword(c *fiber.Ctx) error {
 // @Success		200	{object}	apputils.BaseResponse
 // @Failure		400	{object}	apputils.BaseResponse
 // @Failure		401	{object}	apputils.BaseResponse
+// @Failure		403	{object}	apputils.BaseResponse
 // @Failure		404	{object}	apputils.BaseResponse
+// @Failure		422	{object}	apputils.BaseResponse
 // @Failure		500	{object}	apputils.BaseResponse
 // @Router			/api/v1/auth/password/{userId} [patch]
 func (h *AuthHandler) UpdateUserPassword(c *fiber.Ctx) error {
 	req := c.Locals(constants.RequestBodyJSONKey).(*dto.UpdatePasswordRequest)
 
-	userID := c.Params("userId")
-	userUUID := apputils.UUIDChecker(userID)
+	userUUID, err := tokenUserMatches(c, c.Params("userId"))
+	if err != nil {
+		return err
+	}
 
-	err := h.authService.UpdateUserPassword(c.Context(), req.Password, userUUID)
+	err = h.authService.UpdateUserPassword(clientContext(c), req.Password, userUUID)
 	if err != nil {
 		return err
 	}
@@ -189,3 +215,182 @@ func (h *AuthHandler) UpdateUserPassword(c *fiber.Ctx) error {
 		"message": "Successfully update user password",
 	}))
 }
+
+// tokenUserMatches returns the signed-in user, rejecting requests naming another user's
+// account, password routes only ever act on the caller's own credentials
+func tokenUserMatches(c *fiber.Ctx, userID string) (uuid.UUID, error) {
+	tokenUserID := apputils.UUIDChecker(c.Locals("user_id").(string))
+	if id, err := uuid.Parse(userID); err != nil || id != tokenUserID {
+		return uuid.Nil, fiber.NewError(fiber.StatusForbidden, "not allowed to change the password of another user")
+	}
+
+	return tokenUserID, nil
+}
+
+// ConfirmEmailChange godoc
+// @Summary		Confirm Email Change
+// @Description	Confirm a pending email change with the token sent to the new address. The emailed link opens a page of the app that posts the token
+// @Tags			Authentication
+// @Accept			json
+// @Produce			json
+// @Param			body	body	dto.EmailChangeTokenRequest	true	"Email change confirmation token"
+// @Success		200	{object}	apputils.BaseResponse
+// @Failure		400	{object}	apputils.BaseResponse
+// @Failure		401	{object}	apputils.BaseResponse
+// @Failure		409	{object}	apputils.BaseResponse
+// @Failure		500	{object}	apputils.BaseResponse
+// @Router			/api/v1/auth/email/change/confirm [post]
+func (h *AuthHandler) ConfirmEmailChange(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.EmailChangeTokenRequest)
+
+	err := h.authService.ConfirmEmailChange(clientContext(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "Email changed successfully",
+	}))
+}
+
+// CancelEmailChange godoc
+// @Summary		Cancel Email Change
+// @Description	Cancel a pending email change with the token sent to the current address. The emailed link opens a page of the app that posts the token
+// @Tags			Authentication
+// @Accept			json
+// @Produce			json
+// @Param			body	body	dto.EmailChangeTokenRequest	true	"Email change cancel token"
+// @Success		200	{object}	apputils.BaseResponse
+// @Failure		400	{object}	apputils.BaseResponse
+// @Failure		401	{object}	apputils.BaseResponse
+// @Failure		500	{object}	apputils.BaseResponse
+// @Router			/api/v1/auth/email/change/cancel [post]
+func (h *AuthHandler) CancelEmailChange(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.EmailChangeTokenRequest)
+
+	err := h.authService.CancelEmailChange(c.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "Email change cancelled successfully",
+	}))
+}
+
+// SendReauthenticationOTP godoc
+// @Summary		Send Re-authentication Code
+// @Description	Email a one-time code the current user can use to re-authenticate, replacing the previous one. A code can be requested once a minute and 5 times an hour (requires authentication)
+// @Tags			Authentication
+// @Produce			json
+// @Security		BearerAuth
+// @Success		200	{object}	apputils.BaseResponse
+// @Failure		401	{object}	apputils.BaseResponse
+// @Failure		403	{object}	apputils.BaseResponse
+// @Failure		404	{object}	apputils.BaseResponse
+// @Failure		429	{object}	apputils.BaseResponse
+// @Failure		500	{object}	apputils.BaseResponse
+// @Router			/api/v1/auth/reauthenticate/otp [post]
+func (h *AuthHandler) SendReauthenticationOTP(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	err := h.authService.SendReauthenticationOTP(c.Context(), userIDUUID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "Verification code sent successfully",
+	}))
+}
+
+// Reauthenticate godoc
+// @Summary		Re-authenticate
+// @Description	Confirm the current user's identity with their password or an emailed code, unlocking sensitive operations for a limited time (requires authentication)
+// @Tags			Authentication
+// @Accept			json
+// @Produce			json
+// @Security		BearerAuth
+// @Param			body	body	dto.ReauthenticateRequest	true	"Password or one-time code"
+// @Success		200	{object}	apputils.BaseResponse
+// @Failure		400	{object}	apputils.BaseResponse
+// @Failure		401	{object}	apputils.BaseResponse
+// @Failure		403	{object}	apputils.BaseResponse
+// @Failure		500	{object}	apputils.BaseResponse
+// @Router			/api/v1/auth/reauthenticate [post]
+func (h *AuthHandler) Reauthenticate(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.ReauthenticateRequest)
+
+	userID := apputils.UUIDChecker(c.Locals("user_id").(string))
+
+	sessionID, err := uuid.Parse(fmt.Sprint(c.Locals("session_id")))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "missing session in token")
+	}
+
+	authTime, err := h.authService.Reauthenticate(c.Context(), userID, sessionID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "Successfully reauthenticated",
+		"data": map[string]any{
+			"auth_time": authTime,
+		},
+	}))
+}
+
+// CancelAccountDeletion godoc
+// @Summary		Cancel Account Deletion
+// @Description	Reactivate an account scheduled for deletion with the token emailed when deletion was requested. The emailed link opens a page of the app that posts the token
+// @Tags			Authentication
+// @Accept			json
+// @Produce			json
+// @Param			body	body	dto.CancelAccountDeletionRequest	true	"Account deletion cancel token"
+// @Success		200	{object}	apputils.BaseResponse
+// @Failure		400	{object}	apputils.BaseResponse
+// @Failure		401	{object}	apputils.BaseResponse
+// @Failure		404	{object}	apputils.BaseResponse
+// @Failure		500	{object}	apputils.BaseResponse
+// @Router			/api/v1/auth/account/deletion/cancel [post]
+func (h *AuthHandler) CancelAccountDeletion(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.CancelAccountDeletionRequest)
+
+	err := h.authService.CancelAccountDeletion(c.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "Account deletion cancelled successfully",
+	}))
+}
+
+// ResetPassword godoc
+// @Summary		Reset Password
+// @Description	Choose a new password with the token from a password reset email, whose link opens the reset page of the app. Lifts the reset requirement so the account can sign in again
+// @Tags			Authentication
+// @Accept			json
+// @Produce			json
+// @Param			body	body	dto.ResetPasswordRequest	true	"Password reset token and new password"
+// @Success		200	{object}	apputils.BaseResponse
+// @Failure		400	{object}	apputils.BaseResponse
+// @Failure		401	{object}	apputils.BaseResponse
+// @Failure		404	{object}	apputils.BaseResponse
+// @Failure		422	{object}	apputils.BaseResponse
+// @Failure		500	{object}	apputils.BaseResponse
+// @Router			/api/v1/auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.ResetPasswordRequest)
+
+	err := h.authService.ResetPassword(clientContext(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "Password reset successfully",
+	}))
+}
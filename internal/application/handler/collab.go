@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/rayhan889/neatspace/internal/application/middlewares"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/internal/collab"
+	"github.com/rayhan889/neatspace/internal/domain/note/entities"
+)
+
+// Locals set by the upgrade check for the WebSocket handler
+const (
+	collabNoteIDKey  = "collab_note_id"
+	collabContentKey = "collab_content"
+	collabVersionKey = "collab_version"
+	collabPeerKey    = "collab_peer"
+)
+
+type CollabHandlerInterface interface {
+	UpgradeCollab(c *fiber.Ctx) error
+	ServeCollab(conn *websocket.Conn)
+}
+
+var _ CollabHandlerInterface = (*CollabHandler)(nil)
+
+type CollabHandler struct {
+	hub         *collab.Hub
+	noteService services.NoteServiceInterface
+	userService services.UserServiceInterface
+}
+
+type CollabHandlerOpts struct {
+	RouteGroup   fiber.Router
+	Hub          *collab.Hub
+	NoteService  services.NoteServiceInterface
+	UserService  services.UserServiceInterface
+	JWTSecretKey []byte
+	SigningAlg   jwa.SignatureAlgorithm
+	Sessions     middlewares.SessionValidator
+}
+
+func NewCollabHandler(opts CollabHandlerOpts) {
+	h := &CollabHandler{
+		hub:         opts.Hub,
+		noteService: opts.NoteService,
+		userService: opts.UserService,
+	}
+
+	// Not under /notes, its JWT middleware only reads the Authorization header
+	g := opts.RouteGroup.Group("/collab", middlewares.WebSocketJWTMiddleware(opts.JWTSecretKey, opts.SigningAlg, opts.Sessions))
+	g.Get("/notes/:noteId", h.UpgradeCollab, websocket.New(h.ServeCollab))
+}
+
+// UpgradeCollab godoc
+// @Summary 		Collaborate on Note
+// @Description 	Upgrade to a WebSocket joining the live editing session of the note. The access token may be passed in the access_token query. Viewers and commenters receive edits and presence but can't send edits. Peers are disconnected when their access token expires, their session is revoked or they lose access to the note.
+// @Tags 			Notes
+// @Security		BearerAuth
+// @Param			noteId			path	string	true	"Note ID"
+// @Param			access_token	query	string	false	"Access token, for clients that can't set headers"
+// @Success      	101
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	426   {object}  apputils.BaseResponse
+// @Router       	/api/v1/collab/notes/{noteId} [get]
+func (h *CollabHandler) UpgradeCollab(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.NewError(fiber.StatusUpgradeRequired, "websocket upgrade required")
+	}
+
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	userID := currentUserID(c)
+	note, permission, err := h.noteService.NoteAccess(c.Context(), userID, noteID)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.userService.GetUserByID(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fiber.NewError(fiber.StatusNotFound, "user not found")
+	}
+
+	c.Locals(collabNoteIDKey, noteID)
+	// The hub ends the session with the access token, and earlier once the session is revoked
+	sessionID, _ := uuid.Parse(fmt.Sprint(c.Locals("session_id")))
+	claims, _ := c.Locals("jwt_claims").(map[string]any)
+	expiresAt, _ := claims["exp"].(time.Time)
+
+	c.Locals(collabContentKey, note.Content)
+	c.Locals(collabVersionKey, note.Version)
+	c.Locals(collabPeerKey, collab.Peer{
+		UserID:      userID,
+		DisplayName: user.DisplayName,
+		Permission:  permission,
+		SessionID:   sessionID,
+		ExpiresAt:   expiresAt,
+	})
+
+	return c.Next()
+}
+
+func (h *CollabHandler) ServeCollab(conn *websocket.Conn) {
+	noteID := conn.Locals(collabNoteIDKey).(uuid.UUID)
+	content := conn.Locals(collabContentKey).(entities.TiptapContent)
+	version := conn.Locals(collabVersionKey).(int64)
+	peer := conn.Locals(collabPeerKey).(collab.Peer)
+
+	conn.SetReadLimit(collab.MaxMessageSize)
+
+	h.hub.Serve(noteID, content, version, peer, conn)
+}
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+// clientContext returns the request context enriched with the caller's IP address and user agent,
+// and the admin behind an impersonation token
+func clientContext(c *fiber.Ctx) context.Context {
+	impersonatorID, _ := c.Locals("impersonator_id").(string)
+
+	return apputils.WithClientInfo(c.Context(), apputils.ClientInfo{
+		IPAddress:      c.IP(),
+		UserAgent:      c.Get(fiber.HeaderUserAgent),
+		ImpersonatorID: impersonatorID,
+	})
+}
@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	AdminUserItem struct {
+		ID                  uuid.UUID  `json:"id"`
+		DisplayName         string     `json:"display_name"`
+		Username            *string    `json:"username"`
+		Email               string     `json:"email"`
+		Role                string     `json:"role" example:"user"`
+		Status              string     `json:"status" example:"active"`
+		EmailVerifiedAt     *time.Time `json:"email_verified_at"`
+		LastLoginAt         *time.Time `json:"last_login_at"`
+		DeactivatedAt       *time.Time `json:"deactivated_at,omitempty"`
+		DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+		CreatedAt           time.Time  `json:"created_at"`
+	}
+	AdminUserDetail struct {
+		AdminUserItem
+		Timezone  string        `json:"timezone"`
+		UpdatedAt *time.Time    `json:"updated_at"`
+		NoteCount int           `json:"note_count"`
+		Sessions  []SessionItem `json:"sessions"`
+	}
+	UpdateUserRoleRequest struct {
+		Role string `json:"role" validate:"required,oneof=user admin" example:"admin"`
+	}
+	ImpersonationResponse struct {
+		UserID      uuid.UUID `json:"user_id"`
+		SessionID   uuid.UUID `json:"session_id"`
+		AccessToken string    `json:"access_token"`
+		ExpiresAt   time.Time `json:"expires_at"`
+	}
+	AuditEventItem struct {
+		ID             uuid.UUID      `json:"id"`
+		ActorID        *uuid.UUID     `json:"actor_id"`
+		ImpersonatorID *uuid.UUID     `json:"impersonator_id,omitempty"`
+		Action         string         `json:"action" example:"user.deactivate"`
+		TargetType     *string        `json:"target_type" example:"user"`
+		TargetID       *uuid.UUID     `json:"target_id"`
+		Metadata       map[string]any `json:"metadata"`
+		IPAddress      *string        `json:"ip_address,omitempty"`
+		UserAgent      *string        `json:"user_agent,omitempty"`
+		CreatedAt      time.Time      `json:"created_at"`
+	}
+)
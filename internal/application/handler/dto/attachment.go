@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// AttachmentItem describes an uploaded file. Src is the stable path image nodes refer to the
+	// file by, URL a signed download link that works without a session until URLExpiresAt.
+	AttachmentItem struct {
+		ID           uuid.UUID  `json:"id"`
+		NoteID       *uuid.UUID `json:"note_id"`
+		FileName     string     `json:"file_name" example:"diagram.png"`
+		ContentType  string     `json:"content_type" example:"image/png"`
+		SizeBytes    int64      `json:"size_bytes" example:"48213"`
+		Src          string     `json:"src" example:"/api/v1/attachments/0b6f2c9e-8d1a-4c1e-9f3b-2a7d5e4c3b21/content"`
+		URL          string     `json:"url"`
+		URLExpiresAt time.Time  `json:"url_expires_at"`
+		CreatedAt    time.Time  `json:"created_at"`
+	}
+
+	AttachmentUsage struct {
+		UsedBytes    int64 `json:"used_bytes"`
+		QuotaBytes   int64 `json:"quota_bytes"`
+		MaxFileBytes int64 `json:"max_file_bytes"` // Largest single upload
+	}
+)
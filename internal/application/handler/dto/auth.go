@@ -1,5 +1,11 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type (
 	InitiateEmailVerificationRequest struct {
 		Email      string `json:"email" validate:"required,email"`
@@ -10,20 +16,68 @@ type (
 	}
 	SignInWithEmailRequest struct {
 		Email    string `json:"email" validate:"required,email"`
-		Password string `json:"password" validate:"min=8,max=24"`
+		Password string `json:"password" validate:"required,max=1024"`
 	}
 	AccessTokenPayload struct {
 		UserID string `json:"user_id"` // User ID
 		Email  string `json:"email"`   // User Email
 		SID    string `json:"sid"`     // Session ID
+
+		Act *ActorClaim `json:"act,omitempty"` // Set when an admin is acting as the user
+	}
+	// ActorClaim identifies who is acting on behalf of the subject (RFC 8693)
+	ActorClaim struct {
+		Sub string `json:"sub"` // Actor user ID
 	}
 	SetUserPasswordRequest struct {
 		UserID               string `json:"user_id" validate:"required,uuid"`
-		Password             string `json:"password" validate:"required,min=8" example:"secure.password"`
+		Password             string `json:"password" validate:"required" example:"secure.password"`
 		PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password" example:"secret.password"`
 	}
+	ChangeEmailRequest struct {
+		Email      string `json:"email" validate:"required,email" example:"new.address@example.com"`
+		RedirectTo string `json:"redirect_to" validate:"omitempty,url"`
+	}
+	EmailChangeTokenRequest struct {
+		Token string `json:"token" validate:"required"`
+	}
+	ResetPasswordRequest struct {
+		Token                string `json:"token" validate:"required"`
+		Password             string `json:"password" validate:"required" example:"new.secret.password"`
+		PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password" example:"new.secret.password"`
+	}
+	CancelAccountDeletionRequest struct {
+		Token string `json:"token" validate:"required"`
+	}
+	ReauthenticateRequest struct {
+		Password string `json:"password" validate:"required_without=OTP,max=1024"`
+		OTP      string `json:"otp" validate:"required_without=Password,max=16" example:"123456"`
+	}
 	UpdatePasswordRequest struct {
-		Password             string `json:"password" validate:"required,min=8" example:"secure.password"`
+		Password             string `json:"password" validate:"required" example:"secure.password"`
 		PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password" example:"secret.password"`
 	}
+	LoginHistoryItem struct {
+		ID            uuid.UUID `json:"id"`
+		Method        string    `json:"method"`
+		Success       bool      `json:"success"`
+		FailureReason *string   `json:"failure_reason,omitempty"`
+		IPAddress     *string   `json:"ip_address,omitempty"`
+		UserAgent     *string   `json:"user_agent,omitempty"`
+		CreatedAt     time.Time `json:"created_at"`
+	}
+	SessionItem struct {
+		ID          uuid.UUID  `json:"id"`
+		UserAgent   *string    `json:"user_agent,omitempty"`
+		DeviceName  *string    `json:"device_name,omitempty"`
+		IPAddress   *string    `json:"ip_address,omitempty"`
+		AMR         []string   `json:"amr"`
+		AuthTime    *time.Time `json:"auth_time,omitempty"`
+		CreatedAt   time.Time  `json:"created_at"`
+		RefreshedAt *time.Time `json:"refreshed_at,omitempty"`
+		ExpiresAt   time.Time  `json:"expires_at"`
+		RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+
+		ImpersonatorID *uuid.UUID `json:"impersonator_id,omitempty"` // Admin who started the session, if impersonated
+	}
 )
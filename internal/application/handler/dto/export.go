@@ -0,0 +1,18 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	DataExportItem struct {
+		ID          uuid.UUID  `json:"id"`
+		Status      string     `json:"status" example:"pending"`
+		FileSize    *int64     `json:"file_size,omitempty"`
+		CreatedAt   time.Time  `json:"created_at"`
+		CompletedAt *time.Time `json:"completed_at,omitempty"`
+		ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	}
+)
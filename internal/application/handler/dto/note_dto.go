@@ -3,18 +3,136 @@ package dto
 import (
 	"time"
 
+	"github.com/google/uuid"
 	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
 )
 
 type (
 	NotePaginationResponse struct {
+		ID          uuid.UUID                `json:"id"`
+		WorkspaceID uuid.UUID                `json:"workspace_id"`
+		Title       string                   `json:"title"`
+		Content     noteEntity.TiptapContent `json:"content"`
+		Tags        []string                 `json:"tags"`
+		CreatedAt   time.Time                `json:"created_at"`
+		UpdatedAt   *time.Time               `json:"updated_at"`
+	}
+	CreateNoteRequest struct {
+		Title   string                   `json:"title" validate:"required,min=3,max=100"`
+		Content noteEntity.TiptapContent `json:"content" validate:"required"`
+		// Workspace to create the note in, the personal workspace when omitted
+		WorkspaceID *uuid.UUID `json:"workspace_id,omitempty"`
+		Tags        []string   `json:"tags,omitempty" validate:"omitempty,max=20,dive,max=50" example:"work,roadmap"`
+	}
+	// Fields left out keep their value
+	UpdateNoteRequest struct {
+		Title   *string                   `json:"title,omitempty" validate:"omitempty,min=3,max=100"`
+		Content *noteEntity.TiptapContent `json:"content,omitempty"`
+		Tags    *[]string                 `json:"tags,omitempty" validate:"omitempty,max=20,dive,max=50"` // Replaces all tags, empty removes them
+	}
+	NoteResponse struct {
+		ID          uuid.UUID                `json:"id"`
+		WorkspaceID uuid.UUID                `json:"workspace_id"`
+		AuthorID    uuid.UUID                `json:"author_id"`
+		Title       string                   `json:"title"`
+		Content     noteEntity.TiptapContent `json:"content"`
+		Tags        []string                 `json:"tags" example:"work,roadmap"`
+		Permission  string                   `json:"permission" example:"edit"` // Access of the requesting user
+		Version     int64                    `json:"version" example:"3"`       // Also sent as the ETag header
+		CreatedAt   time.Time                `json:"created_at"`
+		UpdatedAt   *time.Time               `json:"updated_at"`
+	}
+	ShareNoteRequest struct {
+		Email      string `json:"email" validate:"required,email" example:"jane@example.com"`
+		Permission string `json:"permission" validate:"required,oneof=view comment edit" example:"comment"`
+	}
+	UpdateNoteShareRequest struct {
+		Permission string `json:"permission" validate:"required,oneof=view comment edit" example:"edit"`
+	}
+	NoteShareItem struct {
+		ID          uuid.UUID  `json:"id"`
+		UserID      *uuid.UUID `json:"user_id"`
+		Email       string     `json:"email"`
+		DisplayName *string    `json:"display_name,omitempty"`
+		Username    *string    `json:"username,omitempty"`
+		Permission  string     `json:"permission" example:"view"`
+		Pending     bool       `json:"pending"` // The invitee hasn't signed up yet
+		SharedBy    *uuid.UUID `json:"shared_by"`
+		CreatedAt   time.Time  `json:"created_at"`
+	}
+	CreatePublicLinkRequest struct {
+		Password  *string    `json:"password,omitempty" validate:"omitempty,min=4,max=128"`
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	}
+	UpdatePublicLinkRequest struct {
+		Password       *string    `json:"password,omitempty" validate:"omitempty,min=4,max=128"` // New password, kept when omitted
+		RemovePassword bool       `json:"remove_password,omitempty"`
+		ExpiresAt      *time.Time `json:"expires_at,omitempty"` // New expiry, kept when omitted
+		RemoveExpiry   bool       `json:"remove_expiry,omitempty"`
+	}
+	PublicLinkItem struct {
+		Slug        string     `json:"slug"`
+		URL         string     `json:"url"`
+		HasPassword bool       `json:"has_password"`
+		ExpiresAt   *time.Time `json:"expires_at"`
+		ViewCount   int64      `json:"view_count"`
+		CreatedAt   time.Time  `json:"created_at"`
+		UpdatedAt   *time.Time `json:"updated_at"`
+	}
+	PublicNoteResponse struct {
 		Title     string                   `json:"title"`
 		Content   noteEntity.TiptapContent `json:"content"`
+		HTML      string                   `json:"html"` // Sanitized rendering of the content
 		CreatedAt time.Time                `json:"created_at"`
 		UpdatedAt *time.Time               `json:"updated_at"`
 	}
-	CreateNoteRequest struct {
-		Title   string                   `json:"title" validate:"required,min=3,max=100"`
-		Content noteEntity.TiptapContent `json:"content" validate:"required"`
+	// A link between two notes, described by the note on the other end
+	NoteLinkItem struct {
+		NoteID      uuid.UUID  `json:"note_id"`
+		WorkspaceID *uuid.UUID `json:"workspace_id"` // Nil when the note is gone or not accessible
+		Title       string     `json:"title"`        // Title of the note, or shown by the link when it's not accessible
+		Dangling    bool       `json:"dangling"`     // The linked note was deleted or never existed
+		Accessible  bool       `json:"accessible"`   // The requesting user can open the note
+		UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+		LinkedAt    time.Time  `json:"linked_at"`
+	}
+	// A checklist item of a note, addressed by the note and its position among the note's tasks
+	NoteTaskItem struct {
+		NoteID      uuid.UUID `json:"note_id"`
+		Position    int       `json:"position"`
+		Text        string    `json:"text"`
+		Checked     bool      `json:"checked"`
+		DueDate     *string   `json:"due_date" example:"2026-10-20"`
+		WorkspaceID uuid.UUID `json:"workspace_id"`
+		NoteTitle   string    `json:"note_title"`
+		NoteVersion int64     `json:"note_version" example:"3"` // Send as If-Match when toggling the task
+		CanToggle   bool      `json:"can_toggle"`               // The requesting user may edit the note
+	}
+	SetTaskCheckedRequest struct {
+		Checked *bool `json:"checked" validate:"required"`
+	}
+)
+
+// Outcomes of an imported file
+const (
+	ImportStatusImported = "imported"
+	ImportStatusFailed   = "failed"
+	ImportStatusSkipped  = "skipped" // Not a note, e.g. an image inside a ZIP
+)
+
+type (
+	ImportNoteResult struct {
+		File   string     `json:"file" example:"archive.zip/meetings/standup.md"`
+		Status string     `json:"status" example:"imported"`
+		NoteID *uuid.UUID `json:"note_id,omitempty"`
+		Title  string     `json:"title,omitempty"`
+		// Tags of the note, taken from the front matter
+		Tags  []string `json:"tags,omitempty"`
+		Error string   `json:"error,omitempty"`
+	}
+	ImportNotesResult struct {
+		Imported int                `json:"imported"`
+		Failed   int                `json:"failed"`
+		Results  []ImportNoteResult `json:"results"`
 	}
 )
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	CreateReminderRequest struct {
+		NoteID uuid.UUID `json:"note_id" validate:"required"`
+		// RFC 3339, or a local date-time read in the user's time zone
+		FireAt     string  `json:"fire_at" validate:"required" example:"2026-10-19T09:00"`
+		Recurrence *string `json:"recurrence,omitempty" validate:"omitempty,max=200" example:"FREQ=WEEKLY;INTERVAL=2"` // RRULE with FREQ, INTERVAL, COUNT and UNTIL
+	}
+	// Fields left out keep their value, a changed schedule starts over from fire_at
+	UpdateReminderRequest struct {
+		FireAt     *string `json:"fire_at,omitempty" example:"2026-10-19T09:00"`
+		Recurrence *string `json:"recurrence,omitempty" validate:"omitempty,max=200"` // Empty makes it a one-off reminder
+	}
+	ReminderItem struct {
+		ID         uuid.UUID  `json:"id"`
+		NoteID     uuid.UUID  `json:"note_id"`
+		FireAt     time.Time  `json:"fire_at"`
+		Timezone   string     `json:"timezone" example:"Europe/Berlin"`
+		Recurrence *string    `json:"recurrence"`
+		NextFireAt *time.Time `json:"next_fire_at"` // Nil once the last occurrence was sent
+		LastSentAt *time.Time `json:"last_sent_at"`
+		CreatedAt  time.Time  `json:"created_at"`
+		UpdatedAt  *time.Time `json:"updated_at"`
+	}
+)
@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+)
+
+// Outcomes of a sync mutation
+const (
+	SyncStatusApplied  = "applied"
+	SyncStatusConflict = "conflict" // The note changed on the server, current holds its state
+	SyncStatusRejected = "rejected" // Invalid or not allowed, error tells why
+)
+
+type (
+	// Either the note or, for a deleted note, the tombstone fields are set
+	SyncNoteChange struct {
+		ID        uuid.UUID     `json:"id"`
+		Deleted   bool          `json:"deleted"`
+		DeletedAt *time.Time    `json:"deleted_at,omitempty"`
+		Note      *NoteResponse `json:"note,omitempty"`
+	}
+	SyncNotesResponse struct {
+		Changes   []SyncNoteChange `json:"changes"`
+		NextToken string           `json:"next_token"` // Send as since in the next request
+		HasMore   bool             `json:"has_more"`   // Request again right away with next_token
+	}
+	SyncNotesRequest struct {
+		Mutations []SyncNoteMutation `json:"mutations" validate:"required,min=1,max=100,dive"`
+	}
+	// Upserting an unknown id creates the note with it, which needs title and content. Updating
+	// or deleting requires the version the client last saw.
+	SyncNoteMutation struct {
+		ID          uuid.UUID                 `json:"id" validate:"required"` // Generated by the client for new notes
+		Op          string                    `json:"op" validate:"required,oneof=upsert delete" example:"upsert"`
+		WorkspaceID *uuid.UUID                `json:"workspace_id,omitempty"` // Only for new notes, the personal workspace when omitted
+		Title       *string                   `json:"title,omitempty" validate:"omitempty,min=3,max=100"`
+		Content     *noteEntity.TiptapContent `json:"content,omitempty"`
+		BaseVersion *int64                    `json:"base_version,omitempty" example:"3"`
+	}
+	SyncNoteMutationResult struct {
+		ID      uuid.UUID     `json:"id"`
+		Status  string        `json:"status" example:"applied"`
+		Error   string        `json:"error,omitempty"`
+		Note    *NoteResponse `json:"note,omitempty"`    // The note after an applied upsert
+		Current *NoteResponse `json:"current,omitempty"` // Server state on conflict, nil when it was deleted
+	}
+	SyncNotesResult struct {
+		Results []SyncNoteMutationResult `json:"results"`
+	}
+)
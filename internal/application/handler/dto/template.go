@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	templateEntity "github.com/rayhan889/neatspace/internal/domain/template/entities"
+)
+
+type (
+	CreateTemplateRequest struct {
+		Name        string                          `json:"name" validate:"required,max=100" example:"Daily standup"`
+		Description *string                         `json:"description,omitempty" validate:"omitempty,max=500"`
+		Title       string                          `json:"title" validate:"required,max=200" example:"Standup {{date}}"`
+		Content     noteEntity.TiptapContent        `json:"content" validate:"required"`
+		Prompts     []templateEntity.TemplatePrompt `json:"prompts,omitempty" validate:"omitempty,max=20,dive"`
+		// Workspace to share the template in, a personal template when omitted
+		WorkspaceID *uuid.UUID `json:"workspace_id,omitempty"`
+	}
+	// Fields left out keep their value
+	UpdateTemplateRequest struct {
+		Name        *string                          `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+		Description *string                          `json:"description,omitempty" validate:"omitempty,max=500"` // Empty removes the description
+		Title       *string                          `json:"title,omitempty" validate:"omitempty,min=1,max=200"`
+		Content     *noteEntity.TiptapContent        `json:"content,omitempty"`
+		Prompts     *[]templateEntity.TemplatePrompt `json:"prompts,omitempty" validate:"omitempty,max=20,dive"`
+	}
+	TemplateItem struct {
+		ID          uuid.UUID                       `json:"id"`
+		WorkspaceID *uuid.UUID                      `json:"workspace_id"` // Nil for a personal template
+		Name        string                          `json:"name"`
+		Description *string                         `json:"description"`
+		Title       string                          `json:"title"`
+		Content     noteEntity.TiptapContent        `json:"content"`
+		Prompts     []templateEntity.TemplatePrompt `json:"prompts"`
+		CanEdit     bool                            `json:"can_edit"` // The requesting user may change or delete it
+		CreatedBy   *uuid.UUID                      `json:"created_by"`
+		CreatedAt   time.Time                       `json:"created_at"`
+		UpdatedAt   *time.Time                      `json:"updated_at"`
+	}
+	CreateNoteFromTemplateRequest struct {
+		// Workspace to create the note in. Defaults to the template's workspace, or the personal
+		// workspace for a personal template.
+		WorkspaceID *uuid.UUID `json:"workspace_id,omitempty"`
+		// Answers to the template's prompts by prompt name
+		Values map[string]string `json:"values,omitempty" validate:"omitempty,max=20,dive,max=2000" example:"attendees:Ann, Bob"`
+	}
+)
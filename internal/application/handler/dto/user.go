@@ -1,12 +1,39 @@
 package dto
 
-import userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
+import (
+	"time"
+
+	"github.com/google/uuid"
+	userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
+)
 
 type (
 	CreateUser struct {
 		DisplayName string `json:"display_name" validate:"required,min=10"`
 		Email       string `json:"email" validate:"required,email"`
 	}
+	UpdateProfileRequest struct {
+		DisplayName *string `json:"display_name" validate:"omitempty,max=100" example:"Jane Doe"`
+		Username    *string `json:"username" validate:"omitempty" example:"jane_doe"`
+		Timezone    *string `json:"timezone" validate:"omitempty" example:"Asia/Jakarta"`
+	}
+	UserProfile struct {
+		ID              uuid.UUID  `json:"id"`
+		DisplayName     string     `json:"display_name"`
+		Username        *string    `json:"username"`
+		Email           string     `json:"email"`
+		EmailVerifiedAt *time.Time `json:"email_verified_at"`
+		Timezone        string     `json:"timezone"`
+		Role            string     `json:"role"`
+		LastLoginAt     *time.Time `json:"last_login_at"`
+
+		DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+		CreatedAt           time.Time  `json:"created_at"`
+		UpdatedAt           *time.Time `json:"updated_at"`
+
+		Impersonated   bool       `json:"impersonated"`              // The request was made by an admin acting as the user
+		ImpersonatedBy *uuid.UUID `json:"impersonated_by,omitempty"` // Admin acting as the user
+	}
 	UserPagination struct {
 		DisplayName     string                  `json:"display_name"`
 		Username        string                  `json:"username"`
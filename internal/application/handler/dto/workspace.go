@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	CreateWorkspaceRequest struct {
+		Name string `json:"name" validate:"required,min=1,max=100" example:"Design team"`
+	}
+	UpdateWorkspaceRequest struct {
+		Name string `json:"name" validate:"required,min=1,max=100" example:"Design team"`
+	}
+	InviteWorkspaceMemberRequest struct {
+		Email string `json:"email" validate:"required,email" example:"jane@example.com"`
+		Role  string `json:"role" validate:"required,oneof=admin editor viewer" example:"editor"`
+	}
+	AcceptWorkspaceInvitationRequest struct {
+		Token string `json:"token" validate:"required"`
+	}
+	TransferWorkspaceRequest struct {
+		UserID uuid.UUID `json:"user_id" validate:"required"` // Member who becomes the owner
+	}
+	UpdateWorkspaceMemberRoleRequest struct {
+		Role string `json:"role" validate:"required,oneof=admin editor viewer" example:"viewer"`
+	}
+	WorkspaceItem struct {
+		ID        uuid.UUID  `json:"id"`
+		Name      string     `json:"name"`
+		OwnerID   uuid.UUID  `json:"owner_id"`
+		Personal  bool       `json:"personal"`
+		Role      string     `json:"role" example:"editor"` // Role of the requesting user
+		CreatedAt time.Time  `json:"created_at"`
+		UpdatedAt *time.Time `json:"updated_at"`
+	}
+	WorkspaceMemberItem struct {
+		UserID      uuid.UUID `json:"user_id"`
+		DisplayName string    `json:"display_name"`
+		Username    *string   `json:"username"`
+		Email       string    `json:"email"`
+		Role        string    `json:"role" example:"viewer"`
+		JoinedAt    time.Time `json:"joined_at"`
+	}
+	WorkspaceInvitationItem struct {
+		ID        uuid.UUID  `json:"id"`
+		Email     string     `json:"email"`
+		Role      string     `json:"role" example:"editor"`
+		InvitedBy *uuid.UUID `json:"invited_by"`
+		CreatedAt time.Time  `json:"created_at"`
+		ExpiresAt time.Time  `json:"expires_at"`
+	}
+)
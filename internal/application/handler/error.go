@@ -8,6 +8,13 @@ import (
 )
 
 func Error(c *fiber.Ctx, err error) error {
+	// Business rule validation errors carry per-field messages
+	var validationErr *apputils.ValidationError
+	if errors.As(err, &validationErr) {
+		code := fiber.StatusUnprocessableEntity
+		return c.Status(code).JSON(apputils.ErrorValidationResponse(code, validationErr.Errors, validationErr.Message))
+	}
+
 	// Status code defaults to 500
 	code := fiber.StatusInternalServerError
 
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/rayhan889/neatspace/internal/application/middlewares"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+type ExportHandlerInterface interface {
+	RequestExport(c *fiber.Ctx) error
+	ListExports(c *fiber.Ctx) error
+	DownloadExport(c *fiber.Ctx) error
+}
+
+var _ ExportHandlerInterface = (*ExportHandler)(nil)
+
+type ExportHandler struct {
+	exportService services.ExportServiceInterface
+}
+
+type ExportHandlerOpts struct {
+	RouteGroup    fiber.Router
+	ExportService services.ExportServiceInterface
+	JWTSecretKey  []byte
+	SigningAlg    jwa.SignatureAlgorithm
+	Sessions      middlewares.SessionValidator
+}
+
+func NewExportHandler(opts ExportHandlerOpts) {
+	h := &ExportHandler{
+		exportService: opts.ExportService,
+	}
+
+	meGroup := opts.RouteGroup.Group("/users/me/exports", middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg, opts.Sessions))
+	meGroup.Post("", h.RequestExport)
+	meGroup.Get("", h.ListExports)
+
+	// Authorized by the signed link emailed to the user, not by a session
+	opts.RouteGroup.Get("/exports/:exportId/download", h.DownloadExport)
+}
+
+// RequestExport godoc
+// @Summary 		Request Data Export
+// @Description 	Queue an export of the signed-in user's profile, sessions and notes. A signed download link is emailed once the ZIP archive is ready
+// @Tags 			Users
+// @Produce 		json
+// @Security		BearerAuth
+// @Success      	202   {object}  apputils.BaseResponse{data=dto.DataExportItem}
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/users/me/exports [post]
+func (h *ExportHandler) RequestExport(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	export, err := h.exportService.RequestExport(c.Context(), userIDUUID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(apputils.SuccessResponse(export))
+}
+
+// ListExports godoc
+// @Summary 		List Data Exports
+// @Description 	List the signed-in user's data exports and their status, newest first
+// @Tags 			Users
+// @Produce 		json
+// @Security		BearerAuth
+// @Success      	200   {object}  apputils.BaseResponse{data=[]dto.DataExportItem}
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/users/me/exports [get]
+func (h *ExportHandler) ListExports(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	exports, err := h.exportService.ListExports(c.Context(), userIDUUID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(exports))
+}
+
+// DownloadExport godoc
+// @Summary 		Download Data Export
+// @Description 	Download a finished data export archive using the signed link from the notification email
+// @Tags 			Users
+// @Produce 		application/zip
+// @Param			exportId	path	string	true	"Export ID (UUID)"
+// @Param			expires		query	string	true	"Link expiry (unix seconds)"
+// @Param			signature	query	string	true	"Link signature"
+// @Success      	200   {file}    file
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	410   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/exports/{exportId}/download [get]
+func (h *ExportHandler) DownloadExport(c *fiber.Ctx) error {
+	exportID, err := uuid.Parse(c.Params("exportId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid export id")
+	}
+
+	download, err := h.exportService.ResolveDownload(c.Context(), exportID, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.Download(download.Path, download.FileName)
+}
@@ -1,6 +1,11 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -17,6 +22,23 @@ import (
 type NoteHandlerInterface interface {
 	PaginationNote(c *fiber.Ctx) error
 	CreateNote(c *fiber.Ctx) error
+	GetNote(c *fiber.Ctx) error
+	UpdateNote(c *fiber.Ctx) error
+	DeleteNote(c *fiber.Ctx) error
+	ExportNote(c *fiber.Ctx) error
+	ExportWorkspaceNotes(c *fiber.Ctx) error
+	ImportNotes(c *fiber.Ctx) error
+	ListBacklinks(c *fiber.Ctx) error
+	ListOutgoingLinks(c *fiber.Ctx) error
+	ShareNote(c *fiber.Ctx) error
+	ListNoteShares(c *fiber.Ctx) error
+	UpdateNoteShare(c *fiber.Ctx) error
+	RemoveNoteShare(c *fiber.Ctx) error
+	CreatePublicLink(c *fiber.Ctx) error
+	GetPublicLink(c *fiber.Ctx) error
+	UpdatePublicLink(c *fiber.Ctx) error
+	DeletePublicLink(c *fiber.Ctx) error
+	ViewPublicNote(c *fiber.Ctx) error
 }
 
 var _ NoteHandlerInterface = (*NoteHandler)(nil)
@@ -30,6 +52,7 @@ type NoteHandlerOpts struct {
 	NoteService  services.NoteServiceInterface
 	JWTSecretKey []byte
 	SigningAlg   jwa.SignatureAlgorithm
+	Sessions     middlewares.SessionValidator
 }
 
 func NewNoteHandler(opts NoteHandlerOpts) {
@@ -39,9 +62,28 @@ func NewNoteHandler(opts NoteHandlerOpts) {
 
 	publicGroup := opts.RouteGroup.Group("/notes")
 
-	privateGroup := publicGroup.Group("", middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg))
+	privateGroup := publicGroup.Group("", middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg, opts.Sessions))
 	privateGroup.Get("", h.PaginationNote)
 	privateGroup.Post("/new", middlewares.ValidateRequestJSON[dto.CreateNoteRequest](), h.CreateNote)
+	privateGroup.Get("/export", h.ExportWorkspaceNotes)
+	privateGroup.Post("/import", h.ImportNotes)
+	privateGroup.Get("/:noteId", h.GetNote)
+	privateGroup.Patch("/:noteId", middlewares.ValidateRequestJSON[dto.UpdateNoteRequest](), h.UpdateNote)
+	privateGroup.Delete("/:noteId", h.DeleteNote)
+	privateGroup.Get("/:noteId/export", h.ExportNote)
+	privateGroup.Get("/:noteId/backlinks", h.ListBacklinks)
+	privateGroup.Get("/:noteId/links", h.ListOutgoingLinks)
+	privateGroup.Post("/:noteId/shares", middlewares.ValidateRequestJSON[dto.ShareNoteRequest](), h.ShareNote)
+	privateGroup.Get("/:noteId/shares", h.ListNoteShares)
+	privateGroup.Patch("/:noteId/shares/:shareId", middlewares.ValidateRequestJSON[dto.UpdateNoteShareRequest](), h.UpdateNoteShare)
+	privateGroup.Delete("/:noteId/shares/:shareId", h.RemoveNoteShare)
+	privateGroup.Post("/:noteId/public-link", middlewares.ValidateRequestJSON[dto.CreatePublicLinkRequest](), h.CreatePublicLink)
+	privateGroup.Get("/:noteId/public-link", h.GetPublicLink)
+	privateGroup.Patch("/:noteId/public-link", middlewares.ValidateRequestJSON[dto.UpdatePublicLinkRequest](), h.UpdatePublicLink)
+	privateGroup.Delete("/:noteId/public-link", h.DeletePublicLink)
+
+	// Outside the /notes group so the JWT middleware doesn't apply
+	opts.RouteGroup.Get("/public/notes/:slug", h.ViewPublicNote)
 }
 
 func (h *NoteHandler) PaginationNote(c *fiber.Ctx) error {
@@ -69,8 +111,12 @@ func (h *NoteHandler) CreateNote(c *fiber.Ctx) error {
 		Title:       req.Title,
 		Content:     req.Content,
 		ContentText: "",
+		Tags:        req.Tags,
 		CreatedAt:   time.Now(),
 	}
+	if req.WorkspaceID != nil {
+		note.WorkspaceID = *req.WorkspaceID
+	}
 
 	err := h.noteService.CreateNote(c.Context(), note)
 	if err != nil {
@@ -79,3 +125,459 @@ func (h *NoteHandler) CreateNote(c *fiber.Ctx) error {
 
 	return c.SendStatus(fiber.StatusCreated)
 }
+
+// GetNote returns the note as JSON, or with format=html just its content rendered
+// as a sanitized HTML fragment
+func (h *NoteHandler) GetNote(c *fiber.Ctx) error {
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	switch c.Query("format", "json") {
+	case "json":
+		note, err := h.noteService.GetNote(c.Context(), userIDUUID, noteID)
+		if err != nil {
+			return err
+		}
+
+		etag := noteETag(note.Version)
+		c.Set(fiber.HeaderETag, etag)
+		if c.Get(fiber.HeaderIfNoneMatch) == etag {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(note))
+	case "html":
+		body, err := h.noteService.GetNoteHTML(c.Context(), userIDUUID, noteID)
+		if err != nil {
+			return err
+		}
+
+		// Images carry signed links that expire, so the version alone doesn't identify the body
+		c.Set(fiber.HeaderCacheControl, "private, no-store")
+		// The fragment is already sanitized, the policy keeps it inert if opened directly
+		c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'none'; img-src 'self'; style-src 'unsafe-inline'; sandbox")
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.Status(fiber.StatusOK).SendString(body)
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "format must be json or html")
+	}
+}
+
+// UpdateNote requires the If-Match header with the ETag of the version the client edited,
+// or * to overwrite whatever is stored. A stale version gets 412 with the current note.
+func (h *NoteHandler) UpdateNote(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.UpdateNoteRequest)
+
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	ifVersion, err := parseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	note, err := h.noteService.UpdateNote(c.Context(), userIDUUID, noteID, ifVersion, req)
+	if err != nil {
+		return noteConflictResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, noteETag(note.Version))
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(note))
+}
+
+func (h *NoteHandler) DeleteNote(c *fiber.Ctx) error {
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	if err := h.noteService.DeleteNote(clientContext(c), userIDUUID, noteID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *NoteHandler) ExportNote(c *fiber.Ctx) error {
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	file, err := h.noteService.ExportNote(c.Context(), userIDUUID, noteID, c.Query("format", services.NoteExportFormatMarkdown))
+	if err != nil {
+		return err
+	}
+
+	return sendNoteExport(c, file)
+}
+
+// ExportWorkspaceNotes downloads as a ZIP every note of the workspace given by the workspace_id
+// query, or with the tag query only those with the tag. A tag without a workspace exports the
+// tagged notes of all workspaces and shares.
+func (h *NoteHandler) ExportWorkspaceNotes(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	tag := c.Query("tag")
+	format := c.Query("format", services.NoteExportFormatMarkdown)
+
+	var file *services.NoteExportFile
+	if raw := c.Query("workspace_id"); raw == "" && tag != "" {
+		var err error
+		if file, err = h.noteService.ExportTaggedNotes(c.Context(), userIDUUID, tag, format); err != nil {
+			return err
+		}
+	} else {
+		workspaceID, err := uuid.Parse(raw)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid workspace id")
+		}
+		if file, err = h.noteService.ExportWorkspaceNotes(c.Context(), userIDUUID, workspaceID, tag, format); err != nil {
+			return err
+		}
+	}
+
+	return sendNoteExport(c, file)
+}
+
+// ImportNotes creates notes from the .md, .html and .zip files uploaded in the files field of a
+// multipart form. workspace_id picks the workspace and atomic=true imports all files or none.
+func (h *NoteHandler) ImportNotes(c *fiber.Ctx) error {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "expected a multipart form")
+	}
+
+	var workspaceID *uuid.UUID
+	if raw := c.FormValue("workspace_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid workspace id")
+		}
+		workspaceID = &id
+	}
+
+	atomic := false
+	if raw := c.FormValue("atomic"); raw != "" {
+		if atomic, err = strconv.ParseBool(raw); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid atomic flag")
+		}
+	}
+
+	files := make([]services.NoteImportFile, 0, len(form.File["files"]))
+	for _, header := range form.File["files"] {
+		f, err := header.Open()
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("can't read %s", header.Filename))
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("can't read %s", header.Filename))
+		}
+		files = append(files, services.NoteImportFile{Name: header.Filename, Data: data})
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	result, err := h.noteService.ImportNotes(c.Context(), userIDUUID, workspaceID, files, atomic)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(result))
+}
+
+// ListBacklinks godoc
+// @Summary 		List Note Backlinks
+// @Description 	List the notes linking to a note through a [[Note Title]] mention or a link to its page, by title. Only notes the signed-in user can view are listed
+// @Tags 			Notes
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			noteId	path	string	true	"Note ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse{data=[]dto.NoteLinkItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/notes/{noteId}/backlinks [get]
+func (h *NoteHandler) ListBacklinks(c *fiber.Ctx) error {
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	links, err := h.noteService.ListBacklinks(c.Context(), userIDUUID, noteID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(links))
+}
+
+// ListOutgoingLinks godoc
+// @Summary 		List Note Links
+// @Description 	List the notes a note links to, in the order they were linked. Links to deleted notes are dangling, linked notes the signed-in user can't view only show the title of the link
+// @Tags 			Notes
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			noteId	path	string	true	"Note ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse{data=[]dto.NoteLinkItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/notes/{noteId}/links [get]
+func (h *NoteHandler) ListOutgoingLinks(c *fiber.Ctx) error {
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	links, err := h.noteService.ListOutgoingLinks(c.Context(), userIDUUID, noteID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(links))
+}
+
+func (h *NoteHandler) ShareNote(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.ShareNoteRequest)
+
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	share, err := h.noteService.ShareNote(clientContext(c), userIDUUID, noteID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(apputils.SuccessResponse(share))
+}
+
+func (h *NoteHandler) ListNoteShares(c *fiber.Ctx) error {
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	shares, err := h.noteService.ListNoteShares(c.Context(), userIDUUID, noteID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(shares))
+}
+
+func (h *NoteHandler) UpdateNoteShare(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.UpdateNoteShareRequest)
+
+	noteID, shareID, err := noteAndShareParams(c)
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	if err := h.noteService.UpdateNoteShare(clientContext(c), userIDUUID, noteID, shareID, req.Permission); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "Share permission updated successfully",
+	}))
+}
+
+func (h *NoteHandler) RemoveNoteShare(c *fiber.Ctx) error {
+	noteID, shareID, err := noteAndShareParams(c)
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	if err := h.noteService.RemoveNoteShare(clientContext(c), userIDUUID, noteID, shareID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *NoteHandler) CreatePublicLink(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.CreatePublicLinkRequest)
+
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	link, err := h.noteService.CreatePublicLink(clientContext(c), userIDUUID, noteID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(apputils.SuccessResponse(link))
+}
+
+func (h *NoteHandler) GetPublicLink(c *fiber.Ctx) error {
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	link, err := h.noteService.GetPublicLink(c.Context(), userIDUUID, noteID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(link))
+}
+
+func (h *NoteHandler) UpdatePublicLink(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.UpdatePublicLinkRequest)
+
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	link, err := h.noteService.UpdatePublicLink(clientContext(c), userIDUUID, noteID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(link))
+}
+
+func (h *NoteHandler) DeletePublicLink(c *fiber.Ctx) error {
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	if err := h.noteService.DeletePublicLink(clientContext(c), userIDUUID, noteID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *NoteHandler) ViewPublicNote(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set("X-Robots-Tag", "noindex")
+
+	note, err := h.noteService.ViewPublicNote(c.Context(), c.Params("slug"), c.Get("X-Link-Password"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(note))
+}
+
+// noteConflictResponse answers a NoteVersionConflictError with 412 and the current note,
+// other errors are returned as they are
+func noteConflictResponse(c *fiber.Ctx, err error) error {
+	var conflict *services.NoteVersionConflictError
+	if !errors.As(err, &conflict) {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, noteETag(conflict.Current.Version))
+
+	resp := apputils.ErrorResponse(fiber.StatusPreconditionFailed, conflict.Error(), "")
+	var data any = conflict.Current
+	resp.Data = &data
+	return c.Status(fiber.StatusPreconditionFailed).JSON(resp)
+}
+
+func noteETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parseIfMatch returns the version of an If-Match header, nil for *
+func parseIfMatch(header string) (*int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil, fiber.NewError(fiber.StatusPreconditionRequired, "If-Match header with the note version is required")
+	}
+	if header == "*" {
+		return nil, nil
+	}
+
+	// Weak tags like W/"3" never match, If-Match uses strong comparison
+	version, err := strconv.ParseInt(strings.Trim(header, `"`), 10, 64)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid If-Match header")
+	}
+
+	return &version, nil
+}
+
+func noteAndShareParams(c *fiber.Ctx) (noteID, shareID uuid.UUID, err error) {
+	noteID, err = uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+
+	shareID, err = uuid.Parse(c.Params("shareId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "invalid share id")
+	}
+
+	return noteID, shareID, nil
+}
+
+func sendNoteExport(c *fiber.Ctx, file *services.NoteExportFile) error {
+	c.Attachment(file.FileName)
+	c.Set(fiber.HeaderContentType, file.ContentType)
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.Status(fiber.StatusOK).Send(file.Data)
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/rayhan889/neatspace/internal/application/constants"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	"github.com/rayhan889/neatspace/internal/application/middlewares"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+type ReminderHandlerInterface interface {
+	CreateReminder(c *fiber.Ctx) error
+	ListReminders(c *fiber.Ctx) error
+	GetReminder(c *fiber.Ctx) error
+	UpdateReminder(c *fiber.Ctx) error
+	DeleteReminder(c *fiber.Ctx) error
+}
+
+var _ ReminderHandlerInterface = (*ReminderHandler)(nil)
+
+type ReminderHandler struct {
+	reminderService services.ReminderServiceInterface
+}
+
+type ReminderHandlerOpts struct {
+	RouteGroup      fiber.Router
+	ReminderService services.ReminderServiceInterface
+	JWTSecretKey    []byte
+	SigningAlg      jwa.SignatureAlgorithm
+	Sessions        middlewares.SessionValidator
+}
+
+func NewReminderHandler(opts ReminderHandlerOpts) {
+	h := &ReminderHandler{
+		reminderService: opts.ReminderService,
+	}
+
+	jwtMiddleware := middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg, opts.Sessions)
+
+	privateGroup := opts.RouteGroup.Group("/reminders", jwtMiddleware)
+	privateGroup.Post("", middlewares.ValidateRequestJSON[dto.CreateReminderRequest](), h.CreateReminder)
+	privateGroup.Get("", h.ListReminders)
+	privateGroup.Get("/:reminderId", h.GetReminder)
+	privateGroup.Patch("/:reminderId", middlewares.ValidateRequestJSON[dto.UpdateReminderRequest](), h.UpdateReminder)
+	privateGroup.Delete("/:reminderId", h.DeleteReminder)
+}
+
+// CreateReminder godoc
+// @Summary 		Create Reminder
+// @Description 	Have a note emailed to the signed-in user at a time, optionally repeating by an RRULE with FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, COUNT and UNTIL. Times without an offset and repetitions are read in the user's time zone
+// @Tags 			Reminders
+// @Accept			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			request	body	dto.CreateReminderRequest	true	"Reminder"
+// @Success      	201   {object}  apputils.BaseResponse{data=dto.ReminderItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/reminders [post]
+func (h *ReminderHandler) CreateReminder(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.CreateReminderRequest)
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	reminder, err := h.reminderService.CreateReminder(c.Context(), userIDUUID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(apputils.SuccessResponse(reminder))
+}
+
+// ListReminders godoc
+// @Summary 		List Reminders
+// @Description 	List the signed-in user's reminders, upcoming ones first. Finished reminders have no next_fire_at
+// @Tags 			Reminders
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			note_id	query	string	false	"Only reminders of this note (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse{data=[]dto.ReminderItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/reminders [get]
+func (h *ReminderHandler) ListReminders(c *fiber.Ctx) error {
+	var noteID *uuid.UUID
+	if raw := c.Query("note_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+		}
+		noteID = &id
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	reminders, err := h.reminderService.ListReminders(c.Context(), userIDUUID, noteID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(reminders))
+}
+
+// GetReminder godoc
+// @Summary 		Get Reminder
+// @Description 	Get a reminder of the signed-in user
+// @Tags 			Reminders
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			reminderId	path	string	true	"Reminder ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.ReminderItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/reminders/{reminderId} [get]
+func (h *ReminderHandler) GetReminder(c *fiber.Ctx) error {
+	reminderID, err := uuid.Parse(c.Params("reminderId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid reminder id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	reminder, err := h.reminderService.GetReminder(c.Context(), userIDUUID, reminderID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(reminder))
+}
+
+// UpdateReminder godoc
+// @Summary 		Update Reminder
+// @Description 	Change the fire time or repetition of a reminder, fields left out keep their value. The schedule is computed again in the user's current time zone
+// @Tags 			Reminders
+// @Accept			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			reminderId	path	string						true	"Reminder ID (UUID)"
+// @Param			request		body	dto.UpdateReminderRequest	true	"Changed fields"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.ReminderItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/reminders/{reminderId} [patch]
+func (h *ReminderHandler) UpdateReminder(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.UpdateReminderRequest)
+
+	reminderID, err := uuid.Parse(c.Params("reminderId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid reminder id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	reminder, err := h.reminderService.UpdateReminder(c.Context(), userIDUUID, reminderID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(reminder))
+}
+
+// DeleteReminder godoc
+// @Summary 		Delete Reminder
+// @Description 	Delete a reminder of the signed-in user
+// @Tags 			Reminders
+// @Security		BearerAuth
+// @Param			reminderId	path	string	true	"Reminder ID (UUID)"
+// @Success      	204
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/reminders/{reminderId} [delete]
+func (h *ReminderHandler) DeleteReminder(c *fiber.Ctx) error {
+	reminderID, err := uuid.Parse(c.Params("reminderId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid reminder id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	if err := h.reminderService.DeleteReminder(c.Context(), userIDUUID, reminderID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"bytes"
 	"context"
 	"embed"
+	"errors"
 	"fmt"
+	"html/template"
 	"io/fs"
 	"log/slog"
 	"net/http"
@@ -15,7 +18,10 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rayhan889/neatspace/docs"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	"github.com/rayhan889/neatspace/internal/application/services"
 	"github.com/rayhan889/neatspace/internal/config"
+	templateFS "github.com/rayhan889/neatspace/templates"
 	"github.com/rayhan889/neatspace/web"
 
 	scalar "github.com/bdpiprava/scalar-go"
@@ -23,17 +29,22 @@ import (
 
 // ServerHandler holds dependencies for HTTP handlers.
 type ServerHandler struct {
-	PGPool *pgxpool.Pool
-	Logger *slog.Logger
-	WebFS  embed.FS
+	PGPool      *pgxpool.Pool
+	Logger      *slog.Logger
+	WebFS       embed.FS
+	NoteService services.NoteServiceInterface
+
+	publicNotePage *template.Template
 }
 
 // NewServerHandler creates a new ServerHandler.
-func NewServerHandler(pgPool *pgxpool.Pool, logger *slog.Logger) *ServerHandler {
+func NewServerHandler(pgPool *pgxpool.Pool, logger *slog.Logger, noteService services.NoteServiceInterface) *ServerHandler {
 	return &ServerHandler{
-		PGPool: pgPool,
-		Logger: logger,
-		WebFS:  web.WebDir,
+		PGPool:         pgPool,
+		Logger:         logger,
+		WebFS:          web.WebDir,
+		NoteService:    noteService,
+		publicNotePage: template.Must(template.ParseFS(templateFS.TemplateDir, "pages/public_note.html")),
 	}
 }
 
@@ -51,6 +62,9 @@ func (h *ServerHandler) RegisterRoutes(fiberApp *fiber.App) {
 	// API docs + OpenAPI spec
 	fiberApp.Get("/api-docs", h.APIDocsHandler)
 	fiberApp.Get("/api/openapi.json", h.OpenAPISpecHandler)
+	// Public note links are rendered server-side so they work without the SPA
+	fiberApp.Get("/p/:slug", h.PublicNoteHandler)
+	fiberApp.Post("/p/:slug", h.PublicNoteHandler)
 
 	// Serve index.html for root and all non-static paths (catch-all LAST)
 	fiberApp.Get("/", h.RootHandler(staticFS))
@@ -91,6 +105,72 @@ func (h *ServerHandler) RootHandler(staticFS http.FileSystem) fiber.Handler {
 	}
 }
 
+// publicNoteView is the data of the public note page
+type publicNoteView struct {
+	AppName          string
+	Note             *dto.PublicNoteResponse
+	Content          template.HTML
+	UpdatedAt        time.Time
+	PasswordRequired bool
+	Error            string
+}
+
+// PublicNoteHandler renders a note published through a public link. Password protected
+// links show a form that posts the password back to the same URL.
+func (h *ServerHandler) PublicNoteHandler(c *fiber.Ctx) error {
+	// The note body is user content, lock the page down to inline styles and remote images
+	c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'none'; img-src http: https:; style-src 'unsafe-inline'; form-action 'self'; base-uri 'none'; frame-ancestors 'none'")
+	c.Set(fiber.HeaderReferrerPolicy, "no-referrer")
+	c.Set("X-Robots-Tag", "noindex")
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	var password string
+	if c.Method() == fiber.MethodPost {
+		password = c.FormValue("password")
+	}
+
+	view := publicNoteView{AppName: "Neatspace"}
+	status := fiber.StatusOK
+
+	note, err := h.NoteService.ViewPublicNote(c.Context(), c.Params("slug"), password)
+	switch {
+	case err == nil:
+		view.Note = note
+		// Rendered by the note renderer, which escapes all text and filters URLs
+		view.Content = template.HTML(note.HTML)
+		view.UpdatedAt = note.CreatedAt
+		if note.UpdatedAt != nil {
+			view.UpdatedAt = *note.UpdatedAt
+		}
+	case errors.Is(err, services.ErrPublicLinkPasswordRequired):
+		status = fiber.StatusUnauthorized
+		view.PasswordRequired = true
+	case errors.Is(err, services.ErrPublicLinkPasswordInvalid):
+		status = fiber.StatusUnauthorized
+		view.PasswordRequired = true
+		view.Error = "The password is incorrect, please try again."
+	default:
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) && fiberErr.Code < fiber.StatusInternalServerError {
+			status = fiberErr.Code
+			view.Error = "This link doesn't exist or is no longer available."
+		} else {
+			h.Logger.Error("failed to render public note", "err", err)
+			status = fiber.StatusInternalServerError
+			view.Error = "Something went wrong, please try again later."
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := h.publicNotePage.Execute(&buf, view); err != nil {
+		h.Logger.Error("failed to execute public note template", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/html; charset=utf-8")
+	return c.Status(status).Send(buf.Bytes())
+}
+
 // @Summary		    Service healthcheck
 // @Description	    Checks the health of the service
 // @Tags	        General Information
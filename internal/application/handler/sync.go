@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/rayhan889/neatspace/internal/application/constants"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	"github.com/rayhan889/neatspace/internal/application/middlewares"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+type SyncHandlerInterface interface {
+	SyncNotes(c *fiber.Ctx) error
+	ApplyNoteMutations(c *fiber.Ctx) error
+}
+
+var _ SyncHandlerInterface = (*SyncHandler)(nil)
+
+type SyncHandler struct {
+	noteService services.NoteServiceInterface
+}
+
+type SyncHandlerOpts struct {
+	RouteGroup   fiber.Router
+	NoteService  services.NoteServiceInterface
+	JWTSecretKey []byte
+	SigningAlg   jwa.SignatureAlgorithm
+	Sessions     middlewares.SessionValidator
+}
+
+func NewSyncHandler(opts SyncHandlerOpts) {
+	h := &SyncHandler{
+		noteService: opts.NoteService,
+	}
+
+	g := opts.RouteGroup.Group("/sync", middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg, opts.Sessions))
+	g.Get("/notes", h.SyncNotes)
+	g.Post("/notes", middlewares.ValidateRequestJSON[dto.SyncNotesRequest](), h.ApplyNoteMutations)
+}
+
+// SyncNotes godoc
+// @Summary 		Sync Note Changes
+// @Description 	List the notes created, updated or deleted since the sync token, oldest change first. Without since the initial sync lists every accessible note. Repeat with next_token while has_more is set, then keep the last next_token for the next sync. A token expires (410) after the tombstone retention or once the notes the user can access changed, e.g. by joining a workspace or receiving a share, then a full sync is required.
+// @Tags 			Sync
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			since	query	string	false	"Sync token returned by the previous request"
+// @Param			limit	query	int		false	"Changes per page (1-500, default 100)"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.SyncNotesResponse}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	410   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/sync/notes [get]
+func (h *SyncHandler) SyncNotes(c *fiber.Ctx) error {
+	changes, err := h.noteService.SyncNoteChanges(c.Context(), currentUserID(c), c.Query("since"), c.QueryInt("limit", services.DefaultSyncLimit))
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(changes))
+}
+
+// ApplyNoteMutations godoc
+// @Summary 		Apply Offline Note Changes
+// @Description 	Apply a batch of up to 100 note upserts and deletes made offline, in order. New notes use ids generated by the client. Updates and deletes carry the version the client last saw, if the note changed since the result is a conflict with the current note. Each mutation gets its own result.
+// @Tags 			Sync
+// @Accept 			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			request	body	dto.SyncNotesRequest	true	"Mutations"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.SyncNotesResult}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/sync/notes [post]
+func (h *SyncHandler) ApplyNoteMutations(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.SyncNotesRequest)
+
+	result, err := h.noteService.ApplySyncMutations(clientContext(c), currentUserID(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(result))
+}
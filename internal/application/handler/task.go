@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/rayhan889/neatspace/internal/application/constants"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	"github.com/rayhan889/neatspace/internal/application/middlewares"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+type TaskHandlerInterface interface {
+	ListTasks(c *fiber.Ctx) error
+	SetTaskChecked(c *fiber.Ctx) error
+}
+
+var _ TaskHandlerInterface = (*TaskHandler)(nil)
+
+type TaskHandler struct {
+	noteService services.NoteServiceInterface
+}
+
+type TaskHandlerOpts struct {
+	RouteGroup   fiber.Router
+	NoteService  services.NoteServiceInterface
+	JWTSecretKey []byte
+	SigningAlg   jwa.SignatureAlgorithm
+	Sessions     middlewares.SessionValidator
+}
+
+func NewTaskHandler(opts TaskHandlerOpts) {
+	h := &TaskHandler{
+		noteService: opts.NoteService,
+	}
+
+	jwtMiddleware := middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg, opts.Sessions)
+
+	privateGroup := opts.RouteGroup.Group("/tasks", jwtMiddleware)
+	privateGroup.Get("", h.ListTasks)
+	privateGroup.Patch("/:noteId/:position", middlewares.ValidateRequestJSON[dto.SetTaskCheckedRequest](), h.SetTaskChecked)
+}
+
+// ListTasks godoc
+// @Summary 		List Tasks
+// @Description 	Paginate through the checklist items of all notes the signed-in user can view. Open tasks come first, then by due date with undated tasks last, then by the most recently updated note
+// @Tags 			Tasks
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			page			query	int		false	"Page number (default: 1, min: 1)"				default(1)		minimum(1)
+// @Param			per_page		query	int		false	"Items per page (default: 10, max: 100)"		default(10)		minimum(1)	maximum(100)
+// @Param			status			query	string	false	"Filter by state"								Enums(open, done)
+// @Param			workspace_id	query	string	false	"Workspace ID (UUID)"
+// @Success      	200   {object}  apputils.PaginationResponse[dto.NoteTaskItem]
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/tasks [get]
+func (h *TaskHandler) ListTasks(c *fiber.Ctx) error {
+	p := apputils.Paginate(c)
+
+	filter := &noteEntity.NoteTaskFilter{
+		Status: c.Query("status"),
+	}
+	if raw := c.Query("workspace_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid workspace id")
+		}
+		filter.WorkspaceID = &id
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	data, total, err := h.noteService.ListTasks(c.Context(), userIDUUID, filter, p)
+	if err != nil {
+		return err
+	}
+
+	meta := apputils.PaginationMetaBuilder(c, total)
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(apputils.PaginationBuilder(data, *meta)))
+}
+
+// SetTaskChecked godoc
+// @Summary 		Check Task
+// @Description 	Check or uncheck a task, saving its note as a new version. Requires the If-Match header with the note version the task was listed with, or * to skip the check. A stale version gets 412 with the current note
+// @Tags 			Tasks
+// @Accept			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			noteId		path	string						true	"Note ID (UUID)"
+// @Param			position	path	int							true	"Position of the task in the note"
+// @Param			If-Match	header	string						true	"Note version as ETag, e.g. \"3\""
+// @Param			request		body	dto.SetTaskCheckedRequest	true	"New state"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.NoteTaskItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	412   {object}  apputils.BaseResponse{data=dto.NoteResponse}
+// @Failure      	428   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/tasks/{noteId}/{position} [patch]
+func (h *TaskHandler) SetTaskChecked(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.SetTaskCheckedRequest)
+
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid note id")
+	}
+	position, err := strconv.Atoi(c.Params("position"))
+	if err != nil || position < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid task position")
+	}
+
+	ifVersion, err := parseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	task, err := h.noteService.SetTaskChecked(c.Context(), userIDUUID, noteID, position, ifVersion, *req.Checked)
+	if err != nil {
+		return noteConflictResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, noteETag(task.NoteVersion))
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(task))
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/rayhan889/neatspace/internal/application/constants"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	"github.com/rayhan889/neatspace/internal/application/middlewares"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+type TemplateHandlerInterface interface {
+	CreateTemplate(c *fiber.Ctx) error
+	ListTemplates(c *fiber.Ctx) error
+	GetTemplate(c *fiber.Ctx) error
+	UpdateTemplate(c *fiber.Ctx) error
+	DeleteTemplate(c *fiber.Ctx) error
+	CreateNoteFromTemplate(c *fiber.Ctx) error
+}
+
+var _ TemplateHandlerInterface = (*TemplateHandler)(nil)
+
+type TemplateHandler struct {
+	templateService services.TemplateServiceInterface
+}
+
+type TemplateHandlerOpts struct {
+	RouteGroup      fiber.Router
+	TemplateService services.TemplateServiceInterface
+	JWTSecretKey    []byte
+	SigningAlg      jwa.SignatureAlgorithm
+	Sessions        middlewares.SessionValidator
+}
+
+func NewTemplateHandler(opts TemplateHandlerOpts) {
+	h := &TemplateHandler{
+		templateService: opts.TemplateService,
+	}
+
+	jwtMiddleware := middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg, opts.Sessions)
+
+	privateGroup := opts.RouteGroup.Group("/templates", jwtMiddleware)
+	privateGroup.Post("", middlewares.ValidateRequestJSON[dto.CreateTemplateRequest](), h.CreateTemplate)
+	privateGroup.Get("", h.ListTemplates)
+	privateGroup.Get("/:templateId", h.GetTemplate)
+	privateGroup.Patch("/:templateId", middlewares.ValidateRequestJSON[dto.UpdateTemplateRequest](), h.UpdateTemplate)
+	privateGroup.Delete("/:templateId", h.DeleteTemplate)
+
+	opts.RouteGroup.Post("/notes/from-template/:templateId", jwtMiddleware, middlewares.ValidateRequestJSON[dto.CreateNoteFromTemplateRequest](), h.CreateNoteFromTemplate)
+}
+
+// CreateTemplate godoc
+// @Summary 		Create Template
+// @Description 	Create a note template, personal or shared in a workspace where the signed-in user is at least an editor. The title and text may hold the placeholders {{date}}, {{time}}, {{datetime}}, {{weekday}}, {{user.display_name}}, {{user.username}}, {{user.email}}, {{workspace.name}} and {{prompt.<name>}} for each prompt
+// @Tags 			Templates
+// @Accept			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			request	body	dto.CreateTemplateRequest	true	"Template"
+// @Success      	201   {object}  apputils.BaseResponse{data=dto.TemplateItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/templates [post]
+func (h *TemplateHandler) CreateTemplate(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.CreateTemplateRequest)
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	template, err := h.templateService.CreateTemplate(c.Context(), userIDUUID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(apputils.SuccessResponse(template))
+}
+
+// ListTemplates godoc
+// @Summary 		List Templates
+// @Description 	List the signed-in user's personal templates and the templates of their workspaces, personal ones first. With workspace_id only that workspace's templates are listed
+// @Tags 			Templates
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			workspace_id	query	string	false	"Workspace ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse{data=[]dto.TemplateItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/templates [get]
+func (h *TemplateHandler) ListTemplates(c *fiber.Ctx) error {
+	var workspaceID *uuid.UUID
+	if raw := c.Query("workspace_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid workspace id")
+		}
+		workspaceID = &id
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	templates, err := h.templateService.ListTemplates(c.Context(), userIDUUID, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(templates))
+}
+
+// GetTemplate godoc
+// @Summary 		Get Template
+// @Description 	Get a personal template of the signed-in user or a template of one of their workspaces
+// @Tags 			Templates
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			templateId	path	string	true	"Template ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.TemplateItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/templates/{templateId} [get]
+func (h *TemplateHandler) GetTemplate(c *fiber.Ctx) error {
+	templateID, err := uuid.Parse(c.Params("templateId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid template id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	template, err := h.templateService.GetTemplate(c.Context(), userIDUUID, templateID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(template))
+}
+
+// UpdateTemplate godoc
+// @Summary 		Update Template
+// @Description 	Change a template, fields left out keep their value. Workspace templates can be changed by editors of the workspace
+// @Tags 			Templates
+// @Accept			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			templateId	path	string						true	"Template ID (UUID)"
+// @Param			request		body	dto.UpdateTemplateRequest	true	"Changed fields"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.TemplateItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/templates/{templateId} [patch]
+func (h *TemplateHandler) UpdateTemplate(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.UpdateTemplateRequest)
+
+	templateID, err := uuid.Parse(c.Params("templateId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid template id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	template, err := h.templateService.UpdateTemplate(c.Context(), userIDUUID, templateID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(template))
+}
+
+// DeleteTemplate godoc
+// @Summary 		Delete Template
+// @Description 	Delete a template, notes created from it are kept. Workspace templates can be deleted by editors of the workspace
+// @Tags 			Templates
+// @Security		BearerAuth
+// @Param			templateId	path	string	true	"Template ID (UUID)"
+// @Success      	204
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/templates/{templateId} [delete]
+func (h *TemplateHandler) DeleteTemplate(c *fiber.Ctx) error {
+	templateID, err := uuid.Parse(c.Params("templateId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid template id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	if err := h.templateService.DeleteTemplate(c.Context(), userIDUUID, templateID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// CreateNoteFromTemplate godoc
+// @Summary 		Create Note From Template
+// @Description 	Create a note from a template with its placeholders filled in. Dates and times are in the signed-in user's time zone, prompts take the given values or their defaults. The note goes to the given workspace, else the template's workspace or the personal workspace
+// @Tags 			Templates
+// @Accept			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			templateId	path	string								true	"Template ID (UUID)"
+// @Param			request		body	dto.CreateNoteFromTemplateRequest	true	"Target workspace and prompt values"
+// @Success      	201   {object}  apputils.BaseResponse{data=dto.NoteResponse}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/notes/from-template/{templateId} [post]
+func (h *TemplateHandler) CreateNoteFromTemplate(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.CreateNoteFromTemplateRequest)
+
+	templateID, err := uuid.Parse(c.Params("templateId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid template id")
+	}
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	note, err := h.templateService.CreateNoteFromTemplate(c.Context(), userIDUUID, templateID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(apputils.SuccessResponse(note))
+}
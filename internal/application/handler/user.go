@@ -5,6 +5,7 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/rayhan889/neatspace/internal/application/constants"
 	"github.com/rayhan889/neatspace/internal/application/handler/dto"
 	"github.com/rayhan889/neatspace/internal/application/middlewares"
@@ -16,27 +17,46 @@ import (
 type UserHandlerInterface interface {
 	PaginationUser(c *fiber.Ctx) error
 	CreateUser(c *fiber.Ctx) error
+	ChangeEmail(c *fiber.Ctx) error
+	GetProfile(c *fiber.Ctx) error
+	UpdateProfile(c *fiber.Ctx) error
+	LoginHistory(c *fiber.Ctx) error
+	DeleteAccount(c *fiber.Ctx) error
 }
 
 var _ UserHandlerInterface = (*UserHandler)(nil)
 
 type UserHandler struct {
 	userService services.UserServiceInterface
+	authService services.AuthServiceInterface
 }
 
 type UserHandlerOpts struct {
-	RouteGroup  fiber.Router
-	UserService services.UserServiceInterface
+	RouteGroup   fiber.Router
+	UserService  services.UserServiceInterface
+	AuthService  services.AuthServiceInterface
+	JWTSecretKey []byte
+	SigningAlg   jwa.SignatureAlgorithm
+	Sessions     middlewares.SessionValidator
+	ReauthWindow time.Duration
 }
 
 func NewUserHandler(opts UserHandlerOpts) {
 	h := &UserHandler{
 		userService: opts.UserService,
+		authService: opts.AuthService,
 	}
 
 	g := opts.RouteGroup.Group("/users")
 	g.Get("", h.PaginationUser)
 	g.Post("", middlewares.ValidateRequestJSON[dto.CreateUser](), h.CreateUser)
+
+	meGroup := g.Group("/me", middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg, opts.Sessions))
+	meGroup.Get("", h.GetProfile)
+	meGroup.Patch("", middlewares.ValidateRequestJSON[dto.UpdateProfileRequest](), h.UpdateProfile)
+	meGroup.Get("/login-history", h.LoginHistory)
+	meGroup.Delete("", middlewares.RequireRecentAuth(opts.AuthService, opts.ReauthWindow), h.DeleteAccount)
+	meGroup.Post("/email", middlewares.RequireRecentAuth(opts.AuthService, opts.ReauthWindow), middlewares.ValidateRequestJSON[dto.ChangeEmailRequest](), h.ChangeEmail)
 }
 
 // PaginationUser godoc
@@ -97,3 +117,160 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 
 	return c.SendStatus(fiber.StatusCreated)
 }
+
+// ChangeEmail godoc
+// @Summary 		Change Email
+// @Description 	Request an email change for the current user. A confirmation link is sent to the new address and a notice with a cancel link to the current one
+// @Tags 			Users
+// @Accept 			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			request	body	dto.ChangeEmailRequest	true	"Email change request"
+// @Success      	200   {object}  apputils.BaseResponse
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/users/me/email [post]
+func (h *UserHandler) ChangeEmail(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.ChangeEmailRequest)
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	err := h.authService.InitiateEmailChange(c.Context(), userIDUUID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "Email change confirmation sent successfully",
+	}))
+}
+
+// GetProfile godoc
+// @Summary 		Get Current User
+// @Description 	Get the profile of the signed-in user. Requests made with an admin impersonation token are flagged with impersonated and impersonated_by
+// @Tags 			Users
+// @Produce 		json
+// @Security		BearerAuth
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.UserProfile}
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/users/me [get]
+func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	profile, err := h.userService.GetProfile(c.Context(), userIDUUID)
+	if err != nil {
+		return err
+	}
+	flagImpersonation(c, profile)
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(profile))
+}
+
+// UpdateProfile godoc
+// @Summary 		Update Current User
+// @Description 	Update the display name, username or timezone of the signed-in user. Omitted fields are left unchanged
+// @Tags 			Users
+// @Accept 			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			request	body	dto.UpdateProfileRequest	true	"Profile fields to update"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.UserProfile}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	422   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/users/me [patch]
+func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.UpdateProfileRequest)
+
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	profile, err := h.userService.UpdateProfile(c.Context(), userIDUUID, req)
+	if err != nil {
+		return err
+	}
+	flagImpersonation(c, profile)
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(profile))
+}
+
+// LoginHistory godoc
+// @Summary 		Current User Login History
+// @Description 	Paginate through the sign-in attempts on the signed-in user's account, newest first
+// @Tags 			Users
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			page		query	int		false	"Page number (default: 1, min: 1)"				default(1)		minimum(1)
+// @Param			per_page	query	int		false	"Items per page (default: 10, max: 100)"		default(10)		minimum(1)	maximum(100)
+// @Success      	200   {object}  apputils.PaginationResponse[dto.LoginHistoryItem]
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/users/me/login-history [get]
+func (h *UserHandler) LoginHistory(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	p := apputils.Paginate(c)
+
+	data, total, err := h.authService.PaginationLoginHistory(c.Context(), userIDUUID, p)
+	if err != nil {
+		return err
+	}
+
+	meta := apputils.PaginationMetaBuilder(c, total)
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(apputils.PaginationBuilder(data, *meta)))
+}
+
+// DeleteAccount godoc
+// @Summary 		Delete Account
+// @Description 	Deactivate the signed-in user's account and schedule it for permanent deletion. All sessions are revoked and a link to cancel within the grace period is emailed. Shared workspaces owned by the user are handed over to another member on deletion, notes written in workspaces of others stay there without an author (requires recent re-authentication)
+// @Tags 			Users
+// @Produce 		json
+// @Security		BearerAuth
+// @Success      	200   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/users/me [delete]
+func (h *UserHandler) DeleteAccount(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	userIDUUID := apputils.UUIDChecker(userID)
+
+	scheduledAt, err := h.authService.RequestAccountDeletion(c.Context(), userIDUUID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "Account scheduled for deletion",
+		"data": map[string]any{
+			"deletion_scheduled_at": scheduledAt,
+		},
+	}))
+}
+
+// flagImpersonation marks the profile when the request was made with an impersonation token
+func flagImpersonation(c *fiber.Ctx, profile *dto.UserProfile) {
+	impersonatorID, ok := c.Locals("impersonator_id").(string)
+	if !ok {
+		return
+	}
+
+	actorID := apputils.UUIDChecker(impersonatorID)
+	profile.Impersonated = true
+	profile.ImpersonatedBy = &actorID
+}
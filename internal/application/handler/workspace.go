@@ -0,0 +1,463 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/rayhan889/neatspace/internal/application/constants"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	"github.com/rayhan889/neatspace/internal/application/middlewares"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+type WorkspaceHandlerInterface interface {
+	CreateWorkspace(c *fiber.Ctx) error
+	ListWorkspaces(c *fiber.Ctx) error
+	GetWorkspace(c *fiber.Ctx) error
+	UpdateWorkspace(c *fiber.Ctx) error
+	DeleteWorkspace(c *fiber.Ctx) error
+	ListMembers(c *fiber.Ctx) error
+	UpdateMemberRole(c *fiber.Ctx) error
+	RemoveMember(c *fiber.Ctx) error
+	TransferOwnership(c *fiber.Ctx) error
+	InviteMember(c *fiber.Ctx) error
+	ListInvitations(c *fiber.Ctx) error
+	RevokeInvitation(c *fiber.Ctx) error
+	AcceptInvitation(c *fiber.Ctx) error
+}
+
+var _ WorkspaceHandlerInterface = (*WorkspaceHandler)(nil)
+
+type WorkspaceHandler struct {
+	workspaceService services.WorkspaceServiceInterface
+}
+
+type WorkspaceHandlerOpts struct {
+	RouteGroup       fiber.Router
+	WorkspaceService services.WorkspaceServiceInterface
+	JWTSecretKey     []byte
+	SigningAlg       jwa.SignatureAlgorithm
+	Sessions         middlewares.SessionValidator
+}
+
+func NewWorkspaceHandler(opts WorkspaceHandlerOpts) {
+	h := &WorkspaceHandler{
+		workspaceService: opts.WorkspaceService,
+	}
+
+	g := opts.RouteGroup.Group("/workspaces", middlewares.JWTMiddleware(opts.JWTSecretKey, opts.SigningAlg, opts.Sessions))
+	g.Post("", middlewares.ValidateRequestJSON[dto.CreateWorkspaceRequest](), h.CreateWorkspace)
+	g.Get("", h.ListWorkspaces)
+	g.Post("/invitations/accept", middlewares.ValidateRequestJSON[dto.AcceptWorkspaceInvitationRequest](), h.AcceptInvitation)
+	g.Get("/:workspaceId", h.GetWorkspace)
+	g.Patch("/:workspaceId", middlewares.ValidateRequestJSON[dto.UpdateWorkspaceRequest](), h.UpdateWorkspace)
+	g.Delete("/:workspaceId", h.DeleteWorkspace)
+	g.Get("/:workspaceId/members", h.ListMembers)
+	g.Patch("/:workspaceId/members/:userId", middlewares.ValidateRequestJSON[dto.UpdateWorkspaceMemberRoleRequest](), h.UpdateMemberRole)
+	g.Delete("/:workspaceId/members/:userId", h.RemoveMember)
+	g.Post("/:workspaceId/transfer", middlewares.ValidateRequestJSON[dto.TransferWorkspaceRequest](), h.TransferOwnership)
+	g.Post("/:workspaceId/invitations", middlewares.ValidateRequestJSON[dto.InviteWorkspaceMemberRequest](), h.InviteMember)
+	g.Get("/:workspaceId/invitations", h.ListInvitations)
+	g.Delete("/:workspaceId/invitations/:invitationId", h.RevokeInvitation)
+}
+
+// CreateWorkspace godoc
+// @Summary 		Create Workspace
+// @Description 	Create a shared workspace owned by the current user
+// @Tags 			Workspaces
+// @Accept 			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			request		body	dto.CreateWorkspaceRequest	true	"Workspace details"
+// @Success      	201   {object}  apputils.BaseResponse{data=dto.WorkspaceItem}
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	422   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/workspaces [post]
+func (h *WorkspaceHandler) CreateWorkspace(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.CreateWorkspaceRequest)
+
+	workspace, err := h.workspaceService.CreateWorkspace(clientContext(c), currentUserID(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(apputils.SuccessResponse(workspace))
+}
+
+// ListWorkspaces godoc
+// @Summary 		List Workspaces
+// @Description 	List the workspaces the current user is a member of, personal workspace first
+// @Tags 			Workspaces
+// @Produce 		json
+// @Security		BearerAuth
+// @Success      	200   {object}  apputils.BaseResponse{data=[]dto.WorkspaceItem}
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/workspaces [get]
+func (h *WorkspaceHandler) ListWorkspaces(c *fiber.Ctx) error {
+	workspaces, err := h.workspaceService.ListWorkspaces(c.Context(), currentUserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(workspaces))
+}
+
+// GetWorkspace godoc
+// @Summary 		Get Workspace
+// @Description 	Get a workspace the current user is a member of
+// @Tags 			Workspaces
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			workspaceId		path	string	true	"Workspace ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.WorkspaceItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/workspaces/{workspaceId} [get]
+func (h *WorkspaceHandler) GetWorkspace(c *fiber.Ctx) error {
+	workspaceID, err := workspaceIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	workspace, err := h.workspaceService.GetWorkspace(c.Context(), currentUserID(c), workspaceID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(workspace))
+}
+
+// UpdateWorkspace godoc
+// @Summary 		Update Workspace
+// @Description 	Rename a workspace (admin role required)
+// @Tags 			Workspaces
+// @Accept 			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			workspaceId		path	string						true	"Workspace ID (UUID)"
+// @Param			request			body	dto.UpdateWorkspaceRequest	true	"Workspace details"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.WorkspaceItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	422   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/workspaces/{workspaceId} [patch]
+func (h *WorkspaceHandler) UpdateWorkspace(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.UpdateWorkspaceRequest)
+
+	workspaceID, err := workspaceIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	workspace, err := h.workspaceService.UpdateWorkspace(clientContext(c), currentUserID(c), workspaceID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(workspace))
+}
+
+// DeleteWorkspace godoc
+// @Summary 		Delete Workspace
+// @Description 	Delete a shared workspace with all of its notes (owner only). Personal workspaces can't be deleted.
+// @Tags 			Workspaces
+// @Security		BearerAuth
+// @Param			workspaceId		path	string	true	"Workspace ID (UUID)"
+// @Success      	204
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/workspaces/{workspaceId} [delete]
+func (h *WorkspaceHandler) DeleteWorkspace(c *fiber.Ctx) error {
+	workspaceID, err := workspaceIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.workspaceService.DeleteWorkspace(clientContext(c), currentUserID(c), workspaceID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListMembers godoc
+// @Summary 		List Workspace Members
+// @Description 	List the members of a workspace with their roles
+// @Tags 			Workspaces
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			workspaceId		path	string	true	"Workspace ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse{data=[]dto.WorkspaceMemberItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/workspaces/{workspaceId}/members [get]
+func (h *WorkspaceHandler) ListMembers(c *fiber.Ctx) error {
+	workspaceID, err := workspaceIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	members, err := h.workspaceService.ListMembers(c.Context(), currentUserID(c), workspaceID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(members))
+}
+
+// UpdateMemberRole godoc
+// @Summary 		Update Member Role
+// @Description 	Change the role of a member (admin role required, only the owner manages admins)
+// @Tags 			Workspaces
+// @Accept 			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			workspaceId		path	string									true	"Workspace ID (UUID)"
+// @Param			userId			path	string									true	"User ID (UUID)"
+// @Param			request			body	dto.UpdateWorkspaceMemberRoleRequest	true	"New role"
+// @Success      	200   {object}  apputils.BaseResponse
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/workspaces/{workspaceId}/members/{userId} [patch]
+func (h *WorkspaceHandler) UpdateMemberRole(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.UpdateWorkspaceMemberRoleRequest)
+
+	workspaceID, userID, err := workspaceAndMemberParams(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.workspaceService.UpdateMemberRole(clientContext(c), currentUserID(c), workspaceID, userID, req.Role); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "Member role updated successfully",
+	}))
+}
+
+// RemoveMember godoc
+// @Summary 		Remove Member
+// @Description 	Remove a member from the workspace, or leave it when userId is the current user
+// @Tags 			Workspaces
+// @Security		BearerAuth
+// @Param			workspaceId		path	string	true	"Workspace ID (UUID)"
+// @Param			userId			path	string	true	"User ID (UUID)"
+// @Success      	204
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/workspaces/{workspaceId}/members/{userId} [delete]
+func (h *WorkspaceHandler) RemoveMember(c *fiber.Ctx) error {
+	workspaceID, userID, err := workspaceAndMemberParams(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.workspaceService.RemoveMember(clientContext(c), currentUserID(c), workspaceID, userID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// TransferOwnership godoc
+// @Summary 		Transfer Workspace
+// @Description 	Make a member the owner of the workspace (owner role required). The current owner stays as an admin
+// @Tags 			Workspaces
+// @Accept 			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			workspaceId		path	string							true	"Workspace ID (UUID)"
+// @Param			request			body	dto.TransferWorkspaceRequest	true	"New owner"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.WorkspaceItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/workspaces/{workspaceId}/transfer [post]
+func (h *WorkspaceHandler) TransferOwnership(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.TransferWorkspaceRequest)
+
+	workspaceID, err := workspaceIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	workspace, err := h.workspaceService.TransferOwnership(clientContext(c), currentUserID(c), workspaceID, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(workspace))
+}
+
+// InviteMember godoc
+// @Summary 		Invite Member
+// @Description 	Email an invitation to join the workspace (admin role required, only the owner invites admins)
+// @Tags 			Workspaces
+// @Accept 			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			workspaceId		path	string								true	"Workspace ID (UUID)"
+// @Param			request			body	dto.InviteWorkspaceMemberRequest	true	"Invitation details"
+// @Success      	202   {object}  apputils.BaseResponse
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	422   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/workspaces/{workspaceId}/invitations [post]
+func (h *WorkspaceHandler) InviteMember(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.InviteWorkspaceMemberRequest)
+
+	workspaceID, err := workspaceIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.workspaceService.InviteMember(clientContext(c), currentUserID(c), workspaceID, req); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(apputils.SuccessResponse(map[string]any{
+		"message": "Invitation sent successfully",
+	}))
+}
+
+// ListInvitations godoc
+// @Summary 		List Invitations
+// @Description 	List the pending invitations of a workspace (admin role required)
+// @Tags 			Workspaces
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			workspaceId		path	string	true	"Workspace ID (UUID)"
+// @Success      	200   {object}  apputils.BaseResponse{data=[]dto.WorkspaceInvitationItem}
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/workspaces/{workspaceId}/invitations [get]
+func (h *WorkspaceHandler) ListInvitations(c *fiber.Ctx) error {
+	workspaceID, err := workspaceIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	invitations, err := h.workspaceService.ListInvitations(c.Context(), currentUserID(c), workspaceID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(invitations))
+}
+
+// RevokeInvitation godoc
+// @Summary 		Revoke Invitation
+// @Description 	Revoke a pending invitation so its link can no longer be used (admin role required)
+// @Tags 			Workspaces
+// @Security		BearerAuth
+// @Param			workspaceId		path	string	true	"Workspace ID (UUID)"
+// @Param			invitationId	path	string	true	"Invitation ID (UUID)"
+// @Success      	204
+// @Failure      	400   {object}  apputils.BaseResponse
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/workspaces/{workspaceId}/invitations/{invitationId} [delete]
+func (h *WorkspaceHandler) RevokeInvitation(c *fiber.Ctx) error {
+	workspaceID, err := workspaceIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	invitationID, err := uuid.Parse(c.Params("invitationId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid invitation id")
+	}
+
+	if err := h.workspaceService.RevokeInvitation(clientContext(c), currentUserID(c), workspaceID, invitationID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// AcceptInvitation godoc
+// @Summary 		Accept Invitation
+// @Description 	Join a workspace with the token from an invitation email. The current user's email must match the invited address.
+// @Tags 			Workspaces
+// @Accept 			json
+// @Produce 		json
+// @Security		BearerAuth
+// @Param			request		body	dto.AcceptWorkspaceInvitationRequest	true	"Invitation token"
+// @Success      	200   {object}  apputils.BaseResponse{data=dto.WorkspaceItem}
+// @Failure      	401   {object}  apputils.BaseResponse
+// @Failure      	403   {object}  apputils.BaseResponse
+// @Failure      	404   {object}  apputils.BaseResponse
+// @Failure      	409   {object}  apputils.BaseResponse
+// @Failure      	410   {object}  apputils.BaseResponse
+// @Failure      	422   {object}  apputils.BaseResponse
+// @Failure      	500   {object}  apputils.BaseResponse
+// @Router       	/api/v1/workspaces/invitations/accept [post]
+func (h *WorkspaceHandler) AcceptInvitation(c *fiber.Ctx) error {
+	req := c.Locals(constants.RequestBodyJSONKey).(*dto.AcceptWorkspaceInvitationRequest)
+
+	workspace, err := h.workspaceService.AcceptInvitation(clientContext(c), currentUserID(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(apputils.SuccessResponse(workspace))
+}
+
+func currentUserID(c *fiber.Ctx) uuid.UUID {
+	return apputils.UUIDChecker(c.Locals("user_id").(string))
+}
+
+func workspaceIDParam(c *fiber.Ctx) (uuid.UUID, error) {
+	workspaceID, err := uuid.Parse(c.Params("workspaceId"))
+	if err != nil {
+		return uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "invalid workspace id")
+	}
+	return workspaceID, nil
+}
+
+func workspaceAndMemberParams(c *fiber.Ctx) (workspaceID, userID uuid.UUID, err error) {
+	workspaceID, err = workspaceIDParam(c)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	userID, err = uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "invalid user id")
+	}
+
+	return workspaceID, userID, nil
+}
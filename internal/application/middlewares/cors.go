@@ -23,6 +23,8 @@ func CORSMiddleware(cfg *config.Config) fiber.Handler {
 			fiber.HeaderOrigin,
 			fiber.HeaderContentType,
 			fiber.HeaderAccept,
+			fiber.HeaderIfMatch,
+			fiber.HeaderIfNoneMatch,
 		}, ","),
 		ExposeHeaders: strings.Join([]string{
 			fiber.HeaderAccept,
@@ -32,6 +34,7 @@ func CORSMiddleware(cfg *config.Config) fiber.Handler {
 			fiber.HeaderConnection,
 			fiber.HeaderContentLength,
 			fiber.HeaderContentType,
+			fiber.HeaderETag,
 			fiber.HeaderOrigin,
 			"X-CSRF-Token",
 			fiber.HeaderXRequestID,
@@ -7,12 +7,21 @@ import (
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/lestrrat-go/jwx/jwt"
 	"github.com/rayhan889/neatspace/pkg/apputils"
 )
 
-func JWTMiddleware(secret []byte, alg jwa.SignatureAlgorithm) fiber.Handler {
+// SessionValidator reports whether the session an access token was issued for is still active
+type SessionValidator interface {
+	IsSessionActive(ctx context.Context, sessionID, userID uuid.UUID) (bool, error)
+}
+
+// JWTMiddleware authenticates the request by its bearer access token. The session behind the
+// token is looked up on every request, so revoked sessions and deactivated users lose access
+// right away instead of when the token expires.
+func JWTMiddleware(secret []byte, alg jwa.SignatureAlgorithm, sessions SessionValidator) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		auth := c.Get("Authorization")
 		if auth == "" {
@@ -45,14 +54,26 @@ func JWTMiddleware(secret []byte, alg jwa.SignatureAlgorithm) fiber.Handler {
 		c.Locals("jwt_claims", claims)
 		log.Println("claims", claims)
 
+		var userID, sessionID string
 		if sub, ok := claims[jwt.SubjectKey]; ok {
-			c.Locals("user_id", fmt.Sprint(sub))
+			userID = fmt.Sprint(sub)
+			c.Locals("user_id", userID)
 		}
 
 		if sid, ok := claims["sid"]; ok {
-			c.Locals("session_id", fmt.Sprint(sid))
+			sessionID = fmt.Sprint(sid)
 		} else if sid2, ok := claims["SID"]; ok {
-			c.Locals("session_id", fmt.Sprint(sid2))
+			sessionID = fmt.Sprint(sid2)
+		}
+		c.Locals("session_id", sessionID)
+
+		if err := checkSession(c.Context(), sessions, sessionID, userID); err != nil {
+			return err
+		}
+		if act, ok := claims["act"].(map[string]any); ok {
+			if actorID, ok := act["sub"]; ok {
+				c.Locals("impersonator_id", fmt.Sprint(actorID))
+			}
 		}
 		if aud, ok := claims["aud"]; ok {
 			c.Locals("audience", fmt.Sprint(aud))
@@ -66,3 +87,45 @@ func JWTMiddleware(secret []byte, alg jwa.SignatureAlgorithm) fiber.Handler {
 		return c.Next()
 	}
 }
+
+func checkSession(ctx context.Context, sessions SessionValidator, sid, sub string) error {
+	sessionID, err := uuid.Parse(sid)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "missing session in token")
+	}
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "missing user in token")
+	}
+
+	active, err := sessions.IsSessionActive(ctx, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return fiber.NewError(fiber.StatusUnauthorized, "session has ended")
+	}
+
+	return nil
+}
+
+// WebSocketJWTMiddleware authenticates WebSocket upgrades like JWTMiddleware. Browsers can't
+// set headers on WebSocket requests, so the access token may also come in the access_token query.
+func WebSocketJWTMiddleware(secret []byte, alg jwa.SignatureAlgorithm, sessions SessionValidator) fiber.Handler {
+	authenticate := JWTMiddleware(secret, alg, sessions)
+
+	return func(c *fiber.Ctx) error {
+		if c.Get(fiber.HeaderAuthorization) == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request().Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+
+				// Keep the token out of the request log
+				uri := c.Request().URI()
+				uri.QueryArgs().Del("access_token")
+				uri.SetQueryStringBytes(uri.QueryArgs().QueryString())
+			}
+		}
+
+		return authenticate(c)
+	}
+}
@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RecentAuthChecker resolves when a session last proved the user's identity
+type RecentAuthChecker interface {
+	GetSessionAuthTime(ctx context.Context, sessionID uuid.UUID) (*time.Time, error)
+}
+
+// RequireRecentAuth rejects the request unless the current session re-authenticated
+// within the given window. Impersonated sessions are always rejected, an admin acting
+// as the user can't change their credentials or delete the account.
+// It must run after JWTMiddleware, which sets the session_id.
+func RequireRecentAuth(checker RecentAuthChecker, window time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if impersonating(c) {
+			return errImpersonating
+		}
+
+		sid, _ := c.Locals("session_id").(string)
+		sessionID, err := uuid.Parse(sid)
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "missing session in token")
+		}
+
+		authTime, err := checker.GetSessionAuthTime(c.Context(), sessionID)
+		if err != nil {
+			return err
+		}
+
+		if authTime == nil || time.Since(*authTime) > window {
+			return fiber.NewError(fiber.StatusForbidden, "reauthentication required")
+		}
+
+		return c.Next()
+	}
+}
+
+// RejectImpersonation rejects impersonated sessions, for credential routes that don't require
+// a recent authentication. It must run after JWTMiddleware, which sets the impersonator_id.
+func RejectImpersonation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if impersonating(c) {
+			return errImpersonating
+		}
+
+		return c.Next()
+	}
+}
+
+var errImpersonating = fiber.NewError(fiber.StatusForbidden, "not allowed while impersonating a user")
+
+func impersonating(c *fiber.Ctx) bool {
+	_, ok := c.Locals("impersonator_id").(string)
+	return ok
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"context"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RoleResolver resolves the role of a user, returning an empty string for unknown users
+type RoleResolver interface {
+	GetUserRole(ctx context.Context, userID uuid.UUID) (string, error)
+}
+
+// RequireRole rejects the request unless the signed-in user has one of the given roles.
+// The role is read from the database on every request so demotions apply immediately.
+// It must run after JWTMiddleware, which sets the user_id.
+func RequireRole(resolver RoleResolver, roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, _ := c.Locals("user_id").(string)
+		userID, err := uuid.Parse(uid)
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "missing user in token")
+		}
+
+		role, err := resolver.GetUserRole(c.Context(), userID)
+		if err != nil {
+			return err
+		}
+
+		if !slices.Contains(roles, role) {
+			return fiber.NewError(fiber.StatusForbidden, "insufficient permissions")
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	"github.com/rayhan889/neatspace/internal/audit"
+	userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+type AdminServiceInterface interface {
+	SearchUsers(ctx context.Context, filter *userEntity.AdminUserFilter, p *apputils.Pagination) (data []dto.AdminUserItem, total int, err error)
+	GetUserDetail(ctx context.Context, actorID, userID uuid.UUID) (*dto.AdminUserDetail, error)
+	UpdateUserRole(ctx context.Context, actorID, userID uuid.UUID, role string) error
+	VerifyUserEmail(ctx context.Context, actorID, userID uuid.UUID) error
+	DeactivateUser(ctx context.Context, actorID, userID uuid.UUID) error
+	ReactivateUser(ctx context.Context, actorID, userID uuid.UUID) error
+	ForcePasswordReset(ctx context.Context, actorID, userID uuid.UUID) error
+	RevokeUserSessions(ctx context.Context, actorID, userID uuid.UUID) error
+	StartImpersonation(ctx context.Context, actorID, userID uuid.UUID) (*dto.ImpersonationResponse, error)
+	EndImpersonation(ctx context.Context, actorID, sessionID uuid.UUID) error
+	PaginationAuditEvents(ctx context.Context, filter *audit.Filter, p *apputils.Pagination) (data []dto.AuditEventItem, total int, err error)
+}
+
+var _ AdminServiceInterface = (*AdminService)(nil)
+
+type AdminService struct {
+	auditRecorder audit.RecorderInterface
+	userService   UserServiceInterface
+	authService   AuthServiceInterface
+	noteService   NoteServiceInterface
+	logger        *slog.Logger
+}
+
+type AdminServiceOpts struct {
+	AuditRecorder audit.RecorderInterface
+	UserService   UserServiceInterface
+	AuthService   AuthServiceInterface
+	NoteService   NoteServiceInterface
+	Logger        *slog.Logger
+}
+
+func NewAdminService(opts AdminServiceOpts) *AdminService {
+	return &AdminService{
+		auditRecorder: opts.AuditRecorder,
+		userService:   opts.UserService,
+		authService:   opts.AuthService,
+		noteService:   opts.NoteService,
+		logger:        opts.Logger,
+	}
+}
+
+func (s *AdminService) SearchUsers(ctx context.Context, filter *userEntity.AdminUserFilter, p *apputils.Pagination) (data []dto.AdminUserItem, total int, err error) {
+	users, total, err := s.userService.SearchUsers(ctx, filter, p)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data = make([]dto.AdminUserItem, 0, len(users))
+	for i := range users {
+		data = append(data, toAdminUserItem(&users[i]))
+	}
+
+	return data, total, nil
+}
+
+// GetUserDetail returns the account with its sessions and note count. Viewing is audited
+// because the detail exposes the user's IP addresses and devices.
+func (s *AdminService) GetUserDetail(ctx context.Context, actorID, userID uuid.UUID) (*dto.AdminUserDetail, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.authService.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	noteCount, err := s.noteService.CountUserNotes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &dto.AdminUserDetail{
+		AdminUserItem: toAdminUserItem(user),
+		Timezone:      "UTC",
+		UpdatedAt:     user.UpdatedAt,
+		NoteCount:     noteCount,
+		Sessions:      sessions,
+	}
+	if user.Metadata != nil && user.Metadata.Timezone != "" {
+		detail.Timezone = user.Metadata.Timezone
+	}
+
+	s.audit(ctx, actorID, audit.ActionUserView, userID, nil)
+
+	return detail, nil
+}
+
+func (s *AdminService) UpdateUserRole(ctx context.Context, actorID, userID uuid.UUID, role string) error {
+	if actorID == userID {
+		return fiber.NewError(fiber.StatusBadRequest, "you can't change your own role")
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	previous := user.GetRole()
+	if previous == role {
+		return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("user already has the %s role", role))
+	}
+
+	if err := s.userService.UpdateRole(ctx, userID, role); err != nil {
+		return err
+	}
+
+	s.audit(ctx, actorID, audit.ActionUserRoleUpdate, userID, map[string]any{
+		"previous_role": previous,
+		"role":          role,
+	})
+
+	return nil
+}
+
+func (s *AdminService) VerifyUserEmail(ctx context.Context, actorID, userID uuid.UUID) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return fiber.NewError(fiber.StatusConflict, "email already verified")
+	}
+
+	if err := s.userService.MarkEmailVerified(ctx, userID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, actorID, audit.ActionUserEmailVerify, userID, map[string]any{
+		"email": user.Email,
+	})
+
+	return nil
+}
+
+// DeactivateUser blocks sign in for the account and signs it out everywhere
+func (s *AdminService) DeactivateUser(ctx context.Context, actorID, userID uuid.UUID) error {
+	if actorID == userID {
+		return fiber.NewError(fiber.StatusBadRequest, "you can't deactivate your own account")
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.IsDeactivated() {
+		return fiber.NewError(fiber.StatusConflict, "account already deactivated")
+	}
+
+	if err := s.userService.Deactivate(ctx, userID, time.Now()); err != nil {
+		return err
+	}
+
+	if err := s.authService.RevokeAllSessions(ctx, userID, actorID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, actorID, audit.ActionUserDeactivate, userID, nil)
+
+	return nil
+}
+
+// ReactivateUser lifts a deactivation. A deletion requested by the user is cancelled as well.
+func (s *AdminService) ReactivateUser(ctx context.Context, actorID, userID uuid.UUID) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsDeactivated() {
+		return fiber.NewError(fiber.StatusConflict, "account is not deactivated")
+	}
+
+	if err := s.userService.Reactivate(ctx, userID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, actorID, audit.ActionUserReactivate, userID, map[string]any{
+		"cancelled_deletion": user.DeletionScheduledAt != nil,
+	})
+
+	return nil
+}
+
+func (s *AdminService) ForcePasswordReset(ctx context.Context, actorID, userID uuid.UUID) error {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.authService.ForcePasswordReset(ctx, userID, actorID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, actorID, audit.ActionUserPasswordReset, userID, nil)
+
+	return nil
+}
+
+func (s *AdminService) RevokeUserSessions(ctx context.Context, actorID, userID uuid.UUID) error {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.authService.RevokeAllSessions(ctx, userID, actorID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, actorID, audit.ActionUserSessionsRevoke, userID, nil)
+
+	return nil
+}
+
+// StartImpersonation issues a short-lived token to act as the user. Admins can't be
+// impersonated, the token would carry their privileges.
+func (s *AdminService) StartImpersonation(ctx context.Context, actorID, userID uuid.UUID) (*dto.ImpersonationResponse, error) {
+	if actorID == userID {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "you can't impersonate yourself")
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.GetRole() == userEntity.RoleAdmin {
+		return nil, fiber.NewError(fiber.StatusForbidden, "admins can't be impersonated")
+	}
+	if user.IsDeactivated() {
+		return nil, fiber.NewError(fiber.StatusConflict, "account is deactivated")
+	}
+
+	impersonation, err := s.authService.StartImpersonation(ctx, actorID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, actorID, audit.ActionImpersonateStart, userID, map[string]any{
+		"session_id": impersonation.SessionID.String(),
+		"expires_at": impersonation.ExpiresAt,
+	})
+
+	return impersonation, nil
+}
+
+func (s *AdminService) EndImpersonation(ctx context.Context, actorID, sessionID uuid.UUID) error {
+	session, err := s.authService.EndImpersonation(ctx, actorID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, actorID, audit.ActionImpersonateEnd, session.UserID, map[string]any{
+		"session_id": sessionID.String(),
+	})
+
+	return nil
+}
+
+func (s *AdminService) PaginationAuditEvents(ctx context.Context, filter *audit.Filter, p *apputils.Pagination) (data []dto.AuditEventItem, total int, err error) {
+	events, total, err := s.auditRecorder.PaginationEvents(ctx, filter, p)
+	if err != nil {
+		return nil, 0, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting audit events: %v", err))
+	}
+
+	data = make([]dto.AuditEventItem, 0, len(events))
+	for _, event := range events {
+		item := dto.AuditEventItem{
+			ID:             event.ID,
+			ActorID:        event.ActorID,
+			ImpersonatorID: event.ImpersonatorID,
+			Action:         event.Action,
+			TargetType:     event.TargetType,
+			TargetID:       event.TargetID,
+			Metadata:       event.Metadata,
+			UserAgent:      event.UserAgent,
+			CreatedAt:      event.CreatedAt,
+		}
+		if event.IPAddress != nil {
+			ip := event.IPAddress.String()
+			item.IPAddress = &ip
+		}
+		data = append(data, item)
+	}
+
+	return data, total, nil
+}
+
+func (s *AdminService) getUser(ctx context.Context, userID uuid.UUID) (*userEntity.UserEntity, error) {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s cannot be found", userID.String()))
+	}
+	return user, nil
+}
+
+// audit records an admin action on the user account
+func (s *AdminService) audit(ctx context.Context, actorID uuid.UUID, action string, targetUserID uuid.UUID, metadata map[string]any) {
+	s.auditRecorder.Record(ctx, &actorID, action, audit.User(targetUserID), metadata)
+}
+
+func toAdminUserItem(user *userEntity.UserEntity) dto.AdminUserItem {
+	return dto.AdminUserItem{
+		ID:                  user.ID,
+		DisplayName:         user.DisplayName,
+		Username:            user.Username,
+		Email:               user.Email,
+		Role:                user.GetRole(),
+		Status:              user.GetStatus(),
+		EmailVerifiedAt:     user.EmailVerifiedAt,
+		LastLoginAt:         user.LastLoginAt,
+		DeactivatedAt:       user.DeactivatedAt,
+		DeletionScheduledAt: user.DeletionScheduledAt,
+		CreatedAt:           user.CreatedAt,
+	}
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	attachmentEntity "github.com/rayhan889/neatspace/internal/domain/attachment/entities"
+	attachmentRepo "github.com/rayhan889/neatspace/internal/domain/attachment/repositories"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/rayhan889/neatspace/internal/infrasturcture/storage"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+const (
+	// Number of orphaned attachments removed per cleanup query
+	attachmentCleanupBatchSize = 100
+
+	// Longest file name kept, longer ones are cut
+	maxAttachmentFileNameLength = 255
+)
+
+// Content types attachments may have, sniffed from the first bytes of the upload. SVG and
+// HTML are left out on purpose, browsers run scripts embedded in them.
+var attachmentContentTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"image/bmp":       true,
+	"application/pdf": true,
+	"application/zip": true,
+	"text/plain":      true,
+	"audio/mpeg":      true,
+	"audio/wave":      true,
+	"application/ogg": true,
+	"video/mp4":       true,
+	"video/webm":      true,
+}
+
+// attachmentSrcPattern matches the src image nodes refer to attachments by
+var attachmentSrcPattern = regexp.MustCompile(`^/api/v1/attachments/([0-9a-fA-F-]{36})/content$`)
+
+type AttachmentServiceInterface interface {
+	UploadAttachment(ctx context.Context, userID, noteID uuid.UUID, upload AttachmentUpload) (*dto.AttachmentItem, error)
+	ListNoteAttachments(ctx context.Context, userID, noteID uuid.UUID) ([]dto.AttachmentItem, error)
+	GetAttachment(ctx context.Context, userID, attachmentID uuid.UUID) (*dto.AttachmentItem, error)
+	OpenAttachment(ctx context.Context, attachmentID uuid.UUID, expires, signature string) (*AttachmentDownload, error)
+	GetUsage(ctx context.Context, userID uuid.UUID) (*dto.AttachmentUsage, error)
+	ImageSigner(ctx context.Context, noteID uuid.UUID) func(src string) string
+	CollectOrphanedAttachments(ctx context.Context) (int, error)
+}
+
+var _ AttachmentServiceInterface = (*AttachmentService)(nil)
+
+// AttachmentUpload is a file received from a client
+type AttachmentUpload struct {
+	FileName string
+	Size     int64
+	Content  io.Reader
+}
+
+// AttachmentDownload streams a stored attachment, Content must be closed
+type AttachmentDownload struct {
+	Content     io.ReadCloser
+	FileName    string
+	ContentType string
+	Size        int64
+}
+
+type AttachmentService struct {
+	attachmentRepo attachmentRepo.AttachmentRepositoryInterface
+	noteService    NoteServiceInterface
+	storage        storage.Storage
+	logger         *slog.Logger
+	baseURL        string
+
+	urlSigner   *apputils.URLSigner // Signs download links
+	urlExpiry   time.Duration       // How long a download link works
+	maxSize     int64               // Largest single upload in bytes
+	quota       int64               // Total bytes a user may have uploaded
+	orphanGrace time.Duration       // How long an attachment no note refers to is kept
+}
+
+type AttachmentServiceOpts struct {
+	AttachmentRepo attachmentRepo.AttachmentRepositoryInterface
+	NoteService    NoteServiceInterface
+	Storage        storage.Storage
+	Logger         *slog.Logger
+	BaseURL        string
+	URLSigner      *apputils.URLSigner
+	URLExpiry      time.Duration
+	MaxSize        int64
+	Quota          int64
+	OrphanGrace    time.Duration
+}
+
+func NewAttachmentService(opts AttachmentServiceOpts) *AttachmentService {
+	return &AttachmentService{
+		attachmentRepo: opts.AttachmentRepo,
+		noteService:    opts.NoteService,
+		storage:        opts.Storage,
+		logger:         opts.Logger,
+		baseURL:        opts.BaseURL,
+		urlSigner:      opts.URLSigner,
+		urlExpiry:      opts.URLExpiry,
+		maxSize:        opts.MaxSize,
+		quota:          opts.Quota,
+		orphanGrace:    opts.OrphanGrace,
+	}
+}
+
+// UploadAttachment stores a file for the note, the user needs the edit permission on it.
+// The content type is sniffed from the content and must be on the allowlist, the size
+// counts against the user's quota.
+func (s *AttachmentService) UploadAttachment(ctx context.Context, userID, noteID uuid.UUID, upload AttachmentUpload) (*dto.AttachmentItem, error) {
+	note, permission, err := s.noteService.NoteAccess(ctx, userID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if !noteEntity.PermissionAtLeast(permission, noteEntity.PermissionEdit) {
+		return nil, fiber.NewError(fiber.StatusForbidden, fmt.Sprintf("this action requires the %s permission on the note", noteEntity.PermissionEdit))
+	}
+
+	if upload.Size <= 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "file is empty")
+	}
+	if upload.Size > s.maxSize {
+		return nil, fiber.NewError(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("file is larger than %d bytes", s.maxSize))
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(upload.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("error reading file: %v", err))
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	if !attachmentContentTypes[strings.TrimSpace(strings.Split(contentType, ";")[0])] {
+		return nil, fiber.NewError(fiber.StatusUnsupportedMediaType, fmt.Sprintf("files of type %s are not allowed", contentType))
+	}
+
+	attachment := &attachmentEntity.AttachmentEntity{
+		ID:          uuid.New(),
+		UserID:      &userID,
+		NoteID:      &noteID,
+		WorkspaceID: &note.WorkspaceID,
+		FileName:    attachmentFileName(upload.FileName),
+		ContentType: contentType,
+		SizeBytes:   upload.Size,
+		CreatedAt:   time.Now(),
+	}
+	attachment.StorageKey = attachmentEntity.StorageKey(attachment.ID)
+
+	// The row goes in first so the quota check covers uploads still in flight. Should the
+	// process die before the object is stored, the garbage collection removes the row.
+	if err := s.attachmentRepo.CreateAttachment(ctx, attachment, s.quota); err != nil {
+		if errors.Is(err, attachmentRepo.ErrQuotaExceeded) {
+			return nil, fiber.NewError(fiber.StatusRequestEntityTooLarge, "attachment storage quota exceeded")
+		}
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error creating attachment: %v", err))
+	}
+
+	content := io.MultiReader(bytes.NewReader(head), upload.Content)
+	if err := s.storage.Put(ctx, attachment.StorageKey, content, attachment.SizeBytes, contentType); err != nil {
+		if delErr := s.attachmentRepo.DeleteAttachment(context.WithoutCancel(ctx), attachment.ID); delErr != nil {
+			s.logger.Error("failed to remove attachment after failed upload", slog.String("op", "UploadAttachment"), slog.String("attachment_id", attachment.ID.String()), slog.String("error", delErr.Error()))
+		}
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error storing attachment: %v", err))
+	}
+
+	item := s.toAttachmentItem(attachment)
+	return &item, nil
+}
+
+// ListNoteAttachments returns the files uploaded to the note, oldest first
+func (s *AttachmentService) ListNoteAttachments(ctx context.Context, userID, noteID uuid.UUID) ([]dto.AttachmentItem, error) {
+	if _, _, err := s.noteService.NoteAccess(ctx, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.attachmentRepo.ListAttachmentsByNoteID(ctx, noteID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting attachments: %v", err))
+	}
+
+	data := make([]dto.AttachmentItem, 0, len(attachments))
+	for i := range attachments {
+		data = append(data, s.toAttachmentItem(&attachments[i]))
+	}
+
+	return data, nil
+}
+
+// GetAttachment returns the attachment with a fresh download link. Anyone who can view the
+// note it was uploaded to or a note of the same workspace showing it may get it.
+func (s *AttachmentService) GetAttachment(ctx context.Context, userID, attachmentID uuid.UUID) (*dto.AttachmentItem, error) {
+	attachment, err := s.attachmentRepo.GetAttachmentByID(ctx, attachmentID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting attachment: %v", err))
+	}
+	if attachment == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("attachment with id %s cannot be found", attachmentID.String()))
+	}
+
+	noteIDs, err := s.attachmentRepo.ListReferencingNoteIDs(ctx, attachmentID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting attachment references: %v", err))
+	}
+	if attachment.NoteID != nil {
+		noteIDs = append(noteIDs, *attachment.NoteID)
+	}
+
+	for _, noteID := range noteIDs {
+		_, _, err := s.noteService.NoteAccess(ctx, userID, noteID)
+		if err == nil {
+			item := s.toAttachmentItem(attachment)
+			return &item, nil
+		}
+		var fiberErr *fiber.Error
+		if !errors.As(err, &fiberErr) || fiberErr.Code >= fiber.StatusInternalServerError {
+			return nil, err
+		}
+	}
+
+	return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("attachment with id %s cannot be found", attachmentID.String()))
+}
+
+// OpenAttachment checks a signed download link and opens the attachment it points to
+func (s *AttachmentService) OpenAttachment(ctx context.Context, attachmentID uuid.UUID, expires, signature string) (*AttachmentDownload, error) {
+	if err := s.urlSigner.Verify(attachmentEntity.ContentPath(attachmentID), expires, signature); err != nil {
+		if errors.Is(err, apputils.ErrSignatureExpired) {
+			return nil, fiber.NewError(fiber.StatusGone, "download link has expired")
+		}
+		return nil, fiber.NewError(fiber.StatusForbidden, "invalid download link")
+	}
+
+	attachment, err := s.attachmentRepo.GetAttachmentByID(ctx, attachmentID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting attachment: %v", err))
+	}
+	if attachment == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "attachment not found")
+	}
+
+	content, err := s.storage.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.logger.Error("attachment object missing", slog.String("op", "OpenAttachment"), slog.String("attachment_id", attachmentID.String()))
+			return nil, fiber.NewError(fiber.StatusNotFound, "attachment not found")
+		}
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error opening attachment: %v", err))
+	}
+
+	return &AttachmentDownload{
+		Content:     content,
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.SizeBytes,
+	}, nil
+}
+
+// GetUsage returns how much of their quota the user's uploads take up
+func (s *AttachmentService) GetUsage(ctx context.Context, userID uuid.UUID) (*dto.AttachmentUsage, error) {
+	used, err := s.attachmentRepo.GetUsedBytes(ctx, userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting attachment usage: %v", err))
+	}
+
+	return &dto.AttachmentUsage{
+		UsedBytes:    used,
+		QuotaBytes:   s.quota,
+		MaxFileBytes: s.maxSize,
+	}, nil
+}
+
+// ImageSigner returns the function adding a download signature to the src of images in the
+// note. Only attachments the note refers to are signed, which are those of its own workspace,
+// other sources are returned unchanged. Rendered notes use it so their images load without
+// a session.
+func (s *AttachmentService) ImageSigner(ctx context.Context, noteID uuid.UUID) func(src string) string {
+	attachmentIDs, err := s.attachmentRepo.ListReferencedAttachmentIDs(ctx, noteID)
+	if err != nil {
+		// The note still renders, its images just don't load
+		s.logger.Error("failed to get note attachments", slog.String("op", "ImageSigner"), slog.String("note_id", noteID.String()), slog.String("error", err.Error()))
+	}
+
+	referenced := make(map[uuid.UUID]bool, len(attachmentIDs))
+	for _, id := range attachmentIDs {
+		referenced[id] = true
+	}
+
+	return func(src string) string {
+		return s.signImageSource(src, referenced)
+	}
+}
+
+// signImageSource signs the src of an image showing one of the allowed attachments
+func (s *AttachmentService) signImageSource(src string, allowed map[uuid.UUID]bool) string {
+	u, err := url.Parse(src)
+	if err != nil {
+		return src
+	}
+
+	match := attachmentSrcPattern.FindStringSubmatch(u.Path)
+	if match == nil {
+		return src
+	}
+	attachmentID, err := uuid.Parse(match[1])
+	if err != nil || !allowed[attachmentID] {
+		return src
+	}
+
+	contentPath := attachmentEntity.ContentPath(attachmentID)
+	u.Path, u.RawPath = contentPath, ""
+	u.RawQuery = s.urlSigner.Sign(contentPath, s.linkExpiry()).Encode()
+	return u.String()
+}
+
+// CollectOrphanedAttachments deletes attachments no note has referred to for the grace
+// period, from the database and from storage
+func (s *AttachmentService) CollectOrphanedAttachments(ctx context.Context) (int, error) {
+	before := time.Now().Add(-s.orphanGrace)
+
+	collected := 0
+	for {
+		attachments, err := s.attachmentRepo.DeleteOrphanedAttachments(ctx, before, attachmentCleanupBatchSize)
+		if err != nil {
+			return collected, err
+		}
+
+		for _, attachment := range attachments {
+			// The row is gone already, a failure here only leaves an unreachable object behind
+			if err := s.storage.Delete(ctx, attachment.StorageKey); err != nil {
+				s.logger.Warn("failed to delete attachment object", slog.String("op", "CollectOrphanedAttachments"), slog.String("storage_key", attachment.StorageKey), slog.String("error", err.Error()))
+			}
+		}
+		collected += len(attachments)
+
+		if len(attachments) < attachmentCleanupBatchSize {
+			return collected, nil
+		}
+	}
+}
+
+// linkExpiry rounds expiries down to a minute so links signed close together are equal
+// and stay cacheable, they always last at least the configured expiry minus that minute
+func (s *AttachmentService) linkExpiry() time.Time {
+	return time.Now().Add(s.urlExpiry).Truncate(time.Minute)
+}
+
+func (s *AttachmentService) toAttachmentItem(attachment *attachmentEntity.AttachmentEntity) dto.AttachmentItem {
+	contentPath := attachmentEntity.ContentPath(attachment.ID)
+	expiresAt := s.linkExpiry()
+
+	return dto.AttachmentItem{
+		ID:           attachment.ID,
+		NoteID:       attachment.NoteID,
+		FileName:     attachment.FileName,
+		ContentType:  attachment.ContentType,
+		SizeBytes:    attachment.SizeBytes,
+		Src:          contentPath,
+		URL:          buildAppLink(s.baseURL, contentPath, s.urlSigner.Sign(contentPath, expiresAt)),
+		URLExpiresAt: expiresAt,
+		CreatedAt:    attachment.CreatedAt,
+	}
+}
+
+// attachmentFileName keeps the base name of an uploaded file, clients may send full paths
+func attachmentFileName(name string) string {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, `\`, "/")))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+
+	// Cutting may split the last rune, ToValidUTF8 drops what is left of it
+	name = strings.ToValidUTF8(name, "")
+	if len(name) > maxAttachmentFileNameLength {
+		name = strings.ToValidUTF8(name[:maxAttachmentFileNameLength], "")
+	}
+	return name
+}
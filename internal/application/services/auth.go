@@ -3,27 +3,58 @@ package services
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/url"
-	"os"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	"github.com/rayhan889/neatspace/internal/audit"
 	authEntity "github.com/rayhan889/neatspace/internal/domain/auth/entities"
 	authRepo "github.com/rayhan889/neatspace/internal/domain/auth/repositories"
+	userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
 	"github.com/rayhan889/neatspace/internal/notification"
 	"github.com/rayhan889/neatspace/pkg/apputils"
 )
 
 var ErrInvalidCredentials = fiber.NewError(fiber.StatusUnauthorized, "invalid credentials")
 
+// errPasswordResetRequired is returned by validatePassword for a correct password that was flagged for reset
+var errPasswordResetRequired = errors.New("password reset required")
+
+const (
+	// How long email change confirmation and cancel links stay valid
+	emailChangeTokenExpiry = 24 * time.Hour
+
+	// Re-authentication codes are short lived and short, so they expire quickly
+	reauthenticationOTPExpiry = 10 * time.Minute
+	reauthenticationOTPDigits = 6
+	// Wrong codes a re-authentication OTP takes before it's burned
+	reauthenticationOTPMaxAttempts = 5
+
+	// How long a password reset link stays valid
+	passwordResetTokenExpiry = 24 * time.Hour
+
+	// Number of accounts hard deleted per purge query
+	accountPurgeBatchSize = 100
+)
+
+// reauthenticationOTPLimit keeps codes from being requested again and again to guess more
+// often than the attempts of a single code allow
+var reauthenticationOTPLimit = authEntity.OneTimeTokenLimit{
+	Cooldown: time.Minute,
+	Window:   time.Hour,
+	Max:      5,
+}
+
 type AuthServiceInterface interface {
 	InitiateEmailVerification(ctx context.Context, req *dto.InitiateEmailVerificationRequest) error
 	ValidateEmailVerification(ctx context.Context, req *dto.ValidateEmailVerificationRequest) error
@@ -32,16 +63,46 @@ type AuthServiceInterface interface {
 	CreateRefreshToken(ctx context.Context, refreshToken *authEntity.RefreshToken) error
 	SetUserPassword(ctx context.Context, userPassword *authEntity.UserPasswordEntity) error
 	UpdateUserPassword(ctx context.Context, password string, userID uuid.UUID) error
+	InitiateEmailChange(ctx context.Context, userID uuid.UUID, req *dto.ChangeEmailRequest) error
+	ConfirmEmailChange(ctx context.Context, req *dto.EmailChangeTokenRequest) error
+	CancelEmailChange(ctx context.Context, req *dto.EmailChangeTokenRequest) error
+	SendReauthenticationOTP(ctx context.Context, userID uuid.UUID) error
+	Reauthenticate(ctx context.Context, userID, sessionID uuid.UUID, req *dto.ReauthenticateRequest) (*time.Time, error)
+	GetSessionAuthTime(ctx context.Context, sessionID uuid.UUID) (*time.Time, error)
+	IsSessionActive(ctx context.Context, sessionID, userID uuid.UUID) (bool, error)
+	PaginationLoginHistory(ctx context.Context, userID uuid.UUID, p *apputils.Pagination) (data []dto.LoginHistoryItem, total int, err error)
+	RequestAccountDeletion(ctx context.Context, userID uuid.UUID) (*time.Time, error)
+	CancelAccountDeletion(ctx context.Context, req *dto.CancelAccountDeletionRequest) error
+	PurgeDeletedAccounts(ctx context.Context) (int, error)
+	OnAccountPurged(hook AccountPurgedHook)
+	RevokeAllSessions(ctx context.Context, userID, revokedBy uuid.UUID) error
+	ForcePasswordReset(ctx context.Context, userID, revokedBy uuid.UUID) error
+	ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) error
+	ListUserSessions(ctx context.Context, userID uuid.UUID) ([]dto.SessionItem, error)
+	StartImpersonation(ctx context.Context, actorID, userID uuid.UUID) (*dto.ImpersonationResponse, error)
+	EndImpersonation(ctx context.Context, actorID, sessionID uuid.UUID) (*authEntity.SessionEntity, error)
 }
 
+// AccountPurgedHook is called after an account has been hard deleted, so other
+// services can clean up data that doesn't live in the database
+type AccountPurgedHook func(ctx context.Context, userID uuid.UUID)
+
 var _ AuthServiceInterface = (*AuthService)(nil)
 
 type AuthService struct {
-	authRepo    authRepo.AuthRepositoryInterface
-	userService UserServiceInterface
-	logger      *slog.Logger
-	mailer      *notification.Mailer
-	baseURL     string
+	authRepo      authRepo.AuthRepositoryInterface
+	userService   UserServiceInterface
+	auditRecorder audit.RecorderInterface
+	logger        *slog.Logger
+	mailer        *notification.Mailer
+	baseURL       string
+
+	passwordPolicy *apputils.PasswordPolicy // Rules enforced when setting or changing a password
+	passwordHasher *apputils.PasswordHasher // Hasher configured with the current Argon2 params
+
+	accountDeletionGracePeriod time.Duration       // How long a deletion request can be cancelled
+	accountPurgedHooks         []AccountPurgedHook // Called for every purged account
+	impersonationExpiry        time.Duration       // Lifetime of an admin impersonation token
 
 	secretKey          []byte                 // Secret key for signing JWTs
 	accessTokenExpiry  time.Duration          // Access token expiration duration
@@ -52,9 +113,15 @@ type AuthService struct {
 type AuthServiceOpts struct {
 	AuthRepository authRepo.AuthRepositoryInterface
 	UserService    UserServiceInterface
+	AuditRecorder  audit.RecorderInterface
 	Logger         *slog.Logger
 	Mailer         *notification.Mailer
 	BaseURL        string
+	PasswordPolicy *apputils.PasswordPolicy
+	PasswordHasher *apputils.PasswordHasher
+
+	AccountDeletionGracePeriod time.Duration
+	ImpersonationExpiry        time.Duration
 
 	JWTSecretKey       []byte                 // Secret key for signing JWTs
 	AccessTokenExpiry  time.Duration          // Access token expiration duration
@@ -64,15 +131,20 @@ type AuthServiceOpts struct {
 
 func NewAuthService(opts AuthServiceOpts) *AuthService {
 	return &AuthService{
-		authRepo:           opts.AuthRepository,
-		userService:        opts.UserService,
-		logger:             opts.Logger,
-		mailer:             opts.Mailer,
-		baseURL:            opts.BaseURL,
-		secretKey:          opts.JWTSecretKey,
-		accessTokenExpiry:  opts.AccessTokenExpiry,
-		refreshTokenExpiry: opts.RefreshTokenExpiry,
-		signingAlg:         opts.SigningAlg,
+		authRepo:                   opts.AuthRepository,
+		userService:                opts.UserService,
+		auditRecorder:              opts.AuditRecorder,
+		logger:                     opts.Logger,
+		mailer:                     opts.Mailer,
+		baseURL:                    opts.BaseURL,
+		passwordPolicy:             opts.PasswordPolicy,
+		passwordHasher:             opts.PasswordHasher,
+		accountDeletionGracePeriod: opts.AccountDeletionGracePeriod,
+		impersonationExpiry:        opts.ImpersonationExpiry,
+		secretKey:                  opts.JWTSecretKey,
+		accessTokenExpiry:          opts.AccessTokenExpiry,
+		refreshTokenExpiry:         opts.RefreshTokenExpiry,
+		signingAlg:                 opts.SigningAlg,
 	}
 }
 
@@ -160,25 +232,10 @@ func (s *AuthService) InitiateEmailVerification(ctx context.Context, req *dto.In
 }
 
 func (s *AuthService) ValidateEmailVerification(ctx context.Context, req *dto.ValidateEmailVerificationRequest) error {
-	hash := sha256.Sum256([]byte(req.Token))
-	tokenHash := hex.EncodeToString(hash[:])
-
-	oneTimeToken, err := s.authRepo.GetOneTimeTokenByTokenHash(ctx, tokenHash)
+	oneTimeToken, err := s.lookupOneTimeToken(ctx, req.Token, authEntity.OneTimeTokenSubjectEmailVerification)
 	if err != nil {
 		return err
 	}
-
-	if oneTimeToken == nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "one time token is not found")
-	}
-
-	if oneTimeToken.ExpiresAt.Before(time.Now()) {
-		return fiber.NewError(fiber.StatusUnauthorized, "one time token is expired")
-	}
-
-	if oneTimeToken.UserID == nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "token is not related to any user")
-	}
 	userID := *oneTimeToken.UserID
 
 	_ = s.authRepo.DeleteOneTimeToken(ctx, oneTimeToken.ID)
@@ -186,6 +243,9 @@ func (s *AuthService) ValidateEmailVerification(ctx context.Context, req *dto.Va
 	if err = s.userService.MarkEmailVerified(ctx, userID); err != nil {
 		return err
 	}
+	s.auditRecorder.Record(ctx, &userID, audit.ActionEmailVerify, audit.User(userID), map[string]any{
+		"email": oneTimeToken.RelatesTo,
+	})
 
 	return nil
 }
@@ -234,18 +294,30 @@ func (s *AuthService) SignInWithEmail(ctx context.Context, req *dto.SignInWithEm
 		return nil, err
 	}
 	if user == nil {
+		s.recordLoginEvent(ctx, nil, req.Email, authEntity.LoginMethodEmailPassword, authEntity.LoginFailureUnknownUser)
 		return nil, ErrInvalidCredentials
 	}
 
 	ok, err := s.validatePassword(ctx, user.ID, req.Password)
+	if errors.Is(err, errPasswordResetRequired) {
+		s.recordLoginEvent(ctx, &user.ID, req.Email, authEntity.LoginMethodEmailPassword, authEntity.LoginFailureResetRequired)
+		return nil, fiber.NewError(fiber.StatusForbidden, "password reset required, check your email for a reset link")
+	}
 	if err != nil || !ok {
+		s.recordLoginEvent(ctx, &user.ID, req.Email, authEntity.LoginMethodEmailPassword, authEntity.LoginFailureInvalidPassword)
 		return nil, ErrInvalidCredentials
 	}
 
 	if !s.isEmailVerified(user) {
+		s.recordLoginEvent(ctx, &user.ID, req.Email, authEntity.LoginMethodEmailPassword, authEntity.LoginFailureEmailNotVerified)
 		return nil, ErrInvalidCredentials
 	}
 
+	if user.IsDeactivated() {
+		s.recordLoginEvent(ctx, &user.ID, req.Email, authEntity.LoginMethodEmailPassword, authEntity.LoginFailureDeactivated)
+		return nil, fiber.NewError(fiber.StatusForbidden, "account is deactivated and scheduled for deletion")
+	}
+
 	jwtGen := apputils.NewJWTGenerator(apputils.JWTConfig{
 		SecretKey:          s.secretKey,
 		AccessTokenExpiry:  s.accessTokenExpiry,
@@ -271,11 +343,23 @@ func (s *AuthService) SignInWithEmail(ctx context.Context, req *dto.SignInWithEm
 	}
 	refreshTokenHash := jwtGen.GetHash(refreshTokenStr)
 
+	now := time.Now()
+	client := apputils.ClientInfoFromContext(ctx)
 	session := &authEntity.SessionEntity{
 		ID:        uuid.New(),
 		UserID:    user.GetID(),
 		TokenHash: refreshTokenHash,
-		ExpiresAt: time.Now().Add(jwtGen.AccessTokenExpiry()),
+		IPAddress: client.IP(),
+		ExpiresAt: now.Add(jwtGen.AccessTokenExpiry()),
+		CreatedAt: now,
+		AuthTime:  &now,
+		AMR:       []string{authEntity.AuthMethodPassword},
+	}
+	if client.UserAgent != "" {
+		userAgent := client.UserAgent
+		deviceName := apputils.SummarizeUserAgent(client.UserAgent)
+		session.UserAgent = &userAgent
+		session.DeviceName = &deviceName
 	}
 	if err := s.CreateSession(ctx, session); err != nil {
 		return nil, err
@@ -302,6 +386,11 @@ func (s *AuthService) SignInWithEmail(ctx context.Context, req *dto.SignInWithEm
 		return nil, err
 	}
 
+	s.recordLoginEvent(ctx, &user.ID, req.Email, authEntity.LoginMethodEmailPassword, "")
+	if err := s.userService.MarkLastLogin(ctx, user.ID, now); err != nil {
+		s.logger.Error("failed to update last login", slog.String("op", "SignInWithEmail"), slog.String("error", err.Error()))
+	}
+
 	authUser := &authEntity.AuthenticatedUser{
 		UserWithCredentials: authEntity.UserWithCredentials{
 			User:         user.AsUserModel(),
@@ -320,27 +409,69 @@ func (s *AuthService) SetUserPassword(ctx context.Context, userPassword *authEnt
 		return fiber.NewError(fiber.StatusBadRequest, "password can't be empty")
 	}
 
-	if !s.userService.IsUserExistsByID(ctx, userPassword.UserID) {
+	user, err := s.userService.GetUserByID(ctx, userPassword.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
 		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s cannot be found", userPassword.UserID.String()))
 	}
 
-	hasher := apputils.NewPasswordHasher()
-	hashed, err := hasher.Hash(string(userPassword.PasswordHash))
+	// Replacing a password goes through UpdateUserPassword, which requires a recent authentication
+	existing, err := s.authRepo.GetUserPasswordByUserID(ctx, userPassword.UserID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fiber.NewError(fiber.StatusConflict, "password is already set")
+	}
+
+	if err := s.checkPasswordPolicy(user, string(userPassword.PasswordHash)); err != nil {
+		return err
+	}
+
+	hashed, err := s.passwordHasher.Hash(string(userPassword.PasswordHash))
 	if err != nil {
 		return err
 	}
 	userPassword.PasswordHash = []byte(hashed)
 
-	return s.authRepo.CreateUserPassword(ctx, userPassword)
+	if err := s.authRepo.CreateUserPassword(ctx, userPassword); err != nil {
+		return err
+	}
+	s.auditRecorder.Record(ctx, &userPassword.UserID, audit.ActionPasswordSet, audit.User(userPassword.UserID), nil)
+
+	return nil
 }
 
 func (s *AuthService) UpdateUserPassword(ctx context.Context, password string, userID uuid.UUID) error {
+	if err := s.changePassword(ctx, password, userID); err != nil {
+		return err
+	}
+	s.auditRecorder.Record(ctx, &userID, audit.ActionPasswordChange, audit.User(userID), nil)
+
+	return nil
+}
+
+// changePassword replaces the user's password after checking it against the password policy
+func (s *AuthService) changePassword(ctx context.Context, password string, userID uuid.UUID) error {
 	if password == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "password can't be empty")
 	}
 
-	hasher := apputils.NewPasswordHasher()
-	hashed, err := hasher.Hash(password)
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s cannot be found", userID.String()))
+	}
+
+	if err := s.checkPasswordPolicy(user, password); err != nil {
+		return err
+	}
+
+	hashed, err := s.passwordHasher.Hash(password)
 	if err != nil {
 		return err
 	}
@@ -348,6 +479,701 @@ func (s *AuthService) UpdateUserPassword(ctx context.Context, password string, u
 	return s.authRepo.UpdateUserPassword(ctx, []byte(hashed), userID)
 }
 
+// InitiateEmailChange starts an email change for the user. The new address only
+// replaces the current one once the link sent to it is confirmed, and the current
+// address receives a notice with a link to cancel the change.
+func (s *AuthService) InitiateEmailChange(ctx context.Context, userID uuid.UUID, req *dto.ChangeEmailRequest) error {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s cannot be found", userID.String()))
+	}
+
+	newEmail := strings.TrimSpace(req.Email)
+	if strings.EqualFold(newEmail, user.Email) {
+		return fiber.NewError(fiber.StatusBadRequest, "new email must be different from the current email")
+	}
+
+	exists, err := s.userService.EmailExists(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fiber.NewError(fiber.StatusConflict, "email already exists")
+	}
+
+	// Only one pending change per user, links from a previous request stop working
+	if err := s.authRepo.DeleteOneTimeTokensByUserID(ctx, userID,
+		authEntity.OneTimeTokenSubjectEmailChange,
+		authEntity.OneTimeTokenSubjectEmailChangeCancel,
+	); err != nil {
+		return err
+	}
+
+	confirmMetadata := map[string]any{
+		"old_email": user.Email,
+	}
+	if req.RedirectTo != "" {
+		confirmMetadata["redirect_to"] = req.RedirectTo
+	}
+
+	confirmToken, err := s.issueOneTimeToken(ctx, userID, authEntity.OneTimeTokenSubjectEmailChange, newEmail, confirmMetadata, emailChangeTokenExpiry)
+	if err != nil {
+		return err
+	}
+
+	cancelToken, err := s.issueOneTimeToken(ctx, userID, authEntity.OneTimeTokenSubjectEmailChangeCancel, user.Email, map[string]any{
+		"new_email": newEmail,
+	}, emailChangeTokenExpiry)
+	if err != nil {
+		return err
+	}
+
+	if err := s.sendEmailChangeConfirmation(ctx, user, newEmail, confirmToken, req.RedirectTo); err != nil {
+		return err
+	}
+
+	return s.sendEmailChangeNotice(ctx, user, newEmail, cancelToken)
+}
+
+// ConfirmEmailChange swaps the user's email for the address the token was sent to
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, req *dto.EmailChangeTokenRequest) error {
+	oneTimeToken, err := s.lookupOneTimeToken(ctx, req.Token, authEntity.OneTimeTokenSubjectEmailChange)
+	if err != nil {
+		return err
+	}
+	userID := *oneTimeToken.UserID
+
+	// Uniqueness is enforced by the database, another account may have taken the address meanwhile
+	if err := s.userService.ChangeEmail(ctx, userID, oneTimeToken.RelatesTo); err != nil {
+		return err
+	}
+	s.auditRecorder.Record(ctx, &userID, audit.ActionEmailChange, audit.User(userID), map[string]any{
+		"email": oneTimeToken.RelatesTo,
+	})
+
+	if err := s.authRepo.DeleteOneTimeTokensByUserID(ctx, userID,
+		authEntity.OneTimeTokenSubjectEmailChange,
+		authEntity.OneTimeTokenSubjectEmailChangeCancel,
+	); err != nil {
+		s.logger.Error("failed to clean up email change tokens", slog.String("op", "ConfirmEmailChange"), slog.String("error", err.Error()))
+	}
+
+	return nil
+}
+
+// CancelEmailChange discards a pending email change using the link sent to the old address
+func (s *AuthService) CancelEmailChange(ctx context.Context, req *dto.EmailChangeTokenRequest) error {
+	oneTimeToken, err := s.lookupOneTimeToken(ctx, req.Token, authEntity.OneTimeTokenSubjectEmailChangeCancel)
+	if err != nil {
+		return err
+	}
+
+	return s.authRepo.DeleteOneTimeTokensByUserID(ctx, *oneTimeToken.UserID,
+		authEntity.OneTimeTokenSubjectEmailChange,
+		authEntity.OneTimeTokenSubjectEmailChangeCancel,
+	)
+}
+
+// SendReauthenticationOTP emails a short numeric code the user can use to re-authenticate
+func (s *AuthService) SendReauthenticationOTP(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s cannot be found", userID.String()))
+	}
+
+	otp, err := apputils.GenerateNumericOTP(reauthenticationOTPDigits)
+	if err != nil {
+		return err
+	}
+
+	// The new code replaces any previous one
+	token := newOneTimeToken(userID, authEntity.OneTimeTokenSubjectReauthentication,
+		reauthenticationOTPSecret(userID, otp), user.Email, nil, reauthenticationOTPExpiry,
+	)
+	created, err := s.authRepo.CreateLimitedOneTimeToken(ctx, token, reauthenticationOTPLimit)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return fiber.NewError(fiber.StatusTooManyRequests, "too many verification codes requested, please wait before requesting another one")
+	}
+
+	data := map[string]any{
+		"Email":         user.Email,
+		"DisplayName":   user.DisplayName,
+		"Code":          otp,
+		"ExpiryMinutes": int(reauthenticationOTPExpiry.Minutes()),
+		"AppName":       "Neatspace",
+	}
+
+	return s.sendMail(ctx, "SendReauthenticationOTP", user.Email, "Your verification code", "reauthentication_otp.html", data)
+}
+
+// Reauthenticate verifies the user's password or emailed code and stamps the
+// session's auth time, unlocking sensitive operations for the re-auth window.
+func (s *AuthService) Reauthenticate(ctx context.Context, userID, sessionID uuid.UUID, req *dto.ReauthenticateRequest) (*time.Time, error) {
+	session, err := s.authRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserID != userID || session.RevokedAt != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "session is not active")
+	}
+	if session.IsImpersonated() {
+		return nil, fiber.NewError(fiber.StatusForbidden, "not allowed while impersonating a user")
+	}
+
+	var method string
+	switch {
+	case req.Password != "":
+		ok, err := s.validatePassword(ctx, userID, req.Password)
+		if err != nil || !ok {
+			return nil, ErrInvalidCredentials
+		}
+		method = authEntity.AuthMethodPassword
+	case req.OTP != "":
+		if err := s.verifyReauthenticationOTP(ctx, userID, req.OTP); err != nil {
+			return nil, err
+		}
+		method = authEntity.AuthMethodOTP
+	default:
+		return nil, fiber.NewError(fiber.StatusBadRequest, "password or otp is required")
+	}
+
+	now := time.Now()
+	if err := s.authRepo.UpdateSessionAuthTime(ctx, sessionID, now, method); err != nil {
+		return nil, err
+	}
+
+	return &now, nil
+}
+
+// verifyReauthenticationOTP checks the code against the user's pending OTP. Every check uses
+// up an attempt, the OTP expires once it matched or its attempts ran out. It's kept to count
+// against the codes the user may request.
+func (s *AuthService) verifyReauthenticationOTP(ctx context.Context, userID uuid.UUID, otp string) error {
+	oneTimeToken, err := s.authRepo.UseOneTimeTokenAttempt(ctx, userID, authEntity.OneTimeTokenSubjectReauthentication,
+		reauthenticationOTPMaxAttempts, time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+	if oneTimeToken == nil {
+		return ErrInvalidCredentials
+	}
+
+	hash := sha256.Sum256([]byte(reauthenticationOTPSecret(userID, otp)))
+	matched := subtle.ConstantTimeCompare([]byte(hex.EncodeToString(hash[:])), []byte(oneTimeToken.TokenHash)) == 1
+
+	if matched || oneTimeToken.Attempts >= reauthenticationOTPMaxAttempts {
+		if err := s.authRepo.ExpireOneTimeToken(ctx, oneTimeToken.ID, time.Now()); err != nil {
+			return err
+		}
+	}
+	if !matched {
+		return ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+// GetSessionAuthTime returns when the session last authenticated, nil for unknown, revoked
+// or impersonated sessions
+func (s *AuthService) GetSessionAuthTime(ctx context.Context, sessionID uuid.UUID) (*time.Time, error) {
+	session, err := s.authRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.RevokedAt != nil || session.IsImpersonated() {
+		return nil, nil
+	}
+
+	return session.AuthTime, nil
+}
+
+// IsSessionActive reports whether an access token of the session may still be used. Revoking
+// a session, signing out everywhere or deactivating the user ends it before the token expires.
+func (s *AuthService) IsSessionActive(ctx context.Context, sessionID, userID uuid.UUID) (bool, error) {
+	active, err := s.authRepo.IsSessionActive(ctx, sessionID, userID, time.Now())
+	if err != nil {
+		return false, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error checking session: %v", err))
+	}
+
+	return active, nil
+}
+
+func (s *AuthService) PaginationLoginHistory(ctx context.Context, userID uuid.UUID, p *apputils.Pagination) (data []dto.LoginHistoryItem, total int, err error) {
+	events, total, err := s.authRepo.PaginationLoginEventsByUserID(ctx, userID, p)
+	if err != nil {
+		return nil, 0, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting login history: %v", err))
+	}
+
+	data = make([]dto.LoginHistoryItem, 0, len(events))
+	for _, event := range events {
+		item := dto.LoginHistoryItem{
+			ID:            event.ID,
+			Method:        event.Method,
+			Success:       event.Success,
+			FailureReason: event.FailureReason,
+			UserAgent:     event.UserAgent,
+			CreatedAt:     event.CreatedAt,
+		}
+		if event.IPAddress != nil {
+			ip := event.IPAddress.String()
+			item.IPAddress = &ip
+		}
+		data = append(data, item)
+	}
+
+	return data, total, nil
+}
+
+// RequestAccountDeletion deactivates the account right away, revoking all of its sessions,
+// and schedules the hard deletion after the grace period. A link to cancel is emailed to the user.
+func (s *AuthService) RequestAccountDeletion(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s cannot be found", userID.String()))
+	}
+	if user.DeletionScheduledAt != nil {
+		return nil, fiber.NewError(fiber.StatusConflict, "account deletion already requested")
+	}
+
+	now := time.Now()
+	scheduledAt := now.Add(s.accountDeletionGracePeriod)
+
+	if err := s.userService.ScheduleDeletion(ctx, userID, now, scheduledAt); err != nil {
+		return nil, err
+	}
+
+	if err := s.authRepo.RevokeUserSessions(ctx, userID, userID, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.authRepo.DeleteOneTimeTokensByUserID(ctx, userID, authEntity.OneTimeTokenSubjectAccountDeletion); err != nil {
+		return nil, err
+	}
+
+	cancelToken, err := s.issueOneTimeToken(ctx, userID, authEntity.OneTimeTokenSubjectAccountDeletion, user.Email, nil, s.accountDeletionGracePeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("token", cancelToken)
+
+	data := map[string]any{
+		"Email":       user.Email,
+		"DisplayName": user.DisplayName,
+		"ScheduledAt": scheduledAt.UTC().Format("January 2, 2006 15:04 MST"),
+		"CancelURL":   s.buildLink("/account/deletion/cancel", q),
+		"AppName":     "Neatspace",
+	}
+
+	if err := s.sendMail(ctx, "RequestAccountDeletion", user.Email, "Your account is scheduled for deletion", "account_deletion_scheduled.html", data); err != nil {
+		return nil, err
+	}
+
+	return &scheduledAt, nil
+}
+
+// CancelAccountDeletion reactivates an account scheduled for deletion using the emailed link
+func (s *AuthService) CancelAccountDeletion(ctx context.Context, req *dto.CancelAccountDeletionRequest) error {
+	oneTimeToken, err := s.lookupOneTimeToken(ctx, req.Token, authEntity.OneTimeTokenSubjectAccountDeletion)
+	if err != nil {
+		return err
+	}
+	userID := *oneTimeToken.UserID
+
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.DeletionScheduledAt == nil {
+		return fiber.NewError(fiber.StatusNotFound, "no pending account deletion")
+	}
+
+	if err := s.userService.CancelDeletion(ctx, userID); err != nil {
+		return err
+	}
+
+	_ = s.authRepo.DeleteOneTimeToken(ctx, oneTimeToken.ID)
+
+	return nil
+}
+
+// PurgeDeletedAccounts hard deletes every account whose grace period has passed and
+// sends each one a final confirmation. Safe to run concurrently on several instances.
+func (s *AuthService) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	purged := 0
+
+	for {
+		// Accounts purged before an error still get their email and hooks
+		users, err := s.userService.PurgeScheduledDeletions(ctx, time.Now(), accountPurgeBatchSize)
+
+		for _, user := range users {
+			data := map[string]any{
+				"Email":       user.Email,
+				"DisplayName": user.DisplayName,
+				"AppName":     "Neatspace",
+			}
+			// The account is already gone, a failed email can only be logged
+			_ = s.sendMail(ctx, "PurgeDeletedAccounts", user.Email, "Your account has been deleted", "account_deleted.html", data)
+
+			for _, hook := range s.accountPurgedHooks {
+				hook(ctx, user.ID)
+			}
+		}
+
+		purged += len(users)
+		if err != nil {
+			return purged, err
+		}
+		if len(users) < accountPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// OnAccountPurged registers a hook run for every account removed by PurgeDeletedAccounts.
+// Hooks must be registered before the purge job starts.
+func (s *AuthService) OnAccountPurged(hook AccountPurgedHook) {
+	s.accountPurgedHooks = append(s.accountPurgedHooks, hook)
+}
+
+// RevokeAllSessions signs the user out everywhere by revoking every active session and refresh token
+func (s *AuthService) RevokeAllSessions(ctx context.Context, userID, revokedBy uuid.UUID) error {
+	if err := s.authRepo.RevokeUserSessions(ctx, userID, revokedBy, time.Now()); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error revoking sessions: %v", err))
+	}
+	return nil
+}
+
+// ForcePasswordReset blocks the user's current password, signs them out everywhere
+// and emails a link to choose a new password
+func (s *AuthService) ForcePasswordReset(ctx context.Context, userID, revokedBy uuid.UUID) error {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s cannot be found", userID.String()))
+	}
+
+	userPassword, err := s.authRepo.GetUserPasswordByUserID(ctx, userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting user password: %v", err))
+	}
+	if userPassword == nil {
+		return fiber.NewError(fiber.StatusConflict, "user has no password to reset")
+	}
+
+	now := time.Now()
+	if err := s.authRepo.SetPasswordResetRequired(ctx, userID, &now); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error flagging password reset: %v", err))
+	}
+
+	if err := s.RevokeAllSessions(ctx, userID, revokedBy); err != nil {
+		return err
+	}
+
+	if err := s.authRepo.DeleteOneTimeTokensByUserID(ctx, userID, authEntity.OneTimeTokenSubjectPasswordReset); err != nil {
+		return err
+	}
+
+	resetToken, err := s.issueOneTimeToken(ctx, userID, authEntity.OneTimeTokenSubjectPasswordReset, user.Email, nil, passwordResetTokenExpiry)
+	if err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	q.Set("token", resetToken)
+
+	data := map[string]any{
+		"Email":       user.Email,
+		"DisplayName": user.DisplayName,
+		"ResetURL":    s.buildLink("/password/reset", q),
+		"ExpiresIn":   "24 hours",
+		"AppName":     "Neatspace",
+	}
+
+	return s.sendMail(ctx, "ForcePasswordReset", user.Email, "Reset your password", "password_reset.html", data)
+}
+
+// ResetPassword sets a new password using an emailed reset link and lifts the reset requirement
+func (s *AuthService) ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) error {
+	oneTimeToken, err := s.lookupOneTimeToken(ctx, req.Token, authEntity.OneTimeTokenSubjectPasswordReset)
+	if err != nil {
+		return err
+	}
+	userID := *oneTimeToken.UserID
+
+	if err := s.changePassword(ctx, req.Password, userID); err != nil {
+		return err
+	}
+
+	if err := s.authRepo.SetPasswordResetRequired(ctx, userID, nil); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error clearing password reset: %v", err))
+	}
+	s.auditRecorder.Record(ctx, &userID, audit.ActionPasswordReset, audit.User(userID), nil)
+
+	_ = s.authRepo.DeleteOneTimeToken(ctx, oneTimeToken.ID)
+
+	return nil
+}
+
+// ListUserSessions returns every session of the user, newest first, without token hashes
+func (s *AuthService) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]dto.SessionItem, error) {
+	sessions, err := s.authRepo.ListSessionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting sessions: %v", err))
+	}
+
+	data := make([]dto.SessionItem, 0, len(sessions))
+	for _, session := range sessions {
+		item := dto.SessionItem{
+			ID:          session.ID,
+			UserAgent:   session.UserAgent,
+			DeviceName:  session.DeviceName,
+			AMR:         session.AMR,
+			AuthTime:    session.AuthTime,
+			CreatedAt:   session.CreatedAt,
+			RefreshedAt: session.RefreshedAt,
+			ExpiresAt:   session.ExpiresAt,
+			RevokedAt:   session.RevokedAt,
+
+			ImpersonatorID: session.ImpersonatorID,
+		}
+		if session.IPAddress != nil {
+			ip := session.IPAddress.String()
+			item.IPAddress = &ip
+		}
+		data = append(data, item)
+	}
+
+	return data, nil
+}
+
+// StartImpersonation opens a session for the user on behalf of an admin and returns a
+// short-lived access token carrying the admin in its act claim. No refresh token is
+// issued, the admin has to start over once the token expires.
+func (s *AuthService) StartImpersonation(ctx context.Context, actorID, userID uuid.UUID) (*dto.ImpersonationResponse, error) {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s cannot be found", userID.String()))
+	}
+
+	jwtGen := apputils.NewJWTGenerator(apputils.JWTConfig{
+		SecretKey:         s.secretKey,
+		AccessTokenExpiry: s.impersonationExpiry,
+		SigninAlgo:        s.signingAlg,
+		Issuer:            s.baseURL,
+	})
+
+	now := time.Now()
+	sessionID := uuid.New()
+	accessTokenPayload := dto.AccessTokenPayload{
+		UserID: user.ID.String(),
+		Email:  user.Email,
+		SID:    sessionID.String(),
+		Act:    &dto.ActorClaim{Sub: actorID.String()},
+	}
+	accessToken, err := jwtGen.Sign(ctx, accessTokenPayload, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	client := apputils.ClientInfoFromContext(ctx)
+	session := &authEntity.SessionEntity{
+		ID:             sessionID,
+		UserID:         user.ID,
+		TokenHash:      jwtGen.GetHash(accessToken),
+		IPAddress:      client.IP(),
+		ExpiresAt:      now.Add(jwtGen.AccessTokenExpiry()),
+		CreatedAt:      now,
+		ImpersonatorID: &actorID,
+	}
+	if client.UserAgent != "" {
+		userAgent := client.UserAgent
+		deviceName := apputils.SummarizeUserAgent(client.UserAgent)
+		session.UserAgent = &userAgent
+		session.DeviceName = &deviceName
+	}
+	if err := s.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return &dto.ImpersonationResponse{
+		UserID:      user.ID,
+		SessionID:   session.ID,
+		AccessToken: accessToken,
+		ExpiresAt:   session.ExpiresAt,
+	}, nil
+}
+
+// EndImpersonation revokes an impersonated session started by the given admin and returns it
+func (s *AuthService) EndImpersonation(ctx context.Context, actorID, sessionID uuid.UUID) (*authEntity.SessionEntity, error) {
+	session, err := s.authRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting session: %v", err))
+	}
+	if session == nil || session.ImpersonatorID == nil || *session.ImpersonatorID != actorID {
+		return nil, fiber.NewError(fiber.StatusNotFound, "impersonation session not found")
+	}
+	if session.RevokedAt != nil {
+		return nil, fiber.NewError(fiber.StatusConflict, "impersonation already ended")
+	}
+
+	now := time.Now()
+	if err := s.authRepo.RevokeSession(ctx, sessionID, actorID, now); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error revoking session: %v", err))
+	}
+	session.RevokedAt = &now
+	session.RevokedBy = &actorID
+
+	return session, nil
+}
+
+// recordLoginEvent stores a sign-in attempt, an empty failureReason marks a successful one.
+// Failures are logged only, a missing history row must not block signing in.
+func (s *AuthService) recordLoginEvent(ctx context.Context, userID *uuid.UUID, identifier, method, failureReason string) {
+	client := apputils.ClientInfoFromContext(ctx)
+
+	event := &authEntity.LoginEventEntity{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Identifier: identifier,
+		Method:     method,
+		Success:    failureReason == "",
+		IPAddress:  client.IP(),
+		CreatedAt:  time.Now(),
+	}
+	if failureReason != "" {
+		event.FailureReason = &failureReason
+	}
+	if client.UserAgent != "" {
+		userAgent := apputils.SummarizeUserAgent(client.UserAgent)
+		event.UserAgent = &userAgent
+	}
+
+	if err := s.authRepo.CreateLoginEvent(ctx, event); err != nil {
+		s.logger.Error("failed to record login event", slog.String("op", "recordLoginEvent"), slog.String("error", err.Error()))
+	}
+
+	var target audit.Target
+	if userID != nil {
+		target = audit.User(*userID)
+	}
+	if failureReason == "" {
+		s.auditRecorder.Record(ctx, userID, audit.ActionSignIn, target, map[string]any{"method": method})
+		return
+	}
+	s.auditRecorder.Record(ctx, nil, audit.ActionSignInFailed, target, map[string]any{
+		"method":     method,
+		"identifier": identifier,
+		"reason":     failureReason,
+	})
+}
+
+// reauthenticationOTPSecret scopes a numeric code to its user, so codes of different
+// users never share a token hash and a code can't be redeemed by another account.
+func reauthenticationOTPSecret(userID uuid.UUID, otp string) string {
+	return userID.String() + ":" + otp
+}
+
+// issueOneTimeToken stores a new token for the user and returns its raw value
+func (s *AuthService) issueOneTimeToken(ctx context.Context, userID uuid.UUID, subject authEntity.OneTimeTokenSubject, relatesTo string, metadata map[string]any, expiry time.Duration) (string, error) {
+	rawToken, err := apputils.GenerateURLSafeToken(48)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.storeOneTimeToken(ctx, userID, subject, rawToken, relatesTo, metadata, expiry); err != nil {
+		return "", err
+	}
+
+	return rawToken, nil
+}
+
+// storeOneTimeToken persists the hash of rawToken, the raw value is never stored
+func (s *AuthService) storeOneTimeToken(ctx context.Context, userID uuid.UUID, subject authEntity.OneTimeTokenSubject, rawToken, relatesTo string, metadata map[string]any, expiry time.Duration) error {
+	return s.authRepo.CreateOneTimeToken(ctx, newOneTimeToken(userID, subject, rawToken, relatesTo, metadata, expiry))
+}
+
+// newOneTimeToken returns a token holding the hash of rawToken, valid for expiry from now
+func newOneTimeToken(userID uuid.UUID, subject authEntity.OneTimeTokenSubject, rawToken, relatesTo string, metadata map[string]any, expiry time.Duration) *authEntity.OneTimeToken {
+	hash := sha256.Sum256([]byte(rawToken))
+
+	now := time.Now()
+	return &authEntity.OneTimeToken{
+		ID:         uuid.New(),
+		UserID:     &userID,
+		Subject:    subject,
+		TokenHash:  hex.EncodeToString(hash[:]),
+		RelatesTo:  relatesTo,
+		Metadata:   metadata,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(expiry),
+		LastSentAt: &now,
+	}
+}
+
+// lookupOneTimeToken resolves a raw token into a valid, unexpired token of the given subject
+func (s *AuthService) lookupOneTimeToken(ctx context.Context, rawToken string, subject authEntity.OneTimeTokenSubject) (*authEntity.OneTimeToken, error) {
+	hash := sha256.Sum256([]byte(rawToken))
+	tokenHash := hex.EncodeToString(hash[:])
+
+	oneTimeToken, err := s.authRepo.GetOneTimeTokenByTokenHash(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if oneTimeToken == nil || oneTimeToken.Subject != subject {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "one time token is not found")
+	}
+
+	if oneTimeToken.ExpiresAt.Before(time.Now()) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "one time token is expired")
+	}
+
+	if oneTimeToken.UserID == nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "token is not related to any user")
+	}
+
+	return oneTimeToken, nil
+}
+
+// checkPasswordPolicy validates a new password against the configured policy,
+// using the user's email and username as disallowed substrings.
+func (s *AuthService) checkPasswordPolicy(user *userEntity.UserEntity, password string) error {
+	if s.passwordPolicy == nil {
+		return nil
+	}
+
+	identities := []string{user.Email}
+	if user.Username != nil {
+		identities = append(identities, *user.Username)
+	}
+
+	if errs := s.passwordPolicy.Validate(password, identities...); len(errs) > 0 {
+		return apputils.NewValidationError("password does not meet the password policy", errs)
+	}
+
+	return nil
+}
+
 func (s *AuthService) validatePassword(ctx context.Context, userID uuid.UUID, password string) (bool, error) {
 	userPassword, err := s.authRepo.GetUserPasswordByUserID(ctx, userID)
 	if err != nil {
@@ -357,8 +1183,40 @@ func (s *AuthService) validatePassword(ctx context.Context, userID uuid.UUID, pa
 		return false, fmt.Errorf("cannot find match user password by user id %s", userID)
 	}
 
-	hasher := apputils.NewPasswordHasher()
-	return hasher.Validate(password, string(userPassword.PasswordHash))
+	storedHash := string(userPassword.PasswordHash)
+	ok, err := s.passwordHasher.Validate(password, storedHash)
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	// Only reported for the correct password, so the flag doesn't leak to anyone guessing
+	if userPassword.ResetRequiredAt != nil {
+		return false, errPasswordResetRequired
+	}
+
+	// Transparently upgrade legacy or weaker hashes while we have the plain password
+	if s.passwordHasher.NeedsRehash(storedHash) {
+		s.rehashPassword(ctx, userID, password)
+	}
+
+	return true, nil
+}
+
+// rehashPassword replaces the stored hash using the current params.
+// Failures are logged only, the user has already been authenticated.
+func (s *AuthService) rehashPassword(ctx context.Context, userID uuid.UUID, password string) {
+	hashed, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		s.logger.Error("failed to rehash user password", slog.String("op", "rehashPassword"), slog.String("error", err.Error()))
+		return
+	}
+
+	if err := s.authRepo.UpdateUserPassword(ctx, []byte(hashed), userID); err != nil {
+		s.logger.Error("failed to store rehashed user password", slog.String("op", "rehashPassword"), slog.String("error", err.Error()))
+		return
+	}
+
+	s.logger.Info("user password rehashed with current params", slog.String("op", "rehashPassword"), slog.String("user_id", userID.String()))
 }
 
 func (s *AuthService) isEmailVerified(u any) bool {
@@ -410,47 +1268,19 @@ func (s *AuthService) isEmailVerified(u any) bool {
 	return false
 }
 
-func (s *AuthService) sendVerificationEmail(ctx context.Context, toEmail, rawToken, redirectTo string) error {
-	// Determine base URL:
-	// 1) prefer configured s.baseURL
-	// 2) fallback to environment SERVER_HOST/SERVER_PORT
-	// 3) final fallback to localhost:8000
-	base := s.baseURL
-	if base == "" {
-		host := os.Getenv("SERVER_HOST")
-		port := os.Getenv("SERVER_PORT")
-		if host == "" {
-			host = "localhost"
-		}
-		if port == "0" {
-			port = "8000"
-		}
-		base = fmt.Sprintf("http://%s:%s", host, port)
-	}
-
-	u, err := url.Parse(base)
-	if err != nil || u.Scheme == "" || u.Host == "" {
-		// fallback to SERVER_HOST/SERVER_PORT env vars explicitly
-		host := os.Getenv("SERVER_HOST")
-		port := os.Getenv("SERVER_PORT")
-		if host == "" {
-			host = "localhost"
-		}
-		if port == "" {
-			port = "8000"
-		}
-		u = &url.URL{Scheme: "http", Host: fmt.Sprintf("%s:%s", host, port)}
-	}
+// buildLink returns an absolute URL for the given path on the configured base URL
+func (s *AuthService) buildLink(path string, query url.Values) string {
+	return buildAppLink(s.baseURL, path, query)
+}
 
+func (s *AuthService) sendVerificationEmail(ctx context.Context, toEmail, rawToken, redirectTo string) error {
 	// Use only the token in the verification link (do NOT include the email)
-	u.Path = "/api/v1/auth/verify-email"
-	q := u.Query()
+	q := url.Values{}
 	q.Set("token", rawToken)
 	if redirectTo != "" {
 		q.Set("redirect_to", redirectTo)
 	}
-	u.RawQuery = q.Encode()
-	verifyURL := u.String()
+	verifyURL := s.buildLink("/api/v1/auth/verify-email", q)
 
 	// Try to fetch user to pass display name to template
 	var displayName string
@@ -468,17 +1298,45 @@ func (s *AuthService) sendVerificationEmail(ctx context.Context, toEmail, rawTok
 		"AppName":     "Neatspace",
 	}
 
-	subject := "Verify your email address"
-	templateFile := "email_verification.html"
+	return s.sendMail(ctx, "sendVerificationEmail", toEmail, "Verify your email address", "email_verification.html", data)
+}
 
-	if s.mailer != nil {
-		if err := s.mailer.SendMail(ctx, []string{toEmail}, subject, templateFile, data); err != nil {
-			s.logger.Error("failed to send verification email", slog.String("op", "sendVerificationEmail"), slog.String("error", err.Error()))
-			return err
-		}
-		return nil
+// sendEmailChangeConfirmation emails the new address a link to the page confirming the change.
+// The page posts the token, mail scanners opening the link don't confirm anything.
+func (s *AuthService) sendEmailChangeConfirmation(ctx context.Context, user *userEntity.UserEntity, newEmail, rawToken, redirectTo string) error {
+	q := url.Values{}
+	q.Set("token", rawToken)
+	if redirectTo != "" {
+		q.Set("redirect_to", redirectTo)
 	}
 
-	s.logger.Warn("mailer not configured, cannot send verification email", slog.String("op", "sendVerificationEmail"))
-	return nil
+	data := map[string]any{
+		"Email":       newEmail,
+		"OldEmail":    user.Email,
+		"DisplayName": user.DisplayName,
+		"ConfirmURL":  s.buildLink("/email/change/confirm", q),
+		"AppName":     "Neatspace",
+	}
+
+	return s.sendMail(ctx, "sendEmailChangeConfirmation", newEmail, "Confirm your new email address", "email_change_confirmation.html", data)
+}
+
+func (s *AuthService) sendEmailChangeNotice(ctx context.Context, user *userEntity.UserEntity, newEmail, rawToken string) error {
+	q := url.Values{}
+	q.Set("token", rawToken)
+
+	data := map[string]any{
+		"Email":       user.Email,
+		"NewEmail":    newEmail,
+		"DisplayName": user.DisplayName,
+		"CancelURL":   s.buildLink("/email/change/cancel", q),
+		"AppName":     "Neatspace",
+	}
+
+	return s.sendMail(ctx, "sendEmailChangeNotice", user.Email, "Your email address is being changed", "email_change_notice.html", data)
+}
+
+// sendMail renders and sends a templated email, logging under the given op
+func (s *AuthService) sendMail(ctx context.Context, op, toEmail, subject, templateFile string, data map[string]any) error {
+	return sendTemplatedMail(ctx, s.mailer, s.logger, op, toEmail, subject, templateFile, data)
 }
@@ -0,0 +1,483 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	exportEntity "github.com/rayhan889/neatspace/internal/domain/export/entities"
+	exportRepo "github.com/rayhan889/neatspace/internal/domain/export/repositories"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/rayhan889/neatspace/internal/domain/note/renderer"
+	"github.com/rayhan889/neatspace/internal/notification"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+const (
+	// Version of the archive layout described by manifest.json
+	dataExportFormatVersion = 1
+
+	// Exports still processing after this long are assumed to be abandoned and retried
+	dataExportStaleAfter = 30 * time.Minute
+
+	// Number of expired exports removed per cleanup query
+	dataExportCleanupBatchSize = 100
+
+	// Directory under the storage root holding export archives, one subdirectory per user
+	dataExportDir = "exports"
+)
+
+type ExportServiceInterface interface {
+	RequestExport(ctx context.Context, userID uuid.UUID) (*dto.DataExportItem, error)
+	ListExports(ctx context.Context, userID uuid.UUID) ([]dto.DataExportItem, error)
+	ResolveDownload(ctx context.Context, exportID uuid.UUID, expires, signature string) (*DataExportDownload, error)
+	ProcessPendingExports(ctx context.Context) (int, error)
+	PurgeExpiredExports(ctx context.Context) (int, error)
+	RemoveUserExports(ctx context.Context, userID uuid.UUID)
+}
+
+var _ ExportServiceInterface = (*ExportService)(nil)
+
+// DataExportDownload locates a finished export archive on disk
+type DataExportDownload struct {
+	Path     string // Absolute path of the archive
+	FileName string // Name suggested to the browser
+}
+
+type ExportService struct {
+	exportRepo  exportRepo.ExportRepositoryInterface
+	userService UserServiceInterface
+	authService AuthServiceInterface
+	noteService NoteServiceInterface
+	logger      *slog.Logger
+	mailer      *notification.Mailer
+	baseURL     string
+
+	storagePath string              // Root directory archives are written under
+	urlSigner   *apputils.URLSigner // Signs the emailed download links
+	expiry      time.Duration       // How long an archive and its link stay available
+}
+
+type ExportServiceOpts struct {
+	ExportRepo  exportRepo.ExportRepositoryInterface
+	UserService UserServiceInterface
+	AuthService AuthServiceInterface
+	NoteService NoteServiceInterface
+	Logger      *slog.Logger
+	Mailer      *notification.Mailer
+	BaseURL     string
+	StoragePath string
+	URLSigner   *apputils.URLSigner
+	Expiry      time.Duration
+}
+
+func NewExportService(opts ExportServiceOpts) *ExportService {
+	return &ExportService{
+		exportRepo:  opts.ExportRepo,
+		userService: opts.UserService,
+		authService: opts.AuthService,
+		noteService: opts.NoteService,
+		logger:      opts.Logger,
+		mailer:      opts.Mailer,
+		baseURL:     opts.BaseURL,
+		storagePath: opts.StoragePath,
+		urlSigner:   opts.URLSigner,
+		expiry:      opts.Expiry,
+	}
+}
+
+// RequestExport queues a new export of the user's data. Only one export can be in progress at a time.
+func (s *ExportService) RequestExport(ctx context.Context, userID uuid.UUID) (*dto.DataExportItem, error) {
+	if !s.userService.IsUserExistsByID(ctx, userID) {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s cannot be found", userID.String()))
+	}
+
+	export := &exportEntity.DataExportEntity{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    exportEntity.DataExportStatusPending,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.exportRepo.CreateDataExport(ctx, export); err != nil {
+		if errors.Is(err, exportRepo.ErrExportInProgress) {
+			return nil, fiber.NewError(fiber.StatusConflict, "a data export is already in progress")
+		}
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error requesting data export: %v", err))
+	}
+
+	item := toDataExportItem(export)
+	return &item, nil
+}
+
+// ListExports returns the user's exports, newest first
+func (s *ExportService) ListExports(ctx context.Context, userID uuid.UUID) ([]dto.DataExportItem, error) {
+	exports, err := s.exportRepo.ListDataExportsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting data exports: %v", err))
+	}
+
+	data := make([]dto.DataExportItem, 0, len(exports))
+	for i := range exports {
+		data = append(data, toDataExportItem(&exports[i]))
+	}
+
+	return data, nil
+}
+
+// ResolveDownload checks a signed download link and returns the archive it points to
+func (s *ExportService) ResolveDownload(ctx context.Context, exportID uuid.UUID, expires, signature string) (*DataExportDownload, error) {
+	if err := s.urlSigner.Verify(dataExportDownloadPath(exportID), expires, signature); err != nil {
+		if errors.Is(err, apputils.ErrSignatureExpired) {
+			return nil, fiber.NewError(fiber.StatusGone, "download link has expired")
+		}
+		return nil, fiber.NewError(fiber.StatusForbidden, "invalid download link")
+	}
+
+	export, err := s.exportRepo.GetDataExportByID(ctx, exportID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting data export: %v", err))
+	}
+	if export == nil || export.Status != exportEntity.DataExportStatusCompleted || export.FilePath == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "data export not found")
+	}
+	if export.ExpiresAt != nil && !time.Now().Before(*export.ExpiresAt) {
+		return nil, fiber.NewError(fiber.StatusGone, "data export has expired")
+	}
+
+	path := filepath.Join(s.storagePath, *export.FilePath)
+	if _, err := os.Stat(path); err != nil {
+		s.logger.Error("data export archive missing", slog.String("op", "ResolveDownload"), slog.String("export_id", exportID.String()), slog.String("error", err.Error()))
+		return nil, fiber.NewError(fiber.StatusGone, "data export is no longer available")
+	}
+
+	return &DataExportDownload{
+		Path:     path,
+		FileName: fmt.Sprintf("neatspace-export-%s.zip", export.CreatedAt.UTC().Format("20060102-150405")),
+	}, nil
+}
+
+// ProcessPendingExports builds every queued export and emails its download link.
+// Safe to run concurrently on several instances, each export is claimed by one of them.
+func (s *ExportService) ProcessPendingExports(ctx context.Context) (int, error) {
+	processed := 0
+
+	for ctx.Err() == nil {
+		export, err := s.exportRepo.ClaimPendingDataExport(ctx, time.Now().Add(-dataExportStaleAfter))
+		if err != nil {
+			return processed, err
+		}
+		if export == nil {
+			return processed, nil
+		}
+
+		if err := s.processExport(ctx, export); err != nil {
+			s.logger.Error("data export failed", slog.String("op", "ProcessPendingExports"), slog.String("export_id", export.ID.String()), slog.String("error", err.Error()))
+			if failErr := s.exportRepo.FailDataExport(ctx, export.ID, err.Error(), time.Now()); failErr != nil {
+				return processed, failErr
+			}
+		}
+
+		processed++
+	}
+
+	return processed, ctx.Err()
+}
+
+// PurgeExpiredExports removes expired exports and their archives
+func (s *ExportService) PurgeExpiredExports(ctx context.Context) (int, error) {
+	purged := 0
+
+	for {
+		exports, err := s.exportRepo.DeleteExpiredDataExports(ctx, time.Now(), dataExportCleanupBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, export := range exports {
+			if export.FilePath == nil {
+				continue
+			}
+			if err := os.Remove(filepath.Join(s.storagePath, *export.FilePath)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				s.logger.Error("failed to remove data export archive", slog.String("op", "PurgeExpiredExports"), slog.String("export_id", export.ID.String()), slog.String("error", err.Error()))
+			}
+		}
+
+		purged += len(exports)
+		if len(exports) < dataExportCleanupBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// RemoveUserExports deletes every archive of the user from storage, used once the account is purged
+func (s *ExportService) RemoveUserExports(ctx context.Context, userID uuid.UUID) {
+	dir := filepath.Join(s.storagePath, dataExportDir, userID.String())
+	if err := os.RemoveAll(dir); err != nil {
+		s.logger.Error("failed to remove user data exports", slog.String("op", "RemoveUserExports"), slog.String("user_id", userID.String()), slog.String("error", err.Error()))
+	}
+}
+
+func (s *ExportService) processExport(ctx context.Context, export *exportEntity.DataExportEntity) error {
+	profile, err := s.userService.GetProfile(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+
+	relPath, size, err := s.writeArchive(ctx, export, profile)
+	if err != nil {
+		return err
+	}
+
+	completedAt := time.Now()
+	expiresAt := completedAt.Add(s.expiry)
+	if err := s.exportRepo.CompleteDataExport(ctx, export.ID, relPath, size, completedAt, expiresAt); err != nil {
+		return err
+	}
+
+	data := map[string]any{
+		"Email":       profile.Email,
+		"DisplayName": profile.DisplayName,
+		"DownloadURL": buildAppLink(s.baseURL, dataExportDownloadPath(export.ID), s.urlSigner.Sign(dataExportDownloadPath(export.ID), expiresAt)),
+		"ExpiresAt":   expiresAt.UTC().Format("January 2, 2006 15:04 MST"),
+		"AppName":     "Neatspace",
+	}
+
+	// The archive is ready either way, a failed email is only logged
+	_ = sendTemplatedMail(ctx, s.mailer, s.logger, "processExport", profile.Email, "Your data export is ready", "data_export_ready.html", data)
+
+	return nil
+}
+
+// exportManifest describes the archive layout, written as manifest.json
+type exportManifest struct {
+	FormatVersion int                  `json:"format_version"`
+	ExportID      uuid.UUID            `json:"export_id"`
+	UserID        uuid.UUID            `json:"user_id"`
+	GeneratedAt   time.Time            `json:"generated_at"`
+	Files         []exportManifestFile `json:"files"`
+	Notes         []exportManifestNote `json:"notes"`
+	Counts        map[string]int       `json:"counts"`
+	Formats       map[string]string    `json:"formats"`
+}
+
+type exportManifestFile struct {
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+type exportManifestNote struct {
+	ID       uuid.UUID `json:"id"`
+	Title    string    `json:"title"`
+	JSON     string    `json:"json"`
+	Markdown string    `json:"markdown"`
+}
+
+// exportedNote is the content of a note's JSON file, Content is the raw Tiptap document
+type exportedNote struct {
+	ID        uuid.UUID                `json:"id"`
+	Title     string                   `json:"title"`
+	Content   noteEntity.TiptapContent `json:"content"`
+	CreatedAt time.Time                `json:"created_at"`
+	UpdatedAt *time.Time               `json:"updated_at"`
+}
+
+// writeArchive builds the ZIP next to its final location and renames it into place,
+// so a partially written archive is never served
+func (s *ExportService) writeArchive(ctx context.Context, export *exportEntity.DataExportEntity, profile *dto.UserProfile) (string, int64, error) {
+	sessions, err := s.authService.ListUserSessions(ctx, export.UserID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	notes, err := s.noteService.ListUserNotes(ctx, export.UserID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	relPath := filepath.Join(dataExportDir, export.UserID.String(), export.ID.String()+".zip")
+	absPath := filepath.Join(s.storagePath, relPath)
+	if err := os.MkdirAll(filepath.Dir(absPath), 0o750); err != nil {
+		return "", 0, fmt.Errorf("create export directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(absPath), export.ID.String()+"-*.tmp")
+	if err != nil {
+		return "", 0, fmt.Errorf("create export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := zip.NewWriter(tmp)
+
+	manifest := exportManifest{
+		FormatVersion: dataExportFormatVersion,
+		ExportID:      export.ID,
+		UserID:        export.UserID,
+		GeneratedAt:   time.Now().UTC(),
+		Files: []exportManifestFile{
+			{Path: "manifest.json", Description: "This file, describes the archive content"},
+			{Path: "profile.json", Description: "Account profile"},
+			{Path: "sessions.json", Description: "Sign-in sessions, including revoked and expired ones"},
+			{Path: "notes/", Description: "One .json file with the raw Tiptap document and one .md file rendered from it per note"},
+		},
+		Notes: make([]exportManifestNote, 0, len(notes)),
+		Counts: map[string]int{
+			"sessions": len(sessions),
+			"notes":    len(notes),
+		},
+		Formats: map[string]string{
+			"json":     "Tiptap (ProseMirror) JSON document",
+			"markdown": "Markdown rendered from the Tiptap document",
+		},
+	}
+
+	if err := writeZipJSON(zw, "profile.json", profile); err != nil {
+		return "", 0, err
+	}
+	if err := writeZipJSON(zw, "sessions.json", sessions); err != nil {
+		return "", 0, err
+	}
+
+	usedNames := make(map[string]bool, len(notes))
+	for _, note := range notes {
+		name := exportNoteFileName(note, usedNames)
+		entry := exportManifestNote{
+			ID:       note.ID,
+			Title:    note.Title,
+			JSON:     "notes/" + name + ".json",
+			Markdown: "notes/" + name + ".md",
+		}
+
+		if err := writeZipJSON(zw, entry.JSON, exportedNote{
+			ID:        note.ID,
+			Title:     note.Title,
+			Content:   note.Content,
+			CreatedAt: note.CreatedAt,
+			UpdatedAt: note.UpdatedAt,
+		}); err != nil {
+			return "", 0, err
+		}
+
+		markdown := "# " + note.Title + "\n"
+		if body := renderer.Markdown(note.Content); body != "" {
+			markdown += "\n" + body
+		}
+		if err := writeZipFile(zw, entry.Markdown, []byte(markdown)); err != nil {
+			return "", 0, err
+		}
+
+		manifest.Notes = append(manifest.Notes, entry)
+	}
+
+	if err := writeZipJSON(zw, "manifest.json", manifest); err != nil {
+		return "", 0, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", 0, fmt.Errorf("finalize export archive: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return "", 0, fmt.Errorf("flush export archive: %w", err)
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		return "", 0, fmt.Errorf("stat export archive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, fmt.Errorf("close export archive: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), absPath); err != nil {
+		return "", 0, fmt.Errorf("move export archive into place: %w", err)
+	}
+
+	return filepath.ToSlash(relPath), info.Size(), nil
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+	return writeZipFile(zw, name, append(data, '\n'))
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("add %s to archive: %w", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("write %s to archive: %w", name, err)
+	}
+	return nil
+}
+
+// exportNoteFileName builds a readable, unique file name from the note title
+func exportNoteFileName(note noteEntity.NoteEntity, used map[string]bool) string {
+	slug := fileNameSlug(note.Title)
+
+	name := slug + "-" + note.ID.String()[:8]
+	if used[name] {
+		name = slug + "-" + note.ID.String()
+	}
+	used[name] = true
+
+	return name
+}
+
+// fileNameSlug turns a title into a lowercase, dash separated file name
+func fileNameSlug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= 60 {
+			break
+		}
+	}
+
+	slug := strings.Trim(b.String(), "-")
+	if slug == "" {
+		slug = "untitled"
+	}
+	return slug
+}
+
+func dataExportDownloadPath(exportID uuid.UUID) string {
+	return "/api/v1/exports/" + exportID.String() + "/download"
+}
+
+func toDataExportItem(export *exportEntity.DataExportEntity) dto.DataExportItem {
+	return dto.DataExportItem{
+		ID:          export.ID,
+		Status:      string(export.Status),
+		FileSize:    export.FileSize,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+	}
+}
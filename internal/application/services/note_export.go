@@ -0,0 +1,116 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/rayhan889/neatspace/internal/domain/note/renderer"
+)
+
+// Formats notes can be exported in
+const (
+	NoteExportFormatMarkdown = "md"
+)
+
+// NoteExportFile is an exported note or archive of notes, ready to be downloaded
+type NoteExportFile struct {
+	FileName    string // Name suggested to the browser
+	ContentType string
+	Data        []byte
+}
+
+// ExportNote renders the note the user can view as a Markdown file with YAML front matter
+func (s *NoteService) ExportNote(ctx context.Context, userID, noteID uuid.UUID, format string) (*NoteExportFile, error) {
+	if format != NoteExportFormatMarkdown {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unsupported export format %q", format))
+	}
+
+	note, _, err := s.noteAccess(ctx, userID, noteID, noteEntity.PermissionView)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NoteExportFile{
+		FileName:    exportNoteFileName(*note, map[string]bool{}) + ".md",
+		ContentType: "text/markdown; charset=utf-8",
+		Data:        []byte(renderer.MarkdownFile(note)),
+	}, nil
+}
+
+// ExportWorkspaceNotes bundles every note of the workspace as Markdown files with YAML front
+// matter in a ZIP archive, only those with the tag when one is given. The user needs at least
+// the viewer role in the workspace.
+func (s *NoteService) ExportWorkspaceNotes(ctx context.Context, userID, workspaceID uuid.UUID, tag, format string) (*NoteExportFile, error) {
+	if format != NoteExportFormatMarkdown {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unsupported export format %q", format))
+	}
+
+	workspace, err := s.workspaceService.GetWorkspace(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := s.noteRepo.ListNotesByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting notes: %v", err))
+	}
+
+	name := fileNameSlug(workspace.Name)
+	if tag != "" {
+		tagged := notes[:0]
+		for _, note := range notes {
+			if note.HasTag(tag) {
+				tagged = append(tagged, note)
+			}
+		}
+		notes = tagged
+		name += "-" + fileNameSlug(tag)
+	}
+
+	return zipNoteExport(name, notes)
+}
+
+// ExportTaggedNotes bundles every note with the tag the user can view, across workspaces and
+// shares, as Markdown files with YAML front matter in a ZIP archive
+func (s *NoteService) ExportTaggedNotes(ctx context.Context, userID uuid.UUID, tag, format string) (*NoteExportFile, error) {
+	if format != NoteExportFormatMarkdown {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unsupported export format %q", format))
+	}
+
+	notes, err := s.noteRepo.ListNotesByTag(ctx, userID, strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting notes: %v", err))
+	}
+
+	return zipNoteExport(fileNameSlug(tag), notes)
+}
+
+// zipNoteExport renders the notes into a ZIP archive of Markdown files
+func zipNoteExport(name string, notes []noteEntity.NoteEntity) (*NoteExportFile, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	usedNames := make(map[string]bool, len(notes))
+	for i := range notes {
+		fileName := exportNoteFileName(notes[i], usedNames) + ".md"
+		if err := writeZipFile(zw, fileName, []byte(renderer.MarkdownFile(&notes[i]))); err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("finalize export archive: %v", err))
+	}
+
+	return &NoteExportFile{
+		FileName:    name + ".zip",
+		ContentType: "application/zip",
+		Data:        buf.Bytes(),
+	}, nil
+}
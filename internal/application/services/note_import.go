@@ -0,0 +1,320 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/rayhan889/neatspace/internal/domain/note/importer"
+	workspaceEntity "github.com/rayhan889/neatspace/internal/domain/workspace/entities"
+)
+
+// Limits of an import. ZIP entries are checked before and while they are extracted, so a
+// small archive can't expand into something huge.
+const (
+	maxImportFiles     = 200
+	maxImportFileSize  = 2 << 20
+	maxImportTotalSize = 20 << 20
+)
+
+var (
+	ErrNoImportFiles      = fiber.NewError(fiber.StatusBadRequest, "no files to import, send them in the files field")
+	errImportFileTooLarge = fmt.Errorf("file is larger than %d MiB", maxImportFileSize>>20)
+	errImportTooLarge     = errors.New("import is too large, split it into several")
+)
+
+// NoteImportFile is an uploaded .md, .html or .zip file
+type NoteImportFile struct {
+	Name string
+	Data []byte
+}
+
+// importEntry is a note file to import, from an upload or extracted from a ZIP
+type importEntry struct {
+	name string
+	data []byte
+}
+
+// ImportNotes creates a note from every Markdown and HTML file, ZIP archives are searched for
+// them. The notes go into the workspace, the user's personal workspace when it's nil, which
+// requires the editor role. With atomic either every file is imported or none, otherwise each
+// file succeeds or fails on its own.
+func (s *NoteService) ImportNotes(ctx context.Context, userID uuid.UUID, workspaceID *uuid.UUID, files []NoteImportFile, atomic bool) (*dto.ImportNotesResult, error) {
+	if len(files) == 0 {
+		return nil, ErrNoImportFiles
+	}
+
+	var targetID uuid.UUID
+	if workspaceID != nil {
+		targetID = *workspaceID
+	} else {
+		personalID, err := s.workspaceService.PersonalWorkspaceID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		targetID = personalID
+	}
+	if _, err := s.workspaceService.RequireRole(ctx, targetID, userID, workspaceEntity.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	entries, results := expandImportFiles(files)
+
+	var notes []*noteEntity.NoteEntity
+	var noteResults []int // Index in results of each note
+	for _, entry := range entries {
+		note, result := s.parseImportEntry(entry, userID, targetID)
+		results = append(results, result)
+		if note != nil {
+			notes = append(notes, note)
+			noteResults = append(noteResults, len(results)-1)
+		}
+	}
+
+	if atomic {
+		failed := false
+		for _, r := range results {
+			failed = failed || r.Status == dto.ImportStatusFailed
+		}
+
+		var err error
+		if !failed && len(notes) > 0 {
+			err = s.noteRepo.CreateNotes(ctx, notes)
+		}
+		if failed || err != nil {
+			reason := "not imported because another file failed"
+			if err != nil {
+				reason = "not imported, the notes could not be saved"
+			}
+			for _, i := range noteResults {
+				results[i].Status = dto.ImportStatusFailed
+				results[i].NoteID = nil
+				results[i].Error = reason
+			}
+		}
+	} else {
+		for i, note := range notes {
+			if err := s.noteRepo.CreateNote(ctx, note); err != nil {
+				r := &results[noteResults[i]]
+				r.Status = dto.ImportStatusFailed
+				r.NoteID = nil
+				r.Error = "the note could not be saved"
+			}
+		}
+	}
+
+	for i, note := range notes {
+		if results[noteResults[i]].Status == dto.ImportStatusImported {
+			s.noteCreated(ctx, note)
+		}
+	}
+
+	summary := &dto.ImportNotesResult{Results: results}
+	for _, r := range results {
+		switch r.Status {
+		case dto.ImportStatusImported:
+			summary.Imported++
+		case dto.ImportStatusFailed:
+			summary.Failed++
+		}
+	}
+
+	return summary, nil
+}
+
+// parseImportEntry converts the file into a note, nil with a failed result when it can't be
+func (s *NoteService) parseImportEntry(entry importEntry, userID, workspaceID uuid.UUID) (*noteEntity.NoteEntity, dto.ImportNoteResult) {
+	result := dto.ImportNoteResult{File: entry.name, Status: dto.ImportStatusFailed}
+
+	if !utf8.Valid(entry.data) {
+		result.Error = "file is not UTF-8 text"
+		return nil, result
+	}
+
+	var doc *importer.Document
+	var err error
+	switch importFileType(entry.name) {
+	case "md":
+		doc, err = importer.ParseMarkdown(entry.data)
+	case "html":
+		doc, err = importer.ParseHTML(entry.data)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return nil, result
+	}
+
+	note := &noteEntity.NoteEntity{
+		ID:          uuid.New(),
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		Title:       importTitle(doc.Title, entry.name),
+		Content:     doc.Content,
+		ContentText: s.extractContentToText(doc.Content.Content),
+		Tags:        doc.Tags,
+		Version:     1,
+		CreatedAt:   time.Now(),
+	}
+	if doc.CreatedAt != nil {
+		note.CreatedAt = *doc.CreatedAt
+	}
+
+	result.Status = dto.ImportStatusImported
+	result.NoteID = &note.ID
+	result.Title = note.Title
+	result.Tags = note.Tags
+	return note, result
+}
+
+// expandImportFiles extracts note files from the uploads, files that can't be imported are
+// reported right away. Once the number of files or the total size is reached, the remaining
+// files are reported without being read.
+func expandImportFiles(files []NoteImportFile) ([]importEntry, []dto.ImportNoteResult) {
+	var entries []importEntry
+	var results []dto.ImportNoteResult
+	total := 0
+	stopped := ""
+
+	fail := func(name, reason string) {
+		results = append(results, dto.ImportNoteResult{File: name, Status: dto.ImportStatusFailed, Error: reason})
+	}
+	add := func(name string, data []byte) {
+		if total+len(data) > maxImportTotalSize {
+			stopped = errImportTooLarge.Error()
+			fail(name, stopped)
+			return
+		}
+		total += len(data)
+		entries = append(entries, importEntry{name: name, data: data})
+		if len(entries) >= maxImportFiles {
+			stopped = fmt.Sprintf("too many files, at most %d can be imported at once", maxImportFiles)
+		}
+	}
+
+	for _, file := range files {
+		switch importFileType(file.Name) {
+		case "":
+			fail(file.Name, "unsupported file type, use .md, .html or .zip")
+			continue
+		case "zip":
+		default:
+			switch {
+			case stopped != "":
+				fail(file.Name, stopped)
+			case len(file.Data) > maxImportFileSize:
+				fail(file.Name, errImportFileTooLarge.Error())
+			default:
+				add(file.Name, file.Data)
+			}
+			continue
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(file.Data), int64(len(file.Data)))
+		if err != nil {
+			fail(file.Name, "invalid ZIP archive")
+			continue
+		}
+
+		for _, zf := range zr.File {
+			name := file.Name + "/" + zf.Name
+			base := path.Base(zf.Name)
+			if zf.FileInfo().IsDir() || strings.HasPrefix(zf.Name, "__MACOSX/") || strings.HasPrefix(base, ".") {
+				continue
+			}
+
+			switch importFileType(zf.Name) {
+			case "md", "html":
+			default:
+				results = append(results, dto.ImportNoteResult{File: name, Status: dto.ImportStatusSkipped, Error: "not a Markdown or HTML file"})
+				continue
+			}
+
+			if stopped != "" {
+				fail(name, stopped)
+				continue
+			}
+
+			data, err := readZipEntry(zf, maxImportTotalSize-total)
+			if err != nil {
+				if errors.Is(err, errImportTooLarge) {
+					stopped = err.Error()
+				}
+				fail(name, err.Error())
+				continue
+			}
+			add(name, data)
+		}
+	}
+
+	return entries, results
+}
+
+// readZipEntry extracts the entry when it fits both the size of a file and the budget left
+// for the import, reading no more than that
+func readZipEntry(zf *zip.File, budget int) ([]byte, error) {
+	if zf.UncompressedSize64 > maxImportFileSize {
+		return nil, errImportFileTooLarge
+	}
+	if zf.UncompressedSize64 > uint64(budget) {
+		return nil, errImportTooLarge
+	}
+
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, errors.New("file can't be extracted")
+	}
+	defer rc.Close()
+
+	// The size in the header can lie
+	data, err := io.ReadAll(io.LimitReader(rc, int64(min(maxImportFileSize, budget))+1))
+	if err != nil {
+		return nil, errors.New("file can't be extracted")
+	}
+	if len(data) > maxImportFileSize {
+		return nil, errImportFileTooLarge
+	}
+	if len(data) > budget {
+		return nil, errImportTooLarge
+	}
+	return data, nil
+}
+
+// importFileType returns md, html or zip from the file extension, empty for anything else
+func importFileType(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".markdown":
+		return "md"
+	case ".html", ".htm":
+		return "html"
+	case ".zip":
+		return "zip"
+	}
+	return ""
+}
+
+// importTitle picks the title of the document, else the file name, within the limits notes
+// are created with
+func importTitle(title, fileName string) string {
+	base := path.Base(fileName)
+	for _, candidate := range []string{title, strings.TrimSuffix(base, path.Ext(base))} {
+		candidate = strings.Join(strings.Fields(candidate), " ")
+		if runes := []rune(candidate); len(runes) > 100 {
+			candidate = strings.TrimSpace(string(runes[:100]))
+		}
+		if utf8.RuneCountInString(candidate) >= 3 {
+			return candidate
+		}
+	}
+	return "Untitled note"
+}
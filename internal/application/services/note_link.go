@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+)
+
+// renameAttempts bounds how often rewriting a linking note is retried when it's edited
+// at the same time
+const renameAttempts = 3
+
+// ListBacklinks returns the notes linking to the note that the user can view, by title
+func (s *NoteService) ListBacklinks(ctx context.Context, userID, noteID uuid.UUID) ([]dto.NoteLinkItem, error) {
+	if _, _, err := s.noteAccess(ctx, userID, noteID, noteEntity.PermissionView); err != nil {
+		return nil, err
+	}
+
+	links, err := s.linkRepo.ListBacklinks(ctx, noteID, userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting backlinks: %v", err))
+	}
+
+	items := []dto.NoteLinkItem{}
+	for _, link := range links {
+		if combinePermission(stringValue(link.Role), stringValue(link.SharePermission)) == "" {
+			continue
+		}
+		items = append(items, dto.NoteLinkItem{
+			NoteID:      link.SourceNoteID,
+			WorkspaceID: link.WorkspaceID,
+			Title:       stringValue(link.NoteTitle),
+			Accessible:  true,
+			UpdatedAt:   link.NoteUpdatedAt,
+			LinkedAt:    link.CreatedAt,
+		})
+	}
+
+	return items, nil
+}
+
+// ListOutgoingLinks returns the notes the note links to. Linked notes the user can't view are
+// listed with the title shown by the link only, it's in the content the user can read anyway.
+func (s *NoteService) ListOutgoingLinks(ctx context.Context, userID, noteID uuid.UUID) ([]dto.NoteLinkItem, error) {
+	if _, _, err := s.noteAccess(ctx, userID, noteID, noteEntity.PermissionView); err != nil {
+		return nil, err
+	}
+
+	links, err := s.linkRepo.ListOutgoingLinks(ctx, noteID, userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting note links: %v", err))
+	}
+
+	items := make([]dto.NoteLinkItem, 0, len(links))
+	for _, link := range links {
+		item := dto.NoteLinkItem{
+			NoteID: link.TargetNoteID,
+			Title:  link.TargetTitle,
+			// Also when the target went away without being deleted, e.g. with its workspace
+			Dangling: link.Dangling || link.WorkspaceID == nil,
+			LinkedAt: link.CreatedAt,
+		}
+		if link.WorkspaceID != nil && combinePermission(stringValue(link.Role), stringValue(link.SharePermission)) != "" {
+			item.WorkspaceID = link.WorkspaceID
+			item.Title = stringValue(link.NoteTitle)
+			item.Accessible = true
+			item.UpdatedAt = link.NoteUpdatedAt
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// syncNoteLinks stores the links found in the content of the note
+func (s *NoteService) syncNoteLinks(ctx context.Context, noteID uuid.UUID, content noteEntity.TiptapContent) {
+	var targets []noteEntity.NoteLinkTarget
+	for _, target := range noteEntity.ExtractNoteLinks(content, s.appHost()) {
+		if target.NoteID != noteID {
+			targets = append(targets, target)
+		}
+	}
+
+	if err := s.linkRepo.ReplaceNoteLinks(ctx, noteID, targets); err != nil {
+		s.logger.Error("failed to store note links", slog.String("op", "syncNoteLinks"), slog.String("note_id", noteID.String()), slog.String("error", err.Error()))
+	}
+}
+
+// setLinksDangling marks the links to the note as dangling once it's deleted, or as resolved
+func (s *NoteService) setLinksDangling(ctx context.Context, noteID uuid.UUID, dangling bool) {
+	if err := s.linkRepo.SetLinksDangling(ctx, noteID, dangling); err != nil {
+		s.logger.Error("failed to update links to note", slog.String("op", "setLinksDangling"), slog.String("note_id", noteID.String()), slog.String("error", err.Error()))
+	}
+}
+
+// renameNoteLinks rewrites the links to a renamed note in the notes linking to it whose readers
+// can all view it, others keep showing the old title. Each rewritten note gets a new version
+// like any other edit.
+func (s *NoteService) renameNoteLinks(ctx context.Context, noteID uuid.UUID, oldTitle, newTitle string) {
+	sourceIDs, err := s.linkRepo.ListLinkingNoteIDs(ctx, noteID)
+	if err != nil {
+		s.logger.Error("failed to list notes linking to renamed note", slog.String("op", "renameNoteLinks"), slog.String("note_id", noteID.String()), slog.String("error", err.Error()))
+		return
+	}
+
+	host := s.appHost()
+	for _, sourceID := range sourceIDs {
+		for range renameAttempts {
+			source, err := s.noteRepo.GetNoteByID(ctx, sourceID)
+			if err != nil || source == nil || !noteEntity.RenameNoteLinks(&source.Content, noteID, oldTitle, newTitle, host) {
+				break
+			}
+
+			source.ContentText = s.extractContentToText(source.Content.Content)
+			version := source.Version
+			updated, err := s.noteRepo.UpdateNote(ctx, source, &version)
+			if err != nil {
+				s.logger.Error("failed to rewrite links to renamed note", slog.String("op", "renameNoteLinks"), slog.String("note_id", sourceID.String()), slog.String("error", err.Error()))
+				break
+			}
+			if updated {
+				s.indexNoteContent(ctx, source.ID, source.Content)
+				break
+			}
+			// Edited in the meantime, try again on the new version
+		}
+	}
+}
+
+// appHost is the host links to the pages of notes point at
+func (s *NoteService) appHost() string {
+	u, err := url.Parse(buildAppLink(s.baseURL, "/", nil))
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
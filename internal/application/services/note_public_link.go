@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	"github.com/rayhan889/neatspace/internal/audit"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/rayhan889/neatspace/internal/domain/note/renderer"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+// Length of public link slugs, including the timestamp GenerateURLSafeToken appends
+const publicLinkSlugLength = 32
+
+var (
+	ErrPublicLinkPasswordRequired = fiber.NewError(fiber.StatusUnauthorized, "this note is password protected")
+	ErrPublicLinkPasswordInvalid  = fiber.NewError(fiber.StatusUnauthorized, "invalid password")
+)
+
+// CreatePublicLink publishes the note read-only under a new unguessable slug
+func (s *NoteService) CreatePublicLink(ctx context.Context, actorID, noteID uuid.UUID, req *dto.CreatePublicLinkRequest) (*dto.PublicLinkItem, error) {
+	if _, _, err := s.noteAccess(ctx, actorID, noteID, noteEntity.PermissionManage); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.publicLinkRepo.GetLinkByNoteID(ctx, noteID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting public link: %v", err))
+	}
+	if existing != nil {
+		return nil, fiber.NewError(fiber.StatusConflict, "note already has a public link")
+	}
+
+	now := time.Now()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "expiry must be in the future")
+	}
+
+	slug, err := apputils.GenerateURLSafeToken(publicLinkSlugLength)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error generating public link: %v", err))
+	}
+
+	link := &noteEntity.NotePublicLinkEntity{
+		ID:        uuid.New(),
+		NoteID:    noteID,
+		Slug:      slug,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: &actorID,
+		CreatedAt: now,
+	}
+	if req.Password != nil {
+		if link.PasswordHash, err = s.hashLinkPassword(*req.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.publicLinkRepo.CreateLink(ctx, link); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error creating public link: %v", err))
+	}
+
+	s.auditRecorder.Record(ctx, &actorID, audit.ActionNotePublish, audit.Note(noteID), map[string]any{
+		"has_password": link.HasPassword(),
+		"expires_at":   link.ExpiresAt,
+	})
+
+	item := s.toPublicLinkItem(link)
+	return &item, nil
+}
+
+func (s *NoteService) GetPublicLink(ctx context.Context, actorID, noteID uuid.UUID) (*dto.PublicLinkItem, error) {
+	if _, _, err := s.noteAccess(ctx, actorID, noteID, noteEntity.PermissionManage); err != nil {
+		return nil, err
+	}
+
+	link, err := s.getPublicLink(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := s.toPublicLinkItem(link)
+	return &item, nil
+}
+
+// UpdatePublicLink changes the password and expiry of the link, the slug stays the same
+func (s *NoteService) UpdatePublicLink(ctx context.Context, actorID, noteID uuid.UUID, req *dto.UpdatePublicLinkRequest) (*dto.PublicLinkItem, error) {
+	if _, _, err := s.noteAccess(ctx, actorID, noteID, noteEntity.PermissionManage); err != nil {
+		return nil, err
+	}
+	if req.Password != nil && req.RemovePassword {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "password can't be set and removed at the same time")
+	}
+	if req.ExpiresAt != nil && req.RemoveExpiry {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "expiry can't be set and removed at the same time")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "expiry must be in the future")
+	}
+
+	link, err := s.getPublicLink(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case req.RemovePassword:
+		link.PasswordHash = nil
+	case req.Password != nil:
+		if link.PasswordHash, err = s.hashLinkPassword(*req.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case req.RemoveExpiry:
+		link.ExpiresAt = nil
+	case req.ExpiresAt != nil:
+		link.ExpiresAt = req.ExpiresAt
+	}
+
+	if err := s.publicLinkRepo.UpdateLinkSettings(ctx, link.ID, link.PasswordHash, link.ExpiresAt); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error updating public link: %v", err))
+	}
+
+	s.auditRecorder.Record(ctx, &actorID, audit.ActionNotePublishUpdate, audit.Note(noteID), map[string]any{
+		"has_password": link.HasPassword(),
+		"expires_at":   link.ExpiresAt,
+	})
+
+	item := s.toPublicLinkItem(link)
+	return &item, nil
+}
+
+func (s *NoteService) DeletePublicLink(ctx context.Context, actorID, noteID uuid.UUID) error {
+	if _, _, err := s.noteAccess(ctx, actorID, noteID, noteEntity.PermissionManage); err != nil {
+		return err
+	}
+
+	deleted, err := s.publicLinkRepo.DeleteLinkByNoteID(ctx, noteID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error deleting public link: %v", err))
+	}
+	if !deleted {
+		return fiber.NewError(fiber.StatusNotFound, "note has no public link")
+	}
+
+	s.auditRecorder.Record(ctx, &actorID, audit.ActionNoteUnpublish, audit.Note(noteID), nil)
+
+	return nil
+}
+
+// ViewPublicNote returns the note published under slug and counts the view. Protected links
+// return ErrPublicLinkPasswordRequired or ErrPublicLinkPasswordInvalid until the right
+// password is given.
+func (s *NoteService) ViewPublicNote(ctx context.Context, slug, password string) (*dto.PublicNoteResponse, error) {
+	link, err := s.publicLinkRepo.GetLinkBySlug(ctx, slug)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting public link: %v", err))
+	}
+	if link == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "public note cannot be found")
+	}
+	if link.IsExpired(time.Now()) {
+		return nil, fiber.NewError(fiber.StatusGone, "this link has expired")
+	}
+
+	if link.HasPassword() {
+		if password == "" {
+			return nil, ErrPublicLinkPasswordRequired
+		}
+
+		valid, err := s.passwordHasher.Validate(password, *link.PasswordHash)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error validating password: %v", err))
+		}
+		if !valid {
+			return nil, ErrPublicLinkPasswordInvalid
+		}
+	}
+
+	note, err := s.noteRepo.GetNoteByID(ctx, link.NoteID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting note: %v", err))
+	}
+	if note == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "public note cannot be found")
+	}
+
+	// A lost view only makes the counter slightly low, don't fail the page for it
+	if err := s.publicLinkRepo.IncrementViewCount(ctx, link.ID); err != nil {
+		s.logger.Warn("failed to count public note view", slog.String("op", "ViewPublicNote"), slog.String("error", err.Error()))
+	}
+
+	return &dto.PublicNoteResponse{
+		Title:     note.Title,
+		Content:   note.Content,
+		HTML:      renderer.HTML(note.Content, s.htmlOptions(ctx, note.ID)),
+		CreatedAt: note.CreatedAt,
+		UpdatedAt: note.UpdatedAt,
+	}, nil
+}
+
+func (s *NoteService) getPublicLink(ctx context.Context, noteID uuid.UUID) (*noteEntity.NotePublicLinkEntity, error) {
+	link, err := s.publicLinkRepo.GetLinkByNoteID(ctx, noteID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting public link: %v", err))
+	}
+	if link == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "note has no public link")
+	}
+
+	return link, nil
+}
+
+func (s *NoteService) hashLinkPassword(password string) (*string, error) {
+	hash, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error hashing password: %v", err))
+	}
+	return &hash, nil
+}
+
+func (s *NoteService) toPublicLinkItem(link *noteEntity.NotePublicLinkEntity) dto.PublicLinkItem {
+	return dto.PublicLinkItem{
+		Slug:        link.Slug,
+		URL:         buildAppLink(s.baseURL, "/p/"+link.Slug, nil),
+		HasPassword: link.HasPassword(),
+		ExpiresAt:   link.ExpiresAt,
+		ViewCount:   link.ViewCount,
+		CreatedAt:   link.CreatedAt,
+		UpdatedAt:   link.UpdatedAt,
+	}
+}
@@ -4,48 +4,162 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	"github.com/rayhan889/neatspace/internal/audit"
 	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/rayhan889/neatspace/internal/domain/note/renderer"
 	"github.com/rayhan889/neatspace/internal/domain/note/repositories"
+	userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
+	workspaceEntity "github.com/rayhan889/neatspace/internal/domain/workspace/entities"
+	"github.com/rayhan889/neatspace/internal/notification"
 	"github.com/rayhan889/neatspace/pkg/apputils"
 )
 
 type NoteServiceInterface interface {
 	PaginationNote(c *fiber.Ctx, p *apputils.Pagination) (data []dto.NotePaginationResponse, total int, err error)
 	CreateNote(ctx context.Context, note *noteEntity.NoteEntity) error
+	ListUserNotes(ctx context.Context, userID uuid.UUID) ([]noteEntity.NoteEntity, error)
+	CountUserNotes(ctx context.Context, userID uuid.UUID) (int, error)
+	GetNote(ctx context.Context, userID, noteID uuid.UUID) (*dto.NoteResponse, error)
+	GetNoteHTML(ctx context.Context, userID, noteID uuid.UUID) (string, error)
+	UpdateNote(ctx context.Context, userID, noteID uuid.UUID, ifVersion *int64, req *dto.UpdateNoteRequest) (*dto.NoteResponse, error)
+	DeleteNote(ctx context.Context, userID, noteID uuid.UUID) error
+	ShareNote(ctx context.Context, actorID, noteID uuid.UUID, req *dto.ShareNoteRequest) (*dto.NoteShareItem, error)
+	ListNoteShares(ctx context.Context, userID, noteID uuid.UUID) ([]dto.NoteShareItem, error)
+	UpdateNoteShare(ctx context.Context, actorID, noteID, shareID uuid.UUID, permission string) error
+	RemoveNoteShare(ctx context.Context, actorID, noteID, shareID uuid.UUID) error
+	ResolvePendingShares(ctx context.Context, user *userEntity.UserEntity)
+	CreatePublicLink(ctx context.Context, actorID, noteID uuid.UUID, req *dto.CreatePublicLinkRequest) (*dto.PublicLinkItem, error)
+	GetPublicLink(ctx context.Context, actorID, noteID uuid.UUID) (*dto.PublicLinkItem, error)
+	UpdatePublicLink(ctx context.Context, actorID, noteID uuid.UUID, req *dto.UpdatePublicLinkRequest) (*dto.PublicLinkItem, error)
+	DeletePublicLink(ctx context.Context, actorID, noteID uuid.UUID) error
+	ViewPublicNote(ctx context.Context, slug, password string) (*dto.PublicNoteResponse, error)
+	NoteAccess(ctx context.Context, userID, noteID uuid.UUID) (*noteEntity.NoteEntity, string, error)
+	SaveCollabSnapshot(ctx context.Context, noteID uuid.UUID, baseVersion int64, content noteEntity.TiptapContent) (*noteEntity.NoteEntity, bool, error)
+	CollabPermission(ctx context.Context, noteID, userID uuid.UUID) (string, error)
+	SignImagesWith(signer func(ctx context.Context, noteID uuid.UUID) func(src string) string)
+	SyncNoteChanges(ctx context.Context, userID uuid.UUID, since string, limit int) (*dto.SyncNotesResponse, error)
+	ApplySyncMutations(ctx context.Context, userID uuid.UUID, req *dto.SyncNotesRequest) (*dto.SyncNotesResult, error)
+	PurgeDeletedNotes(ctx context.Context) (int, error)
+	ExportNote(ctx context.Context, userID, noteID uuid.UUID, format string) (*NoteExportFile, error)
+	ExportWorkspaceNotes(ctx context.Context, userID, workspaceID uuid.UUID, tag, format string) (*NoteExportFile, error)
+	ExportTaggedNotes(ctx context.Context, userID uuid.UUID, tag, format string) (*NoteExportFile, error)
+	ImportNotes(ctx context.Context, userID uuid.UUID, workspaceID *uuid.UUID, files []NoteImportFile, atomic bool) (*dto.ImportNotesResult, error)
+	ListBacklinks(ctx context.Context, userID, noteID uuid.UUID) ([]dto.NoteLinkItem, error)
+	ListOutgoingLinks(ctx context.Context, userID, noteID uuid.UUID) ([]dto.NoteLinkItem, error)
+	ListTasks(ctx context.Context, userID uuid.UUID, filter *noteEntity.NoteTaskFilter, p *apputils.Pagination) (data []dto.NoteTaskItem, total int, err error)
+	SetTaskChecked(ctx context.Context, userID, noteID uuid.UUID, position int, ifVersion *int64, checked bool) (*dto.NoteTaskItem, error)
 }
 
 var _ NoteServiceInterface = (*NoteService)(nil)
 
+// NoteVersionConflictError is returned when a note changed since the version the client
+// edited. It carries the current note so the client can merge.
+type NoteVersionConflictError struct {
+	Current *dto.NoteResponse
+}
+
+func (e *NoteVersionConflictError) Error() string {
+	return fmt.Sprintf("note has changed since it was loaded, current version is %d", e.Current.Version)
+}
+
 type NoteService struct {
-	noteRepo    repositories.NoteRepositoryInterface
-	userService UserServiceInterface
+	noteRepo         repositories.NoteRepositoryInterface
+	shareRepo        repositories.NoteShareRepositoryInterface
+	publicLinkRepo   repositories.NotePublicLinkRepositoryInterface
+	linkRepo         repositories.NoteLinkRepositoryInterface
+	taskRepo         repositories.NoteTaskRepositoryInterface
+	userService      UserServiceInterface
+	workspaceService WorkspaceServiceInterface
+	auditRecorder    audit.RecorderInterface
+	logger           *slog.Logger
+	mailer           *notification.Mailer
+	baseURL          string
+	passwordHasher   *apputils.PasswordHasher // Hashes public link passwords
+	// How long tombstones of deleted notes are kept, sync tokens older than that are expired
+	tombstoneRetention time.Duration
+	// Signs the src of images in rendered notes so they load without a session
+	imageSigner func(ctx context.Context, noteID uuid.UUID) func(src string) string
 }
 
 type NoteServiceOpts struct {
-	NoteRepo    repositories.NoteRepositoryInterface
-	UserService UserServiceInterface
+	NoteRepo           repositories.NoteRepositoryInterface
+	ShareRepo          repositories.NoteShareRepositoryInterface
+	PublicLinkRepo     repositories.NotePublicLinkRepositoryInterface
+	LinkRepo           repositories.NoteLinkRepositoryInterface
+	TaskRepo           repositories.NoteTaskRepositoryInterface
+	UserService        UserServiceInterface
+	WorkspaceService   WorkspaceServiceInterface
+	AuditRecorder      audit.RecorderInterface
+	Logger             *slog.Logger
+	Mailer             *notification.Mailer
+	BaseURL            string
+	PasswordHasher     *apputils.PasswordHasher
+	TombstoneRetention time.Duration
 }
 
 func NewNoteService(opts NoteServiceOpts) *NoteService {
 	return &NoteService{
-		noteRepo:    opts.NoteRepo,
-		userService: opts.UserService,
+		noteRepo:           opts.NoteRepo,
+		shareRepo:          opts.ShareRepo,
+		publicLinkRepo:     opts.PublicLinkRepo,
+		linkRepo:           opts.LinkRepo,
+		taskRepo:           opts.TaskRepo,
+		userService:        opts.UserService,
+		workspaceService:   opts.WorkspaceService,
+		auditRecorder:      opts.AuditRecorder,
+		logger:             opts.Logger,
+		mailer:             opts.Mailer,
+		baseURL:            opts.BaseURL,
+		passwordHasher:     opts.PasswordHasher,
+		tombstoneRetention: opts.TombstoneRetention,
 	}
 }
 
+// PaginationNote lists notes of the workspaces the user is a member of, optionally narrowed
+// to a single workspace with the workspace_id query. With scope=shared it lists the notes
+// shared directly with the user instead.
 func (s *NoteService) PaginationNote(c *fiber.Ctx, p *apputils.Pagination) (data []dto.NotePaginationResponse, total int, err error) {
+	if raw := c.Query("workspace_id"); raw != "" {
+		workspaceID, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, 0, fiber.NewError(fiber.StatusBadRequest, "invalid workspace id")
+		}
+
+		userID := apputils.UUIDChecker(c.Locals("user_id").(string))
+		if _, err := s.workspaceService.RequireRole(c.Context(), workspaceID, userID, workspaceEntity.RoleViewer); err != nil {
+			return nil, 0, err
+		}
+	}
+
 	return s.noteRepo.PaginationNote(c, p)
 }
 
+// CreateNote stores a note in its workspace, the author's personal workspace when none is set.
+// The author needs at least the editor role in the workspace.
 func (s *NoteService) CreateNote(ctx context.Context, note *noteEntity.NoteEntity) error {
 	if !s.userService.IsUserExistsByID(ctx, note.UserID) {
 		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s not found", note.UserID.String()))
 	}
 
+	if note.WorkspaceID == uuid.Nil {
+		workspaceID, err := s.workspaceService.PersonalWorkspaceID(ctx, note.UserID)
+		if err != nil {
+			return err
+		}
+		note.WorkspaceID = workspaceID
+	}
+
+	if _, err := s.workspaceService.RequireRole(ctx, note.WorkspaceID, note.UserID, workspaceEntity.RoleEditor); err != nil {
+		return err
+	}
+
 	if len(note.Content.Content) > 0 {
 		var doc noteEntity.TiptapContent
 		bytes, err := json.Marshal(note.Content)
@@ -60,15 +174,298 @@ func (s *NoteService) CreateNote(ctx context.Context, note *noteEntity.NoteEntit
 		note.ContentText = s.extractContentToText(doc.Content)
 	}
 
-	return s.noteRepo.CreateNote(ctx, note)
+	if err := s.noteRepo.CreateNote(ctx, note); err != nil {
+		return err
+	}
+
+	s.noteCreated(ctx, note)
+	return nil
+}
+
+// ListUserNotes returns every note owned by the user, oldest first
+func (s *NoteService) ListUserNotes(ctx context.Context, userID uuid.UUID) ([]noteEntity.NoteEntity, error) {
+	notes, err := s.noteRepo.ListNotesByUserID(ctx, userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting notes: %v", err))
+	}
+	return notes, nil
+}
+
+func (s *NoteService) CountUserNotes(ctx context.Context, userID uuid.UUID) (int, error) {
+	total, err := s.noteRepo.CountNotesByUserID(ctx, userID)
+	if err != nil {
+		return 0, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error counting notes: %v", err))
+	}
+	return total, nil
+}
+
+// GetNote returns the note if the user can view it through its workspace or a share
+func (s *NoteService) GetNote(ctx context.Context, userID, noteID uuid.UUID) (*dto.NoteResponse, error) {
+	note, permission, err := s.noteAccess(ctx, userID, noteID, noteEntity.PermissionView)
+	if err != nil {
+		return nil, err
+	}
+
+	return toNoteResponse(note, permission), nil
+}
+
+// GetNoteHTML returns the content of the note rendered as sanitized HTML
+func (s *NoteService) GetNoteHTML(ctx context.Context, userID, noteID uuid.UUID) (string, error) {
+	note, _, err := s.noteAccess(ctx, userID, noteID, noteEntity.PermissionView)
+	if err != nil {
+		return "", err
+	}
+
+	return renderer.HTML(note.Content, s.htmlOptions(ctx, note.ID)), nil
+}
+
+// attachmentPath is where images uploaded to our storage are served from
+const attachmentPath = "/api/v1/attachments/"
+
+// SignImagesWith registers what signs the image sources of a rendered note, called once while
+// wiring the application
+func (s *NoteService) SignImagesWith(signer func(ctx context.Context, noteID uuid.UUID) func(src string) string) {
+	s.imageSigner = signer
+}
+
+// htmlOptions only lets the rendered note load images from our own storage
+func (s *NoteService) htmlOptions(ctx context.Context, noteID uuid.UUID) renderer.HTMLOptions {
+	opts := renderer.HTMLOptions{
+		ImageSources: []string{buildAppLink(s.baseURL, attachmentPath, nil), attachmentPath},
+	}
+	if s.imageSigner != nil {
+		opts.ImageURL = s.imageSigner(ctx, noteID)
+	}
+
+	return opts
+}
+
+// UpdateNote saves the title, content and tags of the note. With ifVersion the update only applies
+// while the note is still at that version, a NoteVersionConflictError is returned otherwise.
+func (s *NoteService) UpdateNote(ctx context.Context, userID, noteID uuid.UUID, ifVersion *int64, req *dto.UpdateNoteRequest) (*dto.NoteResponse, error) {
+	if req.Title == nil && req.Content == nil && req.Tags == nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "nothing to update")
+	}
+
+	note, permission, err := s.noteAccess(ctx, userID, noteID, noteEntity.PermissionEdit)
+	if err != nil {
+		return nil, err
+	}
+	if ifVersion != nil && note.Version != *ifVersion {
+		return nil, &NoteVersionConflictError{Current: toNoteResponse(note, permission)}
+	}
+
+	oldTitle := note.Title
+	if req.Title != nil {
+		note.Title = *req.Title
+	}
+	if req.Content != nil {
+		note.Content = *req.Content
+		note.ContentText = s.extractContentToText(req.Content.Content)
+	}
+	if req.Tags != nil {
+		note.Tags = *req.Tags
+	}
+
+	updated, err := s.noteRepo.UpdateNote(ctx, note, ifVersion)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error updating note: %v", err))
+	}
+	if !updated {
+		// Changed or deleted between loading and saving
+		current, permission, err := s.noteAccess(ctx, userID, noteID, noteEntity.PermissionView)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &NoteVersionConflictError{Current: toNoteResponse(current, permission)}
+	}
+
+	if req.Content != nil {
+		s.indexNoteContent(ctx, note.ID, note.Content)
+	}
+	if note.Title != oldTitle {
+		s.renameNoteLinks(ctx, note.ID, oldTitle, note.Title)
+	}
+
+	return toNoteResponse(note, permission), nil
+}
+
+// DeleteNote removes a note, leaving a tombstone for syncing clients. It requires at least the editor role in the note's workspace.
+// Shares don't allow deleting.
+func (s *NoteService) DeleteNote(ctx context.Context, userID, noteID uuid.UUID) error {
+	note, _, err := s.noteAccess(ctx, userID, noteID, noteEntity.PermissionManage)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.removeNote(ctx, userID, note, nil)
+	return err
+}
+
+// removeNote deletes the note for the user, who must be allowed to. With ifVersion the note is
+// only deleted while it's still at that version, false is returned otherwise.
+func (s *NoteService) removeNote(ctx context.Context, userID uuid.UUID, note *noteEntity.NoteEntity, ifVersion *int64) (bool, error) {
+	deleted, err := s.noteRepo.DeleteNote(ctx, note.ID, ifVersion)
+	if err != nil {
+		return false, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error deleting note: %v", err))
+	}
+	if !deleted {
+		return false, nil
+	}
+
+	s.setLinksDangling(ctx, note.ID, true)
+
+	s.auditRecorder.Record(ctx, &userID, audit.ActionNoteDelete, audit.Note(note.ID), map[string]any{
+		"title":        note.Title,
+		"workspace_id": note.WorkspaceID,
+		"author_id":    note.UserID,
+		"created_at":   note.CreatedAt,
+	})
+
+	return true, nil
+}
+
+// NoteAccess returns the note with the permission the user has on it, at least view
+func (s *NoteService) NoteAccess(ctx context.Context, userID, noteID uuid.UUID) (*noteEntity.NoteEntity, string, error) {
+	return s.noteAccess(ctx, userID, noteID, noteEntity.PermissionView)
+}
+
+// SaveCollabSnapshot stores the document merged by the editors of a live editing session over
+// the note while it's still at baseVersion, the version the session started from or last saved.
+// When the note changed meanwhile nothing is stored and the current note is returned, nil when
+// it was deleted. The hub checks the access of the editors with CollabPermission.
+func (s *NoteService) SaveCollabSnapshot(ctx context.Context, noteID uuid.UUID, baseVersion int64, content noteEntity.TiptapContent) (*noteEntity.NoteEntity, bool, error) {
+	note, err := s.noteRepo.GetNoteByID(ctx, noteID)
+	if err != nil {
+		return nil, false, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting note: %v", err))
+	}
+	if note == nil || note.Version != baseVersion {
+		return note, false, nil
+	}
+
+	note.Content = content
+	note.ContentText = s.extractContentToText(content.Content)
+
+	updated, err := s.noteRepo.UpdateNote(ctx, note, &baseVersion)
+	if err != nil {
+		return nil, false, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error saving note content: %v", err))
+	}
+	if !updated {
+		current, err := s.noteRepo.GetNoteByID(ctx, noteID)
+		if err != nil {
+			return nil, false, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting note: %v", err))
+		}
+		return current, false, nil
+	}
+
+	s.indexNoteContent(ctx, noteID, content)
+	return note, true, nil
+}
+
+// CollabPermission returns the permission the user has on the note now, empty without access or
+// when the note is gone
+func (s *NoteService) CollabPermission(ctx context.Context, noteID, userID uuid.UUID) (string, error) {
+	note, err := s.noteRepo.GetNoteByID(ctx, noteID)
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting note: %v", err))
+	}
+	if note == nil {
+		return "", nil
+	}
+
+	return s.notePermission(ctx, userID, note)
+}
+
+// noteCreated indexes the content of a note that was just created and resolves links that were
+// made to its id before it existed, e.g. by an offline client
+func (s *NoteService) noteCreated(ctx context.Context, note *noteEntity.NoteEntity) {
+	s.indexNoteContent(ctx, note.ID, note.Content)
+	s.setLinksDangling(ctx, note.ID, false)
+}
+
+// indexNoteContent refreshes the links and tasks extracted from the content of a saved note.
+// They are derived data, failing to store them doesn't fail the save, the next save fixes them.
+func (s *NoteService) indexNoteContent(ctx context.Context, noteID uuid.UUID, content noteEntity.TiptapContent) {
+	s.syncNoteLinks(ctx, noteID, content)
+	s.syncNoteTasks(ctx, noteID, content)
+}
+
+// noteAccess loads the note and the permission the user has on it, at least min. Notes the
+// user can't view at all are reported as not found.
+func (s *NoteService) noteAccess(ctx context.Context, userID, noteID uuid.UUID, min string) (*noteEntity.NoteEntity, string, error) {
+	note, err := s.noteRepo.GetNoteByID(ctx, noteID)
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting note: %v", err))
+	}
+	if note == nil {
+		return nil, "", fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("note with id %s cannot be found", noteID.String()))
+	}
+
+	permission, err := s.notePermission(ctx, userID, note)
+	if err != nil {
+		return nil, "", err
+	}
+	if permission == "" {
+		return nil, "", fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("note with id %s cannot be found", noteID.String()))
+	}
+	if !noteEntity.PermissionAtLeast(permission, min) {
+		return nil, "", fiber.NewError(fiber.StatusForbidden, fmt.Sprintf("this action requires the %s permission on the note", min))
+	}
+
+	return note, permission, nil
+}
+
+// notePermission returns the permission the user has on the note, empty without access. The
+// editor role in the note's workspace grants PermissionManage, the viewer role PermissionView,
+// and a share grants its own permission.
+func (s *NoteService) notePermission(ctx context.Context, userID uuid.UUID, note *noteEntity.NoteEntity) (string, error) {
+	role, err := s.workspaceService.GetMemberRole(ctx, note.WorkspaceID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	permission := combinePermission(role, "")
+	if permission != noteEntity.PermissionManage {
+		share, err := s.shareRepo.GetShareForUser(ctx, note.ID, userID)
+		if err != nil {
+			return "", fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting note share: %v", err))
+		}
+		if share != nil {
+			permission = combinePermission(role, share.Permission)
+		}
+	}
+
+	return permission, nil
+}
+
+// combinePermission returns the permission granted by a workspace role and a share permission,
+// either may be empty
+func combinePermission(role, sharePermission string) string {
+	var permission string
+	switch {
+	case workspaceEntity.RoleAtLeast(role, workspaceEntity.RoleEditor):
+		permission = noteEntity.PermissionManage
+	case role == workspaceEntity.RoleViewer:
+		permission = noteEntity.PermissionView
+	}
+
+	if sharePermission != "" && !noteEntity.PermissionAtLeast(permission, sharePermission) {
+		permission = sharePermission
+	}
+
+	return permission
 }
 
 func (s *NoteService) extractContentToText(nodes []noteEntity.TiptapContent) string {
 	var sb strings.Builder
 
 	for _, node := range nodes {
-		if node.Type == "text" {
+		switch node.Type {
+		case "text":
 			sb.WriteString(node.Text)
+		case noteEntity.NodeNoteMention:
+			label, _ := node.Attrs["label"].(string)
+			sb.WriteString(label)
 		}
 
 		if len(node.Content) > 0 {
@@ -83,3 +480,18 @@ func (s *NoteService) extractContentToText(nodes []noteEntity.TiptapContent) str
 
 	return sb.String()
 }
+
+func toNoteResponse(note *noteEntity.NoteEntity, permission string) *dto.NoteResponse {
+	return &dto.NoteResponse{
+		ID:          note.ID,
+		WorkspaceID: note.WorkspaceID,
+		AuthorID:    note.UserID,
+		Title:       note.Title,
+		Content:     note.Content,
+		Tags:        noteEntity.NormalizeTags(note.Tags),
+		Permission:  permission,
+		Version:     note.Version,
+		CreatedAt:   note.CreatedAt,
+		UpdatedAt:   note.UpdatedAt,
+	}
+}
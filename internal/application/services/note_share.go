@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	"github.com/rayhan889/neatspace/internal/audit"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
+)
+
+// ShareNote grants a single user access to the note. Emails without an account get a pending
+// share that is handed over when someone signs up with that email. Sharing again with the same
+// person updates the permission.
+func (s *NoteService) ShareNote(ctx context.Context, actorID, noteID uuid.UUID, req *dto.ShareNoteRequest) (*dto.NoteShareItem, error) {
+	note, _, err := s.noteAccess(ctx, actorID, noteID, noteEntity.PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	share := &noteEntity.NoteShareEntity{
+		ID:         uuid.New(),
+		NoteID:     noteID,
+		Permission: req.Permission,
+		SharedBy:   &actorID,
+		CreatedAt:  time.Now(),
+	}
+
+	grantee, err := s.userService.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if grantee != nil {
+		if grantee.ID == actorID {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "you can't share a note with yourself")
+		}
+
+		role, err := s.workspaceService.GetMemberRole(ctx, note.WorkspaceID, grantee.ID)
+		if err != nil {
+			return nil, err
+		}
+		if role != "" {
+			return nil, fiber.NewError(fiber.StatusConflict, "user already has access through the workspace")
+		}
+
+		share.UserID = &grantee.ID
+	} else {
+		share.Email = &email
+	}
+
+	stored, err := s.shareRepo.UpsertShare(ctx, share)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error sharing note: %v", err))
+	}
+
+	// The share is in place even if the email can't be sent, the grantee finds it under shared notes
+	_ = s.sendShareEmail(ctx, actorID, note, grantee, email, req.Permission)
+
+	s.auditRecorder.Record(ctx, &actorID, audit.ActionNoteShare, audit.Note(noteID), map[string]any{
+		"share_id":   stored.ID,
+		"email":      email,
+		"permission": req.Permission,
+		"pending":    stored.IsPending(),
+	})
+
+	detail := noteEntity.NoteShareDetail{NoteShareEntity: *stored}
+	if grantee != nil {
+		detail.DisplayName = &grantee.DisplayName
+		detail.Username = grantee.Username
+		detail.UserEmail = &grantee.Email
+	}
+
+	item := toNoteShareItem(&detail)
+	return &item, nil
+}
+
+// ListNoteShares returns the collaborators of the note, visible to anyone who can view it
+func (s *NoteService) ListNoteShares(ctx context.Context, userID, noteID uuid.UUID) ([]dto.NoteShareItem, error) {
+	if _, _, err := s.noteAccess(ctx, userID, noteID, noteEntity.PermissionView); err != nil {
+		return nil, err
+	}
+
+	shares, err := s.shareRepo.ListShares(ctx, noteID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting note shares: %v", err))
+	}
+
+	items := make([]dto.NoteShareItem, 0, len(shares))
+	for i := range shares {
+		items = append(items, toNoteShareItem(&shares[i]))
+	}
+
+	return items, nil
+}
+
+func (s *NoteService) UpdateNoteShare(ctx context.Context, actorID, noteID, shareID uuid.UUID, permission string) error {
+	if _, _, err := s.noteAccess(ctx, actorID, noteID, noteEntity.PermissionManage); err != nil {
+		return err
+	}
+
+	share, err := s.getShare(ctx, noteID, shareID)
+	if err != nil {
+		return err
+	}
+	if share.Permission == permission {
+		return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("share already has the %s permission", permission))
+	}
+
+	if err := s.shareRepo.UpdateSharePermission(ctx, shareID, permission); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error updating note share: %v", err))
+	}
+
+	s.auditRecorder.Record(ctx, &actorID, audit.ActionNoteShareUpdate, audit.Note(noteID), map[string]any{
+		"share_id":            shareID,
+		"previous_permission": share.Permission,
+		"permission":          permission,
+	})
+
+	return nil
+}
+
+// RemoveNoteShare revokes a share. Grantees can remove their own share to leave the note.
+func (s *NoteService) RemoveNoteShare(ctx context.Context, actorID, noteID, shareID uuid.UUID) error {
+	if _, _, err := s.noteAccess(ctx, actorID, noteID, noteEntity.PermissionView); err != nil {
+		return err
+	}
+
+	share, err := s.getShare(ctx, noteID, shareID)
+	if err != nil {
+		return err
+	}
+
+	if share.UserID == nil || *share.UserID != actorID {
+		if _, _, err := s.noteAccess(ctx, actorID, noteID, noteEntity.PermissionManage); err != nil {
+			return err
+		}
+	}
+
+	if err := s.shareRepo.DeleteShare(ctx, shareID); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error removing note share: %v", err))
+	}
+
+	s.auditRecorder.Record(ctx, &actorID, audit.ActionNoteUnshare, audit.Note(noteID), map[string]any{
+		"share_id":   shareID,
+		"user_id":    share.UserID,
+		"email":      share.Email,
+		"permission": share.Permission,
+	})
+
+	return nil
+}
+
+// ResolvePendingShares hands shares sent to the email of a new account over to it.
+// It is registered as a UserService hook, failures are only logged.
+func (s *NoteService) ResolvePendingShares(ctx context.Context, user *userEntity.UserEntity) {
+	resolved, err := s.shareRepo.ResolvePendingShares(ctx, user.ID, user.Email)
+	if err != nil {
+		s.logger.Error("failed to resolve pending note shares", slog.String("op", "ResolvePendingShares"), slog.String("user_id", user.ID.String()), slog.String("error", err.Error()))
+		return
+	}
+
+	if resolved > 0 {
+		s.logger.Info("pending note shares resolved", slog.String("op", "ResolvePendingShares"), slog.String("user_id", user.ID.String()), slog.Int("count", resolved))
+	}
+}
+
+func (s *NoteService) getShare(ctx context.Context, noteID, shareID uuid.UUID) (*noteEntity.NoteShareEntity, error) {
+	share, err := s.shareRepo.GetShare(ctx, noteID, shareID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting note share: %v", err))
+	}
+	if share == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("share with id %s cannot be found", shareID.String()))
+	}
+
+	return share, nil
+}
+
+func (s *NoteService) sendShareEmail(ctx context.Context, actorID uuid.UUID, note *noteEntity.NoteEntity, grantee *userEntity.UserEntity, email, permission string) error {
+	var sharerName, displayName string
+	if sharer, err := s.userService.GetUserByID(ctx, actorID); err == nil && sharer != nil {
+		sharerName = sharer.DisplayName
+	}
+	if grantee != nil {
+		displayName = grantee.DisplayName
+	}
+
+	data := map[string]any{
+		"Email":       email,
+		"DisplayName": displayName,
+		"SharerName":  sharerName,
+		"NoteTitle":   note.Title,
+		"Permission":  permission,
+		"Pending":     grantee == nil,
+		"NoteURL":     buildAppLink(s.baseURL, fmt.Sprintf("/notes/%s", note.ID), nil),
+		"AppName":     "Neatspace",
+	}
+
+	subject := fmt.Sprintf("\"%s\" was shared with you", note.Title)
+	return sendTemplatedMail(ctx, s.mailer, s.logger, "sendShareEmail", email, subject, "note_shared.html", data)
+}
+
+func toNoteShareItem(share *noteEntity.NoteShareDetail) dto.NoteShareItem {
+	item := dto.NoteShareItem{
+		ID:          share.ID,
+		UserID:      share.UserID,
+		DisplayName: share.DisplayName,
+		Username:    share.Username,
+		Permission:  share.Permission,
+		Pending:     share.IsPending(),
+		SharedBy:    share.SharedBy,
+		CreatedAt:   share.CreatedAt,
+	}
+
+	switch {
+	case share.UserEmail != nil:
+		item.Email = *share.UserEmail
+	case share.Email != nil:
+		item.Email = *share.Email
+	}
+
+	return item
+}
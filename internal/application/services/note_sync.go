@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+)
+
+// Bounds of the number of changes returned per sync page
+const (
+	DefaultSyncLimit = 100
+	MaxSyncLimit     = 500
+)
+
+// SyncNoteChanges returns the notes the user can access that were created, updated or deleted
+// since the token, oldest change first. Without a token the initial sync starts, which lists
+// every note but leaves out those deleted before it started. Keep requesting with the returned
+// token while has_more is set, the last token is kept for the next sync. Once the user joined or
+// left a workspace or a share changed, the token expires and a full sync starts over.
+func (s *NoteService) SyncNoteChanges(ctx context.Context, userID uuid.UUID, since string, limit int) (*dto.SyncNotesResponse, error) {
+	if limit < 1 || limit > MaxSyncLimit {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", MaxSyncLimit))
+	}
+
+	now := time.Now()
+	token := noteEntity.SyncToken{IssuedAt: now.Unix(), Initial: true}
+	if since != "" {
+		var err error
+		if token, err = noteEntity.ParseSyncToken(since); err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		// Deletions may have been purged since, the client can't tell which notes are gone
+		if token.IssuedBefore(now.Add(-s.tombstoneRetention)) {
+			return nil, fiber.NewError(fiber.StatusGone, "sync token has expired, start a full sync without since")
+		}
+	}
+
+	// Changes are only listed up to the oldest running transaction, a transaction that commits
+	// later can't have written before the position the client reaches
+	watermark, err := s.noteRepo.SyncWatermark(ctx)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting sync position: %v", err))
+	}
+
+	if since == "" {
+		token.Base = watermark
+	} else {
+		// Notes the user gained or lost access to aren't in the feed, only a full sync finds them
+		changed, err := s.noteRepo.AccessChangedSince(ctx, userID, token.Base)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error checking note access: %v", err))
+		}
+		if changed {
+			return nil, fiber.NewError(fiber.StatusGone, "note access has changed, start a full sync without since")
+		}
+	}
+
+	changes, err := s.noteRepo.ListNoteChanges(ctx, userID, token, watermark, limit+1)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error listing note changes: %v", err))
+	}
+
+	hasMore := len(changes) > limit
+	if hasMore {
+		changes = changes[:limit]
+	}
+
+	resp := &dto.SyncNotesResponse{Changes: make([]dto.SyncNoteChange, 0, len(changes)), HasMore: hasMore}
+	for i := range changes {
+		note := &changes[i].Note
+		change := dto.SyncNoteChange{ID: note.ID, Deleted: note.DeletedAt != nil, DeletedAt: note.DeletedAt}
+		if !change.Deleted {
+			change.Note = toNoteResponse(note, combinePermission(changes[i].Role, changes[i].SharePermission))
+		}
+		resp.Changes = append(resp.Changes, change)
+	}
+
+	next := noteEntity.SyncToken{XID: watermark, IssuedAt: now.Unix(), Base: token.Base}
+	if hasMore {
+		last := changes[len(changes)-1].Note
+		next = noteEntity.SyncToken{XID: last.ChangeXID, ID: last.ID, IssuedAt: token.IssuedAt, Initial: token.Initial, Base: token.Base}
+	}
+	resp.NextToken = next.Encode()
+
+	return resp, nil
+}
+
+// ApplySyncMutations applies changes a client made offline, in order. Each mutation succeeds or
+// fails on its own: a note changed on the server since the client's base version is reported as
+// a conflict with its current state, nothing is overwritten.
+func (s *NoteService) ApplySyncMutations(ctx context.Context, userID uuid.UUID, req *dto.SyncNotesRequest) (*dto.SyncNotesResult, error) {
+	result := &dto.SyncNotesResult{Results: make([]dto.SyncNoteMutationResult, 0, len(req.Mutations))}
+
+	for i := range req.Mutations {
+		mutation := &req.Mutations[i]
+
+		var item dto.SyncNoteMutationResult
+		var err error
+		switch mutation.Op {
+		case "upsert":
+			item, err = s.applySyncUpsert(ctx, userID, mutation)
+		case "delete":
+			item, err = s.applySyncDelete(ctx, userID, mutation)
+		default:
+			err = fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown op %q", mutation.Op))
+		}
+		if err != nil {
+			item = s.rejectSyncMutation(mutation.ID, err)
+		}
+
+		result.Results = append(result.Results, item)
+	}
+
+	return result, nil
+}
+
+func (s *NoteService) applySyncUpsert(ctx context.Context, userID uuid.UUID, mutation *dto.SyncNoteMutation) (dto.SyncNoteMutationResult, error) {
+	existing, err := s.noteRepo.GetNoteIncludingDeleted(ctx, mutation.ID)
+	if err != nil {
+		return dto.SyncNoteMutationResult{}, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting note: %v", err))
+	}
+
+	if existing == nil {
+		if mutation.Title == nil || mutation.Content == nil {
+			return dto.SyncNoteMutationResult{}, fiber.NewError(fiber.StatusBadRequest, "title and content are required to create a note")
+		}
+
+		note := &noteEntity.NoteEntity{
+			ID:        mutation.ID,
+			UserID:    userID,
+			Title:     *mutation.Title,
+			Content:   *mutation.Content,
+			Version:   1,
+			CreatedAt: time.Now(),
+		}
+		if mutation.WorkspaceID != nil {
+			note.WorkspaceID = *mutation.WorkspaceID
+		}
+		if err := s.CreateNote(ctx, note); err != nil {
+			return dto.SyncNoteMutationResult{}, err
+		}
+
+		// Creating requires the editor role in the workspace, which grants manage
+		return dto.SyncNoteMutationResult{ID: note.ID, Status: dto.SyncStatusApplied, Note: toNoteResponse(note, noteEntity.PermissionManage)}, nil
+	}
+
+	if existing.DeletedAt != nil {
+		return s.deletedSyncConflict(ctx, userID, existing)
+	}
+	if mutation.WorkspaceID != nil && *mutation.WorkspaceID != existing.WorkspaceID {
+		return dto.SyncNoteMutationResult{}, fiber.NewError(fiber.StatusBadRequest, "notes can't be moved to another workspace")
+	}
+	if mutation.BaseVersion == nil {
+		return s.syncConflict(ctx, userID, mutation.ID)
+	}
+
+	note, err := s.UpdateNote(ctx, userID, mutation.ID, mutation.BaseVersion, &dto.UpdateNoteRequest{Title: mutation.Title, Content: mutation.Content})
+	if err != nil {
+		var conflict *NoteVersionConflictError
+		if errors.As(err, &conflict) {
+			return dto.SyncNoteMutationResult{ID: mutation.ID, Status: dto.SyncStatusConflict, Current: conflict.Current}, nil
+		}
+		return dto.SyncNoteMutationResult{}, err
+	}
+
+	return dto.SyncNoteMutationResult{ID: mutation.ID, Status: dto.SyncStatusApplied, Note: note}, nil
+}
+
+func (s *NoteService) applySyncDelete(ctx context.Context, userID uuid.UUID, mutation *dto.SyncNoteMutation) (dto.SyncNoteMutationResult, error) {
+	applied := dto.SyncNoteMutationResult{ID: mutation.ID, Status: dto.SyncStatusApplied}
+
+	note, err := s.noteRepo.GetNoteByID(ctx, mutation.ID)
+	if err != nil {
+		return dto.SyncNoteMutationResult{}, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting note: %v", err))
+	}
+	// Already gone, the client ends up in the state it wanted
+	if note == nil {
+		return applied, nil
+	}
+
+	note, _, err = s.noteAccess(ctx, userID, mutation.ID, noteEntity.PermissionManage)
+	if err != nil {
+		return dto.SyncNoteMutationResult{}, err
+	}
+	if mutation.BaseVersion == nil || *mutation.BaseVersion != note.Version {
+		return s.syncConflict(ctx, userID, mutation.ID)
+	}
+
+	deleted, err := s.removeNote(ctx, userID, note, mutation.BaseVersion)
+	if err != nil {
+		return dto.SyncNoteMutationResult{}, err
+	}
+	if !deleted {
+		// Changed or deleted between loading and deleting
+		return s.syncConflict(ctx, userID, mutation.ID)
+	}
+
+	return applied, nil
+}
+
+// syncConflict reports the current state of the note, or its deletion, as a conflict
+func (s *NoteService) syncConflict(ctx context.Context, userID, noteID uuid.UUID) (dto.SyncNoteMutationResult, error) {
+	current, permission, err := s.noteAccess(ctx, userID, noteID, noteEntity.PermissionView)
+	if err == nil {
+		return dto.SyncNoteMutationResult{ID: noteID, Status: dto.SyncStatusConflict, Current: toNoteResponse(current, permission)}, nil
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusNotFound {
+		note, err := s.noteRepo.GetNoteIncludingDeleted(ctx, noteID)
+		if err != nil {
+			return dto.SyncNoteMutationResult{}, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting note: %v", err))
+		}
+		if note != nil && note.DeletedAt != nil {
+			return s.deletedSyncConflict(ctx, userID, note)
+		}
+	}
+
+	return dto.SyncNoteMutationResult{}, err
+}
+
+// deletedSyncConflict reports that the note was deleted, when the user could see it
+func (s *NoteService) deletedSyncConflict(ctx context.Context, userID uuid.UUID, note *noteEntity.NoteEntity) (dto.SyncNoteMutationResult, error) {
+	permission, err := s.notePermission(ctx, userID, note)
+	if err != nil {
+		return dto.SyncNoteMutationResult{}, err
+	}
+	if permission == "" {
+		return dto.SyncNoteMutationResult{}, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("note with id %s cannot be found", note.ID.String()))
+	}
+
+	return dto.SyncNoteMutationResult{ID: note.ID, Status: dto.SyncStatusConflict, Error: "note has been deleted"}, nil
+}
+
+func (s *NoteService) rejectSyncMutation(noteID uuid.UUID, err error) dto.SyncNoteMutationResult {
+	result := dto.SyncNoteMutationResult{ID: noteID, Status: dto.SyncStatusRejected, Error: err.Error()}
+
+	var fiberErr *fiber.Error
+	if !errors.As(err, &fiberErr) || fiberErr.Code >= fiber.StatusInternalServerError {
+		s.logger.Error("failed to apply sync mutation", slog.String("op", "ApplySyncMutations"), slog.String("note_id", noteID.String()), slog.String("error", err.Error()))
+		result.Error = "the change could not be applied, retry later"
+	}
+
+	return result
+}
+
+// PurgeDeletedNotes removes tombstones of notes deleted longer ago than the retention period
+func (s *NoteService) PurgeDeletedNotes(ctx context.Context) (int, error) {
+	return s.noteRepo.PurgeDeletedNotes(ctx, time.Now().Add(-s.tombstoneRetention))
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+// ListTasks returns the tasks of all notes the user can view, open ones first
+func (s *NoteService) ListTasks(ctx context.Context, userID uuid.UUID, filter *noteEntity.NoteTaskFilter, p *apputils.Pagination) ([]dto.NoteTaskItem, int, error) {
+	switch filter.Status {
+	case "", noteEntity.TaskStatusOpen, noteEntity.TaskStatusDone:
+	default:
+		return nil, 0, fiber.NewError(fiber.StatusBadRequest, "status must be open or done")
+	}
+
+	tasks, total, err := s.taskRepo.ListTasks(ctx, userID, filter, p)
+	if err != nil {
+		return nil, 0, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting tasks: %v", err))
+	}
+
+	items := make([]dto.NoteTaskItem, 0, len(tasks))
+	for _, task := range tasks {
+		permission := combinePermission(stringValue(task.Role), stringValue(task.SharePermission))
+		item := toNoteTaskItem(task.NoteTaskEntity, task.WorkspaceID, task.NoteTitle, task.NoteVersion)
+		item.CanToggle = noteEntity.PermissionAtLeast(permission, noteEntity.PermissionEdit)
+		items = append(items, item)
+	}
+
+	return items, total, nil
+}
+
+// SetTaskChecked checks or unchecks the task at the position in the note, saving the note like
+// any other edit. With ifVersion the change only applies while the note is still at that version,
+// a NoteVersionConflictError is returned otherwise.
+func (s *NoteService) SetTaskChecked(ctx context.Context, userID, noteID uuid.UUID, position int, ifVersion *int64, checked bool) (*dto.NoteTaskItem, error) {
+	note, permission, err := s.noteAccess(ctx, userID, noteID, noteEntity.PermissionEdit)
+	if err != nil {
+		return nil, err
+	}
+	if ifVersion != nil && note.Version != *ifVersion {
+		return nil, &NoteVersionConflictError{Current: toNoteResponse(note, permission)}
+	}
+
+	if !noteEntity.SetTaskChecked(&note.Content, position, checked) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "task not found")
+	}
+
+	// The loaded version guards the save even without ifVersion, the position must still
+	// point at the same task
+	version := note.Version
+	updated, err := s.noteRepo.UpdateNote(ctx, note, &version)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error updating note: %v", err))
+	}
+	if !updated {
+		current, permission, err := s.noteAccess(ctx, userID, noteID, noteEntity.PermissionView)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &NoteVersionConflictError{Current: toNoteResponse(current, permission)}
+	}
+
+	s.indexNoteContent(ctx, note.ID, note.Content)
+
+	task := noteEntity.ExtractTasks(note.Content)[position]
+	task.NoteID = note.ID
+	item := toNoteTaskItem(task, note.WorkspaceID, note.Title, note.Version)
+	item.CanToggle = true
+	return &item, nil
+}
+
+// syncNoteTasks stores the tasks found in the content of the note
+func (s *NoteService) syncNoteTasks(ctx context.Context, noteID uuid.UUID, content noteEntity.TiptapContent) {
+	if err := s.taskRepo.ReplaceNoteTasks(ctx, noteID, noteEntity.ExtractTasks(content)); err != nil {
+		s.logger.Error("failed to store note tasks", slog.String("op", "syncNoteTasks"), slog.String("note_id", noteID.String()), slog.String("error", err.Error()))
+	}
+}
+
+func toNoteTaskItem(task noteEntity.NoteTaskEntity, workspaceID uuid.UUID, noteTitle string, noteVersion int64) dto.NoteTaskItem {
+	item := dto.NoteTaskItem{
+		NoteID:      task.NoteID,
+		Position:    task.Position,
+		Text:        task.Text,
+		Checked:     task.Checked,
+		WorkspaceID: workspaceID,
+		NoteTitle:   noteTitle,
+		NoteVersion: noteVersion,
+	}
+	if task.DueDate != nil {
+		dueDate := task.DueDate.Format(time.DateOnly)
+		item.DueDate = &dueDate
+	}
+	return item
+}
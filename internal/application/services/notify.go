@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+
+	"github.com/rayhan889/neatspace/internal/notification"
+)
+
+// buildAppLink returns an absolute URL for the given path on baseURL
+func buildAppLink(baseURL, path string, query url.Values) string {
+	// Determine base URL:
+	// 1) prefer configured baseURL
+	// 2) fallback to environment SERVER_HOST/SERVER_PORT
+	// 3) final fallback to localhost:8000
+	base := baseURL
+	if base == "" {
+		host := os.Getenv("SERVER_HOST")
+		port := os.Getenv("SERVER_PORT")
+		if host == "" {
+			host = "localhost"
+		}
+		if port == "0" {
+			port = "8000"
+		}
+		base = fmt.Sprintf("http://%s:%s", host, port)
+	}
+
+	u, err := url.Parse(base)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		// fallback to SERVER_HOST/SERVER_PORT env vars explicitly
+		host := os.Getenv("SERVER_HOST")
+		port := os.Getenv("SERVER_PORT")
+		if host == "" {
+			host = "localhost"
+		}
+		if port == "" {
+			port = "8000"
+		}
+		u = &url.URL{Scheme: "http", Host: fmt.Sprintf("%s:%s", host, port)}
+	}
+
+	u.Path = path
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// sendTemplatedMail renders and sends a templated email, logging under the given op.
+// A nil mailer only logs a warning so local setups without SMTP keep working.
+func sendTemplatedMail(ctx context.Context, mailer *notification.Mailer, logger *slog.Logger, op, toEmail, subject, templateFile string, data map[string]any) error {
+	if mailer != nil {
+		if err := mailer.SendMail(ctx, []string{toEmail}, subject, templateFile, data); err != nil {
+			logger.Error("failed to send email", slog.String("op", op), slog.String("template", templateFile), slog.String("error", err.Error()))
+			return err
+		}
+		return nil
+	}
+
+	logger.Warn("mailer not configured, cannot send email", slog.String("op", op), slog.String("template", templateFile))
+	return nil
+}
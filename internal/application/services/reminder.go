@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	reminderEntity "github.com/rayhan889/neatspace/internal/domain/reminder/entities"
+	reminderRepo "github.com/rayhan889/neatspace/internal/domain/reminder/repositories"
+	"github.com/rayhan889/neatspace/internal/notification"
+)
+
+const (
+	// reminderBatchSize is how many due reminders an instance claims at once
+	reminderBatchSize = 50
+	// reminderClaimLease is how long a claimed reminder waits before another instance may
+	// take it over, covers a crash or a failed email between claiming and finishing
+	reminderClaimLease = 5 * time.Minute
+)
+
+// errReminderNoteGone is reported for a reminder about a note its user can't view anymore
+var errReminderNoteGone = errors.New("note of reminder is gone")
+
+// errReminderUserInactive is reported for a reminder of a user deactivated after it was claimed
+var errReminderUserInactive = errors.New("user of reminder is deactivated")
+
+type ReminderServiceInterface interface {
+	CreateReminder(ctx context.Context, userID uuid.UUID, req *dto.CreateReminderRequest) (*dto.ReminderItem, error)
+	ListReminders(ctx context.Context, userID uuid.UUID, noteID *uuid.UUID) ([]dto.ReminderItem, error)
+	GetReminder(ctx context.Context, userID, reminderID uuid.UUID) (*dto.ReminderItem, error)
+	UpdateReminder(ctx context.Context, userID, reminderID uuid.UUID, req *dto.UpdateReminderRequest) (*dto.ReminderItem, error)
+	DeleteReminder(ctx context.Context, userID, reminderID uuid.UUID) error
+	ProcessDueReminders(ctx context.Context) (int, error)
+}
+
+var _ ReminderServiceInterface = (*ReminderService)(nil)
+
+type ReminderService struct {
+	reminderRepo reminderRepo.ReminderRepositoryInterface
+	noteService  NoteServiceInterface
+	userService  UserServiceInterface
+	logger       *slog.Logger
+	mailer       *notification.Mailer
+	baseURL      string
+}
+
+type ReminderServiceOpts struct {
+	ReminderRepo reminderRepo.ReminderRepositoryInterface
+	NoteService  NoteServiceInterface
+	UserService  UserServiceInterface
+	Logger       *slog.Logger
+	Mailer       *notification.Mailer
+	BaseURL      string
+}
+
+func NewReminderService(opts ReminderServiceOpts) *ReminderService {
+	return &ReminderService{
+		reminderRepo: opts.ReminderRepo,
+		noteService:  opts.NoteService,
+		userService:  opts.UserService,
+		logger:       opts.Logger,
+		mailer:       opts.Mailer,
+		baseURL:      opts.BaseURL,
+	}
+}
+
+// CreateReminder schedules a reminder about a note the user can view. Its occurrences are
+// computed in the user's time zone as set at that moment.
+func (s *ReminderService) CreateReminder(ctx context.Context, userID uuid.UUID, req *dto.CreateReminderRequest) (*dto.ReminderItem, error) {
+	if _, err := s.noteService.GetNote(ctx, userID, req.NoteID); err != nil {
+		return nil, err
+	}
+
+	loc, err := s.userTimezone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	reminder := &reminderEntity.ReminderEntity{
+		ID:        uuid.New(),
+		UserID:    userID,
+		NoteID:    req.NoteID,
+		Timezone:  loc.String(),
+		CreatedAt: now,
+	}
+	if err := setReminderSchedule(reminder, &req.FireAt, req.Recurrence, loc, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.reminderRepo.CreateReminder(ctx, reminder); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error creating reminder: %v", err))
+	}
+
+	return toReminderItem(reminder), nil
+}
+
+// ListReminders returns the user's reminders, optionally of one note only
+func (s *ReminderService) ListReminders(ctx context.Context, userID uuid.UUID, noteID *uuid.UUID) ([]dto.ReminderItem, error) {
+	reminders, err := s.reminderRepo.ListRemindersByUserID(ctx, userID, noteID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting reminders: %v", err))
+	}
+
+	items := make([]dto.ReminderItem, 0, len(reminders))
+	for i := range reminders {
+		items = append(items, *toReminderItem(&reminders[i]))
+	}
+
+	return items, nil
+}
+
+func (s *ReminderService) GetReminder(ctx context.Context, userID, reminderID uuid.UUID) (*dto.ReminderItem, error) {
+	reminder, err := s.ownReminder(ctx, userID, reminderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toReminderItem(reminder), nil
+}
+
+// UpdateReminder changes the schedule of a reminder, picking up the user's current time zone.
+// The next occurrence is computed again from the fire time, a finished reminder whose
+// recurrence is extended becomes active again.
+func (s *ReminderService) UpdateReminder(ctx context.Context, userID, reminderID uuid.UUID, req *dto.UpdateReminderRequest) (*dto.ReminderItem, error) {
+	if req.FireAt == nil && req.Recurrence == nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "nothing to update")
+	}
+
+	reminder, err := s.ownReminder(ctx, userID, reminderID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := s.userTimezone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	reminder.Timezone = loc.String()
+
+	recurrence := req.Recurrence
+	if recurrence == nil {
+		recurrence = reminder.Recurrence
+	}
+	if err := setReminderSchedule(reminder, req.FireAt, recurrence, loc, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.reminderRepo.UpdateReminder(ctx, reminder); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error updating reminder: %v", err))
+	}
+
+	return toReminderItem(reminder), nil
+}
+
+func (s *ReminderService) DeleteReminder(ctx context.Context, userID, reminderID uuid.UUID) error {
+	if _, err := s.ownReminder(ctx, userID, reminderID); err != nil {
+		return err
+	}
+
+	if err := s.reminderRepo.DeleteReminder(ctx, reminderID); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error deleting reminder: %v", err))
+	}
+
+	return nil
+}
+
+// ProcessDueReminders emails every reminder that is due and schedules its next occurrence.
+// Safe to run concurrently on several instances, each reminder is claimed by one of them.
+// A reminder whose email failed stays claimed and is retried once its claim lease ran out.
+func (s *ReminderService) ProcessDueReminders(ctx context.Context) (int, error) {
+	claimID := uuid.New()
+	sent := 0
+
+	for ctx.Err() == nil {
+		now := time.Now()
+		reminders, err := s.reminderRepo.ClaimDueReminders(ctx, claimID, now, now.Add(-reminderClaimLease), reminderBatchSize)
+		if err != nil {
+			return sent, err
+		}
+		if len(reminders) == 0 {
+			return sent, nil
+		}
+
+		for i := range reminders {
+			reminder := &reminders[i]
+
+			var sentAt *time.Time
+			next := s.nextOccurrence(reminder)
+			switch err := s.sendReminder(ctx, reminder); {
+			case errors.Is(err, errReminderNoteGone):
+				// Deleted or no longer visible to the user, stop reminding about it
+				next = nil
+			case errors.Is(err, errReminderUserInactive):
+				// Keep the schedule for a restored account, the claim runs out with the lease
+				continue
+			case err != nil:
+				s.logger.Error("failed to send reminder", slog.String("op", "ProcessDueReminders"), slog.String("reminder_id", reminder.ID.String()), slog.String("error", err.Error()))
+				continue
+			default:
+				sentAt = &now
+				sent++
+			}
+
+			if err := s.reminderRepo.FinishReminderRun(ctx, reminder.ID, claimID, sentAt, next); err != nil {
+				return sent, err
+			}
+		}
+	}
+
+	return sent, ctx.Err()
+}
+
+// sendReminder emails the reminder to its user. Deactivated users keep their reminders in
+// case the account is restored, nothing is sent to them.
+func (s *ReminderService) sendReminder(ctx context.Context, reminder *reminderEntity.ReminderEntity) error {
+	user, err := s.userService.GetUserByID(ctx, reminder.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.IsDeactivated() {
+		return errReminderUserInactive
+	}
+
+	note, err := s.noteService.GetNote(ctx, reminder.UserID, reminder.NoteID)
+	if err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) && (fiberErr.Code == fiber.StatusNotFound || fiberErr.Code == fiber.StatusForbidden) {
+			return errReminderNoteGone
+		}
+		return err
+	}
+
+	due := reminder.FireAt
+	if reminder.NextFireAt != nil {
+		due = *reminder.NextFireAt
+	}
+
+	data := map[string]any{
+		"Email":       user.Email,
+		"DisplayName": user.DisplayName,
+		"NoteTitle":   note.Title,
+		"NoteURL":     buildAppLink(s.baseURL, fmt.Sprintf("/notes/%s", note.ID), nil),
+		"DueAt":       due.In(reminder.Location()).Format("Monday, 2 January 2006 at 15:04 MST"),
+		"Recurring":   reminder.Recurrence != nil,
+		"AppName":     "Neatspace",
+	}
+
+	subject := fmt.Sprintf("Reminder: %s", note.Title)
+	return sendTemplatedMail(ctx, s.mailer, s.logger, "sendReminder", user.Email, subject, "note_reminder.html", data)
+}
+
+// nextOccurrence returns the occurrence following now, nil for one-off reminders and rules
+// that ended
+func (s *ReminderService) nextOccurrence(reminder *reminderEntity.ReminderEntity) *time.Time {
+	if reminder.Recurrence == nil {
+		return nil
+	}
+
+	loc := reminder.Location()
+	recurrence, err := reminderEntity.ParseRecurrence(*reminder.Recurrence, loc)
+	if err != nil {
+		s.logger.Error("invalid stored recurrence", slog.String("op", "nextOccurrence"), slog.String("reminder_id", reminder.ID.String()), slog.String("error", err.Error()))
+		return nil
+	}
+
+	next, ok := recurrence.Next(reminder.FireAt, time.Now(), loc)
+	if !ok {
+		return nil
+	}
+	return &next
+}
+
+func (s *ReminderService) ownReminder(ctx context.Context, userID, reminderID uuid.UUID) (*reminderEntity.ReminderEntity, error) {
+	reminder, err := s.reminderRepo.GetReminderByID(ctx, reminderID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting reminder: %v", err))
+	}
+	if reminder == nil || reminder.UserID != userID {
+		return nil, fiber.NewError(fiber.StatusNotFound, "reminder not found")
+	}
+
+	return reminder, nil
+}
+
+func (s *ReminderService) userTimezone(ctx context.Context, userID uuid.UUID) (*time.Location, error) {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s cannot be found", userID.String()))
+	}
+
+	return userLocation(user.Metadata), nil
+}
+
+// setReminderSchedule applies a fire time and recurrence rule to the reminder, a nil fire time
+// keeps the current one. A new fire time must lie ahead, the next occurrence is the first one
+// from now on.
+func setReminderSchedule(reminder *reminderEntity.ReminderEntity, rawFireAt, rule *string, loc *time.Location, now time.Time) error {
+	if rawFireAt != nil {
+		fireAt, err := reminderEntity.ParseFireAt(*rawFireAt, loc)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if !fireAt.After(now) {
+			return fiber.NewError(fiber.StatusBadRequest, "fire time must be in the future")
+		}
+		reminder.FireAt = fireAt
+	}
+
+	reminder.Recurrence = nil
+	var recurrence *reminderEntity.Recurrence
+	if rule != nil && strings.TrimSpace(*rule) != "" {
+		normalized := strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(*rule), "RRULE:"))
+		parsed, err := reminderEntity.ParseRecurrence(normalized, loc)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		recurrence = parsed
+		reminder.Recurrence = &normalized
+	}
+
+	reminder.NextFireAt = nil
+	switch {
+	case reminder.FireAt.After(now):
+		fireAt := reminder.FireAt
+		reminder.NextFireAt = &fireAt
+	case recurrence != nil:
+		if next, ok := recurrence.Next(reminder.FireAt, now, loc); ok {
+			reminder.NextFireAt = &next
+		}
+	}
+
+	return nil
+}
+
+func toReminderItem(reminder *reminderEntity.ReminderEntity) *dto.ReminderItem {
+	return &dto.ReminderItem{
+		ID:         reminder.ID,
+		NoteID:     reminder.NoteID,
+		FireAt:     reminder.FireAt,
+		Timezone:   reminder.Timezone,
+		Recurrence: reminder.Recurrence,
+		NextFireAt: reminder.NextFireAt,
+		LastSentAt: reminder.LastSentAt,
+		CreatedAt:  reminder.CreatedAt,
+		UpdatedAt:  reminder.UpdatedAt,
+	}
+}
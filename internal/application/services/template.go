@@ -0,0 +1,402 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	templateEntity "github.com/rayhan889/neatspace/internal/domain/template/entities"
+	templateRepo "github.com/rayhan889/neatspace/internal/domain/template/repositories"
+	userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
+	workspaceEntity "github.com/rayhan889/neatspace/internal/domain/workspace/entities"
+)
+
+// maxNoteTitleLength mirrors the limit on the title of notes created through the API
+const maxNoteTitleLength = 100
+
+type TemplateServiceInterface interface {
+	CreateTemplate(ctx context.Context, userID uuid.UUID, req *dto.CreateTemplateRequest) (*dto.TemplateItem, error)
+	ListTemplates(ctx context.Context, userID uuid.UUID, workspaceID *uuid.UUID) ([]dto.TemplateItem, error)
+	GetTemplate(ctx context.Context, userID, templateID uuid.UUID) (*dto.TemplateItem, error)
+	UpdateTemplate(ctx context.Context, userID, templateID uuid.UUID, req *dto.UpdateTemplateRequest) (*dto.TemplateItem, error)
+	DeleteTemplate(ctx context.Context, userID, templateID uuid.UUID) error
+	CreateNoteFromTemplate(ctx context.Context, userID, templateID uuid.UUID, req *dto.CreateNoteFromTemplateRequest) (*dto.NoteResponse, error)
+}
+
+var _ TemplateServiceInterface = (*TemplateService)(nil)
+
+type TemplateService struct {
+	templateRepo     templateRepo.TemplateRepositoryInterface
+	noteService      NoteServiceInterface
+	userService      UserServiceInterface
+	workspaceService WorkspaceServiceInterface
+	logger           *slog.Logger
+}
+
+type TemplateServiceOpts struct {
+	TemplateRepo     templateRepo.TemplateRepositoryInterface
+	NoteService      NoteServiceInterface
+	UserService      UserServiceInterface
+	WorkspaceService WorkspaceServiceInterface
+	Logger           *slog.Logger
+}
+
+func NewTemplateService(opts TemplateServiceOpts) *TemplateService {
+	return &TemplateService{
+		templateRepo:     opts.TemplateRepo,
+		noteService:      opts.NoteService,
+		userService:      opts.UserService,
+		workspaceService: opts.WorkspaceService,
+		logger:           opts.Logger,
+	}
+}
+
+// CreateTemplate stores a personal template, or a template shared in a workspace where the
+// user has at least the editor role
+func (s *TemplateService) CreateTemplate(ctx context.Context, userID uuid.UUID, req *dto.CreateTemplateRequest) (*dto.TemplateItem, error) {
+	if err := validatePrompts(req.Prompts); err != nil {
+		return nil, err
+	}
+
+	template := &templateEntity.NoteTemplateEntity{
+		ID:          uuid.New(),
+		WorkspaceID: req.WorkspaceID,
+		CreatedBy:   &userID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: emptyToNil(req.Description),
+		Title:       req.Title,
+		Content:     req.Content,
+		Prompts:     req.Prompts,
+		CreatedAt:   time.Now(),
+	}
+	if template.Name == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "template name can't be blank")
+	}
+	if template.Prompts == nil {
+		template.Prompts = []templateEntity.TemplatePrompt{}
+	}
+
+	if req.WorkspaceID != nil {
+		if _, err := s.workspaceService.RequireRole(ctx, *req.WorkspaceID, userID, workspaceEntity.RoleEditor); err != nil {
+			return nil, err
+		}
+	} else {
+		template.UserID = &userID
+	}
+
+	if err := s.templateRepo.CreateTemplate(ctx, template); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error creating template: %v", err))
+	}
+
+	return toTemplateItem(template, true), nil
+}
+
+// ListTemplates returns the user's personal templates and those of every workspace they are a
+// member of. With workspaceID only the templates shared in that workspace are listed.
+func (s *TemplateService) ListTemplates(ctx context.Context, userID uuid.UUID, workspaceID *uuid.UUID) ([]dto.TemplateItem, error) {
+	var templates []templateEntity.NoteTemplateEntity
+	var err error
+	if workspaceID != nil {
+		if _, err := s.workspaceService.RequireRole(ctx, *workspaceID, userID, workspaceEntity.RoleViewer); err != nil {
+			return nil, err
+		}
+		templates, err = s.templateRepo.ListTemplatesByWorkspaceID(ctx, *workspaceID)
+	} else {
+		templates, err = s.templateRepo.ListTemplatesForUser(ctx, userID)
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting templates: %v", err))
+	}
+
+	roles := make(map[uuid.UUID]string)
+	items := make([]dto.TemplateItem, 0, len(templates))
+	for i := range templates {
+		template := &templates[i]
+
+		canEdit := template.IsPersonal()
+		if !canEdit {
+			role, ok := roles[*template.WorkspaceID]
+			if !ok {
+				if role, err = s.workspaceService.GetMemberRole(ctx, *template.WorkspaceID, userID); err != nil {
+					return nil, err
+				}
+				roles[*template.WorkspaceID] = role
+			}
+			canEdit = workspaceEntity.RoleAtLeast(role, workspaceEntity.RoleEditor)
+		}
+
+		items = append(items, *toTemplateItem(template, canEdit))
+	}
+
+	return items, nil
+}
+
+// GetTemplate returns a template the user can use
+func (s *TemplateService) GetTemplate(ctx context.Context, userID, templateID uuid.UUID) (*dto.TemplateItem, error) {
+	template, canEdit, err := s.templateAccess(ctx, userID, templateID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return toTemplateItem(template, canEdit), nil
+}
+
+// UpdateTemplate changes a personal template of the user, or a workspace template when the
+// user has at least the editor role in the workspace
+func (s *TemplateService) UpdateTemplate(ctx context.Context, userID, templateID uuid.UUID, req *dto.UpdateTemplateRequest) (*dto.TemplateItem, error) {
+	if req.Name == nil && req.Description == nil && req.Title == nil && req.Content == nil && req.Prompts == nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "nothing to update")
+	}
+
+	template, _, err := s.templateAccess(ctx, userID, templateID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		template.Name = strings.TrimSpace(*req.Name)
+		if template.Name == "" {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "template name can't be blank")
+		}
+	}
+	if req.Description != nil {
+		template.Description = emptyToNil(req.Description)
+	}
+	if req.Title != nil {
+		template.Title = *req.Title
+	}
+	if req.Content != nil {
+		template.Content = *req.Content
+	}
+	if req.Prompts != nil {
+		if err := validatePrompts(*req.Prompts); err != nil {
+			return nil, err
+		}
+		template.Prompts = *req.Prompts
+		if template.Prompts == nil {
+			template.Prompts = []templateEntity.TemplatePrompt{}
+		}
+	}
+
+	if err := s.templateRepo.UpdateTemplate(ctx, template); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error updating template: %v", err))
+	}
+
+	return toTemplateItem(template, true), nil
+}
+
+// DeleteTemplate removes a template the user may edit, notes created from it are kept
+func (s *TemplateService) DeleteTemplate(ctx context.Context, userID, templateID uuid.UUID) error {
+	if _, _, err := s.templateAccess(ctx, userID, templateID, true); err != nil {
+		return err
+	}
+
+	if err := s.templateRepo.DeleteTemplate(ctx, templateID); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error deleting template: %v", err))
+	}
+	return nil
+}
+
+// CreateNoteFromTemplate creates a note from the template with its placeholders expanded.
+// Dates are in the user's time zone, prompts take the given values or their defaults.
+func (s *TemplateService) CreateNoteFromTemplate(ctx context.Context, userID, templateID uuid.UUID, req *dto.CreateNoteFromTemplateRequest) (*dto.NoteResponse, error) {
+	template, _, err := s.templateAccess(ctx, userID, templateID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s not found", userID.String()))
+	}
+
+	workspaceID := req.WorkspaceID
+	if workspaceID == nil {
+		workspaceID = template.WorkspaceID
+	}
+	if workspaceID == nil {
+		personalID, err := s.workspaceService.PersonalWorkspaceID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		workspaceID = &personalID
+	}
+	workspace, err := s.workspaceService.GetWorkspace(ctx, userID, *workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	values := templateEntity.DateValues(now.In(userLocation(user.Metadata)))
+	values[templateEntity.PlaceholderUserDisplayName] = user.DisplayName
+	values[templateEntity.PlaceholderUserUsername] = stringValue(user.Username)
+	values[templateEntity.PlaceholderUserEmail] = user.Email
+	values[templateEntity.PlaceholderWorkspaceName] = workspace.Name
+
+	if err := promptValues(template.Prompts, req.Values, values); err != nil {
+		return nil, err
+	}
+
+	title := truncateRunes(strings.TrimSpace(templateEntity.ExpandPlaceholders(template.Title, values)), maxNoteTitleLength)
+	if title == "" {
+		title = truncateRunes(template.Name, maxNoteTitleLength)
+	}
+
+	note := &noteEntity.NoteEntity{
+		ID:          uuid.New(),
+		WorkspaceID: workspace.ID,
+		UserID:      userID,
+		Title:       title,
+		Content:     templateEntity.ExpandContent(template.Content, values),
+		CreatedAt:   now,
+	}
+	if err := s.noteService.CreateNote(ctx, note); err != nil {
+		return nil, err
+	}
+
+	return s.noteService.GetNote(ctx, userID, note.ID)
+}
+
+// templateAccess loads a template the user can use and reports whether they may change it.
+// Templates the user can't see are reported as not found.
+func (s *TemplateService) templateAccess(ctx context.Context, userID, templateID uuid.UUID, edit bool) (*templateEntity.NoteTemplateEntity, bool, error) {
+	template, err := s.templateRepo.GetTemplateByID(ctx, templateID)
+	if err != nil {
+		return nil, false, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting template: %v", err))
+	}
+	notFound := fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("template with id %s cannot be found", templateID.String()))
+	if template == nil {
+		return nil, false, notFound
+	}
+
+	if template.IsPersonal() {
+		if template.UserID == nil || *template.UserID != userID {
+			return nil, false, notFound
+		}
+		return template, true, nil
+	}
+
+	role, err := s.workspaceService.GetMemberRole(ctx, *template.WorkspaceID, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if role == "" {
+		return nil, false, notFound
+	}
+
+	canEdit := workspaceEntity.RoleAtLeast(role, workspaceEntity.RoleEditor)
+	if edit && !canEdit {
+		return nil, false, fiber.NewError(fiber.StatusForbidden, "changing a workspace template requires at least the editor role")
+	}
+
+	return template, canEdit, nil
+}
+
+// validatePrompts checks that prompt names can be written in a placeholder and are unique
+func validatePrompts(prompts []templateEntity.TemplatePrompt) error {
+	seen := make(map[string]bool, len(prompts))
+	for _, prompt := range prompts {
+		if !templateEntity.PromptNamePattern.MatchString(prompt.Name) {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("prompt name %q must start with a lowercase letter followed by lowercase letters, digits or underscores", prompt.Name))
+		}
+		if seen[prompt.Name] {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("prompt name %q is used more than once", prompt.Name))
+		}
+		seen[prompt.Name] = true
+	}
+	return nil
+}
+
+// promptValues adds the answer to every prompt to values, falling back to the prompt's
+// default. Missing required answers and answers to unknown prompts are rejected.
+func promptValues(prompts []templateEntity.TemplatePrompt, answers map[string]string, values map[string]string) error {
+	known := make(map[string]bool, len(prompts))
+	var missing []string
+	for _, prompt := range prompts {
+		known[prompt.Name] = true
+
+		value := strings.TrimSpace(answers[prompt.Name])
+		if value == "" {
+			value = prompt.Default
+		}
+		if value == "" && prompt.Required {
+			missing = append(missing, prompt.Name)
+		}
+		values[templateEntity.PromptPlaceholderPrefix+prompt.Name] = value
+	}
+
+	if len(missing) > 0 {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("missing values for required prompts: %s", strings.Join(missing, ", ")))
+	}
+
+	var unknown []string
+	for name := range answers {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("the template has no prompts named %s", strings.Join(unknown, ", ")))
+	}
+
+	return nil
+}
+
+func toTemplateItem(template *templateEntity.NoteTemplateEntity, canEdit bool) *dto.TemplateItem {
+	return &dto.TemplateItem{
+		ID:          template.ID,
+		WorkspaceID: template.WorkspaceID,
+		Name:        template.Name,
+		Description: template.Description,
+		Title:       template.Title,
+		Content:     template.Content,
+		Prompts:     template.Prompts,
+		CanEdit:     canEdit,
+		CreatedBy:   template.CreatedBy,
+		CreatedAt:   template.CreatedAt,
+		UpdatedAt:   template.UpdatedAt,
+	}
+}
+
+// userLocation returns the user's time zone, UTC when it's not set or not known
+func userLocation(metadata *userEntity.UserMetadata) *time.Location {
+	if metadata == nil || metadata.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(metadata.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// emptyToNil returns nil for a missing or blank string, the trimmed string otherwise
+func emptyToNil(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return strings.TrimSpace(string([]rune(s)[:max]))
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -19,14 +20,44 @@ type UserServiceInterface interface {
 	PaginationUser(c *fiber.Ctx, p *apputils.Pagination) (data []dto.UserPagination, total int, err error)
 	CreateUser(ctx context.Context, user *entities.UserEntity) error
 	GetUserByEmail(ctx context.Context, email string) (*entities.UserEntity, error)
+	GetUserByID(ctx context.Context, userID uuid.UUID) (*entities.UserEntity, error)
 	MarkEmailVerified(ctx context.Context, userID uuid.UUID) error
+	EmailExists(ctx context.Context, email string) (bool, error)
+	ChangeEmail(ctx context.Context, userID uuid.UUID, email string) error
+	MarkLastLogin(ctx context.Context, userID uuid.UUID, at time.Time) error
+	GetUserRole(ctx context.Context, userID uuid.UUID) (string, error)
+	ScheduleDeletion(ctx context.Context, userID uuid.UUID, deactivatedAt, scheduledAt time.Time) error
+	CancelDeletion(ctx context.Context, userID uuid.UUID) error
+	PurgeScheduledDeletions(ctx context.Context, before time.Time, limit int) ([]entities.UserEntity, error)
+	GetProfile(ctx context.Context, userID uuid.UUID) (*dto.UserProfile, error)
+	UpdateProfile(ctx context.Context, userID uuid.UUID, req *dto.UpdateProfileRequest) (*dto.UserProfile, error)
 	IsUserExistsByID(ctx context.Context, userID uuid.UUID) bool
+	SearchUsers(ctx context.Context, filter *entities.AdminUserFilter, p *apputils.Pagination) (data []entities.UserEntity, total int, err error)
+	UpdateRole(ctx context.Context, userID uuid.UUID, role string) error
+	Deactivate(ctx context.Context, userID uuid.UUID, at time.Time) error
+	Reactivate(ctx context.Context, userID uuid.UUID) error
+	OnUserCreated(hook UserCreatedHook)
+	OnUserPurging(hook UserPurgingHook)
 }
 
+// UserCreatedHook is called after a new account has been stored, so other
+// services can provision data the account needs
+type UserCreatedHook func(ctx context.Context, user *entities.UserEntity)
+
+// UserPurgingHook is called before an account scheduled for deletion is removed, so
+// other services can hand over data shared with other users. An error keeps the
+// account until the next purge.
+type UserPurgingHook func(ctx context.Context, userID uuid.UUID) error
+
 var _ UserServiceInterface = (*UserService)(nil)
 
+// usernamePattern mirrors the chk_username_format constraint on the users table
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]{3,32}$`)
+
 type UserService struct {
-	userRepo repositories.UserRepositoryInterface
+	userRepo         repositories.UserRepositoryInterface
+	userCreatedHooks []UserCreatedHook // Called for every created account
+	userPurgingHooks []UserPurgingHook // Called for every account about to be purged
 }
 
 type UserServiceOpts struct {
@@ -79,9 +110,25 @@ func (s *UserService) CreateUser(ctx context.Context, user *entities.UserEntity)
 		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error creating user: %v", err))
 	}
 
+	for _, hook := range s.userCreatedHooks {
+		hook(ctx, user)
+	}
+
 	return nil
 }
 
+// OnUserCreated registers a hook run for every account created by CreateUser.
+// Hooks must be registered before the server starts handling requests.
+func (s *UserService) OnUserCreated(hook UserCreatedHook) {
+	s.userCreatedHooks = append(s.userCreatedHooks, hook)
+}
+
+// OnUserPurging registers a hook run for every account before PurgeScheduledDeletions
+// removes it. Hooks must be registered before the purge job starts.
+func (s *UserService) OnUserPurging(hook UserPurgingHook) {
+	s.userPurgingHooks = append(s.userPurgingHooks, hook)
+}
+
 func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*entities.UserEntity, error) {
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
@@ -91,6 +138,15 @@ func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*entiti
 	return user, nil
 }
 
+func (s *UserService) GetUserByID(ctx context.Context, userID uuid.UUID) (*entities.UserEntity, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting user by id: %v", err))
+	}
+
+	return user, nil
+}
+
 func (s *UserService) MarkEmailVerified(ctx context.Context, userID uuid.UUID) error {
 	now := time.Now()
 	err := s.userRepo.UpdateUserEmailVerifiedAt(ctx, userID, now)
@@ -101,6 +157,262 @@ func (s *UserService) MarkEmailVerified(ctx context.Context, userID uuid.UUID) e
 	return nil
 }
 
+func (s *UserService) MarkLastLogin(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	err := s.userRepo.UpdateUserLastLoginAt(ctx, userID, at)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error updating last login: %v", err))
+	}
+
+	return nil
+}
+
+// GetUserRole returns the role of the user, or an empty string when the user doesn't exist
+func (s *UserService) GetUserRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", nil
+	}
+
+	return user.GetRole(), nil
+}
+
+func (s *UserService) EmailExists(ctx context.Context, email string) (bool, error) {
+	exist, err := s.userRepo.EmailExists(ctx, email)
+	if err != nil {
+		return false, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error checking email existence: %v", err))
+	}
+
+	return exist, nil
+}
+
+// ChangeEmail replaces the user's email with an already confirmed address,
+// marking it as verified at the same time.
+func (s *UserService) ChangeEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	err := s.userRepo.UpdateUserEmail(ctx, userID, email, time.Now())
+	if errors.Is(err, repositories.ErrEmailAlreadyExists) {
+		return fiber.NewError(fiber.StatusConflict, "email already exists")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error updating user email: %v", err))
+	}
+
+	return nil
+}
+
+func (s *UserService) ScheduleDeletion(ctx context.Context, userID uuid.UUID, deactivatedAt, scheduledAt time.Time) error {
+	err := s.userRepo.ScheduleUserDeletion(ctx, userID, deactivatedAt, scheduledAt)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error scheduling account deletion: %v", err))
+	}
+
+	return nil
+}
+
+func (s *UserService) CancelDeletion(ctx context.Context, userID uuid.UUID) error {
+	err := s.userRepo.CancelUserDeletion(ctx, userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error cancelling account deletion: %v", err))
+	}
+
+	return nil
+}
+
+// PurgeScheduledDeletions hard deletes up to limit accounts whose deletion date has passed
+// and returns them. The purging hooks run first, accounts a hook failed for are kept for the
+// next run and their errors returned along with the purged accounts.
+func (s *UserService) PurgeScheduledDeletions(ctx context.Context, before time.Time, limit int) ([]entities.UserEntity, error) {
+	userIDs, err := s.userRepo.ListScheduledDeletions(ctx, before, limit)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting scheduled deletions: %v", err))
+	}
+
+	var errs []error
+	ready := make([]uuid.UUID, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if err := s.runUserPurgingHooks(ctx, userID); err != nil {
+			errs = append(errs, fmt.Errorf("error preparing purge of user %s: %w", userID.String(), err))
+			continue
+		}
+		ready = append(ready, userID)
+	}
+
+	if len(ready) == 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	users, err := s.userRepo.PurgeScheduledUsers(ctx, ready, before)
+	if err != nil {
+		errs = append(errs, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error purging users: %v", err)))
+	}
+
+	return users, errors.Join(errs...)
+}
+
+func (s *UserService) runUserPurgingHooks(ctx context.Context, userID uuid.UUID) error {
+	for _, hook := range s.userPurgingHooks {
+		if err := hook(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *UserService) GetProfile(ctx context.Context, userID uuid.UUID) (*dto.UserProfile, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s cannot be found", userID.String()))
+	}
+
+	return toUserProfile(user), nil
+}
+
+func (s *UserService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *dto.UpdateProfileRequest) (*dto.UserProfile, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("user with id %s cannot be found", userID.String()))
+	}
+
+	update := &entities.UserProfileUpdate{}
+	var errs []apputils.ErrorValidation
+
+	if req.DisplayName != nil {
+		displayName := strings.TrimSpace(*req.DisplayName)
+		if displayName == "" {
+			errs = append(errs, apputils.ErrorValidation{Key: "display_name", Message: "display name can't be empty"})
+		}
+		update.DisplayName = &displayName
+	}
+
+	if req.Username != nil {
+		username := strings.TrimSpace(*req.Username)
+		if !usernamePattern.MatchString(username) {
+			errs = append(errs, apputils.ErrorValidation{Key: "username", Message: "username must be 3-32 characters of letters, digits or underscores"})
+		}
+		update.Username = &username
+	}
+
+	if req.Timezone != nil {
+		timezone := strings.TrimSpace(*req.Timezone)
+		// LoadLocation also accepts "" and "Local", which are not IANA names
+		if _, err := time.LoadLocation(timezone); err != nil || timezone == "" || timezone == "Local" {
+			errs = append(errs, apputils.ErrorValidation{Key: "timezone", Message: "timezone must be a valid IANA time zone name"})
+		}
+		update.Timezone = &timezone
+	}
+
+	if len(errs) > 0 {
+		return nil, apputils.NewValidationError("invalid profile", errs)
+	}
+
+	// Changing only the letter case of the current username is always allowed
+	if update.Username != nil && (user.Username == nil || !strings.EqualFold(*update.Username, *user.Username)) {
+		exist, err := s.userRepo.UsernameExists(ctx, *update.Username)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error checking username existence: %v", err))
+		}
+		if exist {
+			return nil, fiber.NewError(fiber.StatusConflict, "username already exists")
+		}
+	}
+
+	err = s.userRepo.UpdateUserProfile(ctx, userID, update)
+	if errors.Is(err, repositories.ErrUsernameAlreadyExists) {
+		return nil, fiber.NewError(fiber.StatusConflict, "username already exists")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error updating user profile: %v", err))
+	}
+
+	return s.GetProfile(ctx, userID)
+}
+
 func (s *UserService) IsUserExistsByID(ctx context.Context, userID uuid.UUID) bool {
 	return s.userRepo.IsUserExistsByID(ctx, userID)
 }
+
+func (s *UserService) SearchUsers(ctx context.Context, filter *entities.AdminUserFilter, p *apputils.Pagination) (data []entities.UserEntity, total int, err error) {
+	var errs []apputils.ErrorValidation
+	switch filter.Role {
+	case "", entities.RoleUser, entities.RoleAdmin:
+	default:
+		errs = append(errs, apputils.ErrorValidation{Key: "role", Message: "role must be user or admin"})
+	}
+	switch filter.Status {
+	case "", entities.UserStatusActive, entities.UserStatusDeactivated, entities.UserStatusPendingDeletion, entities.UserStatusUnverified:
+	default:
+		errs = append(errs, apputils.ErrorValidation{Key: "status", Message: "status must be active, deactivated, pending_deletion or unverified"})
+	}
+	if len(errs) > 0 {
+		return nil, 0, apputils.NewValidationError("invalid user filter", errs)
+	}
+
+	data, total, err = s.userRepo.SearchUsers(ctx, filter, p)
+	if err != nil {
+		return nil, 0, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error searching users: %v", err))
+	}
+
+	return data, total, nil
+}
+
+func (s *UserService) UpdateRole(ctx context.Context, userID uuid.UUID, role string) error {
+	if role != entities.RoleUser && role != entities.RoleAdmin {
+		return fiber.NewError(fiber.StatusBadRequest, "role must be user or admin")
+	}
+
+	err := s.userRepo.UpdateUserRole(ctx, userID, role)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error updating user role: %v", err))
+	}
+
+	return nil
+}
+
+func (s *UserService) Deactivate(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	err := s.userRepo.DeactivateUser(ctx, userID, at)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error deactivating user: %v", err))
+	}
+
+	return nil
+}
+
+func (s *UserService) Reactivate(ctx context.Context, userID uuid.UUID) error {
+	err := s.userRepo.ReactivateUser(ctx, userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error reactivating user: %v", err))
+	}
+
+	return nil
+}
+
+func toUserProfile(user *entities.UserEntity) *dto.UserProfile {
+	profile := &dto.UserProfile{
+		ID:              user.ID,
+		DisplayName:     user.DisplayName,
+		Username:        user.Username,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		Timezone:        "UTC",
+		Role:            user.GetRole(),
+		LastLoginAt:     user.LastLoginAt,
+
+		DeletionScheduledAt: user.DeletionScheduledAt,
+		CreatedAt:           user.CreatedAt,
+		UpdatedAt:           user.UpdatedAt,
+	}
+
+	if user.Metadata != nil && user.Metadata.Timezone != "" {
+		profile.Timezone = user.Metadata.Timezone
+	}
+
+	return profile
+}
@@ -0,0 +1,719 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/application/handler/dto"
+	"github.com/rayhan889/neatspace/internal/audit"
+	authEntity "github.com/rayhan889/neatspace/internal/domain/auth/entities"
+	userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
+	workspaceEntity "github.com/rayhan889/neatspace/internal/domain/workspace/entities"
+	workspaceRepo "github.com/rayhan889/neatspace/internal/domain/workspace/repositories"
+	"github.com/rayhan889/neatspace/internal/notification"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+const (
+	// How long a workspace invitation link stays valid
+	workspaceInvitationExpiry = 7 * 24 * time.Hour
+
+	// Name given to the workspace created with every account
+	personalWorkspaceName = "Personal"
+)
+
+type WorkspaceServiceInterface interface {
+	CreateWorkspace(ctx context.Context, userID uuid.UUID, req *dto.CreateWorkspaceRequest) (*dto.WorkspaceItem, error)
+	CreatePersonalWorkspace(ctx context.Context, user *userEntity.UserEntity)
+	PersonalWorkspaceID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error)
+	ListWorkspaces(ctx context.Context, userID uuid.UUID) ([]dto.WorkspaceItem, error)
+	GetWorkspace(ctx context.Context, userID, workspaceID uuid.UUID) (*dto.WorkspaceItem, error)
+	UpdateWorkspace(ctx context.Context, userID, workspaceID uuid.UUID, req *dto.UpdateWorkspaceRequest) (*dto.WorkspaceItem, error)
+	DeleteWorkspace(ctx context.Context, userID, workspaceID uuid.UUID) error
+	ListMembers(ctx context.Context, userID, workspaceID uuid.UUID) ([]dto.WorkspaceMemberItem, error)
+	UpdateMemberRole(ctx context.Context, actorID, workspaceID, userID uuid.UUID, role string) error
+	RemoveMember(ctx context.Context, actorID, workspaceID, userID uuid.UUID) error
+	TransferOwnership(ctx context.Context, actorID, workspaceID, userID uuid.UUID) (*dto.WorkspaceItem, error)
+	HandOverWorkspaces(ctx context.Context, userID uuid.UUID) error
+	InviteMember(ctx context.Context, actorID, workspaceID uuid.UUID, req *dto.InviteWorkspaceMemberRequest) error
+	ListInvitations(ctx context.Context, actorID, workspaceID uuid.UUID) ([]dto.WorkspaceInvitationItem, error)
+	RevokeInvitation(ctx context.Context, actorID, workspaceID, invitationID uuid.UUID) error
+	AcceptInvitation(ctx context.Context, userID uuid.UUID, req *dto.AcceptWorkspaceInvitationRequest) (*dto.WorkspaceItem, error)
+	RequireRole(ctx context.Context, workspaceID, userID uuid.UUID, minRole string) (*workspaceEntity.WorkspaceMemberEntity, error)
+	GetMemberRole(ctx context.Context, workspaceID, userID uuid.UUID) (string, error)
+}
+
+var _ WorkspaceServiceInterface = (*WorkspaceService)(nil)
+
+type WorkspaceService struct {
+	workspaceRepo workspaceRepo.WorkspaceRepositoryInterface
+	userService   UserServiceInterface
+	auditRecorder audit.RecorderInterface
+	logger        *slog.Logger
+	mailer        *notification.Mailer
+	baseURL       string
+}
+
+type WorkspaceServiceOpts struct {
+	WorkspaceRepo workspaceRepo.WorkspaceRepositoryInterface
+	UserService   UserServiceInterface
+	AuditRecorder audit.RecorderInterface
+	Logger        *slog.Logger
+	Mailer        *notification.Mailer
+	BaseURL       string
+}
+
+func NewWorkspaceService(opts WorkspaceServiceOpts) *WorkspaceService {
+	return &WorkspaceService{
+		workspaceRepo: opts.WorkspaceRepo,
+		userService:   opts.UserService,
+		auditRecorder: opts.AuditRecorder,
+		logger:        opts.Logger,
+		mailer:        opts.Mailer,
+		baseURL:       opts.BaseURL,
+	}
+}
+
+func (s *WorkspaceService) CreateWorkspace(ctx context.Context, userID uuid.UUID, req *dto.CreateWorkspaceRequest) (*dto.WorkspaceItem, error) {
+	workspace := &workspaceEntity.WorkspaceEntity{
+		ID:        uuid.New(),
+		Name:      strings.TrimSpace(req.Name),
+		OwnerID:   userID,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.workspaceRepo.CreateWorkspace(ctx, workspace); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error creating workspace: %v", err))
+	}
+
+	s.auditRecorder.Record(ctx, &userID, audit.ActionWorkspaceCreate, audit.Workspace(workspace.ID), map[string]any{
+		"name": workspace.Name,
+	})
+
+	item := toWorkspaceItem(workspace, workspaceEntity.RoleOwner)
+	return &item, nil
+}
+
+// CreatePersonalWorkspace creates the personal workspace of a new account. It is registered
+// as a UserService hook, failures are only logged since PersonalWorkspaceID creates the
+// workspace on demand.
+func (s *WorkspaceService) CreatePersonalWorkspace(ctx context.Context, user *userEntity.UserEntity) {
+	if _, err := s.ensurePersonalWorkspace(ctx, user.ID); err != nil {
+		s.logger.Error("failed to create personal workspace", slog.String("op", "CreatePersonalWorkspace"), slog.String("user_id", user.ID.String()), slog.String("error", err.Error()))
+	}
+}
+
+// PersonalWorkspaceID returns the id of the user's personal workspace, creating it if missing
+func (s *WorkspaceService) PersonalWorkspaceID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	workspace, err := s.ensurePersonalWorkspace(ctx, userID)
+	if err != nil {
+		return uuid.Nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting personal workspace: %v", err))
+	}
+
+	return workspace.ID, nil
+}
+
+func (s *WorkspaceService) ListWorkspaces(ctx context.Context, userID uuid.UUID) ([]dto.WorkspaceItem, error) {
+	workspaces, err := s.workspaceRepo.ListWorkspacesByUserID(ctx, userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting workspaces: %v", err))
+	}
+
+	items := make([]dto.WorkspaceItem, 0, len(workspaces))
+	for i := range workspaces {
+		items = append(items, toWorkspaceItem(&workspaces[i].WorkspaceEntity, workspaces[i].Role))
+	}
+
+	return items, nil
+}
+
+func (s *WorkspaceService) GetWorkspace(ctx context.Context, userID, workspaceID uuid.UUID) (*dto.WorkspaceItem, error) {
+	member, err := s.RequireRole(ctx, workspaceID, userID, workspaceEntity.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace, err := s.getWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := toWorkspaceItem(workspace, member.Role)
+	return &item, nil
+}
+
+func (s *WorkspaceService) UpdateWorkspace(ctx context.Context, userID, workspaceID uuid.UUID, req *dto.UpdateWorkspaceRequest) (*dto.WorkspaceItem, error) {
+	member, err := s.RequireRole(ctx, workspaceID, userID, workspaceEntity.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace, err := s.getWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := workspace.Name
+	workspace.Name = strings.TrimSpace(req.Name)
+	if err := s.workspaceRepo.UpdateWorkspaceName(ctx, workspaceID, workspace.Name); err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error updating workspace: %v", err))
+	}
+
+	s.auditRecorder.Record(ctx, &userID, audit.ActionWorkspaceUpdate, audit.Workspace(workspaceID), map[string]any{
+		"previous_name": previous,
+		"name":          workspace.Name,
+	})
+
+	item := toWorkspaceItem(workspace, member.Role)
+	return &item, nil
+}
+
+// DeleteWorkspace removes a shared workspace with all of its notes, only the owner can do this
+func (s *WorkspaceService) DeleteWorkspace(ctx context.Context, userID, workspaceID uuid.UUID) error {
+	if _, err := s.RequireRole(ctx, workspaceID, userID, workspaceEntity.RoleOwner); err != nil {
+		return err
+	}
+
+	workspace, err := s.getWorkspace(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	if workspace.Personal {
+		return fiber.NewError(fiber.StatusBadRequest, "personal workspace can't be deleted")
+	}
+
+	if err := s.workspaceRepo.DeleteWorkspace(ctx, workspaceID); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error deleting workspace: %v", err))
+	}
+
+	s.auditRecorder.Record(ctx, &userID, audit.ActionWorkspaceDelete, audit.Workspace(workspaceID), map[string]any{
+		"name": workspace.Name,
+	})
+
+	return nil
+}
+
+func (s *WorkspaceService) ListMembers(ctx context.Context, userID, workspaceID uuid.UUID) ([]dto.WorkspaceMemberItem, error) {
+	if _, err := s.RequireRole(ctx, workspaceID, userID, workspaceEntity.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	members, err := s.workspaceRepo.ListMembers(ctx, workspaceID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting workspace members: %v", err))
+	}
+
+	items := make([]dto.WorkspaceMemberItem, 0, len(members))
+	for _, member := range members {
+		items = append(items, dto.WorkspaceMemberItem{
+			UserID:      member.UserID,
+			DisplayName: member.DisplayName,
+			Username:    member.Username,
+			Email:       member.Email,
+			Role:        member.Role,
+			JoinedAt:    member.CreatedAt,
+		})
+	}
+
+	return items, nil
+}
+
+// UpdateMemberRole changes the role of a member. Admins manage editors and viewers,
+// only the owner can promote or demote admins. Ownership is handed over with TransferOwnership.
+func (s *WorkspaceService) UpdateMemberRole(ctx context.Context, actorID, workspaceID, userID uuid.UUID, role string) error {
+	if actorID == userID {
+		return fiber.NewError(fiber.StatusBadRequest, "you can't change your own role")
+	}
+	if role == workspaceEntity.RoleOwner || !workspaceEntity.IsValidRole(role) {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("role %s can't be assigned", role))
+	}
+
+	actor, err := s.RequireRole(ctx, workspaceID, actorID, workspaceEntity.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	member, err := s.getMember(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if err := canManageMember(actor, member, role); err != nil {
+		return err
+	}
+	if member.Role == role {
+		return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("member already has the %s role", role))
+	}
+
+	if err := s.workspaceRepo.UpdateMemberRole(ctx, workspaceID, userID, role); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error updating member role: %v", err))
+	}
+
+	s.auditRecorder.Record(ctx, &actorID, audit.ActionWorkspaceMemberRoleUpdate, audit.Workspace(workspaceID), map[string]any{
+		"user_id":       userID,
+		"previous_role": member.Role,
+		"role":          role,
+	})
+
+	return nil
+}
+
+// RemoveMember removes a member from the workspace. Any member but the owner can leave,
+// removing someone else follows the same rules as UpdateMemberRole.
+func (s *WorkspaceService) RemoveMember(ctx context.Context, actorID, workspaceID, userID uuid.UUID) error {
+	if actorID == userID {
+		member, err := s.RequireRole(ctx, workspaceID, actorID, workspaceEntity.RoleViewer)
+		if err != nil {
+			return err
+		}
+		if member.Role == workspaceEntity.RoleOwner {
+			return fiber.NewError(fiber.StatusBadRequest, "the owner can't leave the workspace")
+		}
+	} else {
+		actor, err := s.RequireRole(ctx, workspaceID, actorID, workspaceEntity.RoleAdmin)
+		if err != nil {
+			return err
+		}
+
+		member, err := s.getMember(ctx, workspaceID, userID)
+		if err != nil {
+			return err
+		}
+		if err := canManageMember(actor, member, ""); err != nil {
+			return err
+		}
+	}
+
+	if err := s.workspaceRepo.RemoveMember(ctx, workspaceID, userID); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error removing member: %v", err))
+	}
+
+	s.auditRecorder.Record(ctx, &actorID, audit.ActionWorkspaceMemberRemove, audit.Workspace(workspaceID), map[string]any{
+		"user_id": userID,
+	})
+
+	return nil
+}
+
+// TransferOwnership makes a member the owner of the workspace, the current owner stays as an
+// admin. Only the owner can hand it over, personal workspaces can't be.
+func (s *WorkspaceService) TransferOwnership(ctx context.Context, actorID, workspaceID, userID uuid.UUID) (*dto.WorkspaceItem, error) {
+	if actorID == userID {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "you already own the workspace")
+	}
+
+	if _, err := s.RequireRole(ctx, workspaceID, actorID, workspaceEntity.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	workspace, err := s.getWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if workspace.Personal {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "personal workspace can't be transferred")
+	}
+
+	member, err := s.getMember(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transferred, err := s.workspaceRepo.TransferOwnership(ctx, workspaceID, actorID, userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error transferring workspace: %v", err))
+	}
+	if !transferred {
+		return nil, fiber.NewError(fiber.StatusConflict, "workspace ownership changed meanwhile")
+	}
+
+	s.auditRecorder.Record(ctx, &actorID, audit.ActionWorkspaceTransfer, audit.Workspace(workspaceID), map[string]any{
+		"user_id":       userID,
+		"previous_role": member.Role,
+	})
+
+	workspace.OwnerID = userID
+	item := toWorkspaceItem(workspace, workspaceEntity.RoleAdmin)
+	return &item, nil
+}
+
+// HandOverWorkspaces gives every shared workspace the user owns to the member with the
+// highest role, the longest-standing one among equals, so purging the account doesn't remove
+// the workspace and the notes of its other members. It's registered as a UserService hook run
+// before the account is deleted, workspaces without other members go with the account.
+func (s *WorkspaceService) HandOverWorkspaces(ctx context.Context, userID uuid.UUID) error {
+	workspaces, err := s.workspaceRepo.ListWorkspacesByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("error getting workspaces: %w", err)
+	}
+
+	for _, workspace := range workspaces {
+		if workspace.Personal || workspace.Role != workspaceEntity.RoleOwner {
+			continue
+		}
+
+		members, err := s.workspaceRepo.ListMembers(ctx, workspace.ID)
+		if err != nil {
+			return fmt.Errorf("error getting workspace members: %w", err)
+		}
+
+		var successor *workspaceEntity.WorkspaceMemberDetail
+		for i := range members {
+			member := &members[i]
+			if member.UserID == userID {
+				continue
+			}
+			if successor == nil || outranks(member, successor) {
+				successor = member
+			}
+		}
+		if successor == nil {
+			continue
+		}
+
+		transferred, err := s.workspaceRepo.TransferOwnership(ctx, workspace.ID, userID, successor.UserID)
+		if err != nil {
+			return fmt.Errorf("error transferring workspace: %w", err)
+		}
+		if !transferred {
+			continue
+		}
+
+		s.auditRecorder.Record(ctx, nil, audit.ActionWorkspaceTransfer, audit.Workspace(workspace.ID), map[string]any{
+			"user_id":           successor.UserID,
+			"previous_owner_id": userID,
+			"previous_role":     successor.Role,
+		})
+	}
+
+	return nil
+}
+
+// outranks reports whether a should take over a workspace before b
+func outranks(a, b *workspaceEntity.WorkspaceMemberDetail) bool {
+	if a.Role != b.Role {
+		return workspaceEntity.RoleAtLeast(a.Role, b.Role)
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+// InviteMember emails an invitation link to join the workspace with the given role.
+// A new invitation replaces any pending one for the same email.
+func (s *WorkspaceService) InviteMember(ctx context.Context, actorID, workspaceID uuid.UUID, req *dto.InviteWorkspaceMemberRequest) error {
+	actor, err := s.RequireRole(ctx, workspaceID, actorID, workspaceEntity.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if req.Role == workspaceEntity.RoleAdmin && actor.Role != workspaceEntity.RoleOwner {
+		return fiber.NewError(fiber.StatusForbidden, "only the owner can invite admins")
+	}
+
+	workspace, err := s.getWorkspace(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	if workspace.Personal {
+		return fiber.NewError(fiber.StatusBadRequest, "personal workspace can't be shared")
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	invitee, err := s.userService.GetUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if invitee != nil {
+		member, err := s.workspaceRepo.GetMember(ctx, workspaceID, invitee.ID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting workspace member: %v", err))
+		}
+		if member != nil {
+			return fiber.NewError(fiber.StatusConflict, "user is already a member of this workspace")
+		}
+	}
+
+	if err := s.workspaceRepo.DeleteInvitationsForEmail(ctx, workspaceID, email); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error replacing invitation: %v", err))
+	}
+
+	rawToken, err := apputils.GenerateURLSafeToken(48)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error generating invitation token: %v", err))
+	}
+
+	now := time.Now()
+	invitation := &authEntity.OneTimeToken{
+		ID:        uuid.New(),
+		Subject:   authEntity.OneTimeTokenSubjectWorkspaceInvite,
+		TokenHash: hashInvitationToken(rawToken),
+		RelatesTo: email,
+		Metadata: map[string]any{
+			"workspace_id": workspaceID.String(),
+			"role":         req.Role,
+			"invited_by":   actorID.String(),
+		},
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(workspaceInvitationExpiry),
+		LastSentAt: &now,
+	}
+	if err := s.workspaceRepo.CreateInvitation(ctx, invitation); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error creating invitation: %v", err))
+	}
+
+	if err := s.sendInvitationEmail(ctx, actorID, workspace, email, req.Role, rawToken); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to send invitation email")
+	}
+
+	s.auditRecorder.Record(ctx, &actorID, audit.ActionWorkspaceInvite, audit.Workspace(workspaceID), map[string]any{
+		"email": email,
+		"role":  req.Role,
+	})
+
+	return nil
+}
+
+func (s *WorkspaceService) ListInvitations(ctx context.Context, actorID, workspaceID uuid.UUID) ([]dto.WorkspaceInvitationItem, error) {
+	if _, err := s.RequireRole(ctx, workspaceID, actorID, workspaceEntity.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.workspaceRepo.ListInvitations(ctx, workspaceID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting invitations: %v", err))
+	}
+
+	items := make([]dto.WorkspaceInvitationItem, 0, len(invitations))
+	for _, invitation := range invitations {
+		item := dto.WorkspaceInvitationItem{
+			ID:        invitation.ID,
+			Email:     invitation.RelatesTo,
+			CreatedAt: invitation.CreatedAt,
+			ExpiresAt: invitation.ExpiresAt,
+		}
+		item.Role, _ = invitation.Metadata["role"].(string)
+		if invitedBy, err := uuid.Parse(fmt.Sprint(invitation.Metadata["invited_by"])); err == nil {
+			item.InvitedBy = &invitedBy
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (s *WorkspaceService) RevokeInvitation(ctx context.Context, actorID, workspaceID, invitationID uuid.UUID) error {
+	if _, err := s.RequireRole(ctx, workspaceID, actorID, workspaceEntity.RoleAdmin); err != nil {
+		return err
+	}
+
+	deleted, err := s.workspaceRepo.DeleteInvitation(ctx, workspaceID, invitationID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error revoking invitation: %v", err))
+	}
+	if !deleted {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("invitation with id %s cannot be found", invitationID.String()))
+	}
+
+	s.auditRecorder.Record(ctx, &actorID, audit.ActionWorkspaceInviteRevoke, audit.Workspace(workspaceID), map[string]any{
+		"invitation_id": invitationID,
+	})
+
+	return nil
+}
+
+// AcceptInvitation adds the signed in user to the workspace of the invitation. The invitation
+// is bound to the invited email, so it can't be redeemed from another account.
+func (s *WorkspaceService) AcceptInvitation(ctx context.Context, userID uuid.UUID, req *dto.AcceptWorkspaceInvitationRequest) (*dto.WorkspaceItem, error) {
+	invitation, err := s.workspaceRepo.GetInvitationByTokenHash(ctx, hashInvitationToken(req.Token))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting invitation: %v", err))
+	}
+	if invitation == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "invitation is not found")
+	}
+	if invitation.ExpiresAt.Before(time.Now()) {
+		return nil, fiber.NewError(fiber.StatusGone, "invitation is expired")
+	}
+
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !strings.EqualFold(user.Email, invitation.RelatesTo) {
+		return nil, fiber.NewError(fiber.StatusForbidden, "invitation was sent to a different email address")
+	}
+
+	workspaceID, err := uuid.Parse(fmt.Sprint(invitation.Metadata["workspace_id"]))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "invitation has no workspace")
+	}
+	role, _ := invitation.Metadata["role"].(string)
+	if role == workspaceEntity.RoleOwner || !workspaceEntity.IsValidRole(role) {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "invitation has an invalid role")
+	}
+
+	workspace, err := s.getWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.workspaceRepo.AddMember(ctx, &workspaceEntity.WorkspaceMemberEntity{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		Role:        role,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		if errors.Is(err, workspaceRepo.ErrAlreadyMember) {
+			return nil, fiber.NewError(fiber.StatusConflict, "you are already a member of this workspace")
+		}
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error joining workspace: %v", err))
+	}
+
+	if _, err := s.workspaceRepo.DeleteInvitation(ctx, workspaceID, invitation.ID); err != nil {
+		s.logger.Warn("failed to delete accepted invitation", slog.String("op", "AcceptInvitation"), slog.String("error", err.Error()))
+	}
+
+	s.auditRecorder.Record(ctx, &userID, audit.ActionWorkspaceJoin, audit.Workspace(workspaceID), map[string]any{
+		"role":          role,
+		"invitation_id": invitation.ID,
+	})
+
+	item := toWorkspaceItem(workspace, role)
+	return &item, nil
+}
+
+// RequireRole returns the user's membership if it grants at least minRole. Non members get
+// a not found error so workspace ids can't be probed.
+func (s *WorkspaceService) RequireRole(ctx context.Context, workspaceID, userID uuid.UUID, minRole string) (*workspaceEntity.WorkspaceMemberEntity, error) {
+	member, err := s.workspaceRepo.GetMember(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting workspace member: %v", err))
+	}
+	if member == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("workspace with id %s cannot be found", workspaceID.String()))
+	}
+
+	if !workspaceEntity.RoleAtLeast(member.Role, minRole) {
+		return nil, fiber.NewError(fiber.StatusForbidden, fmt.Sprintf("this action requires the %s role", minRole))
+	}
+
+	return member, nil
+}
+
+// GetMemberRole returns the user's role in the workspace, or an empty string for non members
+func (s *WorkspaceService) GetMemberRole(ctx context.Context, workspaceID, userID uuid.UUID) (string, error) {
+	member, err := s.workspaceRepo.GetMember(ctx, workspaceID, userID)
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting workspace member: %v", err))
+	}
+	if member == nil {
+		return "", nil
+	}
+
+	return member.Role, nil
+}
+
+func (s *WorkspaceService) ensurePersonalWorkspace(ctx context.Context, userID uuid.UUID) (*workspaceEntity.WorkspaceEntity, error) {
+	workspace, err := s.workspaceRepo.GetPersonalWorkspace(ctx, userID)
+	if err != nil || workspace != nil {
+		return workspace, err
+	}
+
+	workspace = &workspaceEntity.WorkspaceEntity{
+		ID:        uuid.New(),
+		Name:      personalWorkspaceName,
+		OwnerID:   userID,
+		Personal:  true,
+		CreatedAt: time.Now(),
+	}
+	if err := s.workspaceRepo.CreatePersonalWorkspace(ctx, workspace); err != nil {
+		return nil, err
+	}
+
+	// A concurrent request may have created it first, read back whichever was stored
+	return s.workspaceRepo.GetPersonalWorkspace(ctx, userID)
+}
+
+func (s *WorkspaceService) getWorkspace(ctx context.Context, workspaceID uuid.UUID) (*workspaceEntity.WorkspaceEntity, error) {
+	workspace, err := s.workspaceRepo.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting workspace: %v", err))
+	}
+	if workspace == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("workspace with id %s cannot be found", workspaceID.String()))
+	}
+
+	return workspace, nil
+}
+
+func (s *WorkspaceService) getMember(ctx context.Context, workspaceID, userID uuid.UUID) (*workspaceEntity.WorkspaceMemberEntity, error) {
+	member, err := s.workspaceRepo.GetMember(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("error getting workspace member: %v", err))
+	}
+	if member == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("member with id %s cannot be found", userID.String()))
+	}
+
+	return member, nil
+}
+
+func (s *WorkspaceService) sendInvitationEmail(ctx context.Context, actorID uuid.UUID, workspace *workspaceEntity.WorkspaceEntity, email, role, rawToken string) error {
+	var inviterName string
+	if inviter, err := s.userService.GetUserByID(ctx, actorID); err == nil && inviter != nil {
+		inviterName = inviter.DisplayName
+	}
+
+	q := url.Values{}
+	q.Set("token", rawToken)
+
+	data := map[string]any{
+		"Email":         email,
+		"InviterName":   inviterName,
+		"WorkspaceName": workspace.Name,
+		"Role":          role,
+		"AcceptURL":     buildAppLink(s.baseURL, "/api/v1/workspaces/invitations/accept", q),
+		"ExpiresIn":     "7 days",
+		"AppName":       "Neatspace",
+	}
+
+	subject := fmt.Sprintf("You have been invited to %s", workspace.Name)
+	return sendTemplatedMail(ctx, s.mailer, s.logger, "sendInvitationEmail", email, subject, "workspace_invitation.html", data)
+}
+
+// canManageMember checks that actor may change member's membership, newRole is empty for removals
+func canManageMember(actor, member *workspaceEntity.WorkspaceMemberEntity, newRole string) error {
+	if member.Role == workspaceEntity.RoleOwner {
+		return fiber.NewError(fiber.StatusForbidden, "the workspace owner can't be changed")
+	}
+	if actor.Role != workspaceEntity.RoleOwner && (member.Role == workspaceEntity.RoleAdmin || newRole == workspaceEntity.RoleAdmin) {
+		return fiber.NewError(fiber.StatusForbidden, "only the owner can manage admins")
+	}
+
+	return nil
+}
+
+func hashInvitationToken(rawToken string) string {
+	hash := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(hash[:])
+}
+
+func toWorkspaceItem(workspace *workspaceEntity.WorkspaceEntity, role string) dto.WorkspaceItem {
+	return dto.WorkspaceItem{
+		ID:        workspace.ID,
+		Name:      workspace.Name,
+		OwnerID:   workspace.OwnerID,
+		Personal:  workspace.Personal,
+		Role:      role,
+		CreatedAt: workspace.CreatedAt,
+		UpdatedAt: workspace.UpdatedAt,
+	}
+}
@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const EventTable = "public.audit_events"
+
+// Actions recorded in Event.Action, grouped by the area they belong to
+const (
+	ActionSignIn         = "auth.sign_in"
+	ActionSignInFailed   = "auth.sign_in_failed"
+	ActionPasswordSet    = "auth.password_set"
+	ActionPasswordChange = "auth.password_change"
+	ActionPasswordReset  = "auth.password_reset"
+	ActionEmailVerify    = "auth.email_verify"
+	ActionEmailChange    = "auth.email_change"
+
+	ActionUserView           = "user.view"
+	ActionUserRoleUpdate     = "user.role_update"
+	ActionUserEmailVerify    = "user.email_verify"
+	ActionUserDeactivate     = "user.deactivate"
+	ActionUserReactivate     = "user.reactivate"
+	ActionUserPasswordReset  = "user.password_reset"
+	ActionUserSessionsRevoke = "user.sessions_revoke"
+	ActionImpersonateStart   = "user.impersonate_start"
+	ActionImpersonateEnd     = "user.impersonate_end"
+
+	ActionNoteDelete        = "note.delete"
+	ActionNoteShare         = "note.share"
+	ActionNoteShareUpdate   = "note.share_update"
+	ActionNoteUnshare       = "note.unshare"
+	ActionNotePublish       = "note.publish"
+	ActionNotePublishUpdate = "note.publish_update"
+	ActionNoteUnpublish     = "note.unpublish"
+
+	ActionWorkspaceCreate           = "workspace.create"
+	ActionWorkspaceUpdate           = "workspace.update"
+	ActionWorkspaceDelete           = "workspace.delete"
+	ActionWorkspaceInvite           = "workspace.invite"
+	ActionWorkspaceInviteRevoke     = "workspace.invite_revoke"
+	ActionWorkspaceJoin             = "workspace.join"
+	ActionWorkspaceMemberRoleUpdate = "workspace.member_role_update"
+	ActionWorkspaceMemberRemove     = "workspace.member_remove"
+	ActionWorkspaceTransfer         = "workspace.transfer"
+)
+
+// Kinds of resources an event can target
+const (
+	TargetTypeUser      = "user"
+	TargetTypeNote      = "note"
+	TargetTypeWorkspace = "workspace"
+)
+
+// Target is the resource an event acted on. The zero value means no target.
+type Target struct {
+	Type string
+	ID   uuid.UUID
+}
+
+// User returns a target pointing at a user account
+func User(id uuid.UUID) Target {
+	return Target{Type: TargetTypeUser, ID: id}
+}
+
+// Note returns a target pointing at a note
+func Note(id uuid.UUID) Target {
+	return Target{Type: TargetTypeNote, ID: id}
+}
+
+// Workspace returns a target pointing at a workspace
+func Workspace(id uuid.UUID) Target {
+	return Target{Type: TargetTypeWorkspace, ID: id}
+}
+
+// Event is a single row of the append-only audit trail
+type Event struct {
+	ID             uuid.UUID      `json:"id" db:"id"`
+	ActorID        *uuid.UUID     `json:"actor_id" db:"actor_id"`               // Nil for anonymous attempts and system jobs
+	ImpersonatorID *uuid.UUID     `json:"impersonator_id" db:"impersonator_id"` // Admin acting as the actor, if impersonating
+	Action         string         `json:"action" db:"action"`
+	TargetType     *string        `json:"target_type" db:"target_type"`
+	TargetID       *uuid.UUID     `json:"target_id" db:"target_id"`
+	Metadata       map[string]any `json:"metadata" db:"metadata"` // Action specific details
+	IPAddress      *net.IP        `json:"ip_address" db:"ip_address"`
+	UserAgent      *string        `json:"user_agent" db:"user_agent"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+}
+
+// Filter narrows an audit trail listing, empty fields are ignored
+type Filter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   *uuid.UUID
+	From       *time.Time // Inclusive
+	To         *time.Time // Exclusive
+}
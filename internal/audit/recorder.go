@@ -0,0 +1,217 @@
+// Package audit records security relevant events to the append-only audit_events table.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+// Number of events removed per purge query
+const purgeBatchSize = 1000
+
+type RecorderInterface interface {
+	Record(ctx context.Context, actorID *uuid.UUID, action string, target Target, metadata map[string]any)
+	PaginationEvents(ctx context.Context, filter *Filter, p *apputils.Pagination) (data []Event, total int, err error)
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+var _ RecorderInterface = (*Recorder)(nil)
+
+type Recorder struct {
+	pgPool *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewRecorder(pgPool *pgxpool.Pool, logger *slog.Logger) *Recorder {
+	return &Recorder{
+		pgPool: pgPool,
+		logger: logger,
+	}
+}
+
+// Record appends an event with the client info carried by ctx. Failures are logged
+// only, the audited action has already happened and must not be rolled back.
+func (r *Recorder) Record(ctx context.Context, actorID *uuid.UUID, action string, target Target, metadata map[string]any) {
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+
+	client := apputils.ClientInfoFromContext(ctx)
+	event := &Event{
+		ID:        uuid.New(),
+		ActorID:   actorID,
+		Action:    action,
+		Metadata:  metadata,
+		IPAddress: client.IP(),
+		CreatedAt: time.Now(),
+	}
+	if target.Type != "" {
+		event.TargetType = &target.Type
+		event.TargetID = &target.ID
+	}
+	if impersonatorID, err := uuid.Parse(client.ImpersonatorID); err == nil {
+		event.ImpersonatorID = &impersonatorID
+	}
+	if client.UserAgent != "" {
+		userAgent := client.UserAgent
+		event.UserAgent = &userAgent
+	}
+
+	_, err := r.pgPool.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (id, actor_id, impersonator_id, action, target_type, target_id, metadata, ip_address, user_agent, created_at) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`, EventTable),
+		event.ID,
+		event.ActorID,
+		event.ImpersonatorID,
+		event.Action,
+		event.TargetType,
+		event.TargetID,
+		event.Metadata,
+		event.IPAddress,
+		event.UserAgent,
+		event.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("failed to record audit event", slog.String("op", "Record"), slog.String("action", action), slog.String("error", err.Error()))
+	}
+}
+
+func (r *Recorder) PaginationEvents(ctx context.Context, filter *Filter, p *apputils.Pagination) (data []Event, total int, err error) {
+	where, args := r.queryFilter(filter)
+
+	query := fmt.Sprintf(`
+		SELECT id, actor_id, impersonator_id, action, target_type, target_id, metadata, ip_address, user_agent, created_at 
+		FROM %s 
+		WHERE %s 
+		ORDER BY created_at DESC 
+		LIMIT $%d OFFSET $%d
+	`, EventTable, where, len(args)+1, len(args)+2)
+
+	rows, err := r.pgPool.Query(ctx, query, append(args, p.Limit, p.Offset)...)
+	if err != nil {
+		r.logger.Error("failed to query audit events", slog.String("op", "PaginationEvents"), slog.String("error", err.Error()))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event Event
+		err := rows.Scan(
+			&event.ID,
+			&event.ActorID,
+			&event.ImpersonatorID,
+			&event.Action,
+			&event.TargetType,
+			&event.TargetID,
+			&event.Metadata,
+			&event.IPAddress,
+			&event.UserAgent,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan audit event row", slog.String("op", "PaginationEvents"), slog.String("error", err.Error()))
+			return nil, 0, err
+		}
+
+		data = append(data, event)
+	}
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s`, EventTable, where)
+
+	err = r.pgPool.QueryRow(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		r.logger.Error("failed to count audit events", slog.String("op", "PaginationEvents"), slog.String("error", err.Error()))
+		return nil, 0, err
+	}
+
+	return data, total, nil
+}
+
+// Purge deletes events created before the given time in batches and returns how many
+// were removed. The table rejects deletes unless audit.allow_purge is set for the transaction.
+func (r *Recorder) Purge(ctx context.Context, before time.Time) (int, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %[1]s
+		WHERE id IN (
+			SELECT id FROM %[1]s
+			WHERE created_at < $1
+			ORDER BY created_at
+			LIMIT $2
+		)
+	`, EventTable)
+
+	purged := 0
+	for {
+		var deleted int64
+		err := pgx.BeginFunc(ctx, r.pgPool, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, `SET LOCAL audit.allow_purge = 'on'`); err != nil {
+				return err
+			}
+
+			cmd, err := tx.Exec(ctx, query, before, purgeBatchSize)
+			if err != nil {
+				return err
+			}
+			deleted = cmd.RowsAffected()
+			return nil
+		})
+		if err != nil {
+			r.logger.Error("failed to purge audit events", slog.String("op", "Purge"), slog.String("error", err.Error()))
+			return purged, err
+		}
+
+		purged += int(deleted)
+		if deleted < purgeBatchSize {
+			break
+		}
+	}
+
+	if purged > 0 {
+		r.logger.Info("audit events purged", slog.String("op", "Purge"), slog.Int("count", purged))
+	}
+
+	return purged, nil
+}
+
+func (r *Recorder) queryFilter(filter *Filter) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+
+	if filter == nil {
+		return conditions[0], args
+	}
+
+	if filter.ActorID != nil {
+		args = append(args, *filter.ActorID)
+		conditions = append(conditions, fmt.Sprintf("actor_id = $%d", len(args)))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		conditions = append(conditions, fmt.Sprintf("action = $%d", len(args)))
+	}
+	if filter.TargetType != "" {
+		args = append(args, filter.TargetType)
+		conditions = append(conditions, fmt.Sprintf("target_type = $%d", len(args)))
+	}
+	if filter.TargetID != nil {
+		args = append(args, *filter.TargetID)
+		conditions = append(conditions, fmt.Sprintf("target_id = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
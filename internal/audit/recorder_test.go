@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryFilter(t *testing.T) {
+	r := &Recorder{}
+
+	t.Run("Nil", func(t *testing.T) {
+		where, args := r.queryFilter(nil)
+		assert.Equal(t, "1=1", where)
+		assert.Empty(t, args)
+	})
+
+	t.Run("AllFields", func(t *testing.T) {
+		actorID := uuid.New()
+		targetID := uuid.New()
+		from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := from.Add(24 * time.Hour)
+
+		where, args := r.queryFilter(&Filter{
+			ActorID:    &actorID,
+			Action:     ActionSignIn,
+			TargetType: TargetTypeUser,
+			TargetID:   &targetID,
+			From:       &from,
+			To:         &to,
+		})
+
+		assert.Equal(t, "1=1 AND actor_id = $1 AND action = $2 AND target_type = $3 AND target_id = $4 AND created_at >= $5 AND created_at < $6", where)
+		assert.Equal(t, []interface{}{actorID, ActionSignIn, TargetTypeUser, targetID, from, to}, args)
+	})
+
+	t.Run("TimeRangeOnly", func(t *testing.T) {
+		from := time.Now()
+
+		where, args := r.queryFilter(&Filter{From: &from})
+		assert.Equal(t, "1=1 AND created_at >= $1", where)
+		assert.Len(t, args, 1)
+	})
+}
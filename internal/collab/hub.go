@@ -0,0 +1,646 @@
+// Package collab relays live edits between the people editing the same note.
+//
+// The hub doesn't interpret Yjs data. Clients are the CRDT engines: they send the Yjs updates
+// of their local edits, which the hub relays to the other peers of the note and keeps in an
+// update log so late joiners can catch up. Editors periodically send a snapshot, the merged
+// document both as a Yjs state to compact the log and as Tiptap JSON that is persisted to the
+// note. Editors should send one shortly after editing and before leaving, updates not covered
+// by a snapshot are gone once the last peer leaves.
+//
+// Snapshots are only persisted over the version of the note the room started from. When the
+// note was changed outside the session meanwhile, the stored note wins: the room is reset to
+// it and its peers start over from the stored document.
+package collab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/domain/note/entities"
+)
+
+const (
+	// Largest message accepted from a client
+	MaxMessageSize = 4 << 20
+	// Largest cursor payload of a presence message
+	maxCursorSize = 1 << 10
+	// Messages queued for a peer before it is considered too slow and dropped
+	sendBufferSize = 256
+	// Updates relayed since the last snapshot before an editor is asked for a new one
+	snapshotEvery = 200
+	// Timeout of saving a room's snapshot when its last peer leaves
+	leaveSaveTimeout = 10 * time.Second
+)
+
+// Conn is the part of a WebSocket connection the hub needs
+type Conn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	Close() error
+}
+
+// SnapshotStore persists the documents merged during a session and tells who may stay in it
+type SnapshotStore interface {
+	// SaveCollabSnapshot stores content as the note's content while the note is still at
+	// baseVersion and returns the saved note. When the note changed meanwhile nothing is
+	// stored, saved is false and the current note is returned, nil when it's deleted.
+	SaveCollabSnapshot(ctx context.Context, noteID uuid.UUID, baseVersion int64, content entities.TiptapContent) (note *entities.NoteEntity, saved bool, err error)
+	// CollabPermission returns the permission the user has on the note now, empty without access
+	CollabPermission(ctx context.Context, noteID, userID uuid.UUID) (string, error)
+}
+
+// SessionChecker reports whether the session a peer connected with is still active
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID, userID uuid.UUID) (bool, error)
+}
+
+// Peer describes the user behind a connection
+type Peer struct {
+	UserID      uuid.UUID
+	DisplayName string
+	Permission  string    // Permission of the user on the note, editing needs at least edit
+	SessionID   uuid.UUID // Session of the access token the peer connected with
+	ExpiresAt   time.Time // When that access token expires, zero if it doesn't
+}
+
+type HubOpts struct {
+	Store    SnapshotStore
+	Sessions SessionChecker // Checks the sessions of connected peers (optional)
+	Logger   *slog.Logger
+}
+
+// Hub holds a room per note being edited. Rooms live in process, all peers of a note must
+// be connected to the same instance.
+type Hub struct {
+	store    SnapshotStore
+	sessions SessionChecker
+	logger   *slog.Logger
+
+	mu    sync.Mutex
+	rooms map[uuid.UUID]*room
+}
+
+func NewHub(opts HubOpts) *Hub {
+	return &Hub{
+		store:    opts.Store,
+		sessions: opts.Sessions,
+		logger:   opts.Logger,
+		rooms:    make(map[uuid.UUID]*room),
+	}
+}
+
+// Serve joins conn to the room of the note and relays its messages until the connection is
+// closed. content is the stored document at version, used to seed the room when it's new.
+func (h *Hub) Serve(noteID uuid.UUID, content entities.TiptapContent, version int64, p Peer, conn Conn) {
+	pr := &peer{
+		id:      uuid.New(),
+		Peer:    p,
+		canEdit: entities.PermissionAtLeast(p.Permission, entities.PermissionEdit),
+		conn:    conn,
+		send:    make(chan []byte, sendBufferSize),
+	}
+
+	r := h.join(noteID, content, version, pr)
+	go pr.writeLoop()
+	defer h.leave(r, pr)
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		// The connection is closed once the error is written, which ends this loop
+		if pr.expired(time.Now()) {
+			r.disconnect(pr, "your session has expired")
+			continue
+		}
+		r.handle(pr, data)
+	}
+}
+
+// Flush disconnects peers that lost access to their note and persists the rooms whose
+// document changed since the last flush
+func (h *Hub) Flush(ctx context.Context) error {
+	h.mu.Lock()
+	rooms := make([]*room, 0, len(h.rooms))
+	for _, r := range h.rooms {
+		rooms = append(rooms, r)
+	}
+	h.mu.Unlock()
+
+	var errs []error
+	for _, r := range rooms {
+		h.checkPeers(ctx, r)
+		if err := h.save(ctx, r); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		h.evict(r)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close persists all rooms and disconnects every peer
+func (h *Hub) Close(ctx context.Context) error {
+	err := h.Flush(ctx)
+
+	h.mu.Lock()
+	for _, r := range h.rooms {
+		r.mu.Lock()
+		for p := range r.peers {
+			_ = p.conn.Close()
+		}
+		r.mu.Unlock()
+	}
+	h.mu.Unlock()
+
+	return err
+}
+
+func (h *Hub) join(noteID uuid.UUID, content entities.TiptapContent, version int64, p *peer) *room {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := h.rooms[noteID]
+	if !ok {
+		r = &room{
+			noteID:  noteID,
+			content: content,
+			version: version,
+			peers:   make(map[*peer]struct{}),
+			logger:  h.logger,
+		}
+		h.rooms[noteID] = r
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.peers[p] = struct{}{}
+
+	msg := serverMessage{
+		Type:    MessageSync,
+		PeerID:  &p.id,
+		CanEdit: &p.canEdit,
+		Seq:     &r.seq,
+		Epoch:   r.epoch,
+		Peers:   r.peerInfos(),
+	}
+	for _, u := range r.updates {
+		msg.Updates = append(msg.Updates, u.data)
+	}
+	if !r.seeded {
+		// Until an editor seeds the shared document, everyone can show the stored one
+		msg.Content = &r.content
+		if r.seeder == nil && p.canEdit {
+			r.seeder = p
+			msg.Seed = true
+		}
+	}
+
+	r.sendTo(p, msg)
+	r.broadcastPresence(p)
+
+	return r
+}
+
+func (h *Hub) leave(r *room, p *peer) {
+	h.mu.Lock()
+	r.mu.Lock()
+
+	// A disconnect of a copy taken before the peer left must not send on the closed channel
+	delete(r.peers, p)
+	p.dropped = true
+	close(p.send)
+	_ = p.conn.Close()
+
+	if r.snapshotPeer == p {
+		r.snapshotPeer = nil
+	}
+	if r.seeder == p {
+		r.seeder = nil
+		r.assignSeeder()
+	}
+	r.broadcastPresence(nil)
+
+	empty := len(r.peers) == 0
+
+	r.mu.Unlock()
+	h.mu.Unlock()
+
+	if !empty {
+		return
+	}
+
+	// The room stays while saving, so someone joining meanwhile doesn't start from the
+	// stored document before it's up to date
+	ctx, cancel := context.WithTimeout(context.Background(), leaveSaveTimeout)
+	defer cancel()
+	if err := h.save(ctx, r); err == nil {
+		h.evict(r)
+	}
+}
+
+// evict removes the room if nobody is in it and its document is persisted. Rooms that
+// failed to save are kept for Flush to retry.
+func (h *Hub) evict(r *room) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.peers) == 0 && !r.dirty && h.rooms[r.noteID] == r {
+		delete(h.rooms, r.noteID)
+	}
+}
+
+// save persists the room's document if it changed, it's marked changed again on failure. A
+// note changed outside the session resets the room to the stored note, a deleted note ends
+// the session.
+func (h *Hub) save(ctx context.Context, r *room) error {
+	// Saves of a room run one at a time, a concurrent one would conflict with the first
+	r.saving.Lock()
+	defer r.saving.Unlock()
+
+	r.mu.Lock()
+	if !r.dirty {
+		r.mu.Unlock()
+		return nil
+	}
+	content, version := r.content, r.version
+	r.dirty = false
+	r.mu.Unlock()
+
+	note, saved, err := h.store.SaveCollabSnapshot(ctx, r.noteID, version, content)
+	if err != nil {
+		h.logger.Error("failed to save collaborative snapshot", slog.String("op", "save"), slog.String("note_id", r.noteID.String()), slog.String("error", err.Error()))
+
+		r.mu.Lock()
+		r.dirty = true
+		r.mu.Unlock()
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case saved:
+		r.version = note.Version
+	case note == nil:
+		h.logger.Info("note of collaborative session was deleted", slog.String("op", "save"), slog.String("note_id", r.noteID.String()))
+		r.dirty = false
+		for p := range r.peers {
+			r.disconnectLocked(p, "the note was deleted")
+		}
+	default:
+		h.logger.Warn("note changed outside collaborative session, resetting it", slog.String("op", "save"), slog.String("note_id", r.noteID.String()), slog.Int64("base_version", version), slog.Int64("version", note.Version))
+		r.reset(note.Content, note.Version)
+	}
+
+	return nil
+}
+
+// checkPeers disconnects the peers of the room whose session ended or who lost the access they
+// joined with. Failed checks leave the peer connected, the next flush checks again.
+func (h *Hub) checkPeers(ctx context.Context, r *room) {
+	r.mu.Lock()
+	peers := make([]*peer, 0, len(r.peers))
+	for p := range r.peers {
+		peers = append(peers, p)
+	}
+	r.mu.Unlock()
+
+	now := time.Now()
+	for _, p := range peers {
+		reason, err := h.revokedReason(ctx, r.noteID, p, now)
+		if err != nil {
+			h.logger.Error("failed to check collaboration peer", slog.String("op", "checkPeers"), slog.String("note_id", r.noteID.String()), slog.String("user_id", p.UserID.String()), slog.String("error", err.Error()))
+			continue
+		}
+		if reason != "" {
+			r.disconnect(p, reason)
+		}
+	}
+}
+
+// revokedReason tells why the peer may not stay in the room, empty if it may
+func (h *Hub) revokedReason(ctx context.Context, noteID uuid.UUID, p *peer, now time.Time) (string, error) {
+	if p.expired(now) {
+		return "your session has expired", nil
+	}
+
+	if h.sessions != nil {
+		active, err := h.sessions.IsSessionActive(ctx, p.SessionID, p.UserID)
+		if err != nil {
+			return "", err
+		}
+		if !active {
+			return "your session has ended", nil
+		}
+	}
+
+	permission, err := h.store.CollabPermission(ctx, noteID, p.UserID)
+	if err != nil {
+		return "", err
+	}
+	if !entities.PermissionAtLeast(permission, entities.PermissionView) {
+		return "you no longer have access to this note", nil
+	}
+	if p.canEdit && !entities.PermissionAtLeast(permission, entities.PermissionEdit) {
+		return "you can no longer edit this note", nil
+	}
+
+	return "", nil
+}
+
+type peer struct {
+	Peer
+	id      uuid.UUID
+	canEdit bool
+	cursor  json.RawMessage
+	conn    Conn
+	send    chan []byte
+	dropped bool
+}
+
+// writeLoop sends the queued messages, a nil message closes the connection after the ones
+// queued before it
+func (p *peer) writeLoop() {
+	for msg := range p.send {
+		if msg == nil {
+			_ = p.conn.Close()
+			return
+		}
+		if err := p.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+			_ = p.conn.Close()
+			return
+		}
+	}
+}
+
+func (p *peer) expired(now time.Time) bool {
+	return !p.ExpiresAt.IsZero() && now.After(p.ExpiresAt)
+}
+
+func (p *peer) info() PeerInfo {
+	return PeerInfo{
+		PeerID:      p.id,
+		UserID:      p.UserID,
+		DisplayName: p.DisplayName,
+		CanEdit:     p.canEdit,
+		Cursor:      p.cursor,
+	}
+}
+
+// logUpdate is a Yjs update of the log with the sequence number it was relayed with
+type logUpdate struct {
+	seq  int64
+	data []byte
+}
+
+// room is the live session of a note. All fields are guarded by mu.
+type room struct {
+	noteID uuid.UUID
+	logger *slog.Logger
+
+	saving sync.Mutex // Held while the document is persisted
+
+	mu      sync.Mutex
+	peers   map[*peer]struct{}
+	content entities.TiptapContent // Stored document, replaced by each snapshot
+	version int64                  // Version of the note content was loaded or last saved at
+	dirty   bool                   // content changed since it was last persisted
+	epoch   int64                  // Bumped by every reset, edits of an older epoch are dropped
+
+	seeded bool  // The shared document exists, an editor sent an update or snapshot
+	seeder *peer // Editor asked to seed the shared document from content
+
+	updates       []logUpdate // Updates since the last snapshot, led by the snapshot state
+	seq           int64       // Sequence number of the last relayed update
+	snapshotSeq   int64       // Sequence number the last snapshot was taken at
+	sinceSnapshot int
+	snapshotPeer  *peer // Editor asked for a snapshot that hasn't arrived yet
+}
+
+func (r *room) handle(p *peer, data []byte) {
+	var msg clientMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		r.sendError(p, "invalid message")
+		return
+	}
+
+	switch msg.Type {
+	case MessageUpdate:
+		if !p.canEdit {
+			r.sendError(p, "you can only view this note")
+			return
+		}
+		if len(msg.Update) == 0 {
+			r.sendError(p, "update is empty")
+			return
+		}
+		r.applyUpdate(p, msg.Epoch, msg.Update)
+	case MessageSnapshot:
+		if !p.canEdit {
+			r.sendError(p, "you can only view this note")
+			return
+		}
+		if len(msg.State) == 0 || msg.Content == nil || msg.Content.Type != "doc" {
+			r.sendError(p, "snapshot needs the document state and content")
+			return
+		}
+		r.applySnapshot(msg.Epoch, msg.Seq, msg.State, *msg.Content)
+	case MessagePresence:
+		if len(msg.Cursor) > maxCursorSize {
+			r.sendError(p, "cursor is too large")
+			return
+		}
+		r.setCursor(p, msg.Cursor)
+	default:
+		r.sendError(p, fmt.Sprintf("unknown message type %q", msg.Type))
+	}
+}
+
+func (r *room) applyUpdate(p *peer, epoch int64, update []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Made on the document from before a reset, which the peers dropped
+	if epoch != r.epoch {
+		return
+	}
+
+	r.seq++
+	r.updates = append(r.updates, logUpdate{seq: r.seq, data: update})
+	r.seeded = true
+	r.seeder = nil
+	r.sinceSnapshot++
+
+	seq := r.seq
+	msg := serverMessage{Type: MessageUpdate, PeerID: &p.id, Seq: &seq, Update: update}
+	for other := range r.peers {
+		if other != p {
+			r.sendTo(other, msg)
+		}
+	}
+
+	if r.sinceSnapshot >= snapshotEvery && r.snapshotPeer == nil {
+		r.snapshotPeer = p
+		r.sendTo(p, serverMessage{Type: MessageSnapshotRequest})
+	}
+}
+
+// applySnapshot replaces the updates the snapshot covers with its state. The sender has
+// received every update up to seq and its own updates are in its state, so nothing is lost.
+func (r *room) applySnapshot(epoch, seq int64, state []byte, content entities.TiptapContent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// An older snapshot lacks updates compacted into the newer one
+	if epoch != r.epoch || seq < r.snapshotSeq || seq > r.seq {
+		return
+	}
+
+	updates := []logUpdate{{seq: seq, data: state}}
+	for _, u := range r.updates {
+		if u.seq > seq {
+			updates = append(updates, u)
+		}
+	}
+
+	r.updates = updates
+	r.snapshotSeq = seq
+	r.content = content
+	r.dirty = true
+	r.seeded = true
+	r.seeder = nil
+	r.sinceSnapshot = len(updates) - 1
+	r.snapshotPeer = nil
+}
+
+func (r *room) setCursor(p *peer, cursor json.RawMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p.cursor = cursor
+	r.broadcastPresence(nil)
+}
+
+// reset replaces the shared document with the stored one at version. Peers drop their document
+// and an editor is asked to seed it again. Must be called with mu held.
+func (r *room) reset(content entities.TiptapContent, version int64) {
+	r.content = content
+	r.version = version
+	r.dirty = false
+	r.epoch++
+	r.updates = nil
+	r.snapshotSeq = r.seq
+	r.sinceSnapshot = 0
+	r.snapshotPeer = nil
+	r.seeded = false
+	r.seeder = nil
+
+	seq := r.seq
+	msg := serverMessage{Type: MessageReset, Seq: &seq, Epoch: r.epoch, Content: &r.content}
+	for p := range r.peers {
+		r.sendTo(p, msg)
+	}
+	r.assignSeeder()
+}
+
+// disconnect tells the peer why and closes its connection, which removes it from the room
+func (r *room) disconnect(p *peer, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.disconnectLocked(p, reason)
+}
+
+// disconnectLocked is disconnect with mu held, doing nothing for a peer that already left
+func (r *room) disconnectLocked(p *peer, reason string) {
+	if _, ok := r.peers[p]; !ok {
+		return
+	}
+
+	r.sendTo(p, serverMessage{Type: MessageError, Message: reason})
+	if p.dropped {
+		return
+	}
+
+	p.dropped = true
+	select {
+	case p.send <- nil:
+	default:
+		_ = p.conn.Close()
+	}
+}
+
+// assignSeeder hands seeding over to a remaining editor while the room isn't seeded
+func (r *room) assignSeeder() {
+	if r.seeded {
+		return
+	}
+
+	for p := range r.peers {
+		if p.canEdit {
+			r.seeder = p
+			r.sendTo(p, serverMessage{Type: MessageSeed, Content: &r.content})
+			return
+		}
+	}
+}
+
+// broadcastPresence sends who's in the room to every peer except skip
+func (r *room) broadcastPresence(skip *peer) {
+	msg := serverMessage{Type: MessagePresence, Peers: r.peerInfos()}
+	for p := range r.peers {
+		if p != skip {
+			r.sendTo(p, msg)
+		}
+	}
+}
+
+func (r *room) peerInfos() []PeerInfo {
+	infos := make([]PeerInfo, 0, len(r.peers))
+	for p := range r.peers {
+		infos = append(infos, p.info())
+	}
+	return infos
+}
+
+func (r *room) sendError(p *peer, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sendTo(p, serverMessage{Type: MessageError, Message: message})
+}
+
+// sendTo queues msg for p, dropping the peer when it can't keep up. Must be called with mu held.
+func (r *room) sendTo(p *peer, msg serverMessage) {
+	if p.dropped {
+		return
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		r.logger.Error("failed to marshal collaboration message", slog.String("op", "sendTo"), slog.String("error", err.Error()))
+		return
+	}
+
+	select {
+	case p.send <- data:
+	default:
+		// Closing the connection ends its read loop, which removes the peer from the room
+		p.dropped = true
+		_ = p.conn.Close()
+	}
+}
@@ -0,0 +1,442 @@
+package collab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn feeds queued messages to the hub and records what it writes
+type fakeConn struct {
+	in     chan []byte
+	out    chan serverMessage
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{
+		in:     make(chan []byte, 16),
+		out:    make(chan serverMessage, 64),
+		closed: make(chan struct{}),
+	}
+}
+
+func (c *fakeConn) ReadMessage() (int, []byte, error) {
+	select {
+	case data := <-c.in:
+		return 1, data, nil
+	case <-c.closed:
+		return 0, nil, io.EOF
+	}
+}
+
+func (c *fakeConn) WriteMessage(_ int, data []byte) error {
+	var msg serverMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	select {
+	case c.out <- msg:
+		return nil
+	case <-c.closed:
+		return errors.New("closed")
+	}
+}
+
+func (c *fakeConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *fakeConn) sendJSON(t *testing.T, msg map[string]any) {
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+	c.in <- data
+}
+
+// next returns the next message of the given type, skipping others such as presence
+func (c *fakeConn) next(t *testing.T, msgType string) serverMessage {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case msg := <-c.out:
+			if msg.Type == msgType {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("no %s message received", msgType)
+		}
+	}
+}
+
+// roundTrip waits until the hub handled every message sent before, by sending a presence with
+// a cursor of its own and waiting for it to come back
+func (c *fakeConn) roundTrip(t *testing.T) {
+	t.Helper()
+	marker := uuid.NewString()
+	c.sendJSON(t, map[string]any{"type": MessagePresence, "cursor": map[string]string{"marker": marker}})
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case msg := <-c.out:
+			for _, p := range msg.Peers {
+				if msg.Type == MessagePresence && strings.Contains(string(p.Cursor), marker) {
+					return
+				}
+			}
+		case <-timeout:
+			t.Fatal("presence was not echoed")
+		}
+	}
+}
+
+// fakeStore keeps a version per note starting at 0 and grants edit unless told otherwise
+type fakeStore struct {
+	mu          sync.Mutex
+	saved       map[uuid.UUID]entities.TiptapContent
+	versions    map[uuid.UUID]int64
+	deleted     map[uuid.UUID]bool
+	permissions map[uuid.UUID]string
+	// checking, when set, runs before each permission check, outside of mu
+	checking func(userID uuid.UUID)
+}
+
+func (s *fakeStore) SaveCollabSnapshot(_ context.Context, noteID uuid.UUID, baseVersion int64, content entities.TiptapContent) (*entities.NoteEntity, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.deleted[noteID] {
+		return nil, false, nil
+	}
+	if s.versions[noteID] != baseVersion {
+		return &entities.NoteEntity{ID: noteID, Content: s.saved[noteID], Version: s.versions[noteID]}, false, nil
+	}
+	s.saved[noteID] = content
+	s.versions[noteID]++
+	return &entities.NoteEntity{ID: noteID, Content: content, Version: s.versions[noteID]}, true, nil
+}
+
+func (s *fakeStore) CollabPermission(_ context.Context, _, userID uuid.UUID) (string, error) {
+	if s.checking != nil {
+		s.checking(userID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if permission, ok := s.permissions[userID]; ok {
+		return permission, nil
+	}
+	return entities.PermissionEdit, nil
+}
+
+// edit stores content over the note as an edit made outside the session would
+func (s *fakeStore) edit(noteID uuid.UUID, content entities.TiptapContent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved[noteID] = content
+	s.versions[noteID]++
+}
+
+func (s *fakeStore) setPermission(userID uuid.UUID, permission string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.permissions[userID] = permission
+}
+
+func (s *fakeStore) get(noteID uuid.UUID) (entities.TiptapContent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content, ok := s.saved[noteID]
+	return content, ok
+}
+
+func newTestHub() (*Hub, *fakeStore) {
+	store := &fakeStore{
+		saved:       make(map[uuid.UUID]entities.TiptapContent),
+		versions:    make(map[uuid.UUID]int64),
+		deleted:     make(map[uuid.UUID]bool),
+		permissions: make(map[uuid.UUID]string),
+	}
+	return NewHub(HubOpts{Store: store, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}), store
+}
+
+func TestHub(t *testing.T) {
+	stored := entities.TiptapContent{Type: "doc", Content: []entities.TiptapContent{{Type: "paragraph"}}}
+
+	t.Run("RelayAndSeed", func(t *testing.T) {
+		hub, _ := newTestHub()
+		noteID := uuid.New()
+
+		alice, bob := newFakeConn(), newFakeConn()
+		defer alice.Close()
+		defer bob.Close()
+
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), DisplayName: "Alice", Permission: entities.PermissionEdit}, alice)
+		first := alice.next(t, MessageSync)
+		assert.True(t, first.Seed)
+		require.NotNil(t, first.Content)
+		assert.Equal(t, "doc", first.Content.Type)
+
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), DisplayName: "Bob", Permission: entities.PermissionEdit}, bob)
+		first = bob.next(t, MessageSync)
+		assert.False(t, first.Seed, "only one editor seeds the document")
+		assert.Len(t, first.Peers, 2)
+
+		alice.sendJSON(t, map[string]any{"type": MessageUpdate, "update": []byte{1, 2, 3}})
+		update := bob.next(t, MessageUpdate)
+		assert.Equal(t, []byte{1, 2, 3}, update.Update)
+		require.NotNil(t, update.Seq)
+		assert.EqualValues(t, 1, *update.Seq)
+	})
+
+	t.Run("LateJoinerCatchesUp", func(t *testing.T) {
+		hub, _ := newTestHub()
+		noteID := uuid.New()
+
+		alice, carol := newFakeConn(), newFakeConn()
+		defer alice.Close()
+		defer carol.Close()
+
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), Permission: entities.PermissionManage}, alice)
+		alice.next(t, MessageSync)
+		alice.sendJSON(t, map[string]any{"type": MessageUpdate, "update": []byte{1}})
+		alice.sendJSON(t, map[string]any{"type": MessageUpdate, "update": []byte{2}})
+		alice.sendJSON(t, map[string]any{"type": MessagePresence, "cursor": map[string]int{"anchor": 1, "head": 1}})
+		alice.next(t, MessagePresence)
+
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), Permission: entities.PermissionView}, carol)
+		first := carol.next(t, MessageSync)
+		assert.Equal(t, [][]byte{{1}, {2}}, first.Updates)
+		assert.Nil(t, first.Content, "the shared document is already seeded")
+		require.NotNil(t, first.CanEdit)
+		assert.False(t, *first.CanEdit)
+	})
+
+	t.Run("ViewerCannotEdit", func(t *testing.T) {
+		hub, _ := newTestHub()
+		noteID := uuid.New()
+
+		viewer := newFakeConn()
+		defer viewer.Close()
+
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), Permission: entities.PermissionComment}, viewer)
+		first := viewer.next(t, MessageSync)
+		assert.False(t, first.Seed)
+
+		viewer.sendJSON(t, map[string]any{"type": MessageUpdate, "update": []byte{1}})
+		assert.Equal(t, "you can only view this note", viewer.next(t, MessageError).Message)
+	})
+
+	t.Run("SnapshotCompactsAndPersists", func(t *testing.T) {
+		hub, store := newTestHub()
+		noteID := uuid.New()
+
+		alice, bob := newFakeConn(), newFakeConn()
+		defer bob.Close()
+
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), Permission: entities.PermissionEdit}, alice)
+		alice.next(t, MessageSync)
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), Permission: entities.PermissionEdit}, bob)
+		bob.next(t, MessageSync)
+
+		alice.sendJSON(t, map[string]any{"type": MessageUpdate, "update": []byte{1}})
+		bob.next(t, MessageUpdate)
+		bob.sendJSON(t, map[string]any{"type": MessageUpdate, "update": []byte{2}})
+		alice.next(t, MessageUpdate)
+
+		merged := entities.TiptapContent{Type: "doc", Content: []entities.TiptapContent{{Type: "paragraph", Content: []entities.TiptapContent{{Type: "text", Text: "hello"}}}}}
+		alice.sendJSON(t, map[string]any{"type": MessageSnapshot, "seq": 1, "state": []byte{9}, "content": merged})
+		// Messages of a connection are handled in order, the presence reply follows the snapshot
+		alice.sendJSON(t, map[string]any{"type": MessagePresence})
+		alice.next(t, MessagePresence)
+
+		require.NoError(t, hub.Flush(context.Background()))
+		saved, ok := store.get(noteID)
+		require.True(t, ok)
+		assert.Equal(t, merged, saved)
+
+		// Bob's update came after the snapshot was taken and must survive the compaction
+		carol := newFakeConn()
+		defer carol.Close()
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), Permission: entities.PermissionView}, carol)
+		assert.Equal(t, [][]byte{{9}, {2}}, carol.next(t, MessageSync).Updates)
+
+		alice.Close()
+	})
+
+	t.Run("OutsideEditResetsRoom", func(t *testing.T) {
+		hub, store := newTestHub()
+		noteID := uuid.New()
+
+		alice, bob := newFakeConn(), newFakeConn()
+		defer alice.Close()
+		defer bob.Close()
+
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), Permission: entities.PermissionEdit}, alice)
+		alice.next(t, MessageSync)
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), Permission: entities.PermissionEdit}, bob)
+		bob.next(t, MessageSync)
+
+		outside := entities.TiptapContent{Type: "doc", Content: []entities.TiptapContent{{Type: "heading"}}}
+		store.edit(noteID, outside)
+
+		merged := entities.TiptapContent{Type: "doc", Content: []entities.TiptapContent{{Type: "paragraph", Content: []entities.TiptapContent{{Type: "text", Text: "hello"}}}}}
+		alice.sendJSON(t, map[string]any{"type": MessageUpdate, "update": []byte{1}})
+		bob.next(t, MessageUpdate)
+		alice.sendJSON(t, map[string]any{"type": MessageSnapshot, "seq": 1, "state": []byte{9}, "content": merged})
+		alice.roundTrip(t)
+
+		require.NoError(t, hub.Flush(context.Background()))
+		saved, _ := store.get(noteID)
+		assert.Equal(t, outside, saved, "the outside edit must not be overwritten")
+
+		reset := bob.next(t, MessageReset)
+		require.NotNil(t, reset.Content)
+		assert.Equal(t, outside, *reset.Content)
+		assert.EqualValues(t, 1, reset.Epoch)
+
+		// Edits made before the reset arrived are dropped, the ones after it are relayed
+		alice.sendJSON(t, map[string]any{"type": MessageUpdate, "update": []byte{2}})
+		alice.sendJSON(t, map[string]any{"type": MessageUpdate, "epoch": 1, "update": []byte{3}})
+		assert.Equal(t, []byte{3}, bob.next(t, MessageUpdate).Update)
+
+		// The room now builds on the stored version
+		alice.sendJSON(t, map[string]any{"type": MessageSnapshot, "epoch": 1, "seq": 2, "state": []byte{9}, "content": merged})
+		alice.roundTrip(t)
+		require.NoError(t, hub.Flush(context.Background()))
+		saved, _ = store.get(noteID)
+		assert.Equal(t, merged, saved)
+	})
+
+	t.Run("PeerLosingAccessIsDisconnected", func(t *testing.T) {
+		hub, store := newTestHub()
+		noteID := uuid.New()
+
+		alice, bob := newFakeConn(), newFakeConn()
+		defer alice.Close()
+		defer bob.Close()
+
+		aliceID, bobID := uuid.New(), uuid.New()
+		go hub.Serve(noteID, stored, 0, Peer{UserID: aliceID, Permission: entities.PermissionEdit}, alice)
+		alice.next(t, MessageSync)
+		go hub.Serve(noteID, stored, 0, Peer{UserID: bobID, Permission: entities.PermissionEdit}, bob)
+		bob.next(t, MessageSync)
+
+		store.setPermission(aliceID, entities.PermissionView)
+		store.setPermission(bobID, "")
+		require.NoError(t, hub.Flush(context.Background()))
+
+		assert.Equal(t, "you can no longer edit this note", alice.next(t, MessageError).Message)
+		assert.Equal(t, "you no longer have access to this note", bob.next(t, MessageError).Message)
+		select {
+		case <-bob.closed:
+		case <-time.After(time.Second):
+			t.Fatal("connection was not closed")
+		}
+	})
+
+	t.Run("PeerLeavingWhileCheckedIsSkipped", func(t *testing.T) {
+		hub, store := newTestHub()
+		noteID := uuid.New()
+		aliceID := uuid.New()
+
+		alice, bob := newFakeConn(), newFakeConn()
+		defer bob.Close()
+
+		go hub.Serve(noteID, stored, 0, Peer{UserID: aliceID, Permission: entities.PermissionEdit}, alice)
+		alice.next(t, MessageSync)
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), Permission: entities.PermissionEdit}, bob)
+		bob.next(t, MessageSync)
+		bob.roundTrip(t)
+
+		checking, release := make(chan struct{}), make(chan struct{})
+		store.checking = func(userID uuid.UUID) {
+			if userID == aliceID {
+				close(checking)
+				<-release
+			}
+		}
+		store.setPermission(aliceID, "")
+
+		flushed := make(chan error)
+		go func() { flushed <- hub.Flush(context.Background()) }()
+
+		<-checking
+		alice.Close()
+		for msg := bob.next(t, MessagePresence); len(msg.Peers) != 1; msg = bob.next(t, MessagePresence) {
+		}
+		close(release)
+
+		select {
+		case err := <-flushed:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("flush did not finish")
+		}
+	})
+
+	t.Run("DeletedNoteEndsSession", func(t *testing.T) {
+		hub, store := newTestHub()
+		noteID := uuid.New()
+
+		alice := newFakeConn()
+		defer alice.Close()
+
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), Permission: entities.PermissionEdit}, alice)
+		alice.next(t, MessageSync)
+		alice.sendJSON(t, map[string]any{"type": MessageUpdate, "update": []byte{1}})
+		alice.sendJSON(t, map[string]any{"type": MessageSnapshot, "seq": 1, "state": []byte{9}, "content": stored})
+		alice.roundTrip(t)
+
+		store.mu.Lock()
+		store.deleted[noteID] = true
+		store.mu.Unlock()
+		require.NoError(t, hub.Flush(context.Background()))
+
+		assert.Equal(t, "the note was deleted", alice.next(t, MessageError).Message)
+	})
+
+	t.Run("ExpiredTokenIsDisconnected", func(t *testing.T) {
+		hub, _ := newTestHub()
+		noteID := uuid.New()
+
+		alice := newFakeConn()
+		defer alice.Close()
+
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), Permission: entities.PermissionEdit, ExpiresAt: time.Now().Add(-time.Second)}, alice)
+		alice.next(t, MessageSync)
+
+		alice.sendJSON(t, map[string]any{"type": MessageUpdate, "update": []byte{1}})
+		assert.Equal(t, "your session has expired", alice.next(t, MessageError).Message)
+	})
+
+	t.Run("SeedHandedOverWhenSeederLeaves", func(t *testing.T) {
+		hub, _ := newTestHub()
+		noteID := uuid.New()
+
+		alice, bob := newFakeConn(), newFakeConn()
+		defer bob.Close()
+
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), Permission: entities.PermissionEdit}, alice)
+		assert.True(t, alice.next(t, MessageSync).Seed)
+		go hub.Serve(noteID, stored, 0, Peer{UserID: uuid.New(), Permission: entities.PermissionEdit}, bob)
+		assert.False(t, bob.next(t, MessageSync).Seed)
+
+		alice.Close()
+		seed := bob.next(t, MessageSeed)
+		require.NotNil(t, seed.Content)
+		assert.Equal(t, "doc", seed.Content.Type)
+	})
+}
@@ -0,0 +1,70 @@
+package collab
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/domain/note/entities"
+)
+
+// Message types sent by clients
+const (
+	// Yjs update produced by a local edit, relayed to the other peers
+	MessageUpdate = "update"
+	// Merged document of an editor: the Yjs state as a single update, the Tiptap JSON to persist
+	// and the sequence number of the last update the editor received
+	MessageSnapshot = "snapshot"
+	// Cursor position or selection of the peer, any JSON the editors agree on. The server
+	// sends presence messages with every peer in the room whenever someone joins, leaves
+	// or moves.
+	MessagePresence = "presence"
+)
+
+// Message types sent by the server
+const (
+	// First message after joining, carries the shared document and who's in the room
+	MessageSync = "sync"
+	// Asks an editor to build the shared document from the stored content
+	MessageSeed = "seed"
+	// Asks an editor for a snapshot so the update log can be compacted
+	MessageSnapshotRequest = "snapshot_request"
+	// The note was changed outside the session. Peers drop their shared document and show the
+	// stored content until an editor seeds a new one, edits must carry the new epoch.
+	MessageReset = "reset"
+	MessageError = "error"
+)
+
+// clientMessage is a message received from a peer. Binary Yjs data is base64 encoded.
+type clientMessage struct {
+	Type    string                  `json:"type"`
+	Update  []byte                  `json:"update,omitempty"`
+	State   []byte                  `json:"state,omitempty"`
+	Seq     int64                   `json:"seq,omitempty"`
+	Epoch   int64                   `json:"epoch,omitempty"` // Epoch of the document updates and snapshots were made on
+	Content *entities.TiptapContent `json:"content,omitempty"`
+	Cursor  json.RawMessage         `json:"cursor,omitempty"`
+}
+
+// serverMessage is a message sent to peers, fields are set depending on the type
+type serverMessage struct {
+	Type    string                  `json:"type"`
+	PeerID  *uuid.UUID              `json:"peer_id,omitempty"`
+	CanEdit *bool                   `json:"can_edit,omitempty"`
+	Seq     *int64                  `json:"seq,omitempty"`
+	Epoch   int64                   `json:"epoch,omitempty"` // Current epoch of the shared document
+	Update  []byte                  `json:"update,omitempty"`
+	Updates [][]byte                `json:"updates,omitempty"`
+	Seed    bool                    `json:"seed,omitempty"`
+	Content *entities.TiptapContent `json:"content,omitempty"`
+	Peers   []PeerInfo              `json:"peers,omitempty"`
+	Message string                  `json:"message,omitempty"`
+}
+
+// PeerInfo is the presence of a peer as seen by the others
+type PeerInfo struct {
+	PeerID      uuid.UUID       `json:"peer_id"`
+	UserID      uuid.UUID       `json:"user_id"`
+	DisplayName string          `json:"display_name"`
+	CanEdit     bool            `json:"can_edit"`
+	Cursor      json.RawMessage `json:"cursor,omitempty"`
+}
@@ -36,5 +36,45 @@ func DefaultConfig() Config {
 			SenderEmail:  "\"mailer@example.com\"",
 			SMTPSecure:   false,
 		},
+		Security: SecurityConfig{
+			PasswordMinLength:        8,
+			PasswordMaxLength:        128,
+			PasswordRequireUpper:     false,
+			PasswordRequireLower:     false,
+			PasswordRequireDigit:     false,
+			PasswordRequireSymbol:    false,
+			PasswordDisallowIdentity: true,
+			BreachedPasswordsFile:    "",
+			Argon2Memory:             65536,
+			Argon2Iterations:         3,
+			Argon2Parallelism:        2,
+			Argon2SaltLength:         16,
+			Argon2KeyLength:          32,
+			ReauthWindowMinutes:      10,
+			AccountDeletionGraceDays: 14,
+			ImpersonationMinutes:     15,
+			AuditRetentionDays:       365,
+		},
+		Storage: StorageConfig{
+			Path:                       "./storage",
+			DataExportExpiryHours:      48,
+			NoteTombstoneRetentionDays: 90,
+			Driver:                     StorageDriverLocal,
+			S3Region:                   "us-east-1",
+			S3UseSSL:                   true,
+			AttachmentMaxSizeMB:        10,
+			AttachmentQuotaMB:          500,
+			AttachmentURLExpiryMinutes: 60,
+			AttachmentOrphanGraceHours: 24,
+		},
+		Jobs: JobsConfig{
+			AccountPurgeIntervalMinutes:   60,
+			DataExportIntervalMinutes:     1,
+			AuditPurgeIntervalMinutes:     1440,
+			NotePurgeIntervalMinutes:      1440,
+			AttachmentGCIntervalMinutes:   60,
+			CollabSnapshotIntervalSeconds: 15,
+			ReminderIntervalSeconds:       30,
+		},
 	}
 }
@@ -9,11 +9,23 @@ const (
 	JWTAlgorithmRS256 JWTAlgorithm = "RS256"
 )
 
+// StorageDriver selects the backend attachments are stored in
+// Supported values: "local", "s3"
+type StorageDriver string
+
+const (
+	StorageDriverLocal StorageDriver = "local"
+	StorageDriverS3    StorageDriver = "s3"
+)
+
 type Config struct {
 	App      AppConfig      `env:",squash"`
 	Database DatabaseConfig `env:",squash"`
 	Logging  LoggingConfig  `env:",squash"`
 	Mailer   MailerConfig   `env:",squash"`
+	Security SecurityConfig `env:",squash"`
+	Storage  StorageConfig  `env:",squash"`
+	Jobs     JobsConfig     `env:",squash"`
 }
 
 type AppConfig struct {
@@ -53,3 +65,57 @@ type MailerConfig struct {
 	SenderEmail  string `env:"SMTP_SENDER_EMAIL"`
 	SMTPSecure   bool   `env:"SMTP_SECURE"`
 }
+
+type SecurityConfig struct {
+	PasswordMinLength        int    `env:"PASSWORD_MIN_LENGTH"`
+	PasswordMaxLength        int    `env:"PASSWORD_MAX_LENGTH"`
+	PasswordRequireUpper     bool   `env:"PASSWORD_REQUIRE_UPPERCASE"`
+	PasswordRequireLower     bool   `env:"PASSWORD_REQUIRE_LOWERCASE"`
+	PasswordRequireDigit     bool   `env:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSymbol    bool   `env:"PASSWORD_REQUIRE_SYMBOL"`
+	PasswordDisallowIdentity bool   `env:"PASSWORD_DISALLOW_IDENTITY"`  // reject passwords containing the email/username
+	BreachedPasswordsFile    string `env:"PASSWORD_BREACHED_LIST_FILE"` // optional SHA-1 list, one hash per line
+	Argon2Memory             int    `env:"ARGON2_MEMORY_KIB"`
+	Argon2Iterations         int    `env:"ARGON2_ITERATIONS"`
+	Argon2Parallelism        int    `env:"ARGON2_PARALLELISM"`
+	Argon2SaltLength         int    `env:"ARGON2_SALT_LENGTH"`
+	Argon2KeyLength          int    `env:"ARGON2_KEY_LENGTH"`
+	ReauthWindowMinutes      int    `env:"REAUTH_WINDOW_MINUTES"`              // how long a re-authentication unlocks sensitive operations
+	AccountDeletionGraceDays int    `env:"ACCOUNT_DELETION_GRACE_DAYS"`        // days a user can cancel a deletion request
+	ImpersonationMinutes     int    `env:"IMPERSONATION_TOKEN_EXPIRY_MINUTES"` // lifetime of an admin impersonation token
+	AuditRetentionDays       int    `env:"AUDIT_RETENTION_DAYS"`               // days audit events are kept before being purged
+}
+
+type StorageConfig struct {
+	Path                  string `env:"STORAGE_PATH"`             // local directory for generated files
+	DataExportExpiryHours int    `env:"DATA_EXPORT_EXPIRY_HOURS"` // lifetime of a data export and its download link
+	// days tombstones of deleted notes are kept for offline clients, also the lifetime of sync tokens
+	NoteTombstoneRetentionDays int `env:"NOTE_TOMBSTONE_RETENTION_DAYS"`
+
+	// Where note attachments are stored, "local" keeps them under Path
+	Driver            StorageDriver `env:"STORAGE_DRIVER"`
+	S3Endpoint        string        `env:"S3_ENDPOINT"` // host[:port] of the S3 compatible service, e.g. MinIO
+	S3Region          string        `env:"S3_REGION"`
+	S3Bucket          string        `env:"S3_BUCKET"` // created on startup when missing
+	S3AccessKeyID     string        `env:"S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey string        `env:"S3_SECRET_ACCESS_KEY"`
+	S3UseSSL          bool          `env:"S3_USE_SSL"`
+
+	AttachmentMaxSizeMB        int `env:"ATTACHMENT_MAX_SIZE_MB"`        // largest single upload
+	AttachmentQuotaMB          int `env:"ATTACHMENT_QUOTA_MB"`           // total size of the attachments a user uploaded
+	AttachmentURLExpiryMinutes int `env:"ATTACHMENT_URL_EXPIRY_MINUTES"` // lifetime of signed download links
+	// hours an attachment no note refers to is kept before it is deleted, covers uploads not saved yet and undo
+	AttachmentOrphanGraceHours int `env:"ATTACHMENT_ORPHAN_GRACE_HOURS"`
+}
+
+type JobsConfig struct {
+	AccountPurgeIntervalMinutes int `env:"JOB_ACCOUNT_PURGE_INTERVAL_MINUTES"`
+	DataExportIntervalMinutes   int `env:"JOB_DATA_EXPORT_INTERVAL_MINUTES"`
+	AuditPurgeIntervalMinutes   int `env:"JOB_AUDIT_PURGE_INTERVAL_MINUTES"`
+	NotePurgeIntervalMinutes    int `env:"JOB_NOTE_PURGE_INTERVAL_MINUTES"`
+	AttachmentGCIntervalMinutes int `env:"JOB_ATTACHMENT_GC_INTERVAL_MINUTES"`
+	// Persisting of documents merged in live editing sessions, in seconds
+	CollabSnapshotIntervalSeconds int `env:"JOB_COLLAB_SNAPSHOT_INTERVAL_SECONDS"`
+	// Sending of due reminders, in seconds, also how late a reminder may arrive
+	ReminderIntervalSeconds int `env:"JOB_REMINDER_INTERVAL_SECONDS"`
+}
@@ -76,6 +76,112 @@ func validateConfig(config *Config) error {
 		}
 	}
 
+	// Password policy
+	if config.Security.PasswordMinLength < 1 {
+		errs = append(errs, "password min length must be >= 1")
+	}
+	if config.Security.PasswordMaxLength < config.Security.PasswordMinLength {
+		errs = append(errs, "password max length must be >= password min length")
+	}
+	if config.Security.PasswordMaxLength > 1024 {
+		errs = append(errs, fmt.Sprintf("password max length too large: %d (max 1024)", config.Security.PasswordMaxLength))
+	}
+
+	// Argon2 password hashing parameters
+	if config.Security.Argon2Iterations < 1 {
+		errs = append(errs, "argon2 iterations must be >= 1")
+	}
+	if config.Security.Argon2Parallelism < 1 || config.Security.Argon2Parallelism > 255 {
+		errs = append(errs, fmt.Sprintf("invalid argon2 parallelism: %d (must be 1-255)", config.Security.Argon2Parallelism))
+	}
+	if config.Security.Argon2Memory < 8*config.Security.Argon2Parallelism {
+		errs = append(errs, "argon2 memory must be at least 8 KiB per parallelism lane")
+	}
+	if config.Security.Argon2SaltLength < 8 {
+		errs = append(errs, "argon2 salt length must be >= 8 bytes")
+	}
+	if config.Security.Argon2KeyLength < 16 {
+		errs = append(errs, "argon2 key length must be >= 16 bytes")
+	}
+
+	// Re-authentication
+	if config.Security.ReauthWindowMinutes < 1 {
+		errs = append(errs, "reauth window must be >= 1 minute")
+	}
+
+	// Account deletion
+	if config.Security.AccountDeletionGraceDays < 1 {
+		errs = append(errs, "account deletion grace days must be >= 1")
+	}
+
+	// Impersonation
+	if config.Security.ImpersonationMinutes < 1 || config.Security.ImpersonationMinutes > 60 {
+		errs = append(errs, fmt.Sprintf("invalid impersonation token expiry: %d (must be 1-60 minutes)", config.Security.ImpersonationMinutes))
+	}
+
+	// Audit trail
+	if config.Security.AuditRetentionDays < 1 {
+		errs = append(errs, "audit retention days must be >= 1")
+	}
+
+	// Storage
+	if strings.TrimSpace(config.Storage.Path) == "" {
+		errs = append(errs, "storage path is required")
+	}
+	if config.Storage.DataExportExpiryHours < 1 {
+		errs = append(errs, "data export expiry must be >= 1 hour")
+	}
+	if config.Storage.NoteTombstoneRetentionDays < 1 {
+		errs = append(errs, "note tombstone retention days must be >= 1")
+	}
+	switch config.Storage.Driver {
+	case StorageDriverLocal:
+	case StorageDriverS3:
+		if strings.TrimSpace(config.Storage.S3Endpoint) == "" || strings.TrimSpace(config.Storage.S3Bucket) == "" {
+			errs = append(errs, "s3 endpoint and bucket are required for the s3 storage driver")
+		}
+		if config.Storage.S3AccessKeyID == "" || config.Storage.S3SecretAccessKey == "" {
+			errs = append(errs, "s3 access key id and secret access key are required for the s3 storage driver")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("invalid storage driver: %s (must be local or s3)", config.Storage.Driver))
+	}
+	if config.Storage.AttachmentMaxSizeMB < 1 {
+		errs = append(errs, "attachment max size must be >= 1 MB")
+	}
+	if config.Storage.AttachmentQuotaMB < config.Storage.AttachmentMaxSizeMB {
+		errs = append(errs, "attachment quota must be >= the attachment max size")
+	}
+	if config.Storage.AttachmentURLExpiryMinutes < 1 {
+		errs = append(errs, "attachment url expiry must be >= 1 minute")
+	}
+	if config.Storage.AttachmentOrphanGraceHours < 1 {
+		errs = append(errs, "attachment orphan grace period must be >= 1 hour")
+	}
+
+	// Background jobs
+	if config.Jobs.AccountPurgeIntervalMinutes < 1 {
+		errs = append(errs, "account purge interval must be >= 1 minute")
+	}
+	if config.Jobs.DataExportIntervalMinutes < 1 {
+		errs = append(errs, "data export interval must be >= 1 minute")
+	}
+	if config.Jobs.AuditPurgeIntervalMinutes < 1 {
+		errs = append(errs, "audit purge interval must be >= 1 minute")
+	}
+	if config.Jobs.NotePurgeIntervalMinutes < 1 {
+		errs = append(errs, "note purge interval must be >= 1 minute")
+	}
+	if config.Jobs.AttachmentGCIntervalMinutes < 1 {
+		errs = append(errs, "attachment garbage collection interval must be >= 1 minute")
+	}
+	if config.Jobs.CollabSnapshotIntervalSeconds < 1 {
+		errs = append(errs, "collaboration snapshot interval must be >= 1 second")
+	}
+	if config.Jobs.ReminderIntervalSeconds < 1 {
+		errs = append(errs, "reminder interval must be >= 1 second")
+	}
+
 	if len(errs) > 0 {
 		return errors.New(strings.Join(errs, "; "))
 	}
@@ -0,0 +1,46 @@
+package admin
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/internal/audit"
+)
+
+type Options struct {
+	AuditRecorder audit.RecorderInterface       // Audit trail for admin actions (required)
+	UserService   services.UserServiceInterface // User service (required)
+	AuthService   services.AuthServiceInterface // Auth service, manages sessions and passwords (required)
+	NoteService   services.NoteServiceInterface // Note service, used for user statistics (required)
+	Logger        *slog.Logger                  // Slog logger instance (optional)
+}
+
+type AdminDomain struct {
+	logger       *slog.Logger
+	adminService *services.AdminService
+}
+
+func NewAdminDomain(opts *Options) *AdminDomain {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{}))
+	}
+
+	adminService := services.NewAdminService(services.AdminServiceOpts{
+		AuditRecorder: opts.AuditRecorder,
+		UserService:   opts.UserService,
+		AuthService:   opts.AuthService,
+		NoteService:   opts.NoteService,
+		Logger:        logger,
+	})
+
+	return &AdminDomain{
+		logger:       logger,
+		adminService: adminService,
+	}
+}
+
+func (d *AdminDomain) GetAdminService() services.AdminServiceInterface {
+	return d.adminService
+}
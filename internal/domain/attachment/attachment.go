@@ -0,0 +1,78 @@
+package attachment
+
+import (
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/internal/domain/attachment/repositories"
+	"github.com/rayhan889/neatspace/internal/infrasturcture/storage"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+type Options struct {
+	PgPool      *pgxpool.Pool                 // PostgreSQL connection pool (required)
+	NoteService services.NoteServiceInterface // Note service, decides who may see an attachment (required)
+	Storage     storage.Storage               // Where the files are kept (required)
+	Logger      *slog.Logger                  // Slog logger instance (optional)
+	BaseURL     string                        // Base URL for constructing download links (required)
+
+	URLSigner   *apputils.URLSigner // Signer for download links (required)
+	URLExpiry   time.Duration       // How long a download link works (default: 1 hour)
+	MaxSize     int64               // Largest single upload in bytes (default: 10 MiB)
+	Quota       int64               // Total bytes a user may upload (default: 500 MiB)
+	OrphanGrace time.Duration       // How long an attachment no note refers to is kept (default: 24 hours)
+}
+
+type AttachmentDomain struct {
+	logger            *slog.Logger
+	attachmentService *services.AttachmentService
+}
+
+func NewAttachmentDomain(opts *Options) *AttachmentDomain {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{}))
+	}
+
+	urlExpiry := opts.URLExpiry
+	if urlExpiry <= 0 {
+		urlExpiry = time.Hour
+	}
+	maxSize := opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = 10 << 20
+	}
+	quota := opts.Quota
+	if quota <= 0 {
+		quota = 500 << 20
+	}
+	orphanGrace := opts.OrphanGrace
+	if orphanGrace <= 0 {
+		orphanGrace = 24 * time.Hour
+	}
+
+	attachmentService := services.NewAttachmentService(services.AttachmentServiceOpts{
+		AttachmentRepo: repositories.NewAttachmentRepository(opts.PgPool, logger),
+		NoteService:    opts.NoteService,
+		Storage:        opts.Storage,
+		Logger:         logger,
+		BaseURL:        opts.BaseURL,
+		URLSigner:      opts.URLSigner,
+		URLExpiry:      urlExpiry,
+		MaxSize:        maxSize,
+		Quota:          quota,
+		OrphanGrace:    orphanGrace,
+	})
+
+	return &AttachmentDomain{
+		logger:            logger,
+		attachmentService: attachmentService,
+	}
+}
+
+func (d *AttachmentDomain) GetAttachmentService() services.AttachmentServiceInterface {
+	return d.attachmentService
+}
@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AttachmentTable        = "public.attachments"
+	NoteAttachmentRefTable = "public.note_attachment_refs"
+)
+
+type AttachmentEntity struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	UserID         *uuid.UUID `json:"user_id" db:"user_id"`           // Uploader, nil once their account is gone
+	NoteID         *uuid.UUID `json:"note_id" db:"note_id"`           // Note it was uploaded to, nil once the note is purged
+	WorkspaceID    *uuid.UUID `json:"workspace_id" db:"workspace_id"` // Workspace of that note, only its notes may show the attachment
+	StorageKey     string     `json:"-" db:"storage_key"`
+	FileName       string     `json:"file_name" db:"file_name"`
+	ContentType    string     `json:"content_type" db:"content_type"`
+	SizeBytes      int64      `json:"size_bytes" db:"size_bytes"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UnreferencedAt *time.Time `json:"-" db:"unreferenced_at"` // When the last note referring to it dropped the reference
+}
+
+// ContentPath is the download path of an attachment, also the src image nodes refer to it by
+func ContentPath(id uuid.UUID) string {
+	return "/api/v1/attachments/" + id.String() + "/content"
+}
+
+// StorageKey returns the key an attachment is stored under, spread over directories by id prefix
+func StorageKey(id uuid.UUID) string {
+	s := id.String()
+	return "attachments/" + s[:2] + "/" + s
+}
@@ -0,0 +1,251 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	attachmentEntity "github.com/rayhan889/neatspace/internal/domain/attachment/entities"
+)
+
+// ErrQuotaExceeded is returned when an upload would take the user over their storage quota
+var ErrQuotaExceeded = errors.New("attachment quota exceeded")
+
+const attachmentColumns = `id, user_id, note_id, workspace_id, storage_key, file_name, content_type, size_bytes, created_at, unreferenced_at`
+
+type AttachmentRepositoryInterface interface {
+	CreateAttachment(ctx context.Context, attachment *attachmentEntity.AttachmentEntity, quota int64) error
+	GetAttachmentByID(ctx context.Context, attachmentID uuid.UUID) (*attachmentEntity.AttachmentEntity, error)
+	ListAttachmentsByNoteID(ctx context.Context, noteID uuid.UUID) ([]attachmentEntity.AttachmentEntity, error)
+	ListReferencingNoteIDs(ctx context.Context, attachmentID uuid.UUID) ([]uuid.UUID, error)
+	ListReferencedAttachmentIDs(ctx context.Context, noteID uuid.UUID) ([]uuid.UUID, error)
+	GetUsedBytes(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteAttachment(ctx context.Context, attachmentID uuid.UUID) error
+	DeleteOrphanedAttachments(ctx context.Context, before time.Time, limit int) ([]attachmentEntity.AttachmentEntity, error)
+}
+
+var _ AttachmentRepositoryInterface = (*AttachmentRepository)(nil)
+
+type AttachmentRepository struct {
+	pgPool *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewAttachmentRepository(pgPool *pgxpool.Pool, logger *slog.Logger) *AttachmentRepository {
+	return &AttachmentRepository{
+		pgPool: pgPool,
+		logger: logger,
+	}
+}
+
+// CreateAttachment inserts the attachment if it fits in the quota of its uploader. Uploads of
+// the same user are serialized with an advisory lock so concurrent ones can't overshoot it.
+func (r *AttachmentRepository) CreateAttachment(ctx context.Context, attachment *attachmentEntity.AttachmentEntity, quota int64) error {
+	err := pgx.BeginFunc(ctx, r.pgPool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0))`, attachment.UserID); err != nil {
+			return err
+		}
+
+		var used int64
+		query := fmt.Sprintf(`SELECT COALESCE(SUM(size_bytes), 0) FROM %s WHERE user_id = $1`, attachmentEntity.AttachmentTable)
+		if err := tx.QueryRow(ctx, query, attachment.UserID).Scan(&used); err != nil {
+			return err
+		}
+		if used+attachment.SizeBytes > quota {
+			return ErrQuotaExceeded
+		}
+
+		query = fmt.Sprintf(`INSERT INTO %s (id, user_id, note_id, workspace_id, storage_key, file_name, content_type, size_bytes, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`, attachmentEntity.AttachmentTable)
+		_, err := tx.Exec(ctx, query,
+			attachment.ID,
+			attachment.UserID,
+			attachment.NoteID,
+			attachment.WorkspaceID,
+			attachment.StorageKey,
+			attachment.FileName,
+			attachment.ContentType,
+			attachment.SizeBytes,
+			attachment.CreatedAt,
+		)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrQuotaExceeded) {
+			r.logger.Warn("attachment quota exceeded", slog.String("op", "CreateAttachment"), slog.String("user_id", attachment.UserID.String()))
+			return err
+		}
+		r.logger.Error("failed to create attachment", slog.String("op", "CreateAttachment"), slog.String("error", err.Error()))
+		return err
+	}
+
+	r.logger.Info("attachment created", slog.String("op", "CreateAttachment"), slog.String("attachment_id", attachment.ID.String()))
+	return nil
+}
+
+func (r *AttachmentRepository) GetAttachmentByID(ctx context.Context, attachmentID uuid.UUID) (*attachmentEntity.AttachmentEntity, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, attachmentColumns, attachmentEntity.AttachmentTable)
+
+	attachment, err := scanAttachment(r.pgPool.QueryRow(ctx, query, attachmentID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get attachment by id", slog.String("op", "GetAttachmentByID"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (r *AttachmentRepository) ListAttachmentsByNoteID(ctx context.Context, noteID uuid.UUID) ([]attachmentEntity.AttachmentEntity, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE note_id = $1 ORDER BY created_at`, attachmentColumns, attachmentEntity.AttachmentTable)
+
+	rows, err := r.pgPool.Query(ctx, query, noteID)
+	if err != nil {
+		r.logger.Error("failed to query attachments", slog.String("op", "ListAttachmentsByNoteID"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	attachments, err := collectAttachments(rows)
+	if err != nil {
+		r.logger.Error("failed to scan attachment row", slog.String("op", "ListAttachmentsByNoteID"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// ListReferencingNoteIDs returns the notes with an image of the attachment in their content
+func (r *AttachmentRepository) ListReferencingNoteIDs(ctx context.Context, attachmentID uuid.UUID) ([]uuid.UUID, error) {
+	query := fmt.Sprintf(`SELECT note_id FROM %s WHERE attachment_id = $1`, attachmentEntity.NoteAttachmentRefTable)
+
+	rows, err := r.pgPool.Query(ctx, query, attachmentID)
+	if err != nil {
+		r.logger.Error("failed to query attachment references", slog.String("op", "ListReferencingNoteIDs"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	noteIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		r.logger.Error("failed to scan attachment reference row", slog.String("op", "ListReferencingNoteIDs"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return noteIDs, nil
+}
+
+// ListReferencedAttachmentIDs returns the attachments shown by images in the note's content
+func (r *AttachmentRepository) ListReferencedAttachmentIDs(ctx context.Context, noteID uuid.UUID) ([]uuid.UUID, error) {
+	query := fmt.Sprintf(`SELECT attachment_id FROM %s WHERE note_id = $1`, attachmentEntity.NoteAttachmentRefTable)
+
+	rows, err := r.pgPool.Query(ctx, query, noteID)
+	if err != nil {
+		r.logger.Error("failed to query note attachment references", slog.String("op", "ListReferencedAttachmentIDs"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	attachmentIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		r.logger.Error("failed to scan note attachment reference row", slog.String("op", "ListReferencedAttachmentIDs"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return attachmentIDs, nil
+}
+
+// GetUsedBytes returns the total size of the attachments the user uploaded
+func (r *AttachmentRepository) GetUsedBytes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	query := fmt.Sprintf(`SELECT COALESCE(SUM(size_bytes), 0) FROM %s WHERE user_id = $1`, attachmentEntity.AttachmentTable)
+
+	var used int64
+	if err := r.pgPool.QueryRow(ctx, query, userID).Scan(&used); err != nil {
+		r.logger.Error("failed to sum attachment sizes", slog.String("op", "GetUsedBytes"), slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	return used, nil
+}
+
+func (r *AttachmentRepository) DeleteAttachment(ctx context.Context, attachmentID uuid.UUID) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, attachmentEntity.AttachmentTable)
+
+	if _, err := r.pgPool.Exec(ctx, query, attachmentID); err != nil {
+		r.logger.Error("failed to delete attachment", slog.String("op", "DeleteAttachment"), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteOrphanedAttachments removes up to limit attachments no note refers to that were
+// uploaded or last unreferenced before the given time, and returns them so their objects
+// can be deleted from storage
+func (r *AttachmentRepository) DeleteOrphanedAttachments(ctx context.Context, before time.Time, limit int) ([]attachmentEntity.AttachmentEntity, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %[1]s
+		WHERE id IN (
+			SELECT a.id FROM %[1]s a
+			WHERE GREATEST(a.created_at, a.unreferenced_at) < $1
+				AND NOT EXISTS (SELECT 1 FROM %[2]s r WHERE r.attachment_id = a.id)
+			ORDER BY a.created_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %[3]s
+	`, attachmentEntity.AttachmentTable, attachmentEntity.NoteAttachmentRefTable, attachmentColumns)
+
+	rows, err := r.pgPool.Query(ctx, query, before, limit)
+	if err != nil {
+		r.logger.Error("failed to delete orphaned attachments", slog.String("op", "DeleteOrphanedAttachments"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	attachments, err := collectAttachments(rows)
+	if err != nil {
+		r.logger.Error("failed to delete orphaned attachments", slog.String("op", "DeleteOrphanedAttachments"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func scanAttachment(row pgx.Row) (*attachmentEntity.AttachmentEntity, error) {
+	var attachment attachmentEntity.AttachmentEntity
+	err := row.Scan(
+		&attachment.ID,
+		&attachment.UserID,
+		&attachment.NoteID,
+		&attachment.WorkspaceID,
+		&attachment.StorageKey,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.SizeBytes,
+		&attachment.CreatedAt,
+		&attachment.UnreferencedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func collectAttachments(rows pgx.Rows) ([]attachmentEntity.AttachmentEntity, error) {
+	defer rows.Close()
+
+	var attachments []attachmentEntity.AttachmentEntity
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *attachment)
+	}
+
+	return attachments, rows.Err()
+}
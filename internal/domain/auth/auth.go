@@ -9,16 +9,25 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/lestrrat-go/jwx/jwa"
 	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/internal/audit"
 	authRepo "github.com/rayhan889/neatspace/internal/domain/auth/repositories"
 	"github.com/rayhan889/neatspace/internal/notification"
+	"github.com/rayhan889/neatspace/pkg/apputils"
 )
 
 type Options struct {
-	PgPool      *pgxpool.Pool                 // PostgreSQL connection pool (required)
-	UserService services.UserServiceInterface // User service (optional)
-	Logger      *slog.Logger                  // Slog logger instance (optional)
-	Mailer      *notification.Mailer          // Mailer service (optional)
-	BaseURL     string                        // Base URL for constructing links (required)
+	PgPool        *pgxpool.Pool                 // PostgreSQL connection pool (required)
+	UserService   services.UserServiceInterface // User service (optional)
+	AuditRecorder audit.RecorderInterface       // Audit trail for security events (required)
+	Logger        *slog.Logger                  // Slog logger instance (optional)
+	Mailer        *notification.Mailer          // Mailer service (optional)
+	BaseURL       string                        // Base URL for constructing links (required)
+
+	PasswordPolicy *apputils.PasswordPolicy // Password policy for new passwords (optional)
+	PasswordHasher *apputils.PasswordHasher // Argon2 password hasher (optional)
+
+	AccountDeletionGracePeriod time.Duration // How long a deletion request can be cancelled (default: 14 days)
+	ImpersonationExpiry        time.Duration // Lifetime of an admin impersonation token (default: 15 minutes)
 
 	JWTSecretKey       []byte                 // Secret key for signing JWTs
 	AccessTokenExpiry  time.Duration          // Access token expiration duration
@@ -43,15 +52,20 @@ func NewAuthDomain(opts *Options) *AuthDomain {
 	}
 
 	authService := services.NewAuthService(services.AuthServiceOpts{
-		AuthRepository:     authRepo.NewAuthRepository(opts.PgPool, logger),
-		UserService:        opts.UserService,
-		Logger:             logger,
-		Mailer:             opts.Mailer,
-		BaseURL:            opts.BaseURL,
-		JWTSecretKey:       opts.JWTSecretKey,
-		AccessTokenExpiry:  opts.AccessTokenExpiry,
-		RefreshTokenExpiry: opts.RefreshTokenExpiry,
-		SigningAlg:         opts.SigningAlg,
+		AuthRepository:             authRepo.NewAuthRepository(opts.PgPool, logger),
+		UserService:                opts.UserService,
+		AuditRecorder:              opts.AuditRecorder,
+		Logger:                     logger,
+		Mailer:                     opts.Mailer,
+		BaseURL:                    opts.BaseURL,
+		PasswordPolicy:             opts.PasswordPolicy,
+		PasswordHasher:             opts.PasswordHasher,
+		AccountDeletionGracePeriod: opts.AccountDeletionGracePeriod,
+		ImpersonationExpiry:        opts.ImpersonationExpiry,
+		JWTSecretKey:               opts.JWTSecretKey,
+		AccessTokenExpiry:          opts.AccessTokenExpiry,
+		RefreshTokenExpiry:         opts.RefreshTokenExpiry,
+		SigningAlg:                 opts.SigningAlg,
 	})
 
 	return &AuthDomain{
@@ -80,6 +94,9 @@ func (opts *Options) validateAndSetDefaults() error {
 	if opts.UserService == nil {
 		return errors.New("userService is required")
 	}
+	if opts.AuditRecorder == nil {
+		return errors.New("auditRecorder is required")
+	}
 	if opts.Logger == nil {
 		return errors.New("logger is required")
 	}
@@ -89,6 +106,18 @@ func (opts *Options) validateAndSetDefaults() error {
 	if len(opts.JWTSecretKey) == 0 {
 		return errors.New("jWTSecretKey is required")
 	}
+	if opts.PasswordPolicy == nil {
+		opts.PasswordPolicy = apputils.DefaultPasswordPolicy()
+	}
+	if opts.PasswordHasher == nil {
+		opts.PasswordHasher = apputils.NewPasswordHasher()
+	}
+	if opts.AccountDeletionGracePeriod == 0 {
+		opts.AccountDeletionGracePeriod = 14 * 24 * time.Hour
+	}
+	if opts.ImpersonationExpiry == 0 {
+		opts.ImpersonationExpiry = 15 * time.Minute
+	}
 	if opts.SigningAlg == "" {
 		opts.SigningAlg = jwa.HS256
 	}
@@ -11,10 +11,11 @@ import (
 const UserPasswordTable = "public.user_passwords"
 
 type UserPasswordEntity struct {
-	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
-	PasswordHash []byte     `json:"password_hash" db:"password_hash"`
-	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt    *time.Time `json:"updated_at" db:"updated_at"`
+	UserID          uuid.UUID  `json:"user_id" db:"user_id"`
+	PasswordHash    []byte     `json:"password_hash" db:"password_hash"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at" db:"updated_at"`
+	ResetRequiredAt *time.Time `json:"reset_required_at" db:"reset_required_at"` // Set by an admin, the password can't sign in until reset
 }
 
 const SessionTable = "public.sessions"
@@ -32,8 +33,22 @@ type SessionEntity struct {
 	RefreshedAt       *time.Time `json:"refreshed_at" db:"refreshed_at"`
 	RevokedAt         *time.Time `json:"revoked_at" db:"revoked_at"`
 	RevokedBy         *uuid.UUID `json:"revoked_by" db:"revoked_by"`
+	AuthTime          *time.Time `json:"auth_time" db:"auth_time"`             // Last time the user proved their identity in this session
+	AMR               []string   `json:"amr" db:"amr"`                         // Authentication methods used, e.g. pwd, otp
+	ImpersonatorID    *uuid.UUID `json:"impersonator_id" db:"impersonator_id"` // Admin acting as the user, nil for regular sessions
 }
 
+// IsImpersonated reports whether the session was started by an admin acting as the user
+func (s *SessionEntity) IsImpersonated() bool {
+	return s.ImpersonatorID != nil
+}
+
+// Authentication method references stored in SessionEntity.AMR (RFC 8176)
+const (
+	AuthMethodPassword = "pwd"
+	AuthMethodOTP      = "otp"
+)
+
 const RefreshTokenTable = "public.refresh_tokens"
 
 type RefreshToken struct {
@@ -57,8 +72,21 @@ type OneTimeTokenSubject string
 const (
 	OneTimeTokenSubjectEmailOTP          OneTimeTokenSubject = "email_otp"
 	OneTimeTokenSubjectEmailVerification OneTimeTokenSubject = "email_verification"
+	OneTimeTokenSubjectEmailChange       OneTimeTokenSubject = "email_change"
+	OneTimeTokenSubjectEmailChangeCancel OneTimeTokenSubject = "email_change_cancel"
+	OneTimeTokenSubjectReauthentication  OneTimeTokenSubject = "reauthentication"
+	OneTimeTokenSubjectAccountDeletion   OneTimeTokenSubject = "account_deletion_cancel"
+	OneTimeTokenSubjectPasswordReset     OneTimeTokenSubject = "password_reset"
+	OneTimeTokenSubjectWorkspaceInvite   OneTimeTokenSubject = "workspace_invitation"
 )
 
+// OneTimeTokenLimit bounds how often tokens of a subject are issued to a user
+type OneTimeTokenLimit struct {
+	Cooldown time.Duration // Minimum time between two tokens
+	Window   time.Duration // Period Max applies to
+	Max      int           // Tokens issued within Window
+}
+
 // OneTimeToken represents a one-time-use token for sensitive authentication flows.
 // This table is used for various flows such as email verification, password reset,
 // multi-factor authentication (MFA), and reauthentication. Each token is associated
@@ -78,6 +106,36 @@ type OneTimeToken struct {
 	CreatedAt  time.Time           `json:"created_at" db:"created_at"`       // When the token was created
 	ExpiresAt  time.Time           `json:"expires_at" db:"expires_at"`       // When the token expires
 	LastSentAt *time.Time          `json:"last_sent_at" db:"last_sent_at"`   // Last time the token was sent (for throttling)
+	Attempts   int                 `json:"attempts" db:"attempts"`           // Number of times a code was checked against the token
+}
+
+const LoginEventTable = "public.login_events"
+
+// Sign-in methods recorded in LoginEventEntity.Method
+const (
+	LoginMethodEmailPassword = "email_password"
+)
+
+// Reasons recorded in LoginEventEntity.FailureReason
+const (
+	LoginFailureUnknownUser      = "unknown_user"
+	LoginFailureInvalidPassword  = "invalid_password"
+	LoginFailureEmailNotVerified = "email_not_verified"
+	LoginFailureDeactivated      = "account_deactivated"
+	LoginFailureResetRequired    = "password_reset_required"
+)
+
+// LoginEventEntity records a single sign-in attempt
+type LoginEventEntity struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	UserID        *uuid.UUID `json:"user_id" db:"user_id"`               // Nil when the identifier matched no account
+	Identifier    string     `json:"identifier" db:"identifier"`         // Email or handle used for the attempt
+	Method        string     `json:"method" db:"method"`                 // Sign-in method, e.g. email_password
+	Success       bool       `json:"success" db:"success"`               // Whether the attempt signed the user in
+	FailureReason *string    `json:"failure_reason" db:"failure_reason"` // Why the attempt failed, nil on success
+	IPAddress     *net.IP    `json:"ip_address" db:"ip_address"`
+	UserAgent     *string    `json:"user_agent" db:"user_agent"` // Summarized user agent
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
 }
 
 type UserWithCredentials struct {
@@ -10,19 +10,34 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	authEntity "github.com/rayhan889/neatspace/internal/domain/auth/entities"
+	userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
+	"github.com/rayhan889/neatspace/pkg/apputils"
 )
 
 type AuthRepositoryInterface interface {
 	FindAllOneTimeTokens(ctx context.Context) ([]authEntity.OneTimeToken, error)
 	CreateOneTimeToken(ctx context.Context, token *authEntity.OneTimeToken) error
+	CreateLimitedOneTimeToken(ctx context.Context, token *authEntity.OneTimeToken, limit authEntity.OneTimeTokenLimit) (bool, error)
+	ExpireOneTimeToken(ctx context.Context, tokenID uuid.UUID, at time.Time) error
 	UpdateOneTImeTokenLastSentAt(ctx context.Context, tokenID uuid.UUID, lastSentAt time.Time) error
 	DeleteOneTimeToken(ctx context.Context, tokenID uuid.UUID) error
+	DeleteOneTimeTokensByUserID(ctx context.Context, userID uuid.UUID, subjects ...authEntity.OneTimeTokenSubject) error
 	GetOneTimeTokenByTokenHash(ctx context.Context, tokenHash string) (*authEntity.OneTimeToken, error)
+	UseOneTimeTokenAttempt(ctx context.Context, userID uuid.UUID, subject authEntity.OneTimeTokenSubject, maxAttempts int, at time.Time) (*authEntity.OneTimeToken, error)
 	GetUserPasswordByUserID(ctx context.Context, userID uuid.UUID) (*authEntity.UserPasswordEntity, error)
 	CreateSession(ctx context.Context, session *authEntity.SessionEntity) error
+	GetSessionByID(ctx context.Context, sessionID uuid.UUID) (*authEntity.SessionEntity, error)
+	ListSessionsByUserID(ctx context.Context, userID uuid.UUID) ([]authEntity.SessionEntity, error)
+	IsSessionActive(ctx context.Context, sessionID, userID uuid.UUID, at time.Time) (bool, error)
+	UpdateSessionAuthTime(ctx context.Context, sessionID uuid.UUID, authTime time.Time, method string) error
+	RevokeUserSessions(ctx context.Context, userID, revokedBy uuid.UUID, revokedAt time.Time) error
+	RevokeSession(ctx context.Context, sessionID, revokedBy uuid.UUID, revokedAt time.Time) error
 	CreateRefreshToken(ctx context.Context, refreshToken *authEntity.RefreshToken) error
 	CreateUserPassword(ctx context.Context, userPassword *authEntity.UserPasswordEntity) error
 	UpdateUserPassword(ctx context.Context, newPasswordHash []byte, userID uuid.UUID) error
+	SetPasswordResetRequired(ctx context.Context, userID uuid.UUID, at *time.Time) error
+	CreateLoginEvent(ctx context.Context, event *authEntity.LoginEventEntity) error
+	PaginationLoginEventsByUserID(ctx context.Context, userID uuid.UUID, p *apputils.Pagination) (data []authEntity.LoginEventEntity, total int, err error)
 }
 
 var _ AuthRepositoryInterface = (*AuthRepository)(nil)
@@ -99,6 +114,80 @@ func (r *AuthRepository) CreateOneTimeToken(ctx context.Context, token *authEnti
 	return nil
 }
 
+// CreateLimitedOneTimeToken stores the token in place of the user's previous tokens of its
+// subject, unless the limit is reached, and reports whether it was stored. Previous tokens
+// are expired rather than deleted so they count against the limit until they leave its window.
+// Concurrent calls for the same user and subject are serialized.
+func (r *AuthRepository) CreateLimitedOneTimeToken(ctx context.Context, token *authEntity.OneTimeToken, limit authEntity.OneTimeTokenLimit) (bool, error) {
+	created := false
+	err := pgx.BeginFunc(ctx, r.pgPool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1::TEXT || $2::TEXT, 0))`, token.UserID.String(), string(token.Subject)); err != nil {
+			return err
+		}
+
+		windowStart := token.CreatedAt.Add(-limit.Window)
+		pruneQuery := fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1 AND subject = $2 AND created_at <= $3`, authEntity.OneTimeTokenTable)
+		if _, err := tx.Exec(ctx, pruneQuery, token.UserID, string(token.Subject), windowStart); err != nil {
+			return err
+		}
+
+		var issued int
+		var lastCreatedAt *time.Time
+		countQuery := fmt.Sprintf(`SELECT COUNT(*), MAX(created_at) FROM %s WHERE user_id = $1 AND subject = $2`, authEntity.OneTimeTokenTable)
+		if err := tx.QueryRow(ctx, countQuery, token.UserID, string(token.Subject)).Scan(&issued, &lastCreatedAt); err != nil {
+			return err
+		}
+		if issued >= limit.Max || (lastCreatedAt != nil && lastCreatedAt.After(token.CreatedAt.Add(-limit.Cooldown))) {
+			return nil
+		}
+
+		expireQuery := fmt.Sprintf(`UPDATE %s SET expires_at = $3 WHERE user_id = $1 AND subject = $2 AND expires_at > $3`, authEntity.OneTimeTokenTable)
+		if _, err := tx.Exec(ctx, expireQuery, token.UserID, string(token.Subject), token.CreatedAt); err != nil {
+			return err
+		}
+
+		insertQuery := fmt.Sprintf(`INSERT INTO %s (id, user_id, subject, token_hash, relates_to, metadata, created_at, expires_at, last_sent_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`, authEntity.OneTimeTokenTable)
+		if _, err := tx.Exec(ctx, insertQuery,
+			token.ID,
+			token.UserID,
+			token.Subject,
+			token.TokenHash,
+			token.RelatesTo,
+			token.Metadata,
+			token.CreatedAt,
+			token.ExpiresAt,
+			token.LastSentAt,
+		); err != nil {
+			return err
+		}
+
+		created = true
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("failed to insert limited one-time token", slog.String("op", "CreateLimitedOneTimeToken"), slog.String("error", err.Error()))
+		return false, err
+	}
+
+	if created {
+		r.logger.Info("one-time token created", slog.String("op", "CreateLimitedOneTimeToken"), slog.String("token_id", token.ID.String()))
+	}
+	return created, nil
+}
+
+// ExpireOneTimeToken ends the validity of the token at the given time, keeping its row
+func (r *AuthRepository) ExpireOneTimeToken(ctx context.Context, tokenID uuid.UUID, at time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET expires_at = $2 WHERE id = $1 AND expires_at > $2`, authEntity.OneTimeTokenTable)
+
+	if _, err := r.pgPool.Exec(ctx, query, tokenID, at); err != nil {
+		r.logger.Error("failed to expire one-time token", slog.String("op", "ExpireOneTimeToken"), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
 func (r *AuthRepository) UpdateOneTImeTokenLastSentAt(ctx context.Context, tokenID uuid.UUID, lastSentAt time.Time) error {
 	query := fmt.Sprintf(`UPDATE %s SET last_sent_at = $1 WHERE id = $2`, authEntity.OneTimeTokenTable)
 
@@ -133,6 +222,24 @@ func (r *AuthRepository) DeleteOneTimeToken(ctx context.Context, tokenID uuid.UU
 	return nil
 }
 
+func (r *AuthRepository) DeleteOneTimeTokensByUserID(ctx context.Context, userID uuid.UUID, subjects ...authEntity.OneTimeTokenSubject) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1 AND subject = ANY($2)`, authEntity.OneTimeTokenTable)
+
+	subjectValues := make([]string, 0, len(subjects))
+	for _, subject := range subjects {
+		subjectValues = append(subjectValues, string(subject))
+	}
+
+	cmd, err := r.pgPool.Exec(ctx, query, userID, subjectValues)
+	if err != nil {
+		r.logger.Error("failed to delete one-time tokens by user id", slog.String("op", "DeleteOneTimeTokensByUserID"), slog.String("error", err.Error()))
+		return err
+	}
+
+	r.logger.Info("one-time tokens deleted", slog.String("op", "DeleteOneTimeTokensByUserID"), slog.String("user_id", userID.String()), slog.Int64("count", cmd.RowsAffected()))
+	return nil
+}
+
 func (r *AuthRepository) GetOneTimeTokenByTokenHash(ctx context.Context, tokenHash string) (*authEntity.OneTimeToken, error) {
 	var oneTimeToken authEntity.OneTimeToken
 	query := fmt.Sprintf(`SELECT id, user_id, subject, relates_to, metadata, expires_at, last_sent_at FROM %s WHERE token_hash = $1`, authEntity.OneTimeTokenTable)
@@ -159,9 +266,40 @@ func (r *AuthRepository) GetOneTimeTokenByTokenHash(ctx context.Context, tokenHa
 	return &oneTimeToken, nil
 }
 
+// UseOneTimeTokenAttempt counts an attempt against the user's unexpired token of the subject
+// and returns it with its hash, nil when there is none or its maxAttempts are used up.
+// Concurrent attempts are serialized by the row lock, so no more than maxAttempts codes
+// are ever checked against one token.
+func (r *AuthRepository) UseOneTimeTokenAttempt(ctx context.Context, userID uuid.UUID, subject authEntity.OneTimeTokenSubject, maxAttempts int, at time.Time) (*authEntity.OneTimeToken, error) {
+	var oneTimeToken authEntity.OneTimeToken
+	query := fmt.Sprintf(`
+		UPDATE %s SET attempts = attempts + 1
+		WHERE user_id = $1 AND subject = $2 AND attempts < $3 AND expires_at > $4
+		RETURNING id, user_id, subject, token_hash, relates_to, expires_at, attempts`, authEntity.OneTimeTokenTable)
+
+	err := r.pgPool.QueryRow(ctx, query, userID, string(subject), maxAttempts, at).Scan(
+		&oneTimeToken.ID,
+		&oneTimeToken.UserID,
+		&oneTimeToken.Subject,
+		&oneTimeToken.TokenHash,
+		&oneTimeToken.RelatesTo,
+		&oneTimeToken.ExpiresAt,
+		&oneTimeToken.Attempts,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("failed to use one-time token attempt", slog.String("op", "UseOneTimeTokenAttempt"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &oneTimeToken, nil
+}
+
 func (r *AuthRepository) GetUserPasswordByUserID(ctx context.Context, userID uuid.UUID) (*authEntity.UserPasswordEntity, error) {
 	var userPassword authEntity.UserPasswordEntity
-	query := fmt.Sprintf(`SELECT user_id, password_hash, created_at, updated_at FROM %s WHERE user_id = $1`, authEntity.UserPasswordTable)
+	query := fmt.Sprintf(`SELECT user_id, password_hash, created_at, updated_at, reset_required_at FROM %s WHERE user_id = $1`, authEntity.UserPasswordTable)
 
 	row := r.pgPool.QueryRow(ctx, query, userID)
 
@@ -170,6 +308,7 @@ func (r *AuthRepository) GetUserPasswordByUserID(ctx context.Context, userID uui
 		&userPassword.PasswordHash,
 		&userPassword.CreatedAt,
 		&userPassword.UpdatedAt,
+		&userPassword.ResetRequiredAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -184,8 +323,13 @@ func (r *AuthRepository) GetUserPasswordByUserID(ctx context.Context, userID uui
 }
 
 func (r *AuthRepository) CreateSession(ctx context.Context, session *authEntity.SessionEntity) error {
-	_, err := r.pgPool.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (id, user_id, token_hash, user_agent, device_name, device_fingerprint, ip_address, expires_at, created_at, refreshed_at, revoked_at, revoked_by) 
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`, authEntity.SessionTable),
+	amr := session.AMR
+	if amr == nil {
+		amr = []string{}
+	}
+
+	_, err := r.pgPool.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (id, user_id, token_hash, user_agent, device_name, device_fingerprint, ip_address, expires_at, created_at, refreshed_at, revoked_at, revoked_by, auth_time, amr, impersonator_id) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`, authEntity.SessionTable),
 		session.ID,
 		session.UserID,
 		session.TokenHash,
@@ -198,6 +342,9 @@ func (r *AuthRepository) CreateSession(ctx context.Context, session *authEntity.
 		session.RefreshedAt,
 		session.RevokedAt,
 		session.RevokedBy,
+		session.AuthTime,
+		amr,
+		session.ImpersonatorID,
 	)
 	if err != nil {
 		r.logger.Error("failed to create session", slog.String("op", "CreateSession"), slog.String("error", err.Error()))
@@ -208,6 +355,155 @@ func (r *AuthRepository) CreateSession(ctx context.Context, session *authEntity.
 	return nil
 }
 
+func (r *AuthRepository) GetSessionByID(ctx context.Context, sessionID uuid.UUID) (*authEntity.SessionEntity, error) {
+	var session authEntity.SessionEntity
+	query := fmt.Sprintf(`SELECT id, user_id, token_hash, expires_at, created_at, refreshed_at, revoked_at, revoked_by, auth_time, amr, impersonator_id 
+		FROM %s WHERE id = $1`, authEntity.SessionTable)
+
+	row := r.pgPool.QueryRow(ctx, query, sessionID)
+
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.TokenHash,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+		&session.RefreshedAt,
+		&session.RevokedAt,
+		&session.RevokedBy,
+		&session.AuthTime,
+		&session.AMR,
+		&session.ImpersonatorID,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("failed to get session by id", slog.String("op", "GetSessionByID"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// IsSessionActive reports whether the session of the user is neither revoked nor expired at
+// the given time and the user isn't deactivated
+func (r *AuthRepository) IsSessionActive(ctx context.Context, sessionID, userID uuid.UUID, at time.Time) (bool, error) {
+	query := fmt.Sprintf(`SELECT EXISTS (
+			SELECT 1 FROM %s s
+			JOIN %s u ON u.id = s.user_id
+			WHERE s.id = $1 AND s.user_id = $2 AND s.revoked_at IS NULL AND s.expires_at > $3 AND u.deactivated_at IS NULL
+		)`, authEntity.SessionTable, userEntity.UserTable)
+
+	var active bool
+	if err := r.pgPool.QueryRow(ctx, query, sessionID, userID, at).Scan(&active); err != nil {
+		r.logger.Error("failed to check session", slog.String("op", "IsSessionActive"), slog.String("error", err.Error()))
+		return false, err
+	}
+
+	return active, nil
+}
+
+func (r *AuthRepository) ListSessionsByUserID(ctx context.Context, userID uuid.UUID) ([]authEntity.SessionEntity, error) {
+	query := fmt.Sprintf(`SELECT id, user_id, user_agent, device_name, ip_address, expires_at, created_at, refreshed_at, revoked_at, revoked_by, auth_time, amr, impersonator_id 
+		FROM %s WHERE user_id = $1 ORDER BY created_at DESC`, authEntity.SessionTable)
+
+	rows, err := r.pgPool.Query(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to query sessions", slog.String("op", "ListSessionsByUserID"), slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []authEntity.SessionEntity
+	for rows.Next() {
+		var session authEntity.SessionEntity
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.DeviceName,
+			&session.IPAddress,
+			&session.ExpiresAt,
+			&session.CreatedAt,
+			&session.RefreshedAt,
+			&session.RevokedAt,
+			&session.RevokedBy,
+			&session.AuthTime,
+			&session.AMR,
+			&session.ImpersonatorID,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan session row", slog.String("op", "ListSessionsByUserID"), slog.String("error", err.Error()))
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *AuthRepository) UpdateSessionAuthTime(ctx context.Context, sessionID uuid.UUID, authTime time.Time, method string) error {
+	query := fmt.Sprintf(`UPDATE %s SET auth_time = $1, amr = array_append(array_remove(amr, $2::text), $2::text) 
+		WHERE id = $3 AND revoked_at IS NULL`, authEntity.SessionTable)
+
+	cmd, err := r.pgPool.Exec(ctx, query, authTime, method, sessionID)
+	if err != nil {
+		r.logger.Error("failed to update session auth time", slog.String("op", "UpdateSessionAuthTime"), slog.String("error", err.Error()))
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		r.logger.Warn("no active session found to update", slog.String("op", "UpdateSessionAuthTime"), slog.String("session_id", sessionID.String()))
+		return fmt.Errorf("no active session found with id: %s", sessionID.String())
+	}
+
+	r.logger.Info("session auth time updated", slog.String("op", "UpdateSessionAuthTime"), slog.String("session_id", sessionID.String()), slog.String("method", method))
+	return nil
+}
+
+// RevokeUserSessions revokes every active session and refresh token of the user
+func (r *AuthRepository) RevokeUserSessions(ctx context.Context, userID, revokedBy uuid.UUID, revokedAt time.Time) error {
+	err := pgx.BeginFunc(ctx, r.pgPool, func(tx pgx.Tx) error {
+		sessionQuery := fmt.Sprintf(`UPDATE %s SET revoked_at = $1, revoked_by = $2 WHERE user_id = $3 AND revoked_at IS NULL`, authEntity.SessionTable)
+		if _, err := tx.Exec(ctx, sessionQuery, revokedAt, revokedBy, userID); err != nil {
+			return err
+		}
+
+		refreshTokenQuery := fmt.Sprintf(`UPDATE %s SET revoked_at = $1, revoked_by = $2 WHERE user_id = $3 AND revoked_at IS NULL`, authEntity.RefreshTokenTable)
+		_, err := tx.Exec(ctx, refreshTokenQuery, revokedAt, revokedBy, userID)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("failed to revoke user sessions", slog.String("op", "RevokeUserSessions"), slog.String("error", err.Error()))
+		return err
+	}
+
+	r.logger.Info("user sessions revoked", slog.String("op", "RevokeUserSessions"), slog.String("user_id", userID.String()))
+	return nil
+}
+
+// RevokeSession revokes a single session and the refresh tokens issued for it
+func (r *AuthRepository) RevokeSession(ctx context.Context, sessionID, revokedBy uuid.UUID, revokedAt time.Time) error {
+	err := pgx.BeginFunc(ctx, r.pgPool, func(tx pgx.Tx) error {
+		sessionQuery := fmt.Sprintf(`UPDATE %s SET revoked_at = $1, revoked_by = $2 WHERE id = $3 AND revoked_at IS NULL`, authEntity.SessionTable)
+		if _, err := tx.Exec(ctx, sessionQuery, revokedAt, revokedBy, sessionID); err != nil {
+			return err
+		}
+
+		refreshTokenQuery := fmt.Sprintf(`UPDATE %s SET revoked_at = $1, revoked_by = $2 WHERE session_id = $3 AND revoked_at IS NULL`, authEntity.RefreshTokenTable)
+		_, err := tx.Exec(ctx, refreshTokenQuery, revokedAt, revokedBy, sessionID)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("failed to revoke session", slog.String("op", "RevokeSession"), slog.String("error", err.Error()))
+		return err
+	}
+
+	r.logger.Info("session revoked", slog.String("op", "RevokeSession"), slog.String("session_id", sessionID.String()))
+	return nil
+}
+
 func (r *AuthRepository) CreateRefreshToken(ctx context.Context, refreshToken *authEntity.RefreshToken) error {
 	_, err := r.pgPool.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (id, user_id, session_id, token_hash, ip_address, user_agent, expires_at, created_at, revoked_at, revoked_by) 
 	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`, authEntity.RefreshTokenTable),
@@ -264,3 +560,89 @@ func (r *AuthRepository) UpdateUserPassword(ctx context.Context, newPasswordHash
 	r.logger.Info("user password updated", slog.String("op", "UpdateUserPassword"), slog.String("user_id", userID.String()))
 	return nil
 }
+
+// SetPasswordResetRequired flags the user's password as requiring a reset, a nil time clears the flag
+func (r *AuthRepository) SetPasswordResetRequired(ctx context.Context, userID uuid.UUID, at *time.Time) error {
+	query := fmt.Sprintf("UPDATE %s SET reset_required_at = $1 WHERE user_id = $2", authEntity.UserPasswordTable)
+
+	cmd, err := r.pgPool.Exec(ctx, query, at, userID)
+	if err != nil {
+		r.logger.Error("failed to update password reset flag", slog.String("op", "SetPasswordResetRequired"), slog.String("error", err.Error()))
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		r.logger.Warn("no user password found to flag", slog.String("op", "SetPasswordResetRequired"), slog.String("user_id", userID.String()))
+		return fmt.Errorf("no user password found with user_id: %s", userID.String())
+	}
+
+	return nil
+}
+
+func (r *AuthRepository) CreateLoginEvent(ctx context.Context, event *authEntity.LoginEventEntity) error {
+	_, err := r.pgPool.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (id, user_id, identifier, method, success, failure_reason, ip_address, user_agent, created_at) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`, authEntity.LoginEventTable),
+		event.ID,
+		event.UserID,
+		event.Identifier,
+		event.Method,
+		event.Success,
+		event.FailureReason,
+		event.IPAddress,
+		event.UserAgent,
+		event.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("failed to create login event", slog.String("op", "CreateLoginEvent"), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (r *AuthRepository) PaginationLoginEventsByUserID(ctx context.Context, userID uuid.UUID, p *apputils.Pagination) (data []authEntity.LoginEventEntity, total int, err error) {
+	query := fmt.Sprintf(`
+		SELECT id, user_id, identifier, method, success, failure_reason, ip_address, user_agent, created_at 
+		FROM %s 
+		WHERE user_id = $1 
+		ORDER BY created_at DESC 
+		LIMIT $2 OFFSET $3
+	`, authEntity.LoginEventTable)
+
+	rows, err := r.pgPool.Query(ctx, query, userID, p.Limit, p.Offset)
+	if err != nil {
+		r.logger.Error("failed to query login events", slog.String("op", "PaginationLoginEventsByUserID"), slog.String("error", err.Error()))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event authEntity.LoginEventEntity
+		err := rows.Scan(
+			&event.ID,
+			&event.UserID,
+			&event.Identifier,
+			&event.Method,
+			&event.Success,
+			&event.FailureReason,
+			&event.IPAddress,
+			&event.UserAgent,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan login event row", slog.String("op", "PaginationLoginEventsByUserID"), slog.String("error", err.Error()))
+			return nil, 0, err
+		}
+
+		data = append(data, event)
+	}
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE user_id = $1`, authEntity.LoginEventTable)
+
+	err = r.pgPool.QueryRow(ctx, countQuery, userID).Scan(&total)
+	if err != nil {
+		r.logger.Error("failed to count login events", slog.String("op", "PaginationLoginEventsByUserID"), slog.String("error", err.Error()))
+		return nil, 0, err
+	}
+
+	return data, total, nil
+}
@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const DataExportTable = "public.data_exports"
+
+type DataExportStatus string
+
+const (
+	DataExportStatusPending    DataExportStatus = "pending"
+	DataExportStatusProcessing DataExportStatus = "processing"
+	DataExportStatusCompleted  DataExportStatus = "completed"
+	DataExportStatusFailed     DataExportStatus = "failed"
+)
+
+type DataExportEntity struct {
+	ID          uuid.UUID        `json:"id" db:"id"`
+	UserID      uuid.UUID        `json:"user_id" db:"user_id"`
+	Status      DataExportStatus `json:"status" db:"status"`
+	FilePath    *string          `json:"file_path" db:"file_path"` // Relative to the storage root
+	FileSize    *int64           `json:"file_size" db:"file_size"`
+	Error       *string          `json:"error" db:"error"`
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+	StartedAt   *time.Time       `json:"started_at" db:"started_at"`
+	CompletedAt *time.Time       `json:"completed_at" db:"completed_at"`
+	ExpiresAt   *time.Time       `json:"expires_at" db:"expires_at"` // When the archive and its download link stop being available
+}
@@ -0,0 +1,66 @@
+package export
+
+import (
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/internal/domain/export/repositories"
+	"github.com/rayhan889/neatspace/internal/notification"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+type Options struct {
+	PgPool      *pgxpool.Pool                 // PostgreSQL connection pool (required)
+	UserService services.UserServiceInterface // User service (required)
+	AuthService services.AuthServiceInterface // Auth service, source of the exported sessions (required)
+	NoteService services.NoteServiceInterface // Note service, source of the exported notes (required)
+	Logger      *slog.Logger                  // Slog logger instance (optional)
+	Mailer      *notification.Mailer          // Mailer service (optional)
+	BaseURL     string                        // Base URL for constructing download links (required)
+
+	StoragePath string              // Directory export archives are written to (required)
+	URLSigner   *apputils.URLSigner // Signer for download links (required)
+	Expiry      time.Duration       // How long an export can be downloaded (default: 48 hours)
+}
+
+type ExportDomain struct {
+	logger        *slog.Logger
+	exportService *services.ExportService
+}
+
+func NewExportDomain(opts *Options) *ExportDomain {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{}))
+	}
+
+	expiry := opts.Expiry
+	if expiry <= 0 {
+		expiry = 48 * time.Hour
+	}
+
+	exportService := services.NewExportService(services.ExportServiceOpts{
+		ExportRepo:  repositories.NewExportRepository(opts.PgPool, logger),
+		UserService: opts.UserService,
+		AuthService: opts.AuthService,
+		NoteService: opts.NoteService,
+		Logger:      logger,
+		Mailer:      opts.Mailer,
+		BaseURL:     opts.BaseURL,
+		StoragePath: opts.StoragePath,
+		URLSigner:   opts.URLSigner,
+		Expiry:      expiry,
+	})
+
+	return &ExportDomain{
+		logger:        logger,
+		exportService: exportService,
+	}
+}
+
+func (d *ExportDomain) GetExportService() services.ExportServiceInterface {
+	return d.exportService
+}
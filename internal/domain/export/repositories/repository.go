@@ -0,0 +1,222 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	exportEntity "github.com/rayhan889/neatspace/internal/domain/export/entities"
+)
+
+// ErrExportInProgress is returned when the user already has a pending or processing export
+var ErrExportInProgress = errors.New("data export already in progress")
+
+// Postgres error code for unique constraint violations
+const pgUniqueViolation = "23505"
+
+const dataExportColumns = `id, user_id, status, file_path, file_size, error, created_at, started_at, completed_at, expires_at`
+
+type ExportRepositoryInterface interface {
+	CreateDataExport(ctx context.Context, export *exportEntity.DataExportEntity) error
+	GetDataExportByID(ctx context.Context, exportID uuid.UUID) (*exportEntity.DataExportEntity, error)
+	ListDataExportsByUserID(ctx context.Context, userID uuid.UUID) ([]exportEntity.DataExportEntity, error)
+	ClaimPendingDataExport(ctx context.Context, staleBefore time.Time) (*exportEntity.DataExportEntity, error)
+	CompleteDataExport(ctx context.Context, exportID uuid.UUID, filePath string, fileSize int64, completedAt, expiresAt time.Time) error
+	FailDataExport(ctx context.Context, exportID uuid.UUID, reason string, failedAt time.Time) error
+	DeleteExpiredDataExports(ctx context.Context, before time.Time, limit int) ([]exportEntity.DataExportEntity, error)
+}
+
+var _ ExportRepositoryInterface = (*ExportRepository)(nil)
+
+type ExportRepository struct {
+	pgPool *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewExportRepository(pgPool *pgxpool.Pool, logger *slog.Logger) *ExportRepository {
+	return &ExportRepository{
+		pgPool: pgPool,
+		logger: logger,
+	}
+}
+
+func (r *ExportRepository) CreateDataExport(ctx context.Context, export *exportEntity.DataExportEntity) error {
+	query := fmt.Sprintf(`INSERT INTO %s (id, user_id, status, created_at) VALUES ($1, $2, $3, $4)`, exportEntity.DataExportTable)
+
+	_, err := r.pgPool.Exec(ctx, query, export.ID, export.UserID, export.Status, export.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == "idx_data_exports_user_id_active" {
+			r.logger.Warn("data export already in progress", slog.String("op", "CreateDataExport"), slog.String("user_id", export.UserID.String()))
+			return ErrExportInProgress
+		}
+		r.logger.Error("failed to create data export", slog.String("op", "CreateDataExport"), slog.String("error", err.Error()))
+		return err
+	}
+
+	r.logger.Info("data export requested", slog.String("op", "CreateDataExport"), slog.String("export_id", export.ID.String()))
+	return nil
+}
+
+func (r *ExportRepository) GetDataExportByID(ctx context.Context, exportID uuid.UUID) (*exportEntity.DataExportEntity, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, dataExportColumns, exportEntity.DataExportTable)
+
+	export, err := scanDataExport(r.pgPool.QueryRow(ctx, query, exportID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get data export by id", slog.String("op", "GetDataExportByID"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func (r *ExportRepository) ListDataExportsByUserID(ctx context.Context, userID uuid.UUID) ([]exportEntity.DataExportEntity, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE user_id = $1 ORDER BY created_at DESC`, dataExportColumns, exportEntity.DataExportTable)
+
+	rows, err := r.pgPool.Query(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to query data exports", slog.String("op", "ListDataExportsByUserID"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	exports, err := collectDataExports(rows)
+	if err != nil {
+		r.logger.Error("failed to scan data export row", slog.String("op", "ListDataExportsByUserID"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return exports, nil
+}
+
+// ClaimPendingDataExport marks the oldest pending export as processing and returns it.
+// Exports stuck in processing since before staleBefore are claimed again, which recovers
+// jobs interrupted by a crash. Returns nil when there is nothing to do.
+func (r *ExportRepository) ClaimPendingDataExport(ctx context.Context, staleBefore time.Time) (*exportEntity.DataExportEntity, error) {
+	query := fmt.Sprintf(`
+		UPDATE %[1]s SET status = $1, started_at = $2, error = NULL
+		WHERE id = (
+			SELECT id FROM %[1]s
+			WHERE status = $3 OR (status = $1 AND started_at < $4)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %[2]s
+	`, exportEntity.DataExportTable, dataExportColumns)
+
+	export, err := scanDataExport(r.pgPool.QueryRow(ctx, query,
+		exportEntity.DataExportStatusProcessing,
+		time.Now(),
+		exportEntity.DataExportStatusPending,
+		staleBefore,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to claim data export", slog.String("op", "ClaimPendingDataExport"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return export, nil
+}
+
+func (r *ExportRepository) CompleteDataExport(ctx context.Context, exportID uuid.UUID, filePath string, fileSize int64, completedAt, expiresAt time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET status = $1, file_path = $2, file_size = $3, completed_at = $4, expires_at = $5, error = NULL
+		WHERE id = $6`, exportEntity.DataExportTable)
+
+	_, err := r.pgPool.Exec(ctx, query, exportEntity.DataExportStatusCompleted, filePath, fileSize, completedAt, expiresAt, exportID)
+	if err != nil {
+		r.logger.Error("failed to complete data export", slog.String("op", "CompleteDataExport"), slog.String("error", err.Error()))
+		return err
+	}
+
+	r.logger.Info("data export completed", slog.String("op", "CompleteDataExport"), slog.String("export_id", exportID.String()))
+	return nil
+}
+
+func (r *ExportRepository) FailDataExport(ctx context.Context, exportID uuid.UUID, reason string, failedAt time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET status = $1, error = $2, completed_at = $3 WHERE id = $4`, exportEntity.DataExportTable)
+
+	_, err := r.pgPool.Exec(ctx, query, exportEntity.DataExportStatusFailed, reason, failedAt, exportID)
+	if err != nil {
+		r.logger.Error("failed to mark data export as failed", slog.String("op", "FailDataExport"), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExpiredDataExports removes up to limit exports that expired before the given time
+// and returns them so their archives can be deleted from storage
+func (r *ExportRepository) DeleteExpiredDataExports(ctx context.Context, before time.Time, limit int) ([]exportEntity.DataExportEntity, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %[1]s
+		WHERE id IN (
+			SELECT id FROM %[1]s
+			WHERE expires_at IS NOT NULL AND expires_at <= $1
+			ORDER BY expires_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %[2]s
+	`, exportEntity.DataExportTable, dataExportColumns)
+
+	rows, err := r.pgPool.Query(ctx, query, before, limit)
+	if err != nil {
+		r.logger.Error("failed to delete expired data exports", slog.String("op", "DeleteExpiredDataExports"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	exports, err := collectDataExports(rows)
+	if err != nil {
+		r.logger.Error("failed to delete expired data exports", slog.String("op", "DeleteExpiredDataExports"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return exports, nil
+}
+
+func scanDataExport(row pgx.Row) (*exportEntity.DataExportEntity, error) {
+	var export exportEntity.DataExportEntity
+	err := row.Scan(
+		&export.ID,
+		&export.UserID,
+		&export.Status,
+		&export.FilePath,
+		&export.FileSize,
+		&export.Error,
+		&export.CreatedAt,
+		&export.StartedAt,
+		&export.CompletedAt,
+		&export.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func collectDataExports(rows pgx.Rows) ([]exportEntity.DataExportEntity, error) {
+	defer rows.Close()
+
+	var exports []exportEntity.DataExportEntity
+	for rows.Next() {
+		export, err := scanDataExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, *export)
+	}
+
+	return exports, rows.Err()
+}
@@ -1,25 +1,145 @@
 package entities
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-const NoteTable = "public.notes"
+const (
+	NoteTable             = "public.notes"
+	NoteShareTable        = "public.note_shares"
+	NotePublicLinkTable   = "public.note_public_links"
+	NoteLinkTable         = "public.note_links"
+	NoteTaskTable         = "public.note_tasks"
+	NoteAccessChangeTable = "public.note_access_changes"
+)
+
+// Permissions on a single note, from least to most privileged. PermissionManage is never
+// granted by a share, it comes with the editor role in the note's workspace.
+const (
+	PermissionView    = "view"
+	PermissionComment = "comment"
+	PermissionEdit    = "edit"
+	PermissionManage  = "manage"
+)
+
+var permissionRanks = map[string]int{
+	PermissionView:    1,
+	PermissionComment: 2,
+	PermissionEdit:    3,
+	PermissionManage:  4,
+}
+
+// PermissionAtLeast reports whether permission grants at least the access of min
+func PermissionAtLeast(permission, min string) bool {
+	return permissionRanks[permission] >= permissionRanks[min] && permissionRanks[permission] > 0
+}
 
 type NoteEntity struct {
 	ID          uuid.UUID     `json:"id" db:"id"`
-	UserID      uuid.UUID     `json:"user_id" db:"user_id"`
+	WorkspaceID uuid.UUID     `json:"workspace_id" db:"workspace_id"`
+	UserID      uuid.UUID     `json:"user_id" db:"user_id"` // Author of the note, uuid.Nil once their account was purged
 	Title       string        `json:"title" db:"title"`
 	Content     TiptapContent `json:"content" db:"content"`
 	ContentText string        `json:"content_text" db:"content_text"`
+	Tags        []string      `json:"tags" db:"tags"`
+	Version     int64         `json:"version" db:"version"` // Bumped by every update
 	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt   *time.Time    `json:"updated_at" db:"updated_at"`
+	DeletedAt   *time.Time    `json:"deleted_at,omitempty" db:"deleted_at"` // Set on tombstones of deleted notes
+	ChangeXID   uint64        `json:"-" db:"change_xid"`                    // Transaction that last wrote the note
+}
+
+// NormalizeTags trims the tags and their leading #, dropping empty tags and repeats that only
+// differ in case. The first spelling of a tag is kept.
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
+
+// HasTag reports whether the note is tagged with tag, ignoring case and a leading #
+func (n *NoteEntity) HasTag(tag string) bool {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	for _, t := range n.Tags {
+		if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(t), "#"), tag) {
+			return true
+		}
+	}
+
+	return false
 }
 
 type TiptapContent struct {
 	Type    string          `json:"type"`
+	Attrs   map[string]any  `json:"attrs,omitempty"`
 	Text    string          `json:"text,omitempty"`
+	Marks   []TiptapMark    `json:"marks,omitempty"`
 	Content []TiptapContent `json:"content,omitempty"`
 }
+
+// TiptapMark is an inline formatting applied to a text node, e.g. bold or link
+type TiptapMark struct {
+	Type  string         `json:"type"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+// NoteShareEntity grants a user, or a pending email invitee, access to a single note
+type NoteShareEntity struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	NoteID     uuid.UUID  `json:"note_id" db:"note_id"`
+	UserID     *uuid.UUID `json:"user_id" db:"user_id"` // Nil while the share is pending
+	Email      *string    `json:"email" db:"email"`     // Invitee email of a pending share
+	Permission string     `json:"permission" db:"permission"`
+	SharedBy   *uuid.UUID `json:"shared_by" db:"shared_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// IsPending reports whether the invitee doesn't have an account yet
+func (s *NoteShareEntity) IsPending() bool {
+	return s.UserID == nil
+}
+
+// NoteShareDetail is a share joined with the grantee's account, if any
+type NoteShareDetail struct {
+	NoteShareEntity
+	DisplayName *string `json:"display_name" db:"display_name"`
+	Username    *string `json:"username" db:"username"`
+	UserEmail   *string `json:"user_email" db:"user_email"`
+}
+
+// NotePublicLinkEntity publishes a note read-only to anyone who knows the slug
+type NotePublicLinkEntity struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	NoteID       uuid.UUID  `json:"note_id" db:"note_id"`
+	Slug         string     `json:"slug" db:"slug"`
+	PasswordHash *string    `json:"-" db:"password_hash"` // Argon2id hash, nil when not protected
+	ExpiresAt    *time.Time `json:"expires_at" db:"expires_at"`
+	ViewCount    int64      `json:"view_count" db:"view_count"`
+	CreatedBy    *uuid.UUID `json:"created_by" db:"created_by"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// HasPassword reports whether the link asks for a password
+func (l *NotePublicLinkEntity) HasPassword() bool {
+	return l.PasswordHash != nil
+}
+
+// IsExpired reports whether the link had expired at the given time
+func (l *NotePublicLinkEntity) IsExpired(at time.Time) bool {
+	return l.ExpiresAt != nil && !at.Before(*l.ExpiresAt)
+}
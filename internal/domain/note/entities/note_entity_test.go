@@ -0,0 +1,53 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermissionAtLeast(t *testing.T) {
+	t.Run("HigherPermissionSatisfiesLower", func(t *testing.T) {
+		assert.True(t, PermissionAtLeast(PermissionManage, PermissionEdit))
+		assert.True(t, PermissionAtLeast(PermissionEdit, PermissionComment))
+		assert.True(t, PermissionAtLeast(PermissionComment, PermissionView))
+	})
+
+	t.Run("LowerPermissionIsRejected", func(t *testing.T) {
+		assert.False(t, PermissionAtLeast(PermissionView, PermissionComment))
+		assert.False(t, PermissionAtLeast(PermissionEdit, PermissionManage))
+	})
+
+	t.Run("NoPermissionIsRejected", func(t *testing.T) {
+		assert.False(t, PermissionAtLeast("", PermissionView))
+		assert.False(t, PermissionAtLeast("", ""))
+	})
+}
+
+func TestNormalizeTags(t *testing.T) {
+	t.Run("TrimsAndDropsHash", func(t *testing.T) {
+		assert.Equal(t, []string{"work", "road map"}, NormalizeTags([]string{" #work", "road map "}))
+	})
+
+	t.Run("DropsEmptyAndRepeatedTags", func(t *testing.T) {
+		assert.Equal(t, []string{"Work", "ideas"}, NormalizeTags([]string{"Work", "", "#", "work", "ideas", "#IDEAS"}))
+	})
+
+	t.Run("NilIsEmpty", func(t *testing.T) {
+		assert.Equal(t, []string{}, NormalizeTags(nil))
+	})
+}
+
+func TestNoteEntity_HasTag(t *testing.T) {
+	note := &NoteEntity{Tags: []string{"Work", "road map"}}
+
+	t.Run("IgnoresCaseAndHash", func(t *testing.T) {
+		assert.True(t, note.HasTag("work"))
+		assert.True(t, note.HasTag("#Road Map"))
+	})
+
+	t.Run("MissingTag", func(t *testing.T) {
+		assert.False(t, note.HasTag("ideas"))
+		assert.False(t, note.HasTag(""))
+	})
+}
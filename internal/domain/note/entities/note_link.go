@@ -0,0 +1,142 @@
+package entities
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tiptap nodes and marks linking a note to another one. A note mention is inserted by the
+// editor for [[Note Title]], a link mark links any text to the note's page.
+const (
+	NodeNoteMention = "noteMention"
+	MarkLink        = "link"
+)
+
+// NoteLinkEntity is a link from one note to another. The target is not a foreign key, a link
+// outlives its target and is marked dangling when the target is deleted.
+type NoteLinkEntity struct {
+	SourceNoteID uuid.UUID `json:"source_note_id" db:"source_note_id"`
+	TargetNoteID uuid.UUID `json:"target_note_id" db:"target_note_id"`
+	TargetTitle  string    `json:"target_title" db:"target_title"` // Title shown by the link
+	Dangling     bool      `json:"dangling" db:"dangling"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// NoteLinkDetail is a link joined with the note on its other end and the access a user has
+// to that note. The note fields are nil when it's gone.
+type NoteLinkDetail struct {
+	NoteLinkEntity
+	WorkspaceID     *uuid.UUID `json:"workspace_id" db:"workspace_id"`
+	NoteTitle       *string    `json:"note_title" db:"note_title"`
+	NoteUpdatedAt   *time.Time `json:"note_updated_at" db:"note_updated_at"`
+	Role            *string    `json:"-" db:"role"`             // The user's role in the note's workspace
+	SharePermission *string    `json:"-" db:"share_permission"` // Permission of a share with the user
+}
+
+// NoteLinkTarget is a note linked from a document with the title the link shows
+type NoteLinkTarget struct {
+	NoteID uuid.UUID
+	Title  string
+}
+
+// ExtractNoteLinks returns the notes the document links to, in order of their first link.
+// Links are note mentions and link marks pointing at /notes/<id>, either relative or on
+// appHost.
+func ExtractNoteLinks(doc TiptapContent, appHost string) []NoteLinkTarget {
+	var targets []NoteLinkTarget
+	seen := make(map[uuid.UUID]bool)
+
+	walkNoteLinks(&doc, appHost, func(noteID uuid.UUID, title *string, _ bool) {
+		if seen[noteID] {
+			return
+		}
+		seen[noteID] = true
+		targets = append(targets, NoteLinkTarget{NoteID: noteID, Title: *title})
+	})
+
+	return targets
+}
+
+// RenameNoteLinks updates the links of the document to a renamed note. Mentions always show
+// the note's title, the text of link marks only when it was the old title, so text written
+// around the link is left alone. The caller only renames in documents whose readers can view
+// the note. Reports whether the document changed.
+func RenameNoteLinks(doc *TiptapContent, noteID uuid.UUID, oldTitle, newTitle, appHost string) bool {
+	changed := false
+
+	walkNoteLinks(doc, appHost, func(target uuid.UUID, title *string, mention bool) {
+		if target != noteID || *title == newTitle {
+			return
+		}
+		if !mention && *title != oldTitle {
+			return
+		}
+		*title = newTitle
+		changed = true
+	})
+
+	return changed
+}
+
+// walkNoteLinks calls fn for every link to a note with the title the link shows and whether
+// it's a mention, fn may change the title in place
+func walkNoteLinks(node *TiptapContent, appHost string, fn func(noteID uuid.UUID, title *string, mention bool)) {
+	switch {
+	case node.Type == NodeNoteMention:
+		raw, _ := node.Attrs["id"].(string)
+		noteID, err := uuid.Parse(raw)
+		if err != nil {
+			break
+		}
+
+		label, _ := node.Attrs["label"].(string)
+		before := label
+		fn(noteID, &label, true)
+		if label != before {
+			node.Attrs["label"] = label
+		}
+	case node.Type == "text":
+		for _, mark := range node.Marks {
+			if mark.Type != MarkLink {
+				continue
+			}
+			href, _ := mark.Attrs["href"].(string)
+			if noteID, ok := NoteIDFromHref(href, appHost); ok {
+				fn(noteID, &node.Text, false)
+				break
+			}
+		}
+	}
+
+	for i := range node.Content {
+		walkNoteLinks(&node.Content[i], appHost, fn)
+	}
+}
+
+// NoteIDFromHref returns the note a link points at, when it's the page of a note
+func NoteIDFromHref(href, appHost string) (uuid.UUID, bool) {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return uuid.Nil, false
+	}
+	if u.IsAbs() || u.Host != "" {
+		if (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "") || !strings.EqualFold(u.Host, appHost) {
+			return uuid.Nil, false
+		}
+	} else if !strings.HasPrefix(u.Path, "/") {
+		return uuid.Nil, false
+	}
+
+	raw, ok := strings.CutPrefix(strings.TrimSuffix(u.Path, "/"), "/notes/")
+	if !ok {
+		return uuid.Nil, false
+	}
+	noteID, err := uuid.Parse(raw)
+	if err != nil || len(raw) != 36 {
+		return uuid.Nil, false
+	}
+	return noteID, true
+}
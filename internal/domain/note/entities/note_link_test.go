@@ -0,0 +1,91 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func mention(id uuid.UUID, label string) TiptapContent {
+	return TiptapContent{Type: NodeNoteMention, Attrs: map[string]any{"id": id.String(), "label": label}}
+}
+
+func linkText(text, href string) TiptapContent {
+	return TiptapContent{Type: "text", Text: text, Marks: []TiptapMark{{Type: "bold"}, {Type: MarkLink, Attrs: map[string]any{"href": href}}}}
+}
+
+func paragraph(content ...TiptapContent) TiptapContent {
+	return TiptapContent{Type: "paragraph", Content: content}
+}
+
+func TestExtractNoteLinks(t *testing.T) {
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+
+	t.Run("CollectsMentionsAndNoteLinksInOrder", func(t *testing.T) {
+		doc := TiptapContent{Type: "doc", Content: []TiptapContent{
+			paragraph(mention(first, "Meeting notes"), TiptapContent{Type: "text", Text: " and "}),
+			{Type: "bulletList", Content: []TiptapContent{
+				{Type: "listItem", Content: []TiptapContent{paragraph(linkText("Roadmap", "/notes/"+second.String()))}},
+			}},
+			paragraph(linkText("Ideas", "https://app.example.com/notes/"+third.String())),
+		}}
+
+		assert.Equal(t, []NoteLinkTarget{
+			{NoteID: first, Title: "Meeting notes"},
+			{NoteID: second, Title: "Roadmap"},
+			{NoteID: third, Title: "Ideas"},
+		}, ExtractNoteLinks(doc, "app.example.com"))
+	})
+
+	t.Run("KeepsTheFirstLinkToANote", func(t *testing.T) {
+		doc := TiptapContent{Type: "doc", Content: []TiptapContent{
+			paragraph(mention(first, "Meeting notes"), linkText("these notes", "/notes/"+first.String())),
+		}}
+
+		assert.Equal(t, []NoteLinkTarget{{NoteID: first, Title: "Meeting notes"}}, ExtractNoteLinks(doc, "app.example.com"))
+	})
+
+	t.Run("IgnoresOtherLinks", func(t *testing.T) {
+		doc := TiptapContent{Type: "doc", Content: []TiptapContent{
+			paragraph(
+				linkText("elsewhere", "https://other.example.com/notes/"+first.String()),
+				linkText("relative", "notes/"+first.String()),
+				linkText("not a note", "/notes/latest"),
+				linkText("script", "javascript:/notes/"+first.String()),
+				TiptapContent{Type: NodeNoteMention, Attrs: map[string]any{"id": "nope", "label": "Broken"}},
+			),
+		}}
+
+		assert.Empty(t, ExtractNoteLinks(doc, "app.example.com"))
+	})
+}
+
+func TestRenameNoteLinks(t *testing.T) {
+	target, other := uuid.New(), uuid.New()
+
+	t.Run("RewritesMentionsAndLinkTextShowingTheOldTitle", func(t *testing.T) {
+		doc := TiptapContent{Type: "doc", Content: []TiptapContent{
+			paragraph(
+				mention(target, "Draft"),
+				linkText("Draft", "/notes/"+target.String()),
+				linkText("see here", "/notes/"+target.String()),
+				mention(other, "Draft"),
+			),
+		}}
+
+		assert.True(t, RenameNoteLinks(&doc, target, "Draft", "Plan", "app.example.com"))
+
+		content := doc.Content[0].Content
+		assert.Equal(t, "Plan", content[0].Attrs["label"])
+		assert.Equal(t, "Plan", content[1].Text)
+		assert.Equal(t, "see here", content[2].Text)
+		assert.Equal(t, "Draft", content[3].Attrs["label"])
+	})
+
+	t.Run("ReportsUnchangedDocuments", func(t *testing.T) {
+		doc := TiptapContent{Type: "doc", Content: []TiptapContent{paragraph(mention(target, "Plan"))}}
+
+		assert.False(t, RenameNoteLinks(&doc, target, "Draft", "Plan", "app.example.com"))
+	})
+}
@@ -0,0 +1,154 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tiptap nodes of a checklist
+const (
+	NodeTaskList = "taskList"
+	NodeTaskItem = "taskItem"
+)
+
+// Task states accepted by NoteTaskFilter.Status
+const (
+	TaskStatusOpen = "open"
+	TaskStatusDone = "done"
+)
+
+// TaskDueDateAttr is the task item attribute holding its due date, as 2006-01-02 or RFC 3339
+const TaskDueDateAttr = "dueDate"
+
+// NoteTaskEntity is a checklist item of a note. Task items have no id of their own, a task is
+// addressed by its position among the note's task items in document order.
+type NoteTaskEntity struct {
+	NoteID   uuid.UUID  `json:"note_id" db:"note_id"`
+	Position int        `json:"position" db:"position"`
+	Text     string     `json:"text" db:"text"`
+	Checked  bool       `json:"checked" db:"checked"`
+	DueDate  *time.Time `json:"due_date" db:"due_date"` // Date only, nil without a due date
+}
+
+// NoteTaskDetail is a task joined with its note and the access a user has to it
+type NoteTaskDetail struct {
+	NoteTaskEntity
+	WorkspaceID     uuid.UUID `json:"workspace_id" db:"workspace_id"`
+	NoteTitle       string    `json:"note_title" db:"note_title"`
+	NoteVersion     int64     `json:"note_version" db:"note_version"`
+	Role            *string   `json:"-" db:"role"`             // The user's role in the note's workspace
+	SharePermission *string   `json:"-" db:"share_permission"` // Permission of a share with the user
+}
+
+// NoteTaskFilter narrows the task list, empty fields are ignored
+type NoteTaskFilter struct {
+	Status      string     `query:"status"`
+	WorkspaceID *uuid.UUID `query:"workspace_id"`
+}
+
+// ExtractTasks returns the task items of the document in document order. The text of a task
+// leaves out the tasks nested below it, they are tasks of their own.
+func ExtractTasks(doc TiptapContent) []NoteTaskEntity {
+	var tasks []NoteTaskEntity
+	walkTaskItems(&doc, func(item *TiptapContent) bool {
+		checked, _ := item.Attrs["checked"].(bool)
+		tasks = append(tasks, NoteTaskEntity{
+			Position: len(tasks),
+			Text:     taskText(item),
+			Checked:  checked,
+			DueDate:  taskDueDate(item.Attrs),
+		})
+		return false
+	})
+	return tasks
+}
+
+// SetTaskChecked checks or unchecks the task item at the position, reporting whether the
+// document has a task there
+func SetTaskChecked(doc *TiptapContent, position int, checked bool) bool {
+	found := false
+	index := 0
+	walkTaskItems(doc, func(item *TiptapContent) bool {
+		if index != position {
+			index++
+			return false
+		}
+
+		if item.Attrs == nil {
+			item.Attrs = make(map[string]any)
+		}
+		item.Attrs["checked"] = checked
+		found = true
+		return true
+	})
+	return found
+}
+
+// walkTaskItems calls fn for every task item in document order until fn returns true
+func walkTaskItems(node *TiptapContent, fn func(item *TiptapContent) bool) bool {
+	if node.Type == NodeTaskItem && fn(node) {
+		return true
+	}
+	for i := range node.Content {
+		if walkTaskItems(&node.Content[i], fn) {
+			return true
+		}
+	}
+	return false
+}
+
+func taskText(item *TiptapContent) string {
+	var parts []string
+	for i := range item.Content {
+		if child := &item.Content[i]; child.Type != NodeTaskList {
+			if text := strings.Join(strings.Fields(inlineText(child)), " "); text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// inlineText returns the text of the node as read, mentions by their label
+func inlineText(node *TiptapContent) string {
+	switch node.Type {
+	case "text":
+		return node.Text
+	case "hardBreak":
+		return " "
+	case NodeNoteMention:
+		label, _ := node.Attrs["label"].(string)
+		return label
+	}
+
+	var b strings.Builder
+	for i := range node.Content {
+		if i > 0 && node.Content[i].Type != "text" && node.Content[i-1].Type != "text" {
+			b.WriteString(" ")
+		}
+		b.WriteString(inlineText(&node.Content[i]))
+	}
+	return b.String()
+}
+
+// taskDueDate reads the due date of a task item, the date of a timestamp is taken in the
+// offset it's written with
+func taskDueDate(attrs map[string]any) *time.Time {
+	raw, _ := attrs[TaskDueDateAttr].(string)
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		ts, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil
+		}
+		t = time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return &t
+}
@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func taskItem(checked bool, attrs map[string]any, content ...TiptapContent) TiptapContent {
+	if attrs == nil {
+		attrs = map[string]any{}
+	}
+	attrs["checked"] = checked
+	return TiptapContent{Type: NodeTaskItem, Attrs: attrs, Content: content}
+}
+
+func text(s string) TiptapContent {
+	return TiptapContent{Type: "text", Text: s}
+}
+
+func taskDoc() TiptapContent {
+	return TiptapContent{Type: "doc", Content: []TiptapContent{
+		paragraph(text("Not a task")),
+		{Type: NodeTaskList, Content: []TiptapContent{
+			taskItem(false, map[string]any{TaskDueDateAttr: "2026-10-20"},
+				paragraph(text("Write "), TiptapContent{Type: "text", Text: "agenda", Marks: []TiptapMark{{Type: "bold"}}}),
+				TiptapContent{Type: NodeTaskList, Content: []TiptapContent{
+					taskItem(true, nil, paragraph(text("Book room"))),
+				}},
+			),
+			taskItem(false, map[string]any{TaskDueDateAttr: "2026-10-21T23:30:00-05:00"},
+				paragraph(text("Ask"), TiptapContent{Type: "hardBreak"}, text("about  budget")),
+			),
+			taskItem(true, map[string]any{TaskDueDateAttr: "next week"}),
+		}},
+	}}
+}
+
+func TestExtractTasks(t *testing.T) {
+	t.Run("ReadsTasksInDocumentOrder", func(t *testing.T) {
+		due := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+		dueFromTimestamp := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+
+		assert.Equal(t, []NoteTaskEntity{
+			{Position: 0, Text: "Write agenda", DueDate: &due},
+			{Position: 1, Text: "Book room", Checked: true},
+			{Position: 2, Text: "Ask about budget", DueDate: &dueFromTimestamp},
+			{Position: 3, Text: "", Checked: true},
+		}, ExtractTasks(taskDoc()))
+	})
+
+	t.Run("DocumentWithoutTasks", func(t *testing.T) {
+		assert.Empty(t, ExtractTasks(TiptapContent{Type: "doc", Content: []TiptapContent{paragraph(text("Hello"))}}))
+	})
+}
+
+func TestSetTaskChecked(t *testing.T) {
+	t.Run("ChecksTheTaskAtThePosition", func(t *testing.T) {
+		doc := taskDoc()
+
+		assert.True(t, SetTaskChecked(&doc, 2, true))
+		assert.True(t, SetTaskChecked(&doc, 1, false))
+
+		tasks := ExtractTasks(doc)
+		assert.False(t, tasks[0].Checked)
+		assert.False(t, tasks[1].Checked)
+		assert.True(t, tasks[2].Checked)
+		assert.True(t, tasks[3].Checked)
+	})
+
+	t.Run("ReportsMissingTasks", func(t *testing.T) {
+		doc := taskDoc()
+
+		assert.False(t, SetTaskChecked(&doc, 4, true))
+		assert.False(t, SetTaskChecked(&doc, -1, true))
+	})
+}
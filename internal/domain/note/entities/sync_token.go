@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidSyncToken = errors.New("invalid sync token")
+
+// SyncToken is the position of a client in the change feed of notes, clients get it
+// encoded as an opaque string. Changes are ordered by the transaction that wrote them,
+// then by note id.
+type SyncToken struct {
+	XID uint64    `json:"x"` // change_xid of the last change seen
+	ID  uuid.UUID `json:"i"` // Id of the last change seen within its transaction
+	// Unix time the position was reached, tombstones purged since then may be missing
+	IssuedAt int64 `json:"t"`
+	// Set during the initial sync, which skips notes deleted before it started
+	Initial bool `json:"n,omitempty"`
+	// Watermark when the initial sync the position builds on started. The feed doesn't
+	// follow access changes, once the user's access changed after it the position expires.
+	Base uint64 `json:"b,omitempty"`
+}
+
+// IssuedBefore reports whether the position was reached before at
+func (t SyncToken) IssuedBefore(at time.Time) bool {
+	return t.IssuedAt < at.Unix()
+}
+
+func (t SyncToken) Encode() string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func ParseSyncToken(token string) (SyncToken, error) {
+	var t SyncToken
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return t, ErrInvalidSyncToken
+	}
+	if err := json.Unmarshal(data, &t); err != nil || t.IssuedAt <= 0 {
+		return t, ErrInvalidSyncToken
+	}
+
+	return t, nil
+}
+
+// NoteChange is a note or tombstone in the change feed, with the access of the syncing user
+type NoteChange struct {
+	Note            NoteEntity
+	Role            string // Role of the user in the note's workspace, empty when not a member
+	SharePermission string // Permission shared with the user, empty without a share
+}
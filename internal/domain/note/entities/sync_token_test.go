@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncToken(t *testing.T) {
+	t.Run("RoundTrip", func(t *testing.T) {
+		token := SyncToken{XID: 123456, ID: uuid.New(), IssuedAt: time.Now().Unix(), Initial: true, Base: 123000}
+
+		parsed, err := ParseSyncToken(token.Encode())
+		require.NoError(t, err)
+		assert.Equal(t, token, parsed)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, raw := range []string{"", "not base64!", "bm90IGpzb24", SyncToken{XID: 1}.Encode()} {
+			_, err := ParseSyncToken(raw)
+			assert.ErrorIs(t, err, ErrInvalidSyncToken, raw)
+		}
+	})
+
+	t.Run("IssuedBefore", func(t *testing.T) {
+		now := time.Now()
+		token := SyncToken{IssuedAt: now.Add(-time.Hour).Unix()}
+
+		assert.True(t, token.IssuedBefore(now))
+		assert.False(t, token.IssuedBefore(now.Add(-2*time.Hour)))
+	})
+}
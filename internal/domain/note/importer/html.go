@@ -0,0 +1,419 @@
+package importer
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Elements dropped with everything inside them
+var skippedElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Iframe: true, atom.Object: true, atom.Embed: true, atom.Svg: true, atom.Math: true,
+	atom.Canvas: true, atom.Button: true, atom.Select: true, atom.Textarea: true, atom.Input: true,
+}
+
+// Elements whose content is unwrapped into the surrounding blocks
+var containerElements = map[atom.Atom]bool{
+	atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true, atom.Header: true,
+	atom.Footer: true, atom.Aside: true, atom.Nav: true, atom.Figure: true, atom.Figcaption: true,
+	atom.Dl: true, atom.Dt: true, atom.Dd: true, atom.Details: true, atom.Summary: true,
+	atom.Form: true, atom.Fieldset: true, atom.Address: true, atom.Center: true, atom.Body: true,
+	atom.Html: true, atom.Tbody: true, atom.Thead: true, atom.Tfoot: true,
+}
+
+// Inline elements and the mark they apply
+var markElements = map[atom.Atom]string{
+	atom.Strong: "bold", atom.B: "bold",
+	atom.Em: "italic", atom.I: "italic", atom.Cite: "italic",
+	atom.S: "strike", atom.Strike: "strike", atom.Del: "strike",
+	atom.U: "underline", atom.Ins: "underline",
+	atom.Code: "code", atom.Kbd: "code", atom.Samp: "code", atom.Tt: "code",
+	atom.Mark: "highlight",
+	atom.Sub:  "subscript",
+	atom.Sup:  "superscript",
+}
+
+// ParseHTML converts an HTML page or fragment into a Tiptap document. The title comes from
+// the title element or else the first heading, a heading opening the body that repeats the
+// title is removed from the content.
+func ParseHTML(src []byte) (*Document, error) {
+	root, err := html.Parse(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{}
+	if title := findElement(root, atom.Title); title != nil {
+		doc.Title = strings.Join(strings.Fields(textContent(title)), " ")
+	}
+
+	body := findElement(root, atom.Body)
+	if body == nil {
+		body = root
+	}
+
+	c := &htmlConverter{}
+	doc.Content = entities.TiptapContent{Type: "doc", Content: c.blocks(body)}
+
+	if doc.Title == "" {
+		takeTitle(doc)
+	} else if blocks := doc.Content.Content; len(blocks) > 0 && blocks[0].Type == "heading" &&
+		strings.TrimSpace(plainText(blocks[0].Content)) == doc.Title {
+		doc.Content.Content = blocks[1:]
+	}
+
+	return doc, nil
+}
+
+// htmlFragment converts HTML embedded in another document, e.g. a Markdown HTML block
+func htmlFragment(src []byte) []entities.TiptapContent {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(bytes.NewReader(src), context)
+	if err != nil {
+		return nil
+	}
+
+	for _, n := range nodes {
+		context.AppendChild(n)
+	}
+	return (&htmlConverter{}).blocks(context)
+}
+
+type htmlConverter struct{}
+
+// blocks converts the children of a block element, loose inline content is wrapped in paragraphs
+func (c *htmlConverter) blocks(parent *html.Node) []entities.TiptapContent {
+	var out []entities.TiptapContent
+	b := &inlineBuilder{}
+	flush := func() {
+		if inline := trimInline(b.nodes); len(inline) > 0 {
+			out = append(out, paragraph(inline))
+		}
+		b = &inlineBuilder{}
+	}
+
+	for n := parent.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type == html.ElementNode && isBlockElement(n) {
+			flush()
+			out = append(out, c.block(n)...)
+			continue
+		}
+		c.inline(b, n, nil)
+	}
+	flush()
+
+	return out
+}
+
+func isBlockElement(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.P, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Blockquote, atom.Ul,
+		atom.Ol, atom.Li, atom.Pre, atom.Hr, atom.Table, atom.Tr:
+		return true
+	}
+	return containerElements[n.DataAtom] || skippedElements[n.DataAtom] && n.DataAtom != atom.Input
+}
+
+func (c *htmlConverter) block(n *html.Node) []entities.TiptapContent {
+	switch n.DataAtom {
+	case atom.P:
+		if inline := c.inlines(n); len(inline) > 0 {
+			return []entities.TiptapContent{paragraph(inline)}
+		}
+		return nil
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level, _ := strconv.Atoi(n.Data[1:])
+		return []entities.TiptapContent{{Type: "heading", Attrs: map[string]any{"level": level}, Content: c.inlines(n)}}
+	case atom.Blockquote:
+		return []entities.TiptapContent{{Type: "blockquote", Content: c.blocks(n)}}
+	case atom.Ul, atom.Ol:
+		return []entities.TiptapContent{c.list(n)}
+	case atom.Li:
+		// Stray item outside a list
+		return c.blocks(n)
+	case atom.Pre:
+		return []entities.TiptapContent{c.codeBlock(n)}
+	case atom.Hr:
+		return []entities.TiptapContent{{Type: "horizontalRule"}}
+	case atom.Table:
+		if table := c.table(n); len(table.Content) > 0 {
+			return []entities.TiptapContent{table}
+		}
+		return nil
+	}
+
+	if skippedElements[n.DataAtom] {
+		return nil
+	}
+	return c.blocks(n)
+}
+
+// list becomes a task list when every item starts with a checkbox
+func (c *htmlConverter) list(n *html.Node) entities.TiptapContent {
+	list := entities.TiptapContent{Type: "bulletList"}
+	if n.DataAtom == atom.Ol {
+		list.Type = "orderedList"
+		if start, err := strconv.Atoi(attr(n, "start")); err == nil && start != 1 {
+			list.Attrs = map[string]any{"start": start}
+		}
+	}
+
+	var items []*html.Node
+	tasks := true
+	for item := n.FirstChild; item != nil; item = item.NextSibling {
+		if item.Type != html.ElementNode || item.DataAtom != atom.Li {
+			continue
+		}
+		items = append(items, item)
+		if taskCheckBox(item) == nil {
+			tasks = false
+		}
+	}
+	if tasks && len(items) > 0 && list.Type == "bulletList" {
+		list.Type = "taskList"
+	}
+
+	for _, item := range items {
+		li := entities.TiptapContent{Type: listItemType(list.Type), Content: c.blocks(item)}
+		if list.Type == "taskList" {
+			_, checked := getAttr(taskCheckBox(item), "checked")
+			li.Attrs = map[string]any{"checked": checked}
+		}
+		if len(li.Content) == 0 {
+			li.Content = []entities.TiptapContent{paragraph(nil)}
+		}
+		list.Content = append(list.Content, li)
+	}
+	return list
+}
+
+// taskCheckBox returns the checkbox an item starts with, before any text
+func taskCheckBox(item *html.Node) *html.Node {
+	for n := item.FirstChild; n != nil; {
+		switch {
+		case n.Type == html.TextNode && strings.TrimSpace(n.Data) != "":
+			return nil
+		case n.Type == html.ElementNode && n.DataAtom == atom.Input:
+			if strings.EqualFold(attr(n, "type"), "checkbox") {
+				return n
+			}
+			return nil
+		case n.Type == html.ElementNode && n.FirstChild != nil && n.DataAtom != atom.Ul && n.DataAtom != atom.Ol:
+			n = n.FirstChild
+			continue
+		}
+		for n.NextSibling == nil && n.Parent != item {
+			n = n.Parent
+		}
+		n = n.NextSibling
+	}
+	return nil
+}
+
+func (c *htmlConverter) codeBlock(pre *html.Node) entities.TiptapContent {
+	block := entities.TiptapContent{Type: "codeBlock"}
+
+	if code := findElement(pre, atom.Code); code != nil {
+		for _, class := range strings.Fields(attr(code, "class")) {
+			if lang, ok := strings.CutPrefix(class, "language-"); ok {
+				block.Attrs = map[string]any{"language": lang}
+			} else if lang, ok := strings.CutPrefix(class, "lang-"); ok {
+				block.Attrs = map[string]any{"language": lang}
+			}
+		}
+	}
+
+	if code := strings.TrimSuffix(textContent(pre), "\n"); code != "" {
+		block.Content = []entities.TiptapContent{{Type: "text", Text: code}}
+	}
+	return block
+}
+
+// table marks cells of th elements as headers
+func (c *htmlConverter) table(n *html.Node) entities.TiptapContent {
+	table := entities.TiptapContent{Type: "table"}
+
+	var walk func(parent *html.Node)
+	walk = func(parent *html.Node) {
+		for row := parent.FirstChild; row != nil; row = row.NextSibling {
+			if row.Type != html.ElementNode {
+				continue
+			}
+			switch row.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(row)
+			case atom.Tr:
+				tr := entities.TiptapContent{Type: "tableRow"}
+				for cell := row.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type != html.ElementNode || (cell.DataAtom != atom.Td && cell.DataAtom != atom.Th) {
+						continue
+					}
+
+					td := entities.TiptapContent{Type: "tableCell", Content: c.blocks(cell)}
+					if cell.DataAtom == atom.Th {
+						td.Type = "tableHeader"
+					}
+					if len(td.Content) == 0 {
+						td.Content = []entities.TiptapContent{paragraph(nil)}
+					}
+					tr.Content = append(tr.Content, td)
+				}
+				if len(tr.Content) > 0 {
+					table.Content = append(table.Content, tr)
+				}
+			}
+		}
+	}
+	walk(n)
+
+	return table
+}
+
+func (c *htmlConverter) inlines(parent *html.Node) []entities.TiptapContent {
+	b := &inlineBuilder{}
+	for n := parent.FirstChild; n != nil; n = n.NextSibling {
+		c.inline(b, n, nil)
+	}
+	return trimInline(b.nodes)
+}
+
+func (c *htmlConverter) inline(b *inlineBuilder, n *html.Node, marks []entities.TiptapMark) {
+	switch n.Type {
+	case html.TextNode:
+		text := collapseSpace(n.Data)
+		// Whitespace doesn't add up across nodes, nor after a line break
+		if strings.HasPrefix(text, " ") {
+			if last := len(b.nodes) - 1; last < 0 || b.nodes[last].Type == "hardBreak" ||
+				(b.nodes[last].Type == "text" && strings.HasSuffix(b.nodes[last].Text, " ")) {
+				text = text[1:]
+			}
+		}
+		b.text(text, marks)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if skippedElements[n.DataAtom] {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		b.node(entities.TiptapContent{Type: "hardBreak"})
+		return
+	case atom.Img:
+		attrs := map[string]any{"src": attr(n, "src"), "alt": attr(n, "alt")}
+		if title := attr(n, "title"); title != "" {
+			attrs["title"] = title
+		}
+		b.node(entities.TiptapContent{Type: "image", Attrs: attrs})
+		return
+	case atom.A:
+		if href := attr(n, "href"); href != "" {
+			marks = withMark(marks, linkMark(href, attr(n, "title")))
+		}
+	default:
+		if mark, ok := markElements[n.DataAtom]; ok {
+			marks = withMark(marks, entities.TiptapMark{Type: mark})
+		}
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.inline(b, child, marks)
+	}
+}
+
+// trimInline drops whitespace around the content and around line breaks, and trailing breaks
+func trimInline(nodes []entities.TiptapContent) []entities.TiptapContent {
+	out := nodes[:0]
+	for i, n := range nodes {
+		if n.Type == "text" {
+			if i+1 == len(nodes) || nodes[i+1].Type == "hardBreak" {
+				n.Text = strings.TrimRight(n.Text, " ")
+			}
+			if len(out) == 0 {
+				n.Text = strings.TrimLeft(n.Text, " ")
+			}
+			if n.Text == "" {
+				continue
+			}
+		}
+		out = append(out, n)
+	}
+
+	for len(out) > 0 && out[len(out)-1].Type == "hardBreak" {
+		out = out[:len(out)-1]
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		b.WriteRune(r)
+		space = false
+	}
+	return b.String()
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	if n.DataAtom == atom.Br {
+		return "\n"
+	}
+
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	value, _ := getAttr(n, key)
+	return value
+}
+
+func getAttr(n *html.Node, key string) (string, bool) {
+	if n == nil {
+		return "", false
+	}
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
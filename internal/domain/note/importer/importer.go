@@ -0,0 +1,207 @@
+// Package importer converts Markdown and HTML files from other tools into Tiptap documents.
+// Unsupported elements are converted through their text so no content is lost. URLs are kept
+// as they are, the renderers sanitize them on output.
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidFrontMatter = errors.New("invalid front matter")
+
+// Document is a parsed file. Title is empty when the file has neither a title in its front
+// matter nor a heading, the caller picks one.
+type Document struct {
+	Title     string
+	Tags      []string
+	CreatedAt *time.Time
+	Content   entities.TiptapContent
+}
+
+// frontMatter holds the fields read from YAML front matter, others are ignored
+type frontMatter struct {
+	Title     string    `yaml:"title"`
+	Tags      yaml.Node `yaml:"tags"`
+	CreatedAt string    `yaml:"created_at"`
+	Date      string    `yaml:"date"`
+}
+
+// splitFrontMatter separates a leading YAML block delimited by --- lines from the body
+func splitFrontMatter(src []byte) (meta, body []byte, ok bool) {
+	src = bytes.TrimPrefix(src, []byte("\ufeff"))
+	if !bytes.HasPrefix(src, []byte("---\n")) && !bytes.HasPrefix(src, []byte("---\r\n")) {
+		return nil, src, false
+	}
+
+	rest := src[bytes.IndexByte(src, '\n')+1:]
+	for offset := 0; offset <= len(rest); {
+		end := bytes.IndexByte(rest[offset:], '\n')
+		line := rest[offset:]
+		if end >= 0 {
+			line = rest[offset : offset+end]
+		}
+		if trimmed := strings.TrimRight(string(line), "\r"); trimmed == "---" || trimmed == "..." {
+			if end < 0 {
+				return rest[:offset], nil, true
+			}
+			return rest[:offset], rest[offset+end+1:], true
+		}
+		if end < 0 {
+			break
+		}
+		offset += end + 1
+	}
+
+	// No closing delimiter, the dashes are a thematic break
+	return nil, src, false
+}
+
+func parseFrontMatter(meta []byte, doc *Document) error {
+	var fm frontMatter
+	if err := yaml.Unmarshal(meta, &fm); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFrontMatter, err)
+	}
+
+	doc.Title = strings.TrimSpace(fm.Title)
+
+	// Tags are either a YAML list or a comma or space separated string
+	switch fm.Tags.Kind {
+	case yaml.SequenceNode:
+		for _, item := range fm.Tags.Content {
+			doc.Tags = appendTag(doc.Tags, item.Value)
+		}
+	case yaml.ScalarNode:
+		for _, tag := range strings.FieldsFunc(fm.Tags.Value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			doc.Tags = appendTag(doc.Tags, tag)
+		}
+	}
+
+	for _, raw := range []string{fm.CreatedAt, fm.Date} {
+		if at, ok := parseTime(raw); ok {
+			doc.CreatedAt = &at
+			break
+		}
+	}
+
+	return nil
+}
+
+func appendTag(tags []string, tag string) []string {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	if tag == "" {
+		return tags
+	}
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
+
+func parseTime(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if at, err := time.Parse(layout, raw); err == nil {
+			return at, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// takeTitle uses the text of the first heading as the title when the document has none yet.
+// A heading opening the document is removed, it would repeat the title.
+func takeTitle(doc *Document) {
+	if doc.Title != "" {
+		return
+	}
+
+	for i, n := range doc.Content.Content {
+		if n.Type != "heading" {
+			continue
+		}
+		if title := strings.TrimSpace(plainText(n.Content)); title != "" {
+			doc.Title = title
+			if i == 0 {
+				doc.Content.Content = doc.Content.Content[1:]
+			}
+		}
+		return
+	}
+}
+
+func plainText(nodes []entities.TiptapContent) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(n.Text)
+		b.WriteString(plainText(n.Content))
+	}
+	return b.String()
+}
+
+// inlineBuilder collects inline nodes, merging adjacent text with the same marks
+type inlineBuilder struct {
+	nodes []entities.TiptapContent
+}
+
+func (b *inlineBuilder) text(text string, marks []entities.TiptapMark) {
+	if text == "" {
+		return
+	}
+	if last := len(b.nodes) - 1; last >= 0 && b.nodes[last].Type == "text" && sameMarks(b.nodes[last].Marks, marks) {
+		b.nodes[last].Text += text
+		return
+	}
+	b.nodes = append(b.nodes, entities.TiptapContent{Type: "text", Text: text, Marks: cloneMarks(marks)})
+}
+
+func (b *inlineBuilder) node(n entities.TiptapContent) {
+	b.nodes = append(b.nodes, n)
+}
+
+func withMark(marks []entities.TiptapMark, mark entities.TiptapMark) []entities.TiptapMark {
+	for _, m := range marks {
+		if m.Type == mark.Type {
+			return marks
+		}
+	}
+	return append(cloneMarks(marks), mark)
+}
+
+func cloneMarks(marks []entities.TiptapMark) []entities.TiptapMark {
+	if len(marks) == 0 {
+		return nil
+	}
+	return append([]entities.TiptapMark(nil), marks...)
+}
+
+func sameMarks(a, b []entities.TiptapMark) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Type != b[i].Type || fmt.Sprint(a[i].Attrs) != fmt.Sprint(b[i].Attrs) {
+			return false
+		}
+	}
+	return true
+}
+
+func paragraph(inline []entities.TiptapContent) entities.TiptapContent {
+	return entities.TiptapContent{Type: "paragraph", Content: inline}
+}
+
+// listItemType is the item type used in a list of the given type
+func listItemType(listType string) string {
+	if listType == "taskList" {
+		return "taskItem"
+	}
+	return "listItem"
+}
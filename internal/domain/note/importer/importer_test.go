@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/rayhan889/neatspace/internal/domain/note/renderer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	t.Run("FrontMatter", func(t *testing.T) {
+		doc, err := ParseMarkdown([]byte("---\ntitle: \"Plan: Q3\"\ntags: [work, \"#roadmap\", Work]\ncreated_at: 2024-05-01T02:00:00Z\n---\n\n# Heading\n\nBody\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "Plan: Q3", doc.Title)
+		assert.Equal(t, []string{"work", "roadmap"}, doc.Tags)
+		require.NotNil(t, doc.CreatedAt)
+		assert.True(t, doc.CreatedAt.Equal(time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC)))
+		assert.Equal(t, "# Heading\n\nBody\n", renderer.Markdown(doc.Content), "the heading isn't the title")
+	})
+
+	t.Run("TitleFromFirstHeading", func(t *testing.T) {
+		doc, err := ParseMarkdown([]byte("# Weekly *sync*\n\nNotes\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "Weekly sync", doc.Title)
+		assert.Equal(t, "Notes\n", renderer.Markdown(doc.Content))
+	})
+
+	t.Run("InvalidFrontMatter", func(t *testing.T) {
+		_, err := ParseMarkdown([]byte("---\ntitle: [unclosed\n---\nBody\n"))
+		assert.ErrorIs(t, err, ErrInvalidFrontMatter)
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		src := "## Plan\n\n" +
+			"**bold** and _it_ ~~gone~~ [site](https://example.com \"Site\") with `x := 1`\\\nnext line\n\n" +
+			"3. one\n   - child\n4. two\n\n" +
+			"- [x] done\n- [ ] todo\n\n" +
+			"> quoted\n\n" +
+			"```go\nfmt.Println(\"*\")\n```\n\n" +
+			"---\n\n" +
+			"![diagram](https://example.com/a.png)\n\n" +
+			"| Name | Expr |\n| --- | --- |\n| **or**<br>else | `a \\|\\| b` |\n\n" +
+			"a\\*b\\_c \\[d\\]\n"
+
+		doc, err := ParseMarkdown([]byte(src))
+		require.NoError(t, err)
+
+		assert.Equal(t, "Plan", doc.Title)
+		assert.Equal(t, src[len("## Plan\n\n"):], renderer.Markdown(doc.Content))
+	})
+}
+
+func TestParseHTML(t *testing.T) {
+	t.Run("Page", func(t *testing.T) {
+		doc, err := ParseHTML([]byte(`<!DOCTYPE html><html><head><title>Trip</title><style>p{}</style></head><body>
+			<h1>Trip</h1>
+			<p>Pack  <strong>light</strong>,<br> see <a href="https://example.com">map</a></p>
+			<script>alert(1)</script>
+			<div>loose <em>text</em><ul class="task-list">
+				<li class="task-item"><input type="checkbox" disabled checked><p>tickets</p></li>
+				<li><label><input type="checkbox"> hotel</label></li>
+			</ul></div>
+			<pre><code class="language-sh">ls -la
+cd ~</code></pre>
+			<table><thead><tr><th>Day</th><th>Where</th></tr></thead><tbody><tr><td>1</td><td>Oslo</td></tr></tbody></table>
+		</body></html>`))
+		require.NoError(t, err)
+
+		assert.Equal(t, "Trip", doc.Title)
+		assert.Equal(t, "Pack **light**,\\\nsee [map](https://example.com)\n\n"+
+			"loose _text_\n\n"+
+			"- [x] tickets\n- [ ] hotel\n\n"+
+			"```sh\nls -la\ncd ~\n```\n\n"+
+			"| Day | Where |\n| --- | --- |\n| 1 | Oslo |\n", renderer.Markdown(doc.Content))
+	})
+
+	t.Run("RendererOutput", func(t *testing.T) {
+		original := entities.TiptapContent{Type: "doc", Content: []entities.TiptapContent{
+			{Type: "heading", Attrs: map[string]any{"level": 2}, Content: []entities.TiptapContent{{Type: "text", Text: "Intro"}}},
+			{Type: "orderedList", Attrs: map[string]any{"start": 2}, Content: []entities.TiptapContent{
+				{Type: "listItem", Content: []entities.TiptapContent{{Type: "paragraph", Content: []entities.TiptapContent{
+					{Type: "text", Text: "under", Marks: []entities.TiptapMark{{Type: "underline"}}},
+				}}}},
+			}},
+			{Type: "blockquote", Content: []entities.TiptapContent{{Type: "paragraph", Content: []entities.TiptapContent{{Type: "text", Text: "a < b"}}}}},
+		}}
+
+		doc, err := ParseHTML([]byte(renderer.HTML(original, renderer.HTMLOptions{})))
+		require.NoError(t, err)
+
+		assert.Equal(t, "Intro", doc.Title)
+		assert.Equal(t, original.Content[1:], doc.Content.Content)
+	})
+}
@@ -0,0 +1,271 @@
+package importer
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var markdownParser = goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser()
+
+// ParseMarkdown converts a CommonMark or GFM file into a Tiptap document. The title comes
+// from the front matter or else the first heading, which is then removed from the content.
+func ParseMarkdown(src []byte) (*Document, error) {
+	doc := &Document{}
+
+	meta, body, ok := splitFrontMatter(src)
+	if ok {
+		if err := parseFrontMatter(meta, doc); err != nil {
+			return nil, err
+		}
+	}
+
+	body = bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n"))
+	root := markdownParser.Parse(text.NewReader(body))
+
+	c := &markdownConverter{source: body}
+	doc.Content = entities.TiptapContent{Type: "doc", Content: c.blocks(root)}
+	takeTitle(doc)
+
+	return doc, nil
+}
+
+type markdownConverter struct {
+	source []byte
+}
+
+func (c *markdownConverter) blocks(parent ast.Node) []entities.TiptapContent {
+	var out []entities.TiptapContent
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		out = append(out, c.block(n)...)
+	}
+	return out
+}
+
+func (c *markdownConverter) block(n ast.Node) []entities.TiptapContent {
+	switch n := n.(type) {
+	case *ast.Paragraph, *ast.TextBlock:
+		if inline := c.inlines(n, nil); len(inline) > 0 {
+			return []entities.TiptapContent{paragraph(inline)}
+		}
+		return nil
+	case *ast.Heading:
+		return []entities.TiptapContent{{
+			Type:    "heading",
+			Attrs:   map[string]any{"level": n.Level},
+			Content: c.inlines(n, nil),
+		}}
+	case *ast.ThematicBreak:
+		return []entities.TiptapContent{{Type: "horizontalRule"}}
+	case *ast.FencedCodeBlock:
+		block := c.codeBlock(n)
+		if lang := string(n.Language(c.source)); lang != "" {
+			block.Attrs = map[string]any{"language": lang}
+		}
+		return []entities.TiptapContent{block}
+	case *ast.CodeBlock:
+		return []entities.TiptapContent{c.codeBlock(n)}
+	case *ast.Blockquote:
+		return []entities.TiptapContent{{Type: "blockquote", Content: c.blocks(n)}}
+	case *ast.List:
+		return []entities.TiptapContent{c.list(n)}
+	case *ast.HTMLBlock:
+		var raw bytes.Buffer
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			segment := lines.At(i)
+			raw.Write(segment.Value(c.source))
+		}
+		if n.HasClosure() {
+			raw.Write(n.ClosureLine.Value(c.source))
+		}
+		return htmlFragment(raw.Bytes())
+	case *extast.Table:
+		return []entities.TiptapContent{c.table(n)}
+	default:
+		return c.blocks(n)
+	}
+}
+
+func (c *markdownConverter) codeBlock(n ast.Node) entities.TiptapContent {
+	var code strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		code.Write(segment.Value(c.source))
+	}
+
+	block := entities.TiptapContent{Type: "codeBlock"}
+	if s := strings.TrimSuffix(code.String(), "\n"); s != "" {
+		block.Content = []entities.TiptapContent{{Type: "text", Text: s}}
+	}
+	return block
+}
+
+// list becomes a task list when its items start with a checkbox
+func (c *markdownConverter) list(n *ast.List) entities.TiptapContent {
+	list := entities.TiptapContent{Type: "bulletList"}
+	if n.IsOrdered() {
+		list.Type = "orderedList"
+		if n.Start != 1 {
+			list.Attrs = map[string]any{"start": n.Start}
+		}
+	} else if c.isTaskList(n) {
+		list.Type = "taskList"
+	}
+
+	for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+		li := entities.TiptapContent{Type: listItemType(list.Type), Content: c.blocks(item)}
+		if list.Type == "taskList" {
+			li.Attrs = map[string]any{"checked": c.taskChecked(item)}
+		}
+		if len(li.Content) == 0 {
+			li.Content = []entities.TiptapContent{paragraph(nil)}
+		}
+		list.Content = append(list.Content, li)
+	}
+	return list
+}
+
+func (c *markdownConverter) isTaskList(n *ast.List) bool {
+	for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+		if c.taskCheckBox(item) == nil {
+			return false
+		}
+	}
+	return n.FirstChild() != nil
+}
+
+func (c *markdownConverter) taskChecked(item ast.Node) bool {
+	box := c.taskCheckBox(item)
+	return box != nil && box.IsChecked
+}
+
+func (c *markdownConverter) taskCheckBox(item ast.Node) *extast.TaskCheckBox {
+	if block := item.FirstChild(); block != nil {
+		if box, ok := block.FirstChild().(*extast.TaskCheckBox); ok {
+			return box
+		}
+	}
+	return nil
+}
+
+// table marks the cells of the first row as headers, the way GFM renders it
+func (c *markdownConverter) table(n *extast.Table) entities.TiptapContent {
+	table := entities.TiptapContent{Type: "table"}
+	for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+		cellType := "tableCell"
+		if _, ok := row.(*extast.TableHeader); ok {
+			cellType = "tableHeader"
+		}
+
+		tr := entities.TiptapContent{Type: "tableRow"}
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			tr.Content = append(tr.Content, entities.TiptapContent{
+				Type:    cellType,
+				Content: []entities.TiptapContent{paragraph(c.inlines(cell, nil))},
+			})
+		}
+		table.Content = append(table.Content, tr)
+	}
+	return table
+}
+
+func (c *markdownConverter) inlines(parent ast.Node, marks []entities.TiptapMark) []entities.TiptapContent {
+	b := &inlineBuilder{}
+	c.collectInlines(b, parent, marks)
+
+	// Trailing line breaks carry no content
+	for len(b.nodes) > 0 && b.nodes[len(b.nodes)-1].Type == "hardBreak" {
+		b.nodes = b.nodes[:len(b.nodes)-1]
+	}
+	return b.nodes
+}
+
+func (c *markdownConverter) collectInlines(b *inlineBuilder, parent ast.Node, marks []entities.TiptapMark) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		switch n := n.(type) {
+		case *ast.Text:
+			value := n.Segment.Value(c.source)
+			if !n.IsRaw() {
+				value = util.ResolveEntityNames(util.ResolveNumericReferences(util.UnescapePunctuations(value)))
+			}
+			b.text(string(value), marks)
+			switch {
+			case n.HardLineBreak():
+				b.node(entities.TiptapContent{Type: "hardBreak"})
+			case n.SoftLineBreak():
+				b.text(" ", marks)
+			}
+		case *ast.String:
+			b.text(string(n.Value), marks)
+		case *ast.CodeSpan:
+			var code strings.Builder
+			for t := n.FirstChild(); t != nil; t = t.NextSibling() {
+				if segment, ok := t.(*ast.Text); ok {
+					code.Write(segment.Segment.Value(c.source))
+				} else if s, ok := t.(*ast.String); ok {
+					code.Write(s.Value)
+				}
+			}
+			b.text(code.String(), withMark(marks, entities.TiptapMark{Type: "code"}))
+		case *ast.Emphasis:
+			mark := "italic"
+			if n.Level >= 2 {
+				mark = "bold"
+			}
+			c.collectInlines(b, n, withMark(marks, entities.TiptapMark{Type: mark}))
+		case *extast.Strikethrough:
+			c.collectInlines(b, n, withMark(marks, entities.TiptapMark{Type: "strike"}))
+		case *ast.Link:
+			c.collectInlines(b, n, withMark(marks, linkMark(string(n.Destination), string(n.Title))))
+		case *ast.AutoLink:
+			url := string(n.URL(c.source))
+			href := url
+			if n.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(strings.ToLower(url), "mailto:") {
+				href = "mailto:" + url
+			}
+			b.text(string(n.Label(c.source)), withMark(marks, linkMark(href, "")))
+		case *ast.Image:
+			attrs := map[string]any{"src": string(n.Destination), "alt": plainText(c.inlines(n, nil))}
+			if len(n.Title) > 0 {
+				attrs["title"] = string(n.Title)
+			}
+			b.node(entities.TiptapContent{Type: "image", Attrs: attrs})
+		case *ast.RawHTML:
+			// Inline tags have no Tiptap equivalent except line breaks, table cells use them
+			var raw strings.Builder
+			for i := 0; i < n.Segments.Len(); i++ {
+				segment := n.Segments.At(i)
+				raw.Write(segment.Value(c.source))
+			}
+			if isBreakTag(raw.String()) {
+				b.node(entities.TiptapContent{Type: "hardBreak"})
+			}
+		case *extast.TaskCheckBox:
+			// Turned into the checked attribute of the task item
+		default:
+			c.collectInlines(b, n, marks)
+		}
+	}
+}
+
+func linkMark(href, title string) entities.TiptapMark {
+	attrs := map[string]any{"href": href}
+	if title != "" {
+		attrs["title"] = title
+	}
+	return entities.TiptapMark{Type: "link", Attrs: attrs}
+}
+
+func isBreakTag(tag string) bool {
+	tag = strings.ToLower(strings.Join(strings.Fields(tag), ""))
+	return tag == "<br>" || tag == "<br/>"
+}
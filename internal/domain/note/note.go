@@ -3,16 +3,27 @@ package note
 import (
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/internal/audit"
 	"github.com/rayhan889/neatspace/internal/domain/note/repositories"
+	"github.com/rayhan889/neatspace/internal/notification"
+	"github.com/rayhan889/neatspace/pkg/apputils"
 )
 
 type Options struct {
-	PgPool      *pgxpool.Pool
-	Logger      *slog.Logger
-	UserService services.UserServiceInterface
+	PgPool           *pgxpool.Pool
+	Logger           *slog.Logger
+	UserService      services.UserServiceInterface
+	WorkspaceService services.WorkspaceServiceInterface
+	AuditRecorder    audit.RecorderInterface
+	Mailer           *notification.Mailer
+	BaseURL          string
+	PasswordHasher   *apputils.PasswordHasher // Hasher for public link passwords (optional)
+	// How long tombstones of deleted notes are kept for syncing clients
+	TombstoneRetention time.Duration
 }
 
 type NoteDomain struct {
@@ -27,9 +38,25 @@ func NewNoteDomain(opts *Options) *NoteDomain {
 		logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{}))
 	}
 
+	passwordHasher := opts.PasswordHasher
+	if passwordHasher == nil {
+		passwordHasher = apputils.NewPasswordHasher()
+	}
+
 	noteService := services.NewNoteService(services.NoteServiceOpts{
-		NoteRepo:    repositories.NewNoteRepository(opts.PgPool, logger),
-		UserService: opts.UserService,
+		NoteRepo:           repositories.NewNoteRepository(opts.PgPool, logger),
+		ShareRepo:          repositories.NewNoteShareRepository(opts.PgPool, logger),
+		PublicLinkRepo:     repositories.NewNotePublicLinkRepository(opts.PgPool, logger),
+		LinkRepo:           repositories.NewNoteLinkRepository(opts.PgPool, logger),
+		TaskRepo:           repositories.NewNoteTaskRepository(opts.PgPool, logger),
+		UserService:        opts.UserService,
+		WorkspaceService:   opts.WorkspaceService,
+		AuditRecorder:      opts.AuditRecorder,
+		Logger:             logger,
+		Mailer:             opts.Mailer,
+		BaseURL:            opts.BaseURL,
+		PasswordHasher:     passwordHasher,
+		TombstoneRetention: opts.TombstoneRetention,
 	})
 
 	return &NoteDomain{
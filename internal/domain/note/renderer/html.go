@@ -0,0 +1,233 @@
+package renderer
+
+import (
+	"html"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/rayhan889/neatspace/internal/domain/note/entities"
+)
+
+// Schemes allowed in link hrefs and image sources, anything else is dropped
+var (
+	linkSchemes  = map[string]bool{"http": true, "https": true, "mailto": true}
+	imageSchemes = map[string]bool{"http": true, "https": true}
+)
+
+// HTMLOptions restricts what rendered HTML may load
+type HTMLOptions struct {
+	// URL prefixes images may be loaded from, either absolute like
+	// https://app.example.com/api/v1/attachments/ or a path on the same origin.
+	// Images from anywhere else are dropped, all of them when empty.
+	ImageSources []string
+	// Rewrites the src of images that passed the check, e.g. to sign it, optional
+	ImageURL func(src string) string
+}
+
+// HTML renders a Tiptap document as an HTML fragment. Text and attributes are
+// always escaped, link URLs are limited to an allowlist of schemes and images to
+// the sources of the options, so the output is safe to embed in a page as is.
+// Unknown node types are rendered through their children so no text is lost.
+func HTML(doc entities.TiptapContent, opts HTMLOptions) string {
+	r := &htmlRenderer{imageURL: opts.ImageURL}
+	for _, source := range opts.ImageSources {
+		if u, err := url.Parse(source); err == nil {
+			r.imageSources = append(r.imageSources, u)
+		}
+	}
+
+	r.nodes(doc.Content)
+	return r.b.String()
+}
+
+type htmlRenderer struct {
+	b            strings.Builder
+	imageSources []*url.URL
+	imageURL     func(src string) string
+}
+
+func (r *htmlRenderer) nodes(nodes []entities.TiptapContent) {
+	for _, n := range nodes {
+		r.node(n)
+	}
+}
+
+func (r *htmlRenderer) node(n entities.TiptapContent) {
+	b := &r.b
+
+	switch n.Type {
+	case "paragraph":
+		r.wrap("p", n.Content)
+	case "heading":
+		level := strconv.Itoa(min(max(intAttr(n.Attrs, "level", 1), 1), 6))
+		r.wrap("h"+level, n.Content)
+	case "blockquote":
+		r.wrap("blockquote", n.Content)
+	case "bulletList":
+		r.wrap("ul", n.Content)
+	case "orderedList":
+		if start := intAttr(n.Attrs, "start", 1); start != 1 {
+			b.WriteString(`<ol start="` + strconv.Itoa(start) + `">`)
+		} else {
+			b.WriteString("<ol>")
+		}
+		r.nodes(n.Content)
+		b.WriteString("</ol>")
+	case "taskList":
+		b.WriteString(`<ul class="task-list">`)
+		r.nodes(n.Content)
+		b.WriteString("</ul>")
+	case "listItem":
+		r.wrap("li", n.Content)
+	case "taskItem":
+		b.WriteString(`<li class="task-item"><input type="checkbox" disabled`)
+		if boolAttr(n.Attrs, "checked") {
+			b.WriteString(" checked")
+		}
+		b.WriteString(">")
+		r.nodes(n.Content)
+		b.WriteString("</li>")
+	case "codeBlock":
+		b.WriteString("<pre><code")
+		if lang := codeLanguage(stringAttr(n.Attrs, "language")); lang != "" {
+			b.WriteString(` class="language-` + lang + `"`)
+		}
+		b.WriteString(">")
+		b.WriteString(html.EscapeString(plainText(n.Content)))
+		b.WriteString("</code></pre>")
+	case "horizontalRule":
+		b.WriteString("<hr>")
+	case "hardBreak":
+		b.WriteString("<br>")
+	case "image":
+		r.image(n)
+	case "table":
+		b.WriteString("<table><tbody>")
+		r.nodes(n.Content)
+		b.WriteString("</tbody></table>")
+	case "tableRow":
+		r.wrap("tr", n.Content)
+	case "tableHeader":
+		r.wrap("th", n.Content)
+	case "tableCell":
+		r.wrap("td", n.Content)
+	case entities.NodeNoteMention:
+		b.WriteString(`<span class="note-mention">` + html.EscapeString("[["+stringAttr(n.Attrs, "label")+"]]") + "</span>")
+	case "text":
+		r.text(n.Text, n.Marks)
+	default:
+		r.nodes(n.Content)
+	}
+}
+
+func (r *htmlRenderer) wrap(tag string, children []entities.TiptapContent) {
+	r.b.WriteString("<" + tag + ">")
+	r.nodes(children)
+	r.b.WriteString("</" + tag + ">")
+}
+
+// htmlTags maps marks to the element wrapping the marked text
+var htmlTags = map[string]string{
+	"bold":        "strong",
+	"italic":      "em",
+	"strike":      "s",
+	"underline":   "u",
+	"code":        "code",
+	"highlight":   "mark",
+	"subscript":   "sub",
+	"superscript": "sup",
+}
+
+func (r *htmlRenderer) text(text string, marks []entities.TiptapMark) {
+	b := &r.b
+
+	var open, closing []string
+	for _, m := range marks {
+		if tag, ok := htmlTags[m.Type]; ok {
+			open = append(open, "<"+tag+">")
+			closing = append(closing, "</"+tag+">")
+			continue
+		}
+		if m.Type == "link" {
+			if href, ok := safeURL(stringAttr(m.Attrs, "href"), linkSchemes); ok {
+				open = append(open, `<a href="`+html.EscapeString(href)+`" rel="noopener noreferrer nofollow">`)
+				closing = append(closing, "</a>")
+			}
+		}
+	}
+
+	for _, tag := range open {
+		b.WriteString(tag)
+	}
+	b.WriteString(html.EscapeString(text))
+	for i := len(closing) - 1; i >= 0; i-- {
+		b.WriteString(closing[i])
+	}
+}
+
+func (r *htmlRenderer) image(n entities.TiptapContent) {
+	b := &r.b
+	src, ok := r.imageSource(stringAttr(n.Attrs, "src"))
+	if !ok {
+		return
+	}
+	if r.imageURL != nil {
+		src = r.imageURL(src)
+	}
+
+	b.WriteString(`<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(stringAttr(n.Attrs, "alt")) + `"`)
+	if title := stringAttr(n.Attrs, "title"); title != "" {
+		b.WriteString(` title="` + html.EscapeString(title) + `"`)
+	}
+	b.WriteString(">")
+}
+
+// imageSource returns the image URL if it lies under one of the image sources
+func (r *htmlRenderer) imageSource(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.User != nil {
+		return "", false
+	}
+
+	// Resolve dot segments so a source prefix can't be escaped with ../
+	u.Path, u.RawPath = path.Clean("/"+u.Path), ""
+	for _, source := range r.imageSources {
+		if source.Scheme == "" && source.Host == "" {
+			if u.Scheme != "" || u.Host != "" {
+				continue
+			}
+		} else if !imageSchemes[strings.ToLower(u.Scheme)] || !strings.EqualFold(u.Scheme, source.Scheme) || !strings.EqualFold(u.Host, source.Host) {
+			continue
+		}
+		if strings.HasPrefix(u.Path, source.Path) {
+			return u.String(), true
+		}
+	}
+	return "", false
+}
+
+// safeURL returns the URL if it is absolute with one of the allowed schemes
+func safeURL(raw string, schemes map[string]bool) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", false
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || !schemes[strings.ToLower(u.Scheme)] {
+		return "", false
+	}
+	return u.String(), true
+}
+
+// codeLanguage keeps language names usable as a class name, e.g. "c++" or "objective-c"
+func codeLanguage(lang string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '+' {
+			return r
+		}
+		return -1
+	}, lang)
+}
@@ -0,0 +1,111 @@
+package renderer
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTML(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		require.Equal(t, "", HTML(entities.TiptapContent{Type: "doc"}, HTMLOptions{}))
+	})
+
+	t.Run("HeadingsAndMarks", func(t *testing.T) {
+		doc := parseDoc(t, `{"type":"doc","content":[
+			{"type":"heading","attrs":{"level":2},"content":[{"type":"text","text":"Plan"}]},
+			{"type":"paragraph","content":[
+				{"type":"text","text":"bold","marks":[{"type":"bold"},{"type":"italic"}]},
+				{"type":"text","text":" and "},
+				{"type":"text","text":"site","marks":[{"type":"link","attrs":{"href":"https://example.com/?a=1&b=2"}}]}
+			]}
+		]}`)
+
+		require.Equal(t, `<h2>Plan</h2><p><strong><em>bold</em></strong> and <a href="https://example.com/?a=1&amp;b=2" rel="noopener noreferrer nofollow">site</a></p>`, HTML(doc, HTMLOptions{}))
+	})
+
+	t.Run("EscapesText", func(t *testing.T) {
+		doc := parseDoc(t, `{"type":"doc","content":[
+			{"type":"paragraph","content":[{"type":"text","text":"<script>alert(\"x\")</script>"}]},
+			{"type":"codeBlock","attrs":{"language":"js\"><script>"},"content":[{"type":"text","text":"a < b"}]}
+		]}`)
+
+		require.Equal(t, `<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</p><pre><code class="language-jsscript">a &lt; b</code></pre>`, HTML(doc, HTMLOptions{}))
+	})
+
+	t.Run("DropsUnsafeURLs", func(t *testing.T) {
+		doc := parseDoc(t, `{"type":"doc","content":[
+			{"type":"paragraph","content":[
+				{"type":"text","text":"click","marks":[{"type":"link","attrs":{"href":"javascript:alert(1)"}}]}
+			]},
+			{"type":"image","attrs":{"src":"data:image/svg+xml;base64,PHN2Zz4=","alt":"x"}},
+			{"type":"image","attrs":{"src":"https://cdn.example.com/a.png","alt":"x"}},
+			{"type":"image","attrs":{"src":"https://notes.example.com/api/v1/attachments/../admin.png","alt":"x"}},
+			{"type":"image","attrs":{"src":"https://notes.example.com/api/v1/attachments/a.png","alt":"a \"b\""}}
+		]}`)
+
+		opts := HTMLOptions{ImageSources: []string{"https://notes.example.com/api/v1/attachments/"}}
+		require.Equal(t, `<p>click</p><img src="https://notes.example.com/api/v1/attachments/a.png" alt="a &#34;b&#34;">`, HTML(doc, opts))
+	})
+
+	t.Run("RewritesImageURLs", func(t *testing.T) {
+		doc := parseDoc(t, `{"type":"doc","content":[
+			{"type":"image","attrs":{"src":"/api/v1/attachments/a.png","alt":"a"}},
+			{"type":"image","attrs":{"src":"https://cdn.example.com/b.png","alt":"b"}}
+		]}`)
+
+		opts := HTMLOptions{
+			ImageSources: []string{"/api/v1/attachments/"},
+			ImageURL:     func(src string) string { return src + "?signature=x&expires=1" },
+		}
+		require.Equal(t, `<img src="/api/v1/attachments/a.png?signature=x&amp;expires=1" alt="a">`, HTML(doc, opts))
+	})
+
+	t.Run("Lists", func(t *testing.T) {
+		doc := parseDoc(t, `{"type":"doc","content":[
+			{"type":"orderedList","attrs":{"start":3},"content":[
+				{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"one"}]}]}
+			]},
+			{"type":"taskList","content":[
+				{"type":"taskItem","attrs":{"checked":true},"content":[{"type":"paragraph","content":[{"type":"text","text":"done"}]}]}
+			]}
+		]}`)
+
+		require.Equal(t, `<ol start="3"><li><p>one</p></li></ol><ul class="task-list"><li class="task-item"><input type="checkbox" disabled checked><p>done</p></li></ul>`, HTML(doc, HTMLOptions{}))
+	})
+}
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestHTMLGolden renders every testdata/html/*.json document and compares it
+// with the .html file next to it, run with -update after intended changes
+func TestHTMLGolden(t *testing.T) {
+	opts := HTMLOptions{ImageSources: []string{"https://notes.example.com/api/v1/attachments/", "/api/v1/attachments/"}}
+
+	inputs, err := filepath.Glob(filepath.Join("testdata", "html", "*.json"))
+	require.NoError(t, err)
+	require.NotEmpty(t, inputs)
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".json")
+		t.Run(name, func(t *testing.T) {
+			raw, err := os.ReadFile(input)
+			require.NoError(t, err)
+
+			got := HTML(parseDoc(t, string(raw)), opts) + "\n"
+			golden := strings.TrimSuffix(input, ".json") + ".html"
+			if *update {
+				require.NoError(t, os.WriteFile(golden, []byte(got), 0o644))
+			}
+
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(want), got)
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rayhan889/neatspace/internal/domain/note/entities"
+)
+
+// Markdown renders a Tiptap document as Markdown. Unknown node types are
+// rendered through their children so no text is lost.
+func Markdown(doc entities.TiptapContent) string {
+	out := markdownBlocks(doc.Content)
+	if out == "" {
+		return ""
+	}
+	return out + "\n"
+}
+
+// MarkdownFile renders the note as a Markdown file with its metadata in a YAML
+// front matter block, the way static site generators and note apps expect it.
+func MarkdownFile(note *entities.NoteEntity) string {
+	// A JSON string is a valid YAML flow scalar, whatever the title contains
+	title, _ := json.Marshal(note.Title)
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.WriteString("id: " + note.ID.String() + "\n")
+	b.WriteString("title: " + string(title) + "\n")
+	if tags := entities.NormalizeTags(note.Tags); len(tags) > 0 {
+		// A JSON array is a valid YAML flow sequence
+		list, _ := json.Marshal(tags)
+		b.WriteString("tags: " + string(list) + "\n")
+	}
+	b.WriteString("workspace_id: " + note.WorkspaceID.String() + "\n")
+	b.WriteString("created_at: " + note.CreatedAt.UTC().Format(time.RFC3339) + "\n")
+	if note.UpdatedAt != nil {
+		b.WriteString("updated_at: " + note.UpdatedAt.UTC().Format(time.RFC3339) + "\n")
+	}
+	b.WriteString("---\n")
+
+	if body := Markdown(note.Content); body != "" {
+		b.WriteString("\n" + body)
+	}
+	return b.String()
+}
+
+func markdownBlocks(nodes []entities.TiptapContent) string {
+	parts := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		if s := markdownBlock(n); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func markdownBlock(n entities.TiptapContent) string {
+	switch n.Type {
+	case "paragraph":
+		return markdownInline(n.Content)
+	case "heading":
+		level := min(max(intAttr(n.Attrs, "level", 1), 1), 6)
+		return strings.Repeat("#", level) + " " + markdownInline(n.Content)
+	case "blockquote":
+		return prefixLines(markdownBlocks(n.Content), "> ")
+	case "bulletList":
+		return markdownList(n, func(int) string { return "- " })
+	case "orderedList":
+		start := intAttr(n.Attrs, "start", 1)
+		return markdownList(n, func(i int) string { return strconv.Itoa(start+i) + ". " })
+	case "taskList":
+		return markdownList(n, func(int) string { return "- " })
+	case "codeBlock":
+		code := plainText(n.Content)
+		fence := codeFence(code, "```")
+		return fence + stringAttr(n.Attrs, "language") + "\n" + code + "\n" + fence
+	case "horizontalRule":
+		return "---"
+	case "image":
+		return markdownImage(n)
+	case "table":
+		return markdownTable(n)
+	case "text", "hardBreak", entities.NodeNoteMention:
+		return markdownInline([]entities.TiptapContent{n})
+	default:
+		return markdownBlocks(n.Content)
+	}
+}
+
+func markdownList(list entities.TiptapContent, marker func(i int) string) string {
+	items := make([]string, 0, len(list.Content))
+	for i, item := range list.Content {
+		m := marker(i)
+		body := markdownListItem(item)
+		if item.Type == "taskItem" {
+			box := "[ ] "
+			if boolAttr(item.Attrs, "checked") {
+				box = "[x] "
+			}
+			body = box + body
+		}
+		items = append(items, m+indentFollowing(body, strings.Repeat(" ", len(m))))
+	}
+	return strings.Join(items, "\n")
+}
+
+// markdownListItem keeps nested lists tight against the preceding paragraph
+// while still separating sibling paragraphs with a blank line.
+func markdownListItem(item entities.TiptapContent) string {
+	var b strings.Builder
+	for i, n := range item.Content {
+		s := markdownBlock(n)
+		if s == "" {
+			continue
+		}
+		if i > 0 && b.Len() > 0 {
+			if isList(n.Type) {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+// markdownTable renders a GFM table. The first row is the header, as GFM requires one,
+// and rows are padded to the widest so every row has the same number of cells.
+func markdownTable(table entities.TiptapContent) string {
+	rows := make([][]string, 0, len(table.Content))
+	columns := 0
+	for _, row := range table.Content {
+		cells := make([]string, 0, len(row.Content))
+		for _, cell := range row.Content {
+			cells = append(cells, markdownTableCell(cell))
+		}
+		rows = append(rows, cells)
+		columns = max(columns, len(cells))
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	for i, cells := range rows {
+		for len(cells) < columns {
+			cells = append(cells, "")
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markdownTableCell keeps the cell on one line, blocks are separated with <br>
+func markdownTableCell(cell entities.TiptapContent) string {
+	parts := make([]string, 0, len(cell.Content))
+	for _, n := range cell.Content {
+		var s string
+		if n.Type == "paragraph" || n.Type == "heading" {
+			s = markdownInline(n.Content)
+		} else {
+			s = markdownBlock(n)
+		}
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+
+	text := strings.Join(parts, "<br>")
+	text = strings.ReplaceAll(text, "\\\n", "<br>")
+	text = strings.ReplaceAll(text, "\n", " ")
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+func markdownInline(nodes []entities.TiptapContent) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		switch n.Type {
+		case "text":
+			b.WriteString(applyMarks(n.Text, n.Marks))
+		case "hardBreak":
+			b.WriteString("\\\n")
+		case "image":
+			b.WriteString(markdownImage(n))
+		case entities.NodeNoteMention:
+			b.WriteString("[[" + escapeText(stringAttr(n.Attrs, "label")) + "]]")
+		default:
+			b.WriteString(markdownInline(n.Content))
+		}
+	}
+	return b.String()
+}
+
+func applyMarks(text string, marks []entities.TiptapMark) string {
+	if hasMark(marks, "code") {
+		text = codeSpan(text)
+	} else {
+		text = escapeText(text)
+	}
+
+	var link *entities.TiptapMark
+	for i := range marks {
+		switch marks[i].Type {
+		case "bold":
+			text = "**" + text + "**"
+		case "italic":
+			text = "_" + text + "_"
+		case "strike":
+			text = "~~" + text + "~~"
+		case "link":
+			link = &marks[i]
+		}
+	}
+
+	if link != nil {
+		text = "[" + text + "](" + linkDestination(link.Attrs) + ")"
+	}
+	return text
+}
+
+func markdownImage(n entities.TiptapContent) string {
+	alt := escapeText(stringAttr(n.Attrs, "alt"))
+	return "![" + alt + "](" + linkDestination(map[string]any{
+		"href":  stringAttr(n.Attrs, "src"),
+		"title": stringAttr(n.Attrs, "title"),
+	}) + ")"
+}
+
+func linkDestination(attrs map[string]any) string {
+	dest := strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(stringAttr(attrs, "href"))
+	if title := stringAttr(attrs, "title"); title != "" {
+		dest += fmt.Sprintf(" %q", title)
+	}
+	return dest
+}
+
+func codeSpan(text string) string {
+	fence := codeFence(text, "`")
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+// codeFence returns a run of backticks longer than any run found in text
+func codeFence(text, minFence string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	if longest < len(minFence) {
+		return minFence
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+)
+
+func escapeText(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+func plainText(nodes []entities.TiptapContent) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		if n.Type == "hardBreak" {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(n.Text)
+		b.WriteString(plainText(n.Content))
+	}
+	return b.String()
+}
+
+func prefixLines(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(prefix+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func indentFollowing(s, indent string) string {
+	lines := strings.Split(s, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isList(nodeType string) bool {
+	return nodeType == "bulletList" || nodeType == "orderedList" || nodeType == "taskList"
+}
+
+func hasMark(marks []entities.TiptapMark, markType string) bool {
+	for _, m := range marks {
+		if m.Type == markType {
+			return true
+		}
+	}
+	return false
+}
+
+func stringAttr(attrs map[string]any, key string) string {
+	if v, ok := attrs[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func intAttr(attrs map[string]any, key string, def int) int {
+	switch v := attrs[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+func boolAttr(attrs map[string]any, key string) bool {
+	v, _ := attrs[key].(bool)
+	return v
+}
@@ -0,0 +1,155 @@
+package renderer
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/stretchr/testify/require"
+)
+
+func parseDoc(t *testing.T, raw string) entities.TiptapContent {
+	t.Helper()
+	var doc entities.TiptapContent
+	require.NoError(t, json.Unmarshal([]byte(raw), &doc))
+	return doc
+}
+
+func TestMarkdown(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		require.Equal(t, "", Markdown(entities.TiptapContent{Type: "doc"}))
+	})
+
+	t.Run("HeadingsAndMarks", func(t *testing.T) {
+		doc := parseDoc(t, `{"type":"doc","content":[
+			{"type":"heading","attrs":{"level":2},"content":[{"type":"text","text":"Plan"}]},
+			{"type":"paragraph","content":[
+				{"type":"text","text":"bold","marks":[{"type":"bold"}]},
+				{"type":"text","text":" and "},
+				{"type":"text","text":"site","marks":[{"type":"link","attrs":{"href":"https://example.com"}}]},
+				{"type":"text","text":" with "},
+				{"type":"text","text":"x := 1","marks":[{"type":"code"}]}
+			]}
+		]}`)
+
+		require.Equal(t, "## Plan\n\n**bold** and [site](https://example.com) with `x := 1`\n", Markdown(doc))
+	})
+
+	t.Run("EscapesText", func(t *testing.T) {
+		doc := parseDoc(t, `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"a*b_c [d]"}]}]}`)
+		require.Equal(t, "a\\*b\\_c \\[d\\]\n", Markdown(doc))
+	})
+
+	t.Run("NoteMentions", func(t *testing.T) {
+		doc := parseDoc(t, `{"type":"doc","content":[{"type":"paragraph","content":[
+			{"type":"text","text":"See "},
+			{"type":"noteMention","attrs":{"id":"0b5c3f8e-4a51-4d2b-9a0e-3c1f6d7e8a90","label":"Q3 [draft]"}}
+		]}]}`)
+		require.Equal(t, "See [[Q3 \\[draft\\]]]\n", Markdown(doc))
+	})
+
+	t.Run("NestedLists", func(t *testing.T) {
+		doc := parseDoc(t, `{"type":"doc","content":[
+			{"type":"orderedList","attrs":{"start":3},"content":[
+				{"type":"listItem","content":[
+					{"type":"paragraph","content":[{"type":"text","text":"one"}]},
+					{"type":"bulletList","content":[
+						{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"child"}]}]}
+					]}
+				]},
+				{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"two"}]}]}
+			]},
+			{"type":"taskList","content":[
+				{"type":"taskItem","attrs":{"checked":true},"content":[{"type":"paragraph","content":[{"type":"text","text":"done"}]}]},
+				{"type":"taskItem","attrs":{"checked":false},"content":[{"type":"paragraph","content":[{"type":"text","text":"todo"}]}]}
+			]}
+		]}`)
+
+		require.Equal(t, "3. one\n   - child\n4. two\n\n- [x] done\n- [ ] todo\n", Markdown(doc))
+	})
+
+	t.Run("BlocksAndCode", func(t *testing.T) {
+		doc := parseDoc(t, `{"type":"doc","content":[
+			{"type":"blockquote","content":[
+				{"type":"paragraph","content":[{"type":"text","text":"quoted"}]},
+				{"type":"paragraph","content":[{"type":"text","text":"twice"}]}
+			]},
+			{"type":"codeBlock","attrs":{"language":"go"},"content":[{"type":"text","text":"fmt.Println(\"*\")"}]},
+			{"type":"horizontalRule"},
+			{"type":"paragraph","content":[
+				{"type":"text","text":"line"},
+				{"type":"hardBreak"},
+				{"type":"text","text":"next"}
+			]}
+		]}`)
+
+		require.Equal(t, "> quoted\n>\n> twice\n\n```go\nfmt.Println(\"*\")\n```\n\n---\n\nline\\\nnext\n", Markdown(doc))
+	})
+
+	t.Run("Tables", func(t *testing.T) {
+		doc := parseDoc(t, `{"type":"doc","content":[
+			{"type":"table","content":[
+				{"type":"tableRow","content":[
+					{"type":"tableHeader","content":[{"type":"paragraph","content":[{"type":"text","text":"Name"}]}]},
+					{"type":"tableHeader","content":[{"type":"paragraph","content":[{"type":"text","text":"Expr"}]}]}
+				]},
+				{"type":"tableRow","content":[
+					{"type":"tableCell","content":[
+						{"type":"paragraph","content":[{"type":"text","text":"or","marks":[{"type":"bold"}]}]},
+						{"type":"paragraph","content":[{"type":"text","text":"else"}]}
+					]},
+					{"type":"tableCell","content":[{"type":"paragraph","content":[{"type":"text","text":"a || b","marks":[{"type":"code"}]}]}]}
+				]},
+				{"type":"tableRow","content":[
+					{"type":"tableCell","content":[{"type":"paragraph","content":[
+						{"type":"text","text":"line"},
+						{"type":"hardBreak"},
+						{"type":"text","text":"next"}
+					]}]}
+				]}
+			]}
+		]}`)
+
+		require.Equal(t, "| Name | Expr |\n| --- | --- |\n| **or**<br>else | `a \\|\\| b` |\n| line<br>next |  |\n", Markdown(doc))
+	})
+
+	t.Run("FrontMatter", func(t *testing.T) {
+		updatedAt := time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC)
+		note := &entities.NoteEntity{
+			ID:          uuid.MustParse("7d4b0a5e-36a4-4b55-9d3c-0d1f4c6f1a11"),
+			WorkspaceID: uuid.MustParse("0b6f3f0e-7f5c-4b7a-8f8e-2b8f0d3e4c22"),
+			Title:       `Plan: "Q3"`,
+			Content:     parseDoc(t, `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"Ship it"}]}]}`),
+			CreatedAt:   time.Date(2024, 5, 1, 9, 0, 0, 0, time.FixedZone("WIB", 7*3600)),
+			UpdatedAt:   &updatedAt,
+		}
+
+		require.Equal(t, "---\n"+
+			"id: 7d4b0a5e-36a4-4b55-9d3c-0d1f4c6f1a11\n"+
+			"title: \"Plan: \\\"Q3\\\"\"\n"+
+			"workspace_id: 0b6f3f0e-7f5c-4b7a-8f8e-2b8f0d3e4c22\n"+
+			"created_at: 2024-05-01T02:00:00Z\n"+
+			"updated_at: 2024-05-02T08:30:00Z\n"+
+			"---\n\nShip it\n", MarkdownFile(note))
+	})
+
+	t.Run("FrontMatterTags", func(t *testing.T) {
+		note := &entities.NoteEntity{
+			ID:          uuid.MustParse("7d4b0a5e-36a4-4b55-9d3c-0d1f4c6f1a11"),
+			WorkspaceID: uuid.MustParse("0b6f3f0e-7f5c-4b7a-8f8e-2b8f0d3e4c22"),
+			Title:       "Plan",
+			Tags:        []string{"work", "#road map", "Work"},
+			CreatedAt:   time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC),
+		}
+
+		require.Equal(t, "---\n"+
+			"id: 7d4b0a5e-36a4-4b55-9d3c-0d1f4c6f1a11\n"+
+			"title: \"Plan\"\n"+
+			"tags: [\"work\",\"road map\"]\n"+
+			"workspace_id: 0b6f3f0e-7f5c-4b7a-8f8e-2b8f0d3e4c22\n"+
+			"created_at: 2024-05-01T02:00:00Z\n"+
+			"---\n", MarkdownFile(note))
+	})
+}
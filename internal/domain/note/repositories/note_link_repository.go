@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	workspaceEntity "github.com/rayhan889/neatspace/internal/domain/workspace/entities"
+)
+
+type NoteLinkRepositoryInterface interface {
+	ReplaceNoteLinks(ctx context.Context, sourceNoteID uuid.UUID, targets []noteEntity.NoteLinkTarget) error
+	ListBacklinks(ctx context.Context, targetNoteID, userID uuid.UUID) ([]noteEntity.NoteLinkDetail, error)
+	ListOutgoingLinks(ctx context.Context, sourceNoteID, userID uuid.UUID) ([]noteEntity.NoteLinkDetail, error)
+	ListLinkingNoteIDs(ctx context.Context, targetNoteID uuid.UUID) ([]uuid.UUID, error)
+	SetLinksDangling(ctx context.Context, targetNoteID uuid.UUID, dangling bool) error
+}
+
+var _ NoteLinkRepositoryInterface = (*NoteLinkRepository)(nil)
+
+type NoteLinkRepository struct {
+	pgPool *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewNoteLinkRepository(pgPool *pgxpool.Pool, logger *slog.Logger) *NoteLinkRepository {
+	return &NoteLinkRepository{
+		pgPool: pgPool,
+		logger: logger,
+	}
+}
+
+// readersCanViewTarget is the condition, on source note s and target note n, that everyone
+// reading s can view n, as a member of n's workspace or through a share of n. Readers of s
+// are the members of its workspace and the users it's shared with. Takes the member and share
+// tables as arguments.
+const readersCanViewTarget = `NOT EXISTS (
+	SELECT 1 FROM (
+		SELECT sm.user_id FROM %[1]s sm WHERE sm.workspace_id = s.workspace_id
+		UNION SELECT ss.user_id FROM %[2]s ss WHERE ss.note_id = s.id AND ss.user_id IS NOT NULL
+	) reader
+	WHERE NOT EXISTS (SELECT 1 FROM %[1]s tm WHERE tm.workspace_id = n.workspace_id AND tm.user_id = reader.user_id)
+		AND NOT EXISTS (SELECT 1 FROM %[2]s ts WHERE ts.note_id = n.id AND ts.user_id = reader.user_id)
+)`
+
+// ReplaceNoteLinks stores the links found in the content of the source note, dropping the
+// ones it no longer has. Only links to notes every reader of the source can view are kept,
+// so neither backlinks nor renames reveal a note to someone without access to it. Links to
+// notes that don't exist or were deleted are dangling.
+func (r *NoteLinkRepository) ReplaceNoteLinks(ctx context.Context, sourceNoteID uuid.UUID, targets []noteEntity.NoteLinkTarget) error {
+	query := fmt.Sprintf(`
+		WITH allowed AS (
+			SELECT t.id, t.title, n.id IS NULL OR n.deleted_at IS NOT NULL AS dangling
+			FROM unnest($2::UUID[], $3::TEXT[]) AS t(id, title)
+			JOIN %[2]s s ON s.id = $1
+			LEFT JOIN %[2]s n ON n.id = t.id
+			WHERE n.id IS NULL OR %[3]s
+		), dropped AS (
+			DELETE FROM %[1]s WHERE source_note_id = $1 AND target_note_id NOT IN (SELECT id FROM allowed)
+		)
+		INSERT INTO %[1]s (source_note_id, target_note_id, target_title, dangling)
+		SELECT $1, a.id, a.title, a.dangling FROM allowed a
+		ON CONFLICT (source_note_id, target_note_id) DO UPDATE
+		SET target_title = EXCLUDED.target_title, dangling = EXCLUDED.dangling`,
+		noteEntity.NoteLinkTable, noteEntity.NoteTable,
+		fmt.Sprintf(readersCanViewTarget, workspaceEntity.WorkspaceMemberTable, noteEntity.NoteShareTable))
+
+	ids := make([]uuid.UUID, len(targets))
+	titles := make([]string, len(targets))
+	for i, target := range targets {
+		ids[i] = target.NoteID
+		titles[i] = target.Title
+	}
+
+	if _, err := r.pgPool.Exec(ctx, query, sourceNoteID, ids, titles); err != nil {
+		r.logger.Error("failed to replace note links", slog.String("op", "ReplaceNoteLinks"), slog.String("err", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// ListBacklinks returns the links to the note from notes the user can access, with those notes
+// ordered by title
+func (r *NoteLinkRepository) ListBacklinks(ctx context.Context, targetNoteID, userID uuid.UUID) ([]noteEntity.NoteLinkDetail, error) {
+	query := fmt.Sprintf(`
+		SELECT l.source_note_id, l.target_note_id, l.target_title, l.dangling, l.created_at,
+			n.workspace_id, n.title, n.updated_at, wm.role, ns.permission
+		FROM %s l
+		JOIN %s n ON n.id = l.source_note_id AND n.deleted_at IS NULL
+		LEFT JOIN %s wm ON wm.workspace_id = n.workspace_id AND wm.user_id = $2
+		LEFT JOIN %s ns ON ns.note_id = n.id AND ns.user_id = $2
+		WHERE l.target_note_id = $1 AND (wm.user_id IS NOT NULL OR ns.user_id IS NOT NULL)
+		ORDER BY n.title, n.id`, noteEntity.NoteLinkTable, noteEntity.NoteTable, workspaceEntity.WorkspaceMemberTable, noteEntity.NoteShareTable)
+
+	rows, err := r.pgPool.Query(ctx, query, targetNoteID, userID)
+	if err != nil {
+		r.logger.Error("failed to list backlinks", slog.String("op", "ListBacklinks"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return r.scanLinkDetails(rows, "ListBacklinks")
+}
+
+// ListOutgoingLinks returns every link of the note in the order they were added, joined with
+// the target note while it exists. The caller hides targets the user can't access.
+func (r *NoteLinkRepository) ListOutgoingLinks(ctx context.Context, sourceNoteID, userID uuid.UUID) ([]noteEntity.NoteLinkDetail, error) {
+	query := fmt.Sprintf(`
+		SELECT l.source_note_id, l.target_note_id, l.target_title, l.dangling, l.created_at,
+			n.workspace_id, n.title, n.updated_at, wm.role, ns.permission
+		FROM %s l
+		LEFT JOIN %s n ON n.id = l.target_note_id AND n.deleted_at IS NULL
+		LEFT JOIN %s wm ON wm.workspace_id = n.workspace_id AND wm.user_id = $2
+		LEFT JOIN %s ns ON ns.note_id = n.id AND ns.user_id = $2
+		WHERE l.source_note_id = $1
+		ORDER BY l.created_at, l.target_note_id`, noteEntity.NoteLinkTable, noteEntity.NoteTable, workspaceEntity.WorkspaceMemberTable, noteEntity.NoteShareTable)
+
+	rows, err := r.pgPool.Query(ctx, query, sourceNoteID, userID)
+	if err != nil {
+		r.logger.Error("failed to list outgoing links", slog.String("op", "ListOutgoingLinks"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return r.scanLinkDetails(rows, "ListOutgoingLinks")
+}
+
+// ListLinkingNoteIDs returns the notes that link to the note whose readers can all view it,
+// deleted ones excluded. Access may have changed since the links were stored.
+func (r *NoteLinkRepository) ListLinkingNoteIDs(ctx context.Context, targetNoteID uuid.UUID) ([]uuid.UUID, error) {
+	query := fmt.Sprintf(`
+		SELECT l.source_note_id FROM %[1]s l
+		JOIN %[2]s s ON s.id = l.source_note_id AND s.deleted_at IS NULL
+		JOIN %[2]s n ON n.id = l.target_note_id
+		WHERE l.target_note_id = $1 AND %[3]s`,
+		noteEntity.NoteLinkTable, noteEntity.NoteTable,
+		fmt.Sprintf(readersCanViewTarget, workspaceEntity.WorkspaceMemberTable, noteEntity.NoteShareTable))
+
+	rows, err := r.pgPool.Query(ctx, query, targetNoteID)
+	if err != nil {
+		r.logger.Error("failed to list linking notes", slog.String("op", "ListLinkingNoteIDs"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	noteIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		r.logger.Error("failed to scan linking notes", slog.String("op", "ListLinkingNoteIDs"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return noteIDs, nil
+}
+
+// SetLinksDangling marks every link to the note as dangling, or as resolved again
+func (r *NoteLinkRepository) SetLinksDangling(ctx context.Context, targetNoteID uuid.UUID, dangling bool) error {
+	query := fmt.Sprintf(`UPDATE %s SET dangling = $2 WHERE target_note_id = $1 AND dangling <> $2`, noteEntity.NoteLinkTable)
+
+	cmd, err := r.pgPool.Exec(ctx, query, targetNoteID, dangling)
+	if err != nil {
+		r.logger.Error("failed to update dangling note links", slog.String("op", "SetLinksDangling"), slog.String("err", err.Error()))
+		return err
+	}
+
+	if cmd.RowsAffected() > 0 {
+		r.logger.Info("note links updated", slog.String("op", "SetLinksDangling"), slog.String("note_id", targetNoteID.String()), slog.Bool("dangling", dangling), slog.Int64("count", cmd.RowsAffected()))
+	}
+	return nil
+}
+
+func (r *NoteLinkRepository) scanLinkDetails(rows pgx.Rows, op string) ([]noteEntity.NoteLinkDetail, error) {
+	defer rows.Close()
+
+	links := []noteEntity.NoteLinkDetail{}
+	for rows.Next() {
+		var link noteEntity.NoteLinkDetail
+		err := rows.Scan(
+			&link.SourceNoteID,
+			&link.TargetNoteID,
+			&link.TargetTitle,
+			&link.Dangling,
+			&link.CreatedAt,
+			&link.WorkspaceID,
+			&link.NoteTitle,
+			&link.NoteUpdatedAt,
+			&link.Role,
+			&link.SharePermission,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan note link", slog.String("op", op), slog.String("err", err.Error()))
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to iterate note links", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return links, nil
+}
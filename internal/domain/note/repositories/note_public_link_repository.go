@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+)
+
+const notePublicLinkColumns = `id, note_id, slug, password_hash, expires_at, view_count, created_by, created_at, updated_at`
+
+type NotePublicLinkRepositoryInterface interface {
+	CreateLink(ctx context.Context, link *noteEntity.NotePublicLinkEntity) error
+	GetLinkByNoteID(ctx context.Context, noteID uuid.UUID) (*noteEntity.NotePublicLinkEntity, error)
+	GetLinkBySlug(ctx context.Context, slug string) (*noteEntity.NotePublicLinkEntity, error)
+	UpdateLinkSettings(ctx context.Context, linkID uuid.UUID, passwordHash *string, expiresAt *time.Time) error
+	IncrementViewCount(ctx context.Context, linkID uuid.UUID) error
+	DeleteLinkByNoteID(ctx context.Context, noteID uuid.UUID) (bool, error)
+}
+
+var _ NotePublicLinkRepositoryInterface = (*NotePublicLinkRepository)(nil)
+
+type NotePublicLinkRepository struct {
+	pgPool *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewNotePublicLinkRepository(pgPool *pgxpool.Pool, logger *slog.Logger) *NotePublicLinkRepository {
+	return &NotePublicLinkRepository{
+		pgPool: pgPool,
+		logger: logger,
+	}
+}
+
+func (r *NotePublicLinkRepository) CreateLink(ctx context.Context, link *noteEntity.NotePublicLinkEntity) error {
+	query := fmt.Sprintf(`INSERT INTO %s (id, note_id, slug, password_hash, expires_at, created_by, created_at) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`, noteEntity.NotePublicLinkTable)
+
+	_, err := r.pgPool.Exec(ctx, query,
+		link.ID,
+		link.NoteID,
+		link.Slug,
+		link.PasswordHash,
+		link.ExpiresAt,
+		link.CreatedBy,
+		link.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("failed to create public link", slog.String("op", "CreateLink"), slog.String("err", err.Error()))
+		return err
+	}
+
+	r.logger.Info("public link created successfully", slog.String("op", "CreateLink"), slog.String("note_id", link.NoteID.String()))
+	return nil
+}
+
+func (r *NotePublicLinkRepository) GetLinkByNoteID(ctx context.Context, noteID uuid.UUID) (*noteEntity.NotePublicLinkEntity, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE note_id = $1`, notePublicLinkColumns, noteEntity.NotePublicLinkTable)
+
+	link, err := scanNotePublicLink(r.pgPool.QueryRow(ctx, query, noteID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get public link by note id", slog.String("op", "GetLinkByNoteID"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func (r *NotePublicLinkRepository) GetLinkBySlug(ctx context.Context, slug string) (*noteEntity.NotePublicLinkEntity, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE slug = $1`, notePublicLinkColumns, noteEntity.NotePublicLinkTable)
+
+	link, err := scanNotePublicLink(r.pgPool.QueryRow(ctx, query, slug))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get public link by slug", slog.String("op", "GetLinkBySlug"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// UpdateLinkSettings replaces the password and expiry of the link, nil values clear them
+func (r *NotePublicLinkRepository) UpdateLinkSettings(ctx context.Context, linkID uuid.UUID, passwordHash *string, expiresAt *time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET password_hash = $1, expires_at = $2 WHERE id = $3`, noteEntity.NotePublicLinkTable)
+
+	if _, err := r.pgPool.Exec(ctx, query, passwordHash, expiresAt, linkID); err != nil {
+		r.logger.Error("failed to update public link", slog.String("op", "UpdateLinkSettings"), slog.String("err", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (r *NotePublicLinkRepository) IncrementViewCount(ctx context.Context, linkID uuid.UUID) error {
+	query := fmt.Sprintf(`UPDATE %s SET view_count = view_count + 1 WHERE id = $1`, noteEntity.NotePublicLinkTable)
+
+	if _, err := r.pgPool.Exec(ctx, query, linkID); err != nil {
+		r.logger.Error("failed to increment public link view count", slog.String("op", "IncrementViewCount"), slog.String("err", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteLinkByNoteID unpublishes the note, reporting whether it had a link
+func (r *NotePublicLinkRepository) DeleteLinkByNoteID(ctx context.Context, noteID uuid.UUID) (bool, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE note_id = $1`, noteEntity.NotePublicLinkTable)
+
+	cmd, err := r.pgPool.Exec(ctx, query, noteID)
+	if err != nil {
+		r.logger.Error("failed to delete public link", slog.String("op", "DeleteLinkByNoteID"), slog.String("err", err.Error()))
+		return false, err
+	}
+
+	return cmd.RowsAffected() > 0, nil
+}
+
+func scanNotePublicLink(row pgx.Row) (*noteEntity.NotePublicLinkEntity, error) {
+	var link noteEntity.NotePublicLinkEntity
+	err := row.Scan(
+		&link.ID,
+		&link.NoteID,
+		&link.Slug,
+		&link.PasswordHash,
+		&link.ExpiresAt,
+		&link.ViewCount,
+		&link.CreatedBy,
+		&link.CreatedAt,
+		&link.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
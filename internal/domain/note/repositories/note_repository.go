@@ -3,21 +3,43 @@ package repositories
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rayhan889/neatspace/internal/application/handler/dto"
 	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	workspaceEntity "github.com/rayhan889/neatspace/internal/domain/workspace/entities"
 	"github.com/rayhan889/neatspace/pkg/apputils"
 )
 
 type NoteRepositoryInterface interface {
 	PaginationNote(c *fiber.Ctx, p *apputils.Pagination) (data []dto.NotePaginationResponse, total int, err error)
 	CreateNote(ctx context.Context, note *noteEntity.NoteEntity) error
+	CreateNotes(ctx context.Context, notes []*noteEntity.NoteEntity) error
+	ListNotesByUserID(ctx context.Context, userID uuid.UUID) ([]noteEntity.NoteEntity, error)
+	ListNotesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]noteEntity.NoteEntity, error)
+	ListNotesByTag(ctx context.Context, userID uuid.UUID, tag string) ([]noteEntity.NoteEntity, error)
+	CountNotesByUserID(ctx context.Context, userID uuid.UUID) (int, error)
+	GetNoteByID(ctx context.Context, noteID uuid.UUID) (*noteEntity.NoteEntity, error)
+	GetNoteIncludingDeleted(ctx context.Context, noteID uuid.UUID) (*noteEntity.NoteEntity, error)
+	DeleteNote(ctx context.Context, noteID uuid.UUID, ifVersion *int64) (bool, error)
+	UpdateNote(ctx context.Context, note *noteEntity.NoteEntity, ifVersion *int64) (bool, error)
+	SyncWatermark(ctx context.Context) (uint64, error)
+	ListNoteChanges(ctx context.Context, userID uuid.UUID, after noteEntity.SyncToken, before uint64, limit int) ([]noteEntity.NoteChange, error)
+	AccessChangedSince(ctx context.Context, userID uuid.UUID, xid uint64) (bool, error)
+	PurgeDeletedNotes(ctx context.Context, before time.Time) (int, error)
 }
 
+// Notes purged per statement, keeps each delete transaction short
+const purgeBatchSize = 1000
+
 var _ NoteRepositoryInterface = (*NoteRepository)(nil)
 
 type NoteRepository struct {
@@ -34,15 +56,15 @@ func NewNoteRepository(pgPool *pgxpool.Pool, logger *slog.Logger) *NoteRepositor
 
 func (r *NoteRepository) PaginationNote(c *fiber.Ctx, p *apputils.Pagination) (data []dto.NotePaginationResponse, total int, err error) {
 	query := fmt.Sprintf(`
-			SELECT title, content, created_at, updated_at 
+			SELECT id, workspace_id, title, content, tags, created_at, updated_at 
 			FROM %s 
-			WHERE 1=1`,
+			WHERE deleted_at IS NULL`,
 		noteEntity.NoteTable)
 
 	query, args := r.queryFilter(c, query)
 
 	argPos := len(args) + 1
-	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", argPos, argPos+1)
 	args = append(args, p.Limit, p.Offset)
 
 	rows, err := r.pgPool.Query(c.Context(), query, args...)
@@ -59,8 +81,11 @@ func (r *NoteRepository) PaginationNote(c *fiber.Ctx, p *apputils.Pagination) (d
 		var tiptapContentBytes []byte
 
 		err := rows.Scan(
+			&item.ID,
+			&item.WorkspaceID,
 			&item.Title,
 			&tiptapContentBytes,
+			&item.Tags,
 			&item.CreatedAt,
 			&item.UpdatedAt,
 		)
@@ -84,7 +109,7 @@ func (r *NoteRepository) PaginationNote(c *fiber.Ctx, p *apputils.Pagination) (d
 	countQuery := fmt.Sprintf(`
 		SELECT COUNT(*) 
 		FROM %s
-		WHERE 1=1
+		WHERE deleted_at IS NULL
 	`, noteEntity.NoteTable)
 
 	countQuery, countArgs := r.queryFilter(c, countQuery)
@@ -101,15 +126,17 @@ func (r *NoteRepository) PaginationNote(c *fiber.Ctx, p *apputils.Pagination) (d
 
 func (r *NoteRepository) CreateNote(ctx context.Context, note *noteEntity.NoteEntity) error {
 	_, err := r.pgPool.Exec(ctx, fmt.Sprintf(`
-		INSERT INTO %s (id, title, user_id, content, content_text, created_at, updated_at) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7)`, noteEntity.NoteTable),
+		INSERT INTO %s (id, workspace_id, title, user_id, content, content_text, created_at, updated_at, tags) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`, noteEntity.NoteTable),
 		note.ID,
+		note.WorkspaceID,
 		note.Title,
 		note.UserID,
 		note.Content,
 		note.ContentText,
 		note.CreatedAt,
 		note.UpdatedAt,
+		noteEntity.NormalizeTags(note.Tags),
 	)
 	if err != nil {
 		r.logger.Error("failed to create note", slog.String("op", "CreateNote"), slog.String("err", err.Error()))
@@ -120,6 +147,379 @@ func (r *NoteRepository) CreateNote(ctx context.Context, note *noteEntity.NoteEn
 	return nil
 }
 
+// CreateNotes stores all the notes in one transaction, none of them when one fails
+func (r *NoteRepository) CreateNotes(ctx context.Context, notes []*noteEntity.NoteEntity) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, workspace_id, title, user_id, content, content_text, created_at, updated_at, tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`, noteEntity.NoteTable)
+
+	err := pgx.BeginFunc(ctx, r.pgPool, func(tx pgx.Tx) error {
+		batch := &pgx.Batch{}
+		for _, note := range notes {
+			batch.Queue(query, note.ID, note.WorkspaceID, note.Title, note.UserID, note.Content, note.ContentText, note.CreatedAt, note.UpdatedAt, noteEntity.NormalizeTags(note.Tags))
+		}
+		return tx.SendBatch(ctx, batch).Close()
+	})
+	if err != nil {
+		r.logger.Error("failed to create notes", slog.String("op", "CreateNotes"), slog.String("err", err.Error()))
+		return err
+	}
+
+	r.logger.Info("notes created successfully", slog.String("op", "CreateNotes"), slog.Int("count", len(notes)))
+	return nil
+}
+
+func (r *NoteRepository) ListNotesByUserID(ctx context.Context, userID uuid.UUID) ([]noteEntity.NoteEntity, error) {
+	query := fmt.Sprintf(`
+		SELECT id, workspace_id, user_id, title, content, content_text, tags, created_at, updated_at 
+		FROM %s 
+		WHERE user_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at`, noteEntity.NoteTable)
+
+	rows, err := r.pgPool.Query(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to query notes", slog.String("op", "ListNotesByUserID"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return r.scanNotes(rows, "ListNotesByUserID")
+}
+
+// ListNotesByWorkspaceID returns every note of the workspace, oldest first
+func (r *NoteRepository) ListNotesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]noteEntity.NoteEntity, error) {
+	query := fmt.Sprintf(`
+		SELECT id, workspace_id, user_id, title, content, content_text, tags, created_at, updated_at
+		FROM %s
+		WHERE workspace_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at`, noteEntity.NoteTable)
+
+	rows, err := r.pgPool.Query(ctx, query, workspaceID)
+	if err != nil {
+		r.logger.Error("failed to query notes", slog.String("op", "ListNotesByWorkspaceID"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return r.scanNotes(rows, "ListNotesByWorkspaceID")
+}
+
+// ListNotesByTag returns the notes with the tag, ignoring case, that the user can view through
+// a workspace membership or a share, oldest first
+func (r *NoteRepository) ListNotesByTag(ctx context.Context, userID uuid.UUID, tag string) ([]noteEntity.NoteEntity, error) {
+	query := fmt.Sprintf(`
+		SELECT id, workspace_id, user_id, title, content, content_text, tags, created_at, updated_at
+		FROM %s
+		WHERE deleted_at IS NULL
+			AND (workspace_id IN (SELECT workspace_id FROM %s WHERE user_id = $1)
+				OR id IN (SELECT note_id FROM %s WHERE user_id = $1))
+			AND EXISTS (SELECT 1 FROM unnest(tags) t WHERE lower(t) = lower($2))
+		ORDER BY created_at`, noteEntity.NoteTable, workspaceEntity.WorkspaceMemberTable, noteEntity.NoteShareTable)
+
+	rows, err := r.pgPool.Query(ctx, query, userID, tag)
+	if err != nil {
+		r.logger.Error("failed to query notes", slog.String("op", "ListNotesByTag"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return r.scanNotes(rows, "ListNotesByTag")
+}
+
+func (r *NoteRepository) scanNotes(rows pgx.Rows, op string) ([]noteEntity.NoteEntity, error) {
+	defer rows.Close()
+
+	var notes []noteEntity.NoteEntity
+	for rows.Next() {
+		var note noteEntity.NoteEntity
+		var contentBytes []byte
+		var contentText *string
+
+		err := rows.Scan(
+			&note.ID,
+			&note.WorkspaceID,
+			&note.UserID,
+			&note.Title,
+			&contentBytes,
+			&contentText,
+			&note.Tags,
+			&note.CreatedAt,
+			&note.UpdatedAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan note row", slog.String("op", op), slog.String("err", err.Error()))
+			return nil, err
+		}
+		if len(contentBytes) > 0 {
+			if err := json.Unmarshal(contentBytes, &note.Content); err != nil {
+				r.logger.Error("failed to unmarshal tiptap content", slog.String("op", op), slog.String("err", err.Error()))
+				return nil, err
+			}
+		}
+		if contentText != nil {
+			note.ContentText = *contentText
+		}
+
+		notes = append(notes, note)
+	}
+
+	return notes, rows.Err()
+}
+
+func (r *NoteRepository) CountNotesByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	var total int
+	err := r.pgPool.QueryRow(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE user_id = $1 AND deleted_at IS NULL`, noteEntity.NoteTable), userID).Scan(&total)
+	if err != nil {
+		r.logger.Error("failed to count notes", slog.String("op", "CountNotesByUserID"), slog.String("err", err.Error()))
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// GetNoteByID returns the note, nil when there is no such note or it was deleted
+func (r *NoteRepository) GetNoteByID(ctx context.Context, noteID uuid.UUID) (*noteEntity.NoteEntity, error) {
+	return r.getNote(ctx, "GetNoteByID", noteID, false)
+}
+
+// GetNoteIncludingDeleted returns the note or its tombstone, nil when there is no such note
+func (r *NoteRepository) GetNoteIncludingDeleted(ctx context.Context, noteID uuid.UUID) (*noteEntity.NoteEntity, error) {
+	return r.getNote(ctx, "GetNoteIncludingDeleted", noteID, true)
+}
+
+func (r *NoteRepository) getNote(ctx context.Context, op string, noteID uuid.UUID, includeDeleted bool) (*noteEntity.NoteEntity, error) {
+	query := fmt.Sprintf(`
+		SELECT id, workspace_id, user_id, title, content, content_text, tags, version, created_at, updated_at, deleted_at
+		FROM %s
+		WHERE id = $1 AND ($2 OR deleted_at IS NULL)`, noteEntity.NoteTable)
+
+	var note noteEntity.NoteEntity
+	var contentBytes []byte
+	var contentText *string
+
+	err := r.pgPool.QueryRow(ctx, query, noteID, includeDeleted).Scan(
+		&note.ID,
+		&note.WorkspaceID,
+		&note.UserID,
+		&note.Title,
+		&contentBytes,
+		&contentText,
+		&note.Tags,
+		&note.Version,
+		&note.CreatedAt,
+		&note.UpdatedAt,
+		&note.DeletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get note by id", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, err
+	}
+	if len(contentBytes) > 0 {
+		if err := json.Unmarshal(contentBytes, &note.Content); err != nil {
+			r.logger.Error("failed to unmarshal tiptap content", slog.String("op", op), slog.String("err", err.Error()))
+			return nil, err
+		}
+	}
+	if contentText != nil {
+		note.ContentText = *contentText
+	}
+
+	return &note, nil
+}
+
+// DeleteNote turns the note into a tombstone, which syncing clients see as a deletion until it's
+// purged. With ifVersion the note is only deleted while it's still at that version, false is
+// returned otherwise or when the note is already deleted.
+func (r *NoteRepository) DeleteNote(ctx context.Context, noteID uuid.UUID, ifVersion *int64) (bool, error) {
+	cmd, err := r.pgPool.Exec(ctx, fmt.Sprintf(`
+		UPDATE %s SET deleted_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL AND ($2::BIGINT IS NULL OR version = $2)`, noteEntity.NoteTable),
+		noteID,
+		ifVersion,
+	)
+	if err != nil {
+		r.logger.Error("failed to delete note", slog.String("op", "DeleteNote"), slog.String("err", err.Error()))
+		return false, err
+	}
+	if cmd.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	r.logger.Info("note deleted successfully", slog.String("op", "DeleteNote"), slog.String("note_id", noteID.String()))
+	return true, nil
+}
+
+// UpdateNote saves the title, content and tags of the note and sets its new version. With
+// ifVersion the note is only updated while it's still at that version, false is returned otherwise.
+func (r *NoteRepository) UpdateNote(ctx context.Context, note *noteEntity.NoteEntity, ifVersion *int64) (bool, error) {
+	query := fmt.Sprintf(`
+		UPDATE %s SET title = $2, content = $3, content_text = $4, tags = $6
+		WHERE id = $1 AND deleted_at IS NULL AND ($5::BIGINT IS NULL OR version = $5)
+		RETURNING version, updated_at`, noteEntity.NoteTable)
+
+	note.Tags = noteEntity.NormalizeTags(note.Tags)
+	err := r.pgPool.QueryRow(ctx, query, note.ID, note.Title, note.Content, note.ContentText, ifVersion, note.Tags).Scan(&note.Version, &note.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		r.logger.Error("failed to update note", slog.String("op", "UpdateNote"), slog.String("err", err.Error()))
+		return false, err
+	}
+
+	r.logger.Info("note updated successfully", slog.String("op", "UpdateNote"), slog.String("note_id", note.ID.String()), slog.Int64("version", note.Version))
+	return true, nil
+}
+
+// SyncWatermark returns the id of the oldest transaction still running. Every change written
+// by an older transaction is committed, changes at or past it may still show up.
+func (r *NoteRepository) SyncWatermark(ctx context.Context) (uint64, error) {
+	var raw string
+	if err := r.pgPool.QueryRow(ctx, `SELECT pg_snapshot_xmin(pg_current_snapshot())::TEXT`).Scan(&raw); err != nil {
+		r.logger.Error("failed to get sync watermark", slog.String("op", "SyncWatermark"), slog.String("err", err.Error()))
+		return 0, err
+	}
+
+	return strconv.ParseUint(raw, 10, 64)
+}
+
+// AccessChangedSince reports whether the notes the user can access changed in a transaction
+// at or past xid, which may not have been committed when the watermark xid was taken
+func (r *NoteRepository) AccessChangedSince(ctx context.Context, userID uuid.UUID, xid uint64) (bool, error) {
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE user_id = $1 AND change_xid >= $2::TEXT::XID8)`, noteEntity.NoteAccessChangeTable)
+
+	var changed bool
+	if err := r.pgPool.QueryRow(ctx, query, userID, strconv.FormatUint(xid, 10)).Scan(&changed); err != nil {
+		r.logger.Error("failed to check note access changes", slog.String("op", "AccessChangedSince"), slog.String("err", err.Error()))
+		return false, err
+	}
+
+	return changed, nil
+}
+
+// ListNoteChanges returns the notes and tombstones the user can access that were written after
+// the position of the token and before the transaction before, in change order. The initial
+// sync skips tombstones of notes deleted before it started, the client never had them.
+func (r *NoteRepository) ListNoteChanges(ctx context.Context, userID uuid.UUID, after noteEntity.SyncToken, before uint64, limit int) ([]noteEntity.NoteChange, error) {
+	query := fmt.Sprintf(`
+		SELECT n.id, n.workspace_id, n.user_id, n.title, n.content, n.content_text, n.tags, n.version,
+			n.created_at, n.updated_at, n.deleted_at, n.change_xid::TEXT, wm.role, ns.permission
+		FROM %s n
+		LEFT JOIN %s wm ON wm.workspace_id = n.workspace_id AND wm.user_id = $1
+		LEFT JOIN %s ns ON ns.note_id = n.id AND ns.user_id = $1
+		WHERE (wm.user_id IS NOT NULL OR ns.user_id IS NOT NULL)
+			AND (n.change_xid, n.id) > ($2::TEXT::XID8, $3)
+			AND n.change_xid < $4::TEXT::XID8
+			AND ($5::TIMESTAMPTZ IS NULL OR n.deleted_at IS NULL OR n.deleted_at >= $5)
+		ORDER BY n.change_xid, n.id
+		LIMIT $6`, noteEntity.NoteTable, workspaceEntity.WorkspaceMemberTable, noteEntity.NoteShareTable)
+
+	var deletedSince *time.Time
+	if after.Initial {
+		// Allow for clock skew between the app and the database, an extra tombstone is harmless
+		since := time.Unix(after.IssuedAt, 0).Add(-time.Minute)
+		deletedSince = &since
+	}
+
+	rows, err := r.pgPool.Query(ctx, query,
+		userID,
+		strconv.FormatUint(after.XID, 10),
+		after.ID,
+		strconv.FormatUint(before, 10),
+		deletedSince,
+		limit,
+	)
+	if err != nil {
+		r.logger.Error("failed to query note changes", slog.String("op", "ListNoteChanges"), slog.String("err", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []noteEntity.NoteChange
+	for rows.Next() {
+		var change noteEntity.NoteChange
+		var contentBytes []byte
+		var contentText, role, sharePermission *string
+		var changeXID string
+
+		err := rows.Scan(
+			&change.Note.ID,
+			&change.Note.WorkspaceID,
+			&change.Note.UserID,
+			&change.Note.Title,
+			&contentBytes,
+			&contentText,
+			&change.Note.Tags,
+			&change.Note.Version,
+			&change.Note.CreatedAt,
+			&change.Note.UpdatedAt,
+			&change.Note.DeletedAt,
+			&changeXID,
+			&role,
+			&sharePermission,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan note change row", slog.String("op", "ListNoteChanges"), slog.String("err", err.Error()))
+			return nil, err
+		}
+		if len(contentBytes) > 0 {
+			if err := json.Unmarshal(contentBytes, &change.Note.Content); err != nil {
+				r.logger.Error("failed to unmarshal tiptap content", slog.String("op", "ListNoteChanges"), slog.String("err", err.Error()))
+				return nil, err
+			}
+		}
+		if contentText != nil {
+			change.Note.ContentText = *contentText
+		}
+		if change.Note.ChangeXID, err = strconv.ParseUint(changeXID, 10, 64); err != nil {
+			return nil, err
+		}
+		if role != nil {
+			change.Role = *role
+		}
+		if sharePermission != nil {
+			change.SharePermission = *sharePermission
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// PurgeDeletedNotes removes the tombstones of notes deleted before the given time, in batches.
+// It returns how many were removed.
+func (r *NoteRepository) PurgeDeletedNotes(ctx context.Context, before time.Time) (int, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %[1]s
+		WHERE id IN (
+			SELECT id FROM %[1]s
+			WHERE deleted_at < $1
+			ORDER BY deleted_at
+			LIMIT $2
+		)`, noteEntity.NoteTable)
+
+	purged := 0
+	for {
+		cmd, err := r.pgPool.Exec(ctx, query, before, purgeBatchSize)
+		if err != nil {
+			r.logger.Error("failed to purge deleted notes", slog.String("op", "PurgeDeletedNotes"), slog.String("err", err.Error()))
+			return purged, err
+		}
+
+		purged += int(cmd.RowsAffected())
+		if cmd.RowsAffected() < purgeBatchSize {
+			break
+		}
+	}
+
+	if purged > 0 {
+		r.logger.Info("deleted notes purged", slog.String("op", "PurgeDeletedNotes"), slog.Int("count", purged))
+	}
+
+	return purged, nil
+}
+
 func (r *NoteRepository) queryFilter(c *fiber.Ctx, baseQuery string) (string, []interface{}) {
 	if baseQuery == "" {
 		baseQuery = "WHERE 1=1"
@@ -134,6 +534,27 @@ func (r *NoteRepository) queryFilter(c *fiber.Ctx, baseQuery string) (string, []
 
 	// }
 
+	currentUserID, _ := c.Locals("user_id").(string)
+	memberID, _ := uuid.Parse(currentUserID)
+
+	if c.Query("scope") == "shared" {
+		// Only notes shared directly with the requesting user
+		baseQuery += fmt.Sprintf(" AND %s.id IN (SELECT note_id FROM %s WHERE user_id = $%d)",
+			noteEntity.NoteTable, noteEntity.NoteShareTable, argPos)
+	} else {
+		// Only notes of workspaces the requesting user is a member of
+		baseQuery += fmt.Sprintf(" AND %s.workspace_id IN (SELECT workspace_id FROM %s WHERE user_id = $%d)",
+			noteEntity.NoteTable, workspaceEntity.WorkspaceMemberTable, argPos)
+	}
+	args = append(args, memberID)
+	argPos++
+
+	if workspaceID := c.Query("workspace_id"); workspaceID != "" {
+		baseQuery += fmt.Sprintf(" AND %s.workspace_id = $%d", noteEntity.NoteTable, argPos)
+		args = append(args, workspaceID)
+		argPos++
+	}
+
 	if userID := c.Query("user_id"); userID != "" {
 		baseQuery += fmt.Sprintf(" AND %s.user_id = $%d", noteEntity.NoteTable, argPos)
 		args = append(args, userID)
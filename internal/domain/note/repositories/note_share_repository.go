@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
+)
+
+const noteShareColumns = `id, note_id, user_id, email, permission, shared_by, created_at, updated_at`
+
+type NoteShareRepositoryInterface interface {
+	UpsertShare(ctx context.Context, share *noteEntity.NoteShareEntity) (*noteEntity.NoteShareEntity, error)
+	GetShare(ctx context.Context, noteID, shareID uuid.UUID) (*noteEntity.NoteShareEntity, error)
+	GetShareForUser(ctx context.Context, noteID, userID uuid.UUID) (*noteEntity.NoteShareEntity, error)
+	ListShares(ctx context.Context, noteID uuid.UUID) ([]noteEntity.NoteShareDetail, error)
+	UpdateSharePermission(ctx context.Context, shareID uuid.UUID, permission string) error
+	DeleteShare(ctx context.Context, shareID uuid.UUID) error
+	ResolvePendingShares(ctx context.Context, userID uuid.UUID, email string) (int, error)
+}
+
+var _ NoteShareRepositoryInterface = (*NoteShareRepository)(nil)
+
+type NoteShareRepository struct {
+	pgPool *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewNoteShareRepository(pgPool *pgxpool.Pool, logger *slog.Logger) *NoteShareRepository {
+	return &NoteShareRepository{
+		pgPool: pgPool,
+		logger: logger,
+	}
+}
+
+// UpsertShare stores the share, or updates the permission when the grantee already has one
+// for the note. It returns the stored share.
+func (r *NoteShareRepository) UpsertShare(ctx context.Context, share *noteEntity.NoteShareEntity) (*noteEntity.NoteShareEntity, error) {
+	conflict := "(note_id, user_id) WHERE user_id IS NOT NULL"
+	if share.IsPending() {
+		conflict = "(note_id, email) WHERE email IS NOT NULL"
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, note_id, user_id, email, permission, shared_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT %s DO UPDATE SET permission = EXCLUDED.permission, shared_by = EXCLUDED.shared_by
+		RETURNING %s
+	`, noteEntity.NoteShareTable, conflict, noteShareColumns)
+
+	stored, err := scanNoteShare(r.pgPool.QueryRow(ctx, query,
+		share.ID,
+		share.NoteID,
+		share.UserID,
+		share.Email,
+		share.Permission,
+		share.SharedBy,
+		share.CreatedAt,
+	))
+	if err != nil {
+		r.logger.Error("failed to upsert note share", slog.String("op", "UpsertShare"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	r.logger.Info("note shared successfully", slog.String("op", "UpsertShare"), slog.String("note_id", share.NoteID.String()))
+	return stored, nil
+}
+
+func (r *NoteShareRepository) GetShare(ctx context.Context, noteID, shareID uuid.UUID) (*noteEntity.NoteShareEntity, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1 AND note_id = $2`, noteShareColumns, noteEntity.NoteShareTable)
+
+	share, err := scanNoteShare(r.pgPool.QueryRow(ctx, query, shareID, noteID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get note share", slog.String("op", "GetShare"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return share, nil
+}
+
+func (r *NoteShareRepository) GetShareForUser(ctx context.Context, noteID, userID uuid.UUID) (*noteEntity.NoteShareEntity, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE note_id = $1 AND user_id = $2`, noteShareColumns, noteEntity.NoteShareTable)
+
+	share, err := scanNoteShare(r.pgPool.QueryRow(ctx, query, noteID, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get note share for user", slog.String("op", "GetShareForUser"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return share, nil
+}
+
+// ListShares returns every share of the note, pending invitations included, oldest first
+func (r *NoteShareRepository) ListShares(ctx context.Context, noteID uuid.UUID) ([]noteEntity.NoteShareDetail, error) {
+	query := fmt.Sprintf(`
+		SELECT s.id, s.note_id, s.user_id, s.email, s.permission, s.shared_by, s.created_at, s.updated_at,
+			u.display_name, u.username, u.email
+		FROM %s s
+		LEFT JOIN %s u ON u.id = s.user_id
+		WHERE s.note_id = $1
+		ORDER BY s.created_at
+	`, noteEntity.NoteShareTable, userEntity.UserTable)
+
+	rows, err := r.pgPool.Query(ctx, query, noteID)
+	if err != nil {
+		r.logger.Error("failed to query note shares", slog.String("op", "ListShares"), slog.String("err", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shares []noteEntity.NoteShareDetail
+	for rows.Next() {
+		var share noteEntity.NoteShareDetail
+		err := rows.Scan(
+			&share.ID,
+			&share.NoteID,
+			&share.UserID,
+			&share.Email,
+			&share.Permission,
+			&share.SharedBy,
+			&share.CreatedAt,
+			&share.UpdatedAt,
+			&share.DisplayName,
+			&share.Username,
+			&share.UserEmail,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan note share row", slog.String("op", "ListShares"), slog.String("err", err.Error()))
+			return nil, err
+		}
+
+		shares = append(shares, share)
+	}
+
+	return shares, rows.Err()
+}
+
+func (r *NoteShareRepository) UpdateSharePermission(ctx context.Context, shareID uuid.UUID, permission string) error {
+	query := fmt.Sprintf(`UPDATE %s SET permission = $1 WHERE id = $2`, noteEntity.NoteShareTable)
+
+	if _, err := r.pgPool.Exec(ctx, query, permission, shareID); err != nil {
+		r.logger.Error("failed to update note share permission", slog.String("op", "UpdateSharePermission"), slog.String("err", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (r *NoteShareRepository) DeleteShare(ctx context.Context, shareID uuid.UUID) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, noteEntity.NoteShareTable)
+
+	if _, err := r.pgPool.Exec(ctx, query, shareID); err != nil {
+		r.logger.Error("failed to delete note share", slog.String("op", "DeleteShare"), slog.String("err", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// ResolvePendingShares hands the pending shares of email over to the user's account
+func (r *NoteShareRepository) ResolvePendingShares(ctx context.Context, userID uuid.UUID, email string) (int, error) {
+	query := fmt.Sprintf(`UPDATE %s SET user_id = $1, email = NULL WHERE email = lower($2) AND user_id IS NULL`, noteEntity.NoteShareTable)
+
+	cmd, err := r.pgPool.Exec(ctx, query, userID, email)
+	if err != nil {
+		r.logger.Error("failed to resolve pending note shares", slog.String("op", "ResolvePendingShares"), slog.String("err", err.Error()))
+		return 0, err
+	}
+
+	return int(cmd.RowsAffected()), nil
+}
+
+func scanNoteShare(row pgx.Row) (*noteEntity.NoteShareEntity, error) {
+	var share noteEntity.NoteShareEntity
+	err := row.Scan(
+		&share.ID,
+		&share.NoteID,
+		&share.UserID,
+		&share.Email,
+		&share.Permission,
+		&share.SharedBy,
+		&share.CreatedAt,
+		&share.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
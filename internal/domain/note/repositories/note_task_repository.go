@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	workspaceEntity "github.com/rayhan889/neatspace/internal/domain/workspace/entities"
+	"github.com/rayhan889/neatspace/pkg/apputils"
+)
+
+type NoteTaskRepositoryInterface interface {
+	ReplaceNoteTasks(ctx context.Context, noteID uuid.UUID, tasks []noteEntity.NoteTaskEntity) error
+	ListTasks(ctx context.Context, userID uuid.UUID, filter *noteEntity.NoteTaskFilter, p *apputils.Pagination) (data []noteEntity.NoteTaskDetail, total int, err error)
+}
+
+var _ NoteTaskRepositoryInterface = (*NoteTaskRepository)(nil)
+
+type NoteTaskRepository struct {
+	pgPool *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewNoteTaskRepository(pgPool *pgxpool.Pool, logger *slog.Logger) *NoteTaskRepository {
+	return &NoteTaskRepository{
+		pgPool: pgPool,
+		logger: logger,
+	}
+}
+
+// ReplaceNoteTasks stores the tasks found in the content of the note in place of its old ones
+func (r *NoteTaskRepository) ReplaceNoteTasks(ctx context.Context, noteID uuid.UUID, tasks []noteEntity.NoteTaskEntity) error {
+	deleteQuery := fmt.Sprintf(`DELETE FROM %s WHERE note_id = $1`, noteEntity.NoteTaskTable)
+	insertQuery := fmt.Sprintf(`
+		INSERT INTO %s (note_id, position, text, checked, due_date)
+		SELECT $1, t.position, t.text, t.checked, t.due_date
+		FROM unnest($2::INTEGER[], $3::TEXT[], $4::BOOLEAN[], $5::DATE[]) AS t(position, text, checked, due_date)`, noteEntity.NoteTaskTable)
+
+	positions := make([]int32, len(tasks))
+	texts := make([]string, len(tasks))
+	checked := make([]bool, len(tasks))
+	dueDates := make([]*time.Time, len(tasks))
+	for i, task := range tasks {
+		positions[i] = int32(task.Position)
+		texts[i] = task.Text
+		checked[i] = task.Checked
+		dueDates[i] = task.DueDate
+	}
+
+	err := pgx.BeginFunc(ctx, r.pgPool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, deleteQuery, noteID); err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			return nil
+		}
+		_, err := tx.Exec(ctx, insertQuery, noteID, positions, texts, checked, dueDates)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("failed to replace note tasks", slog.String("op", "ReplaceNoteTasks"), slog.String("err", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// ListTasks returns the tasks of the notes the user can access, open ones first, then by due
+// date with undated tasks last, then by the most recently updated note
+func (r *NoteTaskRepository) ListTasks(ctx context.Context, userID uuid.UUID, filter *noteEntity.NoteTaskFilter, p *apputils.Pagination) (data []noteEntity.NoteTaskDetail, total int, err error) {
+	where, args := r.queryFilter(userID, filter)
+
+	from := fmt.Sprintf(`
+		FROM %s t
+		JOIN %s n ON n.id = t.note_id AND n.deleted_at IS NULL
+		LEFT JOIN %s wm ON wm.workspace_id = n.workspace_id AND wm.user_id = $1
+		LEFT JOIN %s ns ON ns.note_id = n.id AND ns.user_id = $1
+		WHERE %s`, noteEntity.NoteTaskTable, noteEntity.NoteTable, workspaceEntity.WorkspaceMemberTable, noteEntity.NoteShareTable, where)
+
+	query := fmt.Sprintf(`
+		SELECT t.note_id, t.position, t.text, t.checked, t.due_date,
+			n.workspace_id, n.title, n.version, wm.role, ns.permission
+		%s
+		ORDER BY t.checked, t.due_date NULLS LAST, COALESCE(n.updated_at, n.created_at) DESC, t.note_id, t.position
+		LIMIT $%d OFFSET $%d`, from, len(args)+1, len(args)+2)
+
+	rows, err := r.pgPool.Query(ctx, query, append(args, p.Limit, p.Offset)...)
+	if err != nil {
+		r.logger.Error("failed to list tasks", slog.String("op", "ListTasks"), slog.String("err", err.Error()))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	data = []noteEntity.NoteTaskDetail{}
+	for rows.Next() {
+		var task noteEntity.NoteTaskDetail
+		err := rows.Scan(
+			&task.NoteID,
+			&task.Position,
+			&task.Text,
+			&task.Checked,
+			&task.DueDate,
+			&task.WorkspaceID,
+			&task.NoteTitle,
+			&task.NoteVersion,
+			&task.Role,
+			&task.SharePermission,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan task row", slog.String("op", "ListTasks"), slog.String("err", err.Error()))
+			return nil, 0, err
+		}
+		data = append(data, task)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to iterate tasks", slog.String("op", "ListTasks"), slog.String("err", err.Error()))
+		return nil, 0, err
+	}
+
+	if err := r.pgPool.QueryRow(ctx, `SELECT COUNT(*) `+from, args...).Scan(&total); err != nil {
+		r.logger.Error("failed to count tasks", slog.String("op", "ListTasks"), slog.String("err", err.Error()))
+		return nil, 0, err
+	}
+
+	return data, total, nil
+}
+
+func (r *NoteTaskRepository) queryFilter(userID uuid.UUID, filter *noteEntity.NoteTaskFilter) (string, []interface{}) {
+	conditions := []string{"(wm.user_id IS NOT NULL OR ns.user_id IS NOT NULL)"}
+	args := []interface{}{userID}
+
+	switch filter.Status {
+	case noteEntity.TaskStatusOpen:
+		conditions = append(conditions, "NOT t.checked")
+	case noteEntity.TaskStatusDone:
+		conditions = append(conditions, "t.checked")
+	}
+
+	if filter.WorkspaceID != nil {
+		args = append(args, *filter.WorkspaceID)
+		conditions = append(conditions, fmt.Sprintf("n.workspace_id = $%d", len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
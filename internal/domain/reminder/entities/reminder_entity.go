@@ -0,0 +1,214 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const ReminderTable = "public.reminders"
+
+// Recurrence frequencies, the FREQ values of RFC 5545 that are supported
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+// Local date-times a fire time can be given as, read in the reminder's time zone
+var localFireAtLayouts = []string{"2006-01-02T15:04", "2006-01-02T15:04:05"}
+
+type ReminderEntity struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	NoteID     uuid.UUID  `json:"note_id" db:"note_id"`
+	FireAt     time.Time  `json:"fire_at" db:"fire_at"`           // First occurrence, recurrences are counted from it
+	Timezone   string     `json:"timezone" db:"timezone"`         // IANA zone occurrences are computed in
+	Recurrence *string    `json:"recurrence" db:"recurrence"`     // RRULE, nil for a one-off reminder
+	NextFireAt *time.Time `json:"next_fire_at" db:"next_fire_at"` // Nil once the last occurrence was sent
+	LastSentAt *time.Time `json:"last_sent_at" db:"last_sent_at"` // When the reminder was last emailed
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Location returns the time zone of the reminder, UTC when it's not known
+func (r *ReminderEntity) Location() *time.Location {
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Recurrence is a parsed RRULE. Only FREQ, INTERVAL, COUNT and UNTIL are supported,
+// occurrences fall on the time of day of the first one.
+type Recurrence struct {
+	Freq     string
+	Interval int
+	Count    int        // Number of occurrences including the first, 0 for no limit
+	Until    *time.Time // Last instant an occurrence may fall on
+}
+
+// ParseRecurrence reads a rule like FREQ=WEEKLY;INTERVAL=2;COUNT=10, with or without the
+// RRULE: prefix. A date-only UNTIL includes the whole day in loc.
+func ParseRecurrence(rule string, loc *time.Location) (*Recurrence, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil, errors.New("recurrence rule is empty")
+	}
+
+	r := &Recurrence{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid recurrence part %q", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("recurrence part %s is repeated", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			switch value = strings.ToUpper(value); value {
+			case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+				r.Freq = value
+			default:
+				return nil, fmt.Errorf("unsupported recurrence frequency %s", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 1000 {
+				return nil, errors.New("recurrence interval must be between 1 and 1000")
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, errors.New("recurrence count must be at least 1")
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value, loc)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = &until
+		default:
+			return nil, fmt.Errorf("unsupported recurrence part %s", name)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, errors.New("recurrence rule needs FREQ")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, errors.New("recurrence rule can't have both COUNT and UNTIL")
+	}
+
+	return r, nil
+}
+
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid recurrence end %s", value)
+}
+
+// Next returns the first occurrence after the given time for a rule starting at first,
+// false when the rule has no occurrence left. Missed occurrences are skipped.
+func (r *Recurrence) Next(first, after time.Time, loc *time.Location) (time.Time, bool) {
+	first = first.In(loc)
+
+	// Start close to the answer for rules running a long time, never past it
+	k := 1
+	if elapsed := after.Sub(first); elapsed > 0 {
+		if estimate := int(elapsed / r.maxPeriod()); estimate > k {
+			k = estimate
+		}
+	}
+
+	for ; ; k++ {
+		if r.Count > 0 && k >= r.Count {
+			return time.Time{}, false
+		}
+		next := r.occurrence(first, k)
+		if r.Until != nil && next.After(*r.Until) {
+			return time.Time{}, false
+		}
+		if next.After(after) {
+			return next, true
+		}
+	}
+}
+
+// occurrence returns the k-th occurrence, the first is 0. Months and years that lack the
+// day of the first occurrence use their last day.
+func (r *Recurrence) occurrence(first time.Time, k int) time.Time {
+	year, month, day := first.Date()
+	hour, minute, sec := first.Clock()
+	steps := k * r.Interval
+
+	switch r.Freq {
+	case FreqWeekly:
+		day += 7 * steps
+	case FreqMonthly:
+		month += time.Month(steps)
+		day = min(day, daysIn(year, month, first.Location()))
+	case FreqYearly:
+		year += steps
+		day = min(day, daysIn(year, month, first.Location()))
+	default:
+		day += steps
+	}
+
+	return time.Date(year, month, day, hour, minute, sec, first.Nanosecond(), first.Location())
+}
+
+// maxPeriod is an upper bound of the time between two occurrences
+func (r *Recurrence) maxPeriod() time.Duration {
+	day := 25 * time.Hour // Days around a daylight saving change last up to 25 hours
+	switch r.Freq {
+	case FreqWeekly:
+		day *= 7
+	case FreqMonthly:
+		day *= 31
+	case FreqYearly:
+		day *= 366
+	}
+	return day * time.Duration(r.Interval)
+}
+
+// daysIn returns the number of days of the month, which may be past December
+func daysIn(year int, month time.Month, loc *time.Location) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+}
+
+// ParseFireAt reads a fire time given with an offset, or as a local date-time in loc
+func ParseFireAt(raw string, loc *time.Location) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	for _, layout := range localFireAtLayouts {
+		if t, err := time.ParseInLocation(layout, raw, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid fire time %q, use RFC 3339 or 2006-01-02T15:04", raw)
+}
@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	t.Run("ReadsSupportedParts", func(t *testing.T) {
+		r, err := ParseRecurrence("RRULE:FREQ=weekly;INTERVAL=2;COUNT=5", time.UTC)
+		require.NoError(t, err)
+		assert.Equal(t, &Recurrence{Freq: FreqWeekly, Interval: 2, Count: 5}, r)
+	})
+
+	t.Run("DateOnlyUntilIncludesTheDay", func(t *testing.T) {
+		loc := time.FixedZone("UTC+7", 7*60*60)
+
+		r, err := ParseRecurrence("FREQ=DAILY;UNTIL=20261031", loc)
+		require.NoError(t, err)
+		require.NotNil(t, r.Until)
+		assert.Equal(t, time.Date(2026, 10, 31, 23, 59, 59, 999999999, loc), *r.Until)
+	})
+
+	t.Run("RejectsInvalidRules", func(t *testing.T) {
+		for _, rule := range []string{
+			"",
+			"INTERVAL=2",
+			"FREQ=HOURLY",
+			"FREQ=DAILY;INTERVAL=0",
+			"FREQ=DAILY;COUNT=2;UNTIL=20261031",
+			"FREQ=DAILY;FREQ=WEEKLY",
+			"FREQ=WEEKLY;BYDAY=MO",
+			"FREQ",
+		} {
+			_, err := ParseRecurrence(rule, time.UTC)
+			assert.Error(t, err, rule)
+		}
+	})
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	t.Run("KeepsTheLocalTimeAcrossDaylightSaving", func(t *testing.T) {
+		loc, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+		first := time.Date(2026, 10, 24, 9, 0, 0, 0, loc)
+		r := &Recurrence{Freq: FreqDaily, Interval: 1}
+
+		next, ok := r.Next(first, first, loc)
+		assert.True(t, ok)
+		assert.True(t, next.Equal(time.Date(2026, 10, 25, 9, 0, 0, 0, loc)))
+
+		next, ok = r.Next(first, next, loc)
+		assert.True(t, ok)
+		assert.True(t, next.Equal(time.Date(2026, 10, 26, 9, 0, 0, 0, loc)))
+	})
+
+	t.Run("SkipsMissedOccurrences", func(t *testing.T) {
+		first := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+		r := &Recurrence{Freq: FreqWeekly, Interval: 2}
+
+		next, ok := r.Next(first, time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC), time.UTC)
+		assert.True(t, ok)
+		assert.Equal(t, time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("UsesTheLastDayOfShortMonths", func(t *testing.T) {
+		first := time.Date(2026, 1, 31, 8, 0, 0, 0, time.UTC)
+		r := &Recurrence{Freq: FreqMonthly, Interval: 1}
+
+		next, _ := r.Next(first, first, time.UTC)
+		assert.Equal(t, time.Date(2026, 2, 28, 8, 0, 0, 0, time.UTC), next)
+
+		next, _ = r.Next(first, next, time.UTC)
+		assert.Equal(t, time.Date(2026, 3, 31, 8, 0, 0, 0, time.UTC), next)
+
+		leap := time.Date(2028, 2, 29, 8, 0, 0, 0, time.UTC)
+		next, _ = (&Recurrence{Freq: FreqYearly, Interval: 1}).Next(leap, leap, time.UTC)
+		assert.Equal(t, time.Date(2029, 2, 28, 8, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("EndsAfterCountOrUntil", func(t *testing.T) {
+		first := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+		counted := &Recurrence{Freq: FreqDaily, Interval: 1, Count: 2}
+		next, ok := counted.Next(first, first, time.UTC)
+		assert.True(t, ok)
+		_, ok = counted.Next(first, next, time.UTC)
+		assert.False(t, ok)
+
+		until := time.Date(2026, 10, 20, 23, 59, 59, 0, time.UTC)
+		bounded := &Recurrence{Freq: FreqDaily, Interval: 1, Until: &until}
+		next, ok = bounded.Next(first, first, time.UTC)
+		assert.True(t, ok)
+		_, ok = bounded.Next(first, next, time.UTC)
+		assert.False(t, ok)
+	})
+}
+
+func TestParseFireAt(t *testing.T) {
+	loc := time.FixedZone("UTC-5", -5*60*60)
+
+	t.Run("ReadsLocalTimesInTheZone", func(t *testing.T) {
+		got, err := ParseFireAt("2026-10-19T09:00", loc)
+		require.NoError(t, err)
+		assert.True(t, got.Equal(time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("KeepsTheGivenOffset", func(t *testing.T) {
+		got, err := ParseFireAt("2026-10-19T09:00:00+02:00", loc)
+		require.NoError(t, err)
+		assert.True(t, got.Equal(time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("RejectsOtherFormats", func(t *testing.T) {
+		_, err := ParseFireAt("tomorrow at 9", loc)
+		assert.Error(t, err)
+	})
+}
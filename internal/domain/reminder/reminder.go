@@ -0,0 +1,50 @@
+package reminder
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/internal/domain/reminder/repositories"
+	"github.com/rayhan889/neatspace/internal/notification"
+)
+
+type Options struct {
+	PgPool      *pgxpool.Pool                 // PostgreSQL connection pool (required)
+	NoteService services.NoteServiceInterface // Note service, checks the user can still view a note (required)
+	UserService services.UserServiceInterface // User service, provides time zones and email addresses (required)
+	Logger      *slog.Logger                  // Slog logger instance (optional)
+	Mailer      *notification.Mailer          // Mailer sending the reminders (optional)
+	BaseURL     string                        // Base URL of the app, for links to notes (optional)
+}
+
+type ReminderDomain struct {
+	logger          *slog.Logger
+	reminderService *services.ReminderService
+}
+
+func NewReminderDomain(opts *Options) *ReminderDomain {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{}))
+	}
+
+	reminderService := services.NewReminderService(services.ReminderServiceOpts{
+		ReminderRepo: repositories.NewReminderRepository(opts.PgPool, logger),
+		NoteService:  opts.NoteService,
+		UserService:  opts.UserService,
+		Logger:       logger,
+		Mailer:       opts.Mailer,
+		BaseURL:      opts.BaseURL,
+	})
+
+	return &ReminderDomain{
+		logger:          logger,
+		reminderService: reminderService,
+	}
+}
+
+func (d *ReminderDomain) GetReminderService() services.ReminderServiceInterface {
+	return d.reminderService
+}
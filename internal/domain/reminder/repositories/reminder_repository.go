@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	reminderEntity "github.com/rayhan889/neatspace/internal/domain/reminder/entities"
+	userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
+)
+
+const reminderColumns = `id, user_id, note_id, fire_at, timezone, recurrence, next_fire_at, last_sent_at, created_at, updated_at`
+
+type ReminderRepositoryInterface interface {
+	CreateReminder(ctx context.Context, reminder *reminderEntity.ReminderEntity) error
+	GetReminderByID(ctx context.Context, reminderID uuid.UUID) (*reminderEntity.ReminderEntity, error)
+	ListRemindersByUserID(ctx context.Context, userID uuid.UUID, noteID *uuid.UUID) ([]reminderEntity.ReminderEntity, error)
+	UpdateReminder(ctx context.Context, reminder *reminderEntity.ReminderEntity) error
+	DeleteReminder(ctx context.Context, reminderID uuid.UUID) error
+	ClaimDueReminders(ctx context.Context, claimID uuid.UUID, now, staleBefore time.Time, limit int) ([]reminderEntity.ReminderEntity, error)
+	FinishReminderRun(ctx context.Context, reminderID, claimID uuid.UUID, sentAt, nextFireAt *time.Time) error
+}
+
+var _ ReminderRepositoryInterface = (*ReminderRepository)(nil)
+
+type ReminderRepository struct {
+	pgPool *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewReminderRepository(pgPool *pgxpool.Pool, logger *slog.Logger) *ReminderRepository {
+	return &ReminderRepository{
+		pgPool: pgPool,
+		logger: logger,
+	}
+}
+
+func (r *ReminderRepository) CreateReminder(ctx context.Context, reminder *reminderEntity.ReminderEntity) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, user_id, note_id, fire_at, timezone, recurrence, next_fire_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`, reminderEntity.ReminderTable)
+
+	_, err := r.pgPool.Exec(ctx, query,
+		reminder.ID,
+		reminder.UserID,
+		reminder.NoteID,
+		reminder.FireAt,
+		reminder.Timezone,
+		reminder.Recurrence,
+		reminder.NextFireAt,
+		reminder.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("failed to create reminder", slog.String("op", "CreateReminder"), slog.String("err", err.Error()))
+		return err
+	}
+
+	r.logger.Info("reminder created successfully", slog.String("op", "CreateReminder"), slog.String("reminder_id", reminder.ID.String()))
+	return nil
+}
+
+func (r *ReminderRepository) GetReminderByID(ctx context.Context, reminderID uuid.UUID) (*reminderEntity.ReminderEntity, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, reminderColumns, reminderEntity.ReminderTable)
+
+	reminder, err := scanReminder(r.pgPool.QueryRow(ctx, query, reminderID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get reminder", slog.String("op", "GetReminderByID"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+// ListRemindersByUserID returns the user's reminders, optionally of one note only, upcoming
+// ones first by their next occurrence
+func (r *ReminderRepository) ListRemindersByUserID(ctx context.Context, userID uuid.UUID, noteID *uuid.UUID) ([]reminderEntity.ReminderEntity, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM %s
+		WHERE user_id = $1 AND ($2::UUID IS NULL OR note_id = $2)
+		ORDER BY next_fire_at NULLS LAST, fire_at DESC`, reminderColumns, reminderEntity.ReminderTable)
+
+	rows, err := r.pgPool.Query(ctx, query, userID, noteID)
+	if err != nil {
+		r.logger.Error("failed to list reminders", slog.String("op", "ListRemindersByUserID"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return r.scanReminders(rows, "ListRemindersByUserID")
+}
+
+// UpdateReminder saves the schedule of the reminder. A run in progress keeps its email but
+// won't reschedule the reminder, its claim is dropped.
+func (r *ReminderRepository) UpdateReminder(ctx context.Context, reminder *reminderEntity.ReminderEntity) error {
+	query := fmt.Sprintf(`
+		UPDATE %s SET fire_at = $2, timezone = $3, recurrence = $4, next_fire_at = $5, claim_id = NULL, claimed_at = NULL
+		WHERE id = $1
+		RETURNING updated_at`, reminderEntity.ReminderTable)
+
+	err := r.pgPool.QueryRow(ctx, query,
+		reminder.ID,
+		reminder.FireAt,
+		reminder.Timezone,
+		reminder.Recurrence,
+		reminder.NextFireAt,
+	).Scan(&reminder.UpdatedAt)
+	if err != nil {
+		r.logger.Error("failed to update reminder", slog.String("op", "UpdateReminder"), slog.String("err", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (r *ReminderRepository) DeleteReminder(ctx context.Context, reminderID uuid.UUID) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, reminderEntity.ReminderTable)
+
+	if _, err := r.pgPool.Exec(ctx, query, reminderID); err != nil {
+		r.logger.Error("failed to delete reminder", slog.String("op", "DeleteReminder"), slog.String("err", err.Error()))
+		return err
+	}
+
+	r.logger.Info("reminder deleted successfully", slog.String("op", "DeleteReminder"), slog.String("reminder_id", reminderID.String()))
+	return nil
+}
+
+// ClaimDueReminders marks up to limit reminders due at now as claimed by claimID and returns
+// them, earliest first. Reminders claimed before staleBefore are claimed again, which recovers
+// runs interrupted by a crash. Rows locked by another instance are skipped, and so are the
+// reminders of deactivated users, which stay due until the account is restored.
+func (r *ReminderRepository) ClaimDueReminders(ctx context.Context, claimID uuid.UUID, now, staleBefore time.Time, limit int) ([]reminderEntity.ReminderEntity, error) {
+	query := fmt.Sprintf(`
+		UPDATE %[1]s SET claim_id = $1, claimed_at = $2
+		WHERE id IN (
+			SELECT r.id FROM %[1]s r
+			JOIN %[3]s u ON u.id = r.user_id
+			WHERE r.next_fire_at <= $2 AND (r.claim_id IS NULL OR r.claimed_at < $3) AND u.deactivated_at IS NULL
+			ORDER BY r.next_fire_at
+			LIMIT $4
+			FOR UPDATE OF r SKIP LOCKED
+		)
+		RETURNING %[2]s`, reminderEntity.ReminderTable, reminderColumns, userEntity.UserTable)
+
+	rows, err := r.pgPool.Query(ctx, query, claimID, now, staleBefore, limit)
+	if err != nil {
+		r.logger.Error("failed to claim due reminders", slog.String("op", "ClaimDueReminders"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return r.scanReminders(rows, "ClaimDueReminders")
+}
+
+// FinishReminderRun releases a claimed reminder and schedules its next occurrence, nil when
+// there is none. sentAt is nil when the reminder was dropped without being sent. Nothing
+// changes when the claim was lost to another instance or to an update of the reminder.
+func (r *ReminderRepository) FinishReminderRun(ctx context.Context, reminderID, claimID uuid.UUID, sentAt, nextFireAt *time.Time) error {
+	query := fmt.Sprintf(`
+		UPDATE %s SET next_fire_at = $3, last_sent_at = COALESCE($4, last_sent_at), claim_id = NULL, claimed_at = NULL
+		WHERE id = $1 AND claim_id = $2`, reminderEntity.ReminderTable)
+
+	if _, err := r.pgPool.Exec(ctx, query, reminderID, claimID, nextFireAt, sentAt); err != nil {
+		r.logger.Error("failed to finish reminder run", slog.String("op", "FinishReminderRun"), slog.String("err", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (r *ReminderRepository) scanReminders(rows pgx.Rows, op string) ([]reminderEntity.ReminderEntity, error) {
+	defer rows.Close()
+
+	reminders := []reminderEntity.ReminderEntity{}
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			r.logger.Error("failed to scan reminder", slog.String("op", op), slog.String("err", err.Error()))
+			return nil, err
+		}
+		reminders = append(reminders, *reminder)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to iterate reminders", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+func scanReminder(row pgx.Row) (*reminderEntity.ReminderEntity, error) {
+	var reminder reminderEntity.ReminderEntity
+	err := row.Scan(
+		&reminder.ID,
+		&reminder.UserID,
+		&reminder.NoteID,
+		&reminder.FireAt,
+		&reminder.Timezone,
+		&reminder.Recurrence,
+		&reminder.NextFireAt,
+		&reminder.LastSentAt,
+		&reminder.CreatedAt,
+		&reminder.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reminder, nil
+}
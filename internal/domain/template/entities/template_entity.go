@@ -0,0 +1,99 @@
+package entities
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+)
+
+const NoteTemplateTable = "public.note_templates"
+
+// NoteTemplateEntity is a Tiptap document notes can be created from. It belongs either to a
+// user, who alone can see it, or to a workspace, where every member can use it.
+type NoteTemplateEntity struct {
+	ID          uuid.UUID                `json:"id" db:"id"`
+	UserID      *uuid.UUID               `json:"user_id" db:"user_id"`           // Owner of a personal template
+	WorkspaceID *uuid.UUID               `json:"workspace_id" db:"workspace_id"` // Workspace of a shared template
+	CreatedBy   *uuid.UUID               `json:"created_by" db:"created_by"`
+	Name        string                   `json:"name" db:"name"`
+	Description *string                  `json:"description" db:"description"`
+	Title       string                   `json:"title" db:"title"` // Title of created notes, may hold placeholders
+	Content     noteEntity.TiptapContent `json:"content" db:"content"`
+	Prompts     []TemplatePrompt         `json:"prompts" db:"prompts"`
+	CreatedAt   time.Time                `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time               `json:"updated_at" db:"updated_at"`
+}
+
+// TemplatePrompt is a value asked from the user when a note is created from the template,
+// filled in where the template has {{prompt.<name>}}
+type TemplatePrompt struct {
+	Name     string `json:"name" validate:"required,max=32" example:"attendees"`
+	Label    string `json:"label" validate:"required,max=100" example:"Attendees"`
+	Default  string `json:"default,omitempty" validate:"max=500"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// IsPersonal reports whether the template belongs to a user rather than a workspace
+func (t *NoteTemplateEntity) IsPersonal() bool {
+	return t.WorkspaceID == nil
+}
+
+// Placeholders filled in by the server, prompts add prompt.<name>
+const (
+	PlaceholderDate            = "date"     // 2006-01-02
+	PlaceholderTime            = "time"     // 15:04
+	PlaceholderDateTime        = "datetime" // 2006-01-02 15:04
+	PlaceholderWeekday         = "weekday"  // Monday
+	PlaceholderUserDisplayName = "user.display_name"
+	PlaceholderUserUsername    = "user.username"
+	PlaceholderUserEmail       = "user.email"
+	PlaceholderWorkspaceName   = "workspace.name"
+	PromptPlaceholderPrefix    = "prompt."
+)
+
+// PromptNamePattern is what prompt names may look like, so they can be written in a placeholder
+var PromptNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z][a-z0-9_]*(?:\.[a-z][a-z0-9_]*)?)\s*\}\}`)
+
+// DateValues returns the date and time placeholders for the moment t, in the time zone of t
+func DateValues(t time.Time) map[string]string {
+	return map[string]string{
+		PlaceholderDate:     t.Format("2006-01-02"),
+		PlaceholderTime:     t.Format("15:04"),
+		PlaceholderDateTime: t.Format("2006-01-02 15:04"),
+		PlaceholderWeekday:  t.Format("Monday"),
+	}
+}
+
+// ExpandPlaceholders replaces the {{name}} placeholders in s that have a value. Unknown ones
+// are kept as written, so a typo shows up in the note instead of vanishing.
+func ExpandPlaceholders(s string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// ExpandContent returns a copy of the document with the placeholders in its text expanded.
+// Attributes are left alone, a value never ends up in a link or an image source.
+func ExpandContent(doc noteEntity.TiptapContent, values map[string]string) noteEntity.TiptapContent {
+	if doc.Type == "text" {
+		doc.Text = ExpandPlaceholders(doc.Text, values)
+	}
+
+	if len(doc.Content) > 0 {
+		content := make([]noteEntity.TiptapContent, len(doc.Content))
+		for i, child := range doc.Content {
+			content[i] = ExpandContent(child, values)
+		}
+		doc.Content = content
+	}
+
+	return doc
+}
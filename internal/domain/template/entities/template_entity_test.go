@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	noteEntity "github.com/rayhan889/neatspace/internal/domain/note/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandPlaceholders(t *testing.T) {
+	values := map[string]string{
+		"date":              "2026-10-18",
+		"user.display_name": "Jane",
+		"prompt.attendees":  "Ann, Bob",
+	}
+
+	t.Run("ReplacesKnownPlaceholders", func(t *testing.T) {
+		assert.Equal(t, "Standup 2026-10-18 by Jane with Ann, Bob",
+			ExpandPlaceholders("Standup {{date}} by {{ user.display_name }} with {{prompt.attendees}}", values))
+	})
+
+	t.Run("KeepsUnknownPlaceholders", func(t *testing.T) {
+		assert.Equal(t, "{{dat}} {{prompt.agenda}} {{ }} {date}", ExpandPlaceholders("{{dat}} {{prompt.agenda}} {{ }} {date}", values))
+	})
+
+	t.Run("DoesNotExpandValues", func(t *testing.T) {
+		assert.Equal(t, "{{date}}", ExpandPlaceholders("{{prompt.attendees}}", map[string]string{"prompt.attendees": "{{date}}", "date": "today"}))
+	})
+}
+
+func TestDateValues(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	assert.NoError(t, err)
+
+	values := DateValues(time.Date(2026, 10, 18, 20, 30, 0, 0, time.UTC).In(loc))
+
+	assert.Equal(t, map[string]string{
+		"date":     "2026-10-19",
+		"time":     "03:30",
+		"datetime": "2026-10-19 03:30",
+		"weekday":  "Monday",
+	}, values)
+}
+
+func TestExpandContent(t *testing.T) {
+	doc := noteEntity.TiptapContent{Type: "doc", Content: []noteEntity.TiptapContent{
+		{Type: "heading", Attrs: map[string]any{"level": 1}, Content: []noteEntity.TiptapContent{{Type: "text", Text: "Standup {{date}}"}}},
+		{Type: "paragraph", Content: []noteEntity.TiptapContent{
+			{Type: "text", Text: "{{date}}", Marks: []noteEntity.TiptapMark{{Type: "link", Attrs: map[string]any{"href": "https://example.com/{{date}}"}}}},
+		}},
+	}}
+
+	expanded := ExpandContent(doc, map[string]string{"date": "2026-10-18"})
+
+	assert.Equal(t, "Standup 2026-10-18", expanded.Content[0].Content[0].Text)
+	assert.Equal(t, "2026-10-18", expanded.Content[1].Content[0].Text)
+	assert.Equal(t, "https://example.com/{{date}}", expanded.Content[1].Content[0].Marks[0].Attrs["href"])
+	assert.Equal(t, "Standup {{date}}", doc.Content[0].Content[0].Text, "the template itself is left unchanged")
+}
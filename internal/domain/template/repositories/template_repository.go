@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	templateEntity "github.com/rayhan889/neatspace/internal/domain/template/entities"
+	workspaceEntity "github.com/rayhan889/neatspace/internal/domain/workspace/entities"
+)
+
+const noteTemplateColumns = `id, user_id, workspace_id, created_by, name, description, title, content, prompts, created_at, updated_at`
+
+type TemplateRepositoryInterface interface {
+	CreateTemplate(ctx context.Context, template *templateEntity.NoteTemplateEntity) error
+	GetTemplateByID(ctx context.Context, templateID uuid.UUID) (*templateEntity.NoteTemplateEntity, error)
+	ListTemplatesForUser(ctx context.Context, userID uuid.UUID) ([]templateEntity.NoteTemplateEntity, error)
+	ListTemplatesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]templateEntity.NoteTemplateEntity, error)
+	UpdateTemplate(ctx context.Context, template *templateEntity.NoteTemplateEntity) error
+	DeleteTemplate(ctx context.Context, templateID uuid.UUID) error
+}
+
+var _ TemplateRepositoryInterface = (*TemplateRepository)(nil)
+
+type TemplateRepository struct {
+	pgPool *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewTemplateRepository(pgPool *pgxpool.Pool, logger *slog.Logger) *TemplateRepository {
+	return &TemplateRepository{
+		pgPool: pgPool,
+		logger: logger,
+	}
+}
+
+func (r *TemplateRepository) CreateTemplate(ctx context.Context, template *templateEntity.NoteTemplateEntity) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, user_id, workspace_id, created_by, name, description, title, content, prompts, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`, templateEntity.NoteTemplateTable)
+
+	_, err := r.pgPool.Exec(ctx, query,
+		template.ID,
+		template.UserID,
+		template.WorkspaceID,
+		template.CreatedBy,
+		template.Name,
+		template.Description,
+		template.Title,
+		template.Content,
+		template.Prompts,
+		template.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("failed to create template", slog.String("op", "CreateTemplate"), slog.String("err", err.Error()))
+		return err
+	}
+
+	r.logger.Info("template created successfully", slog.String("op", "CreateTemplate"), slog.String("template_id", template.ID.String()))
+	return nil
+}
+
+func (r *TemplateRepository) GetTemplateByID(ctx context.Context, templateID uuid.UUID) (*templateEntity.NoteTemplateEntity, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, noteTemplateColumns, templateEntity.NoteTemplateTable)
+
+	template, err := scanTemplate(r.pgPool.QueryRow(ctx, query, templateID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get template", slog.String("op", "GetTemplateByID"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// ListTemplatesForUser returns the personal templates of the user and the templates of every
+// workspace they are a member of, personal ones first, then by name
+func (r *TemplateRepository) ListTemplatesForUser(ctx context.Context, userID uuid.UUID) ([]templateEntity.NoteTemplateEntity, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM %s t
+		WHERE t.user_id = $1 OR t.workspace_id IN (SELECT workspace_id FROM %s WHERE user_id = $1)
+		ORDER BY t.user_id IS NULL, t.name, t.id`, noteTemplateColumns, templateEntity.NoteTemplateTable, workspaceEntity.WorkspaceMemberTable)
+
+	rows, err := r.pgPool.Query(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to list templates", slog.String("op", "ListTemplatesForUser"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return r.scanTemplates(rows, "ListTemplatesForUser")
+}
+
+// ListTemplatesByWorkspaceID returns the templates shared in the workspace by name
+func (r *TemplateRepository) ListTemplatesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]templateEntity.NoteTemplateEntity, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE workspace_id = $1 ORDER BY name, id`, noteTemplateColumns, templateEntity.NoteTemplateTable)
+
+	rows, err := r.pgPool.Query(ctx, query, workspaceID)
+	if err != nil {
+		r.logger.Error("failed to list workspace templates", slog.String("op", "ListTemplatesByWorkspaceID"), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return r.scanTemplates(rows, "ListTemplatesByWorkspaceID")
+}
+
+// UpdateTemplate saves the editable fields of the template and sets its new updated_at
+func (r *TemplateRepository) UpdateTemplate(ctx context.Context, template *templateEntity.NoteTemplateEntity) error {
+	query := fmt.Sprintf(`
+		UPDATE %s SET name = $2, description = $3, title = $4, content = $5, prompts = $6
+		WHERE id = $1
+		RETURNING updated_at`, templateEntity.NoteTemplateTable)
+
+	err := r.pgPool.QueryRow(ctx, query,
+		template.ID,
+		template.Name,
+		template.Description,
+		template.Title,
+		template.Content,
+		template.Prompts,
+	).Scan(&template.UpdatedAt)
+	if err != nil {
+		r.logger.Error("failed to update template", slog.String("op", "UpdateTemplate"), slog.String("err", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (r *TemplateRepository) DeleteTemplate(ctx context.Context, templateID uuid.UUID) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, templateEntity.NoteTemplateTable)
+
+	if _, err := r.pgPool.Exec(ctx, query, templateID); err != nil {
+		r.logger.Error("failed to delete template", slog.String("op", "DeleteTemplate"), slog.String("err", err.Error()))
+		return err
+	}
+
+	r.logger.Info("template deleted successfully", slog.String("op", "DeleteTemplate"), slog.String("template_id", templateID.String()))
+	return nil
+}
+
+func (r *TemplateRepository) scanTemplates(rows pgx.Rows, op string) ([]templateEntity.NoteTemplateEntity, error) {
+	defer rows.Close()
+
+	templates := []templateEntity.NoteTemplateEntity{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			r.logger.Error("failed to scan template", slog.String("op", op), slog.String("err", err.Error()))
+			return nil, err
+		}
+		templates = append(templates, *template)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to iterate templates", slog.String("op", op), slog.String("err", err.Error()))
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func scanTemplate(row pgx.Row) (*templateEntity.NoteTemplateEntity, error) {
+	var template templateEntity.NoteTemplateEntity
+	var contentBytes, promptBytes []byte
+	err := row.Scan(
+		&template.ID,
+		&template.UserID,
+		&template.WorkspaceID,
+		&template.CreatedBy,
+		&template.Name,
+		&template.Description,
+		&template.Title,
+		&contentBytes,
+		&promptBytes,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(contentBytes, &template.Content); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(promptBytes, &template.Prompts); err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
@@ -0,0 +1,47 @@
+package template
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/internal/domain/template/repositories"
+)
+
+type Options struct {
+	PgPool           *pgxpool.Pool                      // PostgreSQL connection pool (required)
+	NoteService      services.NoteServiceInterface      // Note service, creates notes from templates (required)
+	UserService      services.UserServiceInterface      // User service, fills in the user placeholders (required)
+	WorkspaceService services.WorkspaceServiceInterface // Workspace service, decides who may use a template (required)
+	Logger           *slog.Logger                       // Slog logger instance (optional)
+}
+
+type TemplateDomain struct {
+	logger          *slog.Logger
+	templateService *services.TemplateService
+}
+
+func NewTemplateDomain(opts *Options) *TemplateDomain {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{}))
+	}
+
+	templateService := services.NewTemplateService(services.TemplateServiceOpts{
+		TemplateRepo:     repositories.NewTemplateRepository(opts.PgPool, logger),
+		NoteService:      opts.NoteService,
+		UserService:      opts.UserService,
+		WorkspaceService: opts.WorkspaceService,
+		Logger:           logger,
+	})
+
+	return &TemplateDomain{
+		logger:          logger,
+		templateService: templateService,
+	}
+}
+
+func (d *TemplateDomain) GetTemplateService() services.TemplateServiceInterface {
+	return d.templateService
+}
@@ -17,14 +17,46 @@ type UserEntity struct {
 	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt       *time.Time    `json:"updated_at" db:"updated_at"`
 	EmailVerifiedAt *time.Time    `json:"email_verified_at" db:"email_verified_at"`
+	LastLoginAt     *time.Time    `json:"last_login_at" db:"last_login_at"`
+
+	DeactivatedAt       *time.Time `json:"deactivated_at" db:"deactivated_at"`               // Set when deletion is requested, blocks sign in
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at" db:"deletion_scheduled_at"` // When the account will be hard deleted
 }
 
+// Roles stored in UserMetadata.Role
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type UserMetadata struct {
 	Timezone string `json:"timezone,omitempty"`
 	Role     string `json:"role,omitempty"`
 	// Add more metadata fields as needed
 }
 
+// UserProfileUpdate holds the profile fields a user can change, nil fields are left untouched
+type UserProfileUpdate struct {
+	DisplayName *string
+	Username    *string
+	Timezone    *string
+}
+
+// Account states accepted by AdminUserFilter.Status
+const (
+	UserStatusActive          = "active"
+	UserStatusDeactivated     = "deactivated"
+	UserStatusPendingDeletion = "pending_deletion"
+	UserStatusUnverified      = "unverified"
+)
+
+// AdminUserFilter narrows the user search available to admins, empty fields are ignored
+type AdminUserFilter struct {
+	Search string `query:"search"` // Matches display name, username or email
+	Role   string `query:"role"`
+	Status string `query:"status"`
+}
+
 type FilterUser struct {
 	Search *string `json:"search,omitempty" query:"search"`
 	Limit  int     `json:"limit,omitempty" query:"limit"`
@@ -48,3 +80,24 @@ func (u *UserEntity) AsUserModel() UserEntity {
 func (u *UserEntity) GetEmailVerifiedAt() *time.Time {
 	return u.EmailVerifiedAt
 }
+func (u *UserEntity) IsDeactivated() bool {
+	return u.DeactivatedAt != nil
+}
+func (u *UserEntity) GetStatus() string {
+	switch {
+	case u.DeletionScheduledAt != nil:
+		return UserStatusPendingDeletion
+	case u.DeactivatedAt != nil:
+		return UserStatusDeactivated
+	case u.EmailVerifiedAt == nil:
+		return UserStatusUnverified
+	default:
+		return UserStatusActive
+	}
+}
+func (u *UserEntity) GetRole() string {
+	if u.Metadata == nil || u.Metadata.Role == "" {
+		return RoleUser
+	}
+	return u.Metadata.Role
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -11,20 +12,43 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rayhan889/neatspace/internal/application/handler/dto"
 	userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
 	"github.com/rayhan889/neatspace/pkg/apputils"
 )
 
+var (
+	// ErrEmailAlreadyExists is returned when an email update violates the unique email index
+	ErrEmailAlreadyExists = errors.New("email already exists")
+	// ErrUsernameAlreadyExists is returned when a username update violates the unique username index
+	ErrUsernameAlreadyExists = errors.New("username already exists")
+)
+
+// Postgres error code for unique constraint violations
+const pgUniqueViolation = "23505"
+
 type UserRepositoryInterface interface {
 	PaginationUser(c *fiber.Ctx, p *apputils.Pagination) (data []dto.UserPagination, total int, err error)
 	CreateUser(ctx context.Context, user *userEntity.UserEntity) error
 	EmailExists(ctx context.Context, email string) (bool, error)
 	UsernameExists(ctx context.Context, username string) (bool, error)
 	GetUserByEmail(ctx context.Context, email string) (*userEntity.UserEntity, error)
+	GetUserByID(ctx context.Context, userID uuid.UUID) (*userEntity.UserEntity, error)
 	UpdateUserEmailVerifiedAt(ctx context.Context, userID uuid.UUID, now time.Time) error
+	UpdateUserLastLoginAt(ctx context.Context, userID uuid.UUID, at time.Time) error
+	UpdateUserEmail(ctx context.Context, userID uuid.UUID, email string, verifiedAt time.Time) error
+	UpdateUserProfile(ctx context.Context, userID uuid.UUID, update *userEntity.UserProfileUpdate) error
 	IsUserExistsByID(ctx context.Context, userID uuid.UUID) bool
+	ScheduleUserDeletion(ctx context.Context, userID uuid.UUID, deactivatedAt, scheduledAt time.Time) error
+	CancelUserDeletion(ctx context.Context, userID uuid.UUID) error
+	ListScheduledDeletions(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error)
+	PurgeScheduledUsers(ctx context.Context, userIDs []uuid.UUID, before time.Time) ([]userEntity.UserEntity, error)
+	SearchUsers(ctx context.Context, filter *userEntity.AdminUserFilter, p *apputils.Pagination) (data []userEntity.UserEntity, total int, err error)
+	UpdateUserRole(ctx context.Context, userID uuid.UUID, role string) error
+	DeactivateUser(ctx context.Context, userID uuid.UUID, at time.Time) error
+	ReactivateUser(ctx context.Context, userID uuid.UUID) error
 }
 
 var _ UserRepositoryInterface = (*UserRepository)(nil)
@@ -155,7 +179,7 @@ func (r *UserRepository) UsernameExists(ctx context.Context, username string) (b
 
 	query := fmt.Sprintf(`
 		SELECT EXISTS (
-			SELECT 1 FROM %s WHERE username = $1
+			SELECT 1 FROM %s WHERE LOWER(username) = LOWER($1)
 		)
 	`, userEntity.UserTable)
 
@@ -175,7 +199,8 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*use
 	var metadata userEntity.UserMetadata
 
 	query := fmt.Sprintf(`
-		SELECT id, display_name, username, metadata, email, email_verified_at, created_at, updated_at 
+		SELECT id, display_name, username, metadata, email, email_verified_at, created_at, updated_at, last_login_at, 
+			deactivated_at, deletion_scheduled_at 
 		FROM %s 
 		WHERE LOWER(email) = LOWER($1)
 	`, userEntity.UserTable)
@@ -192,6 +217,9 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*use
 		&user.EmailVerifiedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.LastLoginAt,
+		&user.DeactivatedAt,
+		&user.DeletionScheduledAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -212,6 +240,52 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*use
 	return &user, nil
 }
 
+func (r *UserRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (*userEntity.UserEntity, error) {
+	var user userEntity.UserEntity
+	var metadata userEntity.UserMetadata
+
+	query := fmt.Sprintf(`
+		SELECT id, display_name, username, metadata, email, email_verified_at, created_at, updated_at, last_login_at, 
+			deactivated_at, deletion_scheduled_at 
+		FROM %s 
+		WHERE id = $1
+	`, userEntity.UserTable)
+
+	row := r.pgPool.QueryRow(ctx, query, userID)
+	var metadataBytes []byte
+
+	err := row.Scan(
+		&user.ID,
+		&user.DisplayName,
+		&user.Username,
+		&metadataBytes,
+		&user.Email,
+		&user.EmailVerifiedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.LastLoginAt,
+		&user.DeactivatedAt,
+		&user.DeletionScheduledAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("failed to get user by id", slog.String("op", "GetUserByID"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if len(metadataBytes) > 0 {
+		if err := json.Unmarshal(metadataBytes, &metadata); err != nil {
+			r.logger.Error("failed to unmarshal user metadata", slog.String("op", "GetUserByID"), slog.String("error", err.Error()))
+			return nil, err
+		}
+		user.Metadata = &metadata
+	}
+
+	return &user, nil
+}
+
 func (r *UserRepository) UpdateUserEmailVerifiedAt(ctx context.Context, userID uuid.UUID, now time.Time) error {
 	query := fmt.Sprintf(`UPDATE %s SET email_verified_at = $1, updated_at = $2 WHERE id = $3`, userEntity.UserTable)
 
@@ -225,6 +299,94 @@ func (r *UserRepository) UpdateUserEmailVerifiedAt(ctx context.Context, userID u
 	return nil
 }
 
+func (r *UserRepository) UpdateUserLastLoginAt(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET last_login_at = $1 WHERE id = $2`, userEntity.UserTable)
+
+	_, err := r.pgPool.Exec(ctx, query, at, userID)
+	if err != nil {
+		r.logger.Error("failed to update user last login at", slog.String("op", "UpdateUserLastLoginAt"), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UpdateUserEmail(ctx context.Context, userID uuid.UUID, email string, verifiedAt time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET email = $1, email_verified_at = $2, updated_at = $3 WHERE id = $4`, userEntity.UserTable)
+
+	cmd, err := r.pgPool.Exec(ctx, query, email, verifiedAt, time.Now(), userID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation &&
+			(pgErr.ConstraintName == "idx_users_normalized_email" || pgErr.ConstraintName == "users_email_key") {
+			r.logger.Warn("email already taken", slog.String("op", "UpdateUserEmail"), slog.String("user_id", userID.String()))
+			return ErrEmailAlreadyExists
+		}
+		r.logger.Error("failed to update user email", slog.String("op", "UpdateUserEmail"), slog.String("error", err.Error()))
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		r.logger.Warn("no user found to update email", slog.String("op", "UpdateUserEmail"), slog.String("user_id", userID.String()))
+		return fmt.Errorf("no user found with id: %s", userID.String())
+	}
+
+	r.logger.Info("user email updated", slog.String("op", "UpdateUserEmail"), slog.String("user_id", userID.String()))
+	return nil
+}
+
+func (r *UserRepository) UpdateUserProfile(ctx context.Context, userID uuid.UUID, update *userEntity.UserProfileUpdate) error {
+	var sets []string
+	var args []interface{}
+	argPos := 1
+
+	if update.DisplayName != nil {
+		sets = append(sets, fmt.Sprintf("display_name = $%d", argPos))
+		args = append(args, *update.DisplayName)
+		argPos++
+	}
+	if update.Username != nil {
+		sets = append(sets, fmt.Sprintf("username = $%d", argPos))
+		args = append(args, *update.Username)
+		argPos++
+	}
+	if update.Timezone != nil {
+		// Merge into metadata so other keys such as role are preserved
+		sets = append(sets, fmt.Sprintf("metadata = COALESCE(metadata, '{}'::jsonb) || jsonb_build_object('timezone', $%d::text)", argPos))
+		args = append(args, *update.Timezone)
+		argPos++
+	}
+
+	if len(sets) == 0 {
+		return nil
+	}
+
+	sets = append(sets, fmt.Sprintf("updated_at = $%d", argPos))
+	args = append(args, time.Now())
+	argPos++
+
+	query := fmt.Sprintf(`UPDATE %s SET %s WHERE id = $%d`, userEntity.UserTable, strings.Join(sets, ", "), argPos)
+	args = append(args, userID)
+
+	cmd, err := r.pgPool.Exec(ctx, query, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation &&
+			(pgErr.ConstraintName == "idx_users_normalized_username" || pgErr.ConstraintName == "users_username_key") {
+			r.logger.Warn("username already taken", slog.String("op", "UpdateUserProfile"), slog.String("user_id", userID.String()))
+			return ErrUsernameAlreadyExists
+		}
+		r.logger.Error("failed to update user profile", slog.String("op", "UpdateUserProfile"), slog.String("error", err.Error()))
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		r.logger.Warn("no user found to update profile", slog.String("op", "UpdateUserProfile"), slog.String("user_id", userID.String()))
+		return fmt.Errorf("no user found with id: %s", userID.String())
+	}
+
+	r.logger.Info("user profile updated", slog.String("op", "UpdateUserProfile"), slog.String("user_id", userID.String()))
+	return nil
+}
+
 func (r *UserRepository) IsUserExistsByID(ctx context.Context, userID uuid.UUID) bool {
 	query := fmt.Sprintf(`SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1)`, userEntity.UserTable)
 
@@ -238,6 +400,260 @@ func (r *UserRepository) IsUserExistsByID(ctx context.Context, userID uuid.UUID)
 	return exists
 }
 
+func (r *UserRepository) ScheduleUserDeletion(ctx context.Context, userID uuid.UUID, deactivatedAt, scheduledAt time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET deactivated_at = $1, deletion_scheduled_at = $2 
+		WHERE id = $3 AND deletion_scheduled_at IS NULL`, userEntity.UserTable)
+
+	cmd, err := r.pgPool.Exec(ctx, query, deactivatedAt, scheduledAt, userID)
+	if err != nil {
+		r.logger.Error("failed to schedule user deletion", slog.String("op", "ScheduleUserDeletion"), slog.String("error", err.Error()))
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		r.logger.Warn("no user found to schedule deletion", slog.String("op", "ScheduleUserDeletion"), slog.String("user_id", userID.String()))
+		return fmt.Errorf("no user without scheduled deletion found with id: %s", userID.String())
+	}
+
+	r.logger.Info("user deletion scheduled", slog.String("op", "ScheduleUserDeletion"), slog.String("user_id", userID.String()), slog.Time("scheduled_at", scheduledAt))
+	return nil
+}
+
+func (r *UserRepository) CancelUserDeletion(ctx context.Context, userID uuid.UUID) error {
+	query := fmt.Sprintf(`UPDATE %s SET deactivated_at = NULL, deletion_scheduled_at = NULL 
+		WHERE id = $1 AND deletion_scheduled_at IS NOT NULL`, userEntity.UserTable)
+
+	cmd, err := r.pgPool.Exec(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to cancel user deletion", slog.String("op", "CancelUserDeletion"), slog.String("error", err.Error()))
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		r.logger.Warn("no scheduled deletion found to cancel", slog.String("op", "CancelUserDeletion"), slog.String("user_id", userID.String()))
+		return fmt.Errorf("no scheduled deletion found for user id: %s", userID.String())
+	}
+
+	r.logger.Info("user deletion cancelled", slog.String("op", "CancelUserDeletion"), slog.String("user_id", userID.String()))
+	return nil
+}
+
+// ListScheduledDeletions returns the ids of up to limit users whose deletion date has passed,
+// the longest overdue first
+func (r *UserRepository) ListScheduledDeletions(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error) {
+	query := fmt.Sprintf(`
+		SELECT id FROM %s 
+		WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= $1 
+		ORDER BY deletion_scheduled_at 
+		LIMIT $2
+	`, userEntity.UserTable)
+
+	rows, err := r.pgPool.Query(ctx, query, before, limit)
+	if err != nil {
+		r.logger.Error("failed to list scheduled deletions", slog.String("op", "ListScheduledDeletions"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	userIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		r.logger.Error("failed to scan scheduled deletions", slog.String("op", "ListScheduledDeletions"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+// PurgeScheduledUsers hard deletes the given users whose deletion date is still before before
+// and returns them. Accounts whose deletion was cancelled meanwhile, or that another instance
+// purged first, are left out. Dependent rows are removed through the foreign keys.
+func (r *UserRepository) PurgeScheduledUsers(ctx context.Context, userIDs []uuid.UUID, before time.Time) ([]userEntity.UserEntity, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %s 
+		WHERE id = ANY($1) AND deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= $2 
+		RETURNING id, display_name, email
+	`, userEntity.UserTable)
+
+	rows, err := r.pgPool.Query(ctx, query, userIDs, before)
+	if err != nil {
+		r.logger.Error("failed to purge scheduled users", slog.String("op", "PurgeScheduledUsers"), slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []userEntity.UserEntity
+	for rows.Next() {
+		var user userEntity.UserEntity
+		if err := rows.Scan(&user.ID, &user.DisplayName, &user.Email); err != nil {
+			r.logger.Error("failed to scan purged user", slog.String("op", "PurgeScheduledUsers"), slog.String("error", err.Error()))
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to purge scheduled users", slog.String("op", "PurgeScheduledUsers"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	for _, user := range users {
+		r.logger.Info("user purged", slog.String("op", "PurgeScheduledUsers"), slog.String("user_id", user.ID.String()))
+	}
+
+	return users, nil
+}
+
+// SearchUsers lists users matching the admin filter, newest first
+func (r *UserRepository) SearchUsers(ctx context.Context, filter *userEntity.AdminUserFilter, p *apputils.Pagination) (data []userEntity.UserEntity, total int, err error) {
+	where, args := r.adminQueryFilter(filter)
+
+	query := fmt.Sprintf(`
+		SELECT id, display_name, username, metadata, email, email_verified_at, created_at, updated_at, last_login_at, 
+			deactivated_at, deletion_scheduled_at 
+		FROM %s 
+		WHERE %s 
+		ORDER BY created_at DESC 
+		LIMIT $%d OFFSET $%d
+	`, userEntity.UserTable, where, len(args)+1, len(args)+2)
+
+	rows, err := r.pgPool.Query(ctx, query, append(args, p.Limit, p.Offset)...)
+	if err != nil {
+		r.logger.Error("failed to search users", slog.String("op", "SearchUsers"), slog.String("error", err.Error()))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user userEntity.UserEntity
+		var metadataBytes []byte
+
+		err := rows.Scan(
+			&user.ID,
+			&user.DisplayName,
+			&user.Username,
+			&metadataBytes,
+			&user.Email,
+			&user.EmailVerifiedAt,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&user.LastLoginAt,
+			&user.DeactivatedAt,
+			&user.DeletionScheduledAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan user row", slog.String("op", "SearchUsers"), slog.String("error", err.Error()))
+			return nil, 0, err
+		}
+		if len(metadataBytes) > 0 {
+			var metadata userEntity.UserMetadata
+			if err := json.Unmarshal(metadataBytes, &metadata); err != nil {
+				r.logger.Error("failed to unmarshal user metadata", slog.String("op", "SearchUsers"), slog.String("error", err.Error()))
+				return nil, 0, err
+			}
+			user.Metadata = &metadata
+		}
+
+		data = append(data, user)
+	}
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s`, userEntity.UserTable, where)
+
+	err = r.pgPool.QueryRow(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		r.logger.Error("failed to count users", slog.String("op", "SearchUsers"), slog.String("error", err.Error()))
+		return nil, 0, err
+	}
+
+	return data, total, nil
+}
+
+func (r *UserRepository) UpdateUserRole(ctx context.Context, userID uuid.UUID, role string) error {
+	query := fmt.Sprintf(`UPDATE %s SET metadata = COALESCE(metadata, '{}'::jsonb) || jsonb_build_object('role', $1::text) 
+		WHERE id = $2`, userEntity.UserTable)
+
+	cmd, err := r.pgPool.Exec(ctx, query, role, userID)
+	if err != nil {
+		r.logger.Error("failed to update user role", slog.String("op", "UpdateUserRole"), slog.String("error", err.Error()))
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		r.logger.Warn("no user found to update role", slog.String("op", "UpdateUserRole"), slog.String("user_id", userID.String()))
+		return fmt.Errorf("no user found with id: %s", userID.String())
+	}
+
+	r.logger.Info("user role updated", slog.String("op", "UpdateUserRole"), slog.String("user_id", userID.String()), slog.String("role", role))
+	return nil
+}
+
+func (r *UserRepository) DeactivateUser(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET deactivated_at = $1 WHERE id = $2 AND deactivated_at IS NULL`, userEntity.UserTable)
+
+	cmd, err := r.pgPool.Exec(ctx, query, at, userID)
+	if err != nil {
+		r.logger.Error("failed to deactivate user", slog.String("op", "DeactivateUser"), slog.String("error", err.Error()))
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		r.logger.Warn("no active user found to deactivate", slog.String("op", "DeactivateUser"), slog.String("user_id", userID.String()))
+		return fmt.Errorf("no active user found with id: %s", userID.String())
+	}
+
+	r.logger.Info("user deactivated", slog.String("op", "DeactivateUser"), slog.String("user_id", userID.String()))
+	return nil
+}
+
+// ReactivateUser lifts a deactivation, cancelling any scheduled deletion with it
+func (r *UserRepository) ReactivateUser(ctx context.Context, userID uuid.UUID) error {
+	query := fmt.Sprintf(`UPDATE %s SET deactivated_at = NULL, deletion_scheduled_at = NULL 
+		WHERE id = $1 AND deactivated_at IS NOT NULL`, userEntity.UserTable)
+
+	cmd, err := r.pgPool.Exec(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to reactivate user", slog.String("op", "ReactivateUser"), slog.String("error", err.Error()))
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		r.logger.Warn("no deactivated user found to reactivate", slog.String("op", "ReactivateUser"), slog.String("user_id", userID.String()))
+		return fmt.Errorf("no deactivated user found with id: %s", userID.String())
+	}
+
+	r.logger.Info("user reactivated", slog.String("op", "ReactivateUser"), slog.String("user_id", userID.String()))
+	return nil
+}
+
+func (r *UserRepository) adminQueryFilter(filter *userEntity.AdminUserFilter) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+
+	if filter == nil {
+		return conditions[0], args
+	}
+
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		args = append(args, "%"+search+"%")
+		conditions = append(conditions, fmt.Sprintf("(display_name ILIKE $%[1]d OR username ILIKE $%[1]d OR email ILIKE $%[1]d)", len(args)))
+	}
+
+	switch filter.Role {
+	case userEntity.RoleAdmin:
+		args = append(args, fmt.Sprintf(`{"role":"%s"}`, userEntity.RoleAdmin))
+		conditions = append(conditions, fmt.Sprintf("metadata @> $%d", len(args)))
+	case userEntity.RoleUser:
+		// Users without a role in their metadata are regular users
+		args = append(args, fmt.Sprintf(`{"role":"%s"}`, userEntity.RoleAdmin))
+		conditions = append(conditions, fmt.Sprintf("(metadata IS NULL OR NOT metadata @> $%d)", len(args)))
+	}
+
+	switch filter.Status {
+	case userEntity.UserStatusActive:
+		conditions = append(conditions, "deactivated_at IS NULL AND email_verified_at IS NOT NULL")
+	case userEntity.UserStatusDeactivated:
+		conditions = append(conditions, "deactivated_at IS NOT NULL AND deletion_scheduled_at IS NULL")
+	case userEntity.UserStatusPendingDeletion:
+		conditions = append(conditions, "deletion_scheduled_at IS NOT NULL")
+	case userEntity.UserStatusUnverified:
+		conditions = append(conditions, "deactivated_at IS NULL AND email_verified_at IS NULL")
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
 func (r *UserRepository) queryFilter(c *fiber.Ctx, baseQuery string) (string, []interface{}) {
 	if baseQuery == "" {
 		baseQuery = "WHERE 1=1"
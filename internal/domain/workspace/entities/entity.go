@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WorkspaceTable       = "public.workspaces"
+	WorkspaceMemberTable = "public.workspace_members"
+)
+
+// Membership roles, from most to least privileged
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+var roleRanks = map[string]int{
+	RoleOwner:  4,
+	RoleAdmin:  3,
+	RoleEditor: 2,
+	RoleViewer: 1,
+}
+
+// IsValidRole reports whether role is a known membership role
+func IsValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// RoleAtLeast reports whether role grants at least the permissions of min
+func RoleAtLeast(role, min string) bool {
+	return roleRanks[role] >= roleRanks[min] && roleRanks[role] > 0
+}
+
+type WorkspaceEntity struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	OwnerID   uuid.UUID  `json:"owner_id" db:"owner_id"`
+	Personal  bool       `json:"personal" db:"personal"` // Created with the account, can't be shared or deleted
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WorkspaceWithRole is a workspace together with the role of the user who listed it
+type WorkspaceWithRole struct {
+	WorkspaceEntity
+	Role string `json:"role" db:"role"`
+}
+
+type WorkspaceMemberEntity struct {
+	WorkspaceID uuid.UUID  `json:"workspace_id" db:"workspace_id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	Role        string     `json:"role" db:"role"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WorkspaceMemberDetail is a membership joined with the member's account
+type WorkspaceMemberDetail struct {
+	WorkspaceMemberEntity
+	DisplayName string  `json:"display_name" db:"display_name"`
+	Username    *string `json:"username" db:"username"`
+	Email       string  `json:"email" db:"email"`
+}
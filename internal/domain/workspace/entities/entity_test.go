@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleAtLeast(t *testing.T) {
+	t.Run("HigherRoleSatisfiesLower", func(t *testing.T) {
+		assert.True(t, RoleAtLeast(RoleOwner, RoleAdmin))
+		assert.True(t, RoleAtLeast(RoleAdmin, RoleEditor))
+		assert.True(t, RoleAtLeast(RoleEditor, RoleViewer))
+	})
+
+	t.Run("SameRoleSatisfiesItself", func(t *testing.T) {
+		assert.True(t, RoleAtLeast(RoleViewer, RoleViewer))
+	})
+
+	t.Run("LowerRoleIsRejected", func(t *testing.T) {
+		assert.False(t, RoleAtLeast(RoleViewer, RoleEditor))
+		assert.False(t, RoleAtLeast(RoleAdmin, RoleOwner))
+	})
+
+	t.Run("UnknownRoleIsRejected", func(t *testing.T) {
+		assert.False(t, RoleAtLeast("", RoleViewer))
+		assert.False(t, RoleAtLeast("guest", ""))
+		assert.False(t, IsValidRole("guest"))
+	})
+}
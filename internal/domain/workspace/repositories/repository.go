@@ -0,0 +1,476 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	authEntity "github.com/rayhan889/neatspace/internal/domain/auth/entities"
+	userEntity "github.com/rayhan889/neatspace/internal/domain/user/entities"
+	workspaceEntity "github.com/rayhan889/neatspace/internal/domain/workspace/entities"
+)
+
+// ErrAlreadyMember is returned when adding a user who is already a member of the workspace
+var ErrAlreadyMember = errors.New("user is already a workspace member")
+
+// Postgres error code for unique constraint violations
+const pgUniqueViolation = "23505"
+
+const workspaceColumns = `id, name, owner_id, personal, created_at, updated_at`
+
+type WorkspaceRepositoryInterface interface {
+	CreateWorkspace(ctx context.Context, workspace *workspaceEntity.WorkspaceEntity) error
+	CreatePersonalWorkspace(ctx context.Context, workspace *workspaceEntity.WorkspaceEntity) error
+	GetWorkspaceByID(ctx context.Context, workspaceID uuid.UUID) (*workspaceEntity.WorkspaceEntity, error)
+	GetPersonalWorkspace(ctx context.Context, ownerID uuid.UUID) (*workspaceEntity.WorkspaceEntity, error)
+	ListWorkspacesByUserID(ctx context.Context, userID uuid.UUID) ([]workspaceEntity.WorkspaceWithRole, error)
+	UpdateWorkspaceName(ctx context.Context, workspaceID uuid.UUID, name string) error
+	DeleteWorkspace(ctx context.Context, workspaceID uuid.UUID) error
+
+	GetMember(ctx context.Context, workspaceID, userID uuid.UUID) (*workspaceEntity.WorkspaceMemberEntity, error)
+	ListMembers(ctx context.Context, workspaceID uuid.UUID) ([]workspaceEntity.WorkspaceMemberDetail, error)
+	AddMember(ctx context.Context, member *workspaceEntity.WorkspaceMemberEntity) error
+	UpdateMemberRole(ctx context.Context, workspaceID, userID uuid.UUID, role string) error
+	RemoveMember(ctx context.Context, workspaceID, userID uuid.UUID) error
+	TransferOwnership(ctx context.Context, workspaceID, fromUserID, toUserID uuid.UUID) (bool, error)
+
+	CreateInvitation(ctx context.Context, token *authEntity.OneTimeToken) error
+	GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*authEntity.OneTimeToken, error)
+	ListInvitations(ctx context.Context, workspaceID uuid.UUID) ([]authEntity.OneTimeToken, error)
+	DeleteInvitation(ctx context.Context, workspaceID, invitationID uuid.UUID) (bool, error)
+	DeleteInvitationsForEmail(ctx context.Context, workspaceID uuid.UUID, email string) error
+}
+
+var _ WorkspaceRepositoryInterface = (*WorkspaceRepository)(nil)
+
+type WorkspaceRepository struct {
+	pgPool *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewWorkspaceRepository(pgPool *pgxpool.Pool, logger *slog.Logger) *WorkspaceRepository {
+	return &WorkspaceRepository{
+		pgPool: pgPool,
+		logger: logger,
+	}
+}
+
+// CreateWorkspace stores the workspace and makes its owner a member with the owner role
+func (r *WorkspaceRepository) CreateWorkspace(ctx context.Context, workspace *workspaceEntity.WorkspaceEntity) error {
+	err := pgx.BeginFunc(ctx, r.pgPool, func(tx pgx.Tx) error {
+		query := fmt.Sprintf(`INSERT INTO %s (id, name, owner_id, personal, created_at) VALUES ($1, $2, $3, $4, $5)`, workspaceEntity.WorkspaceTable)
+		if _, err := tx.Exec(ctx, query, workspace.ID, workspace.Name, workspace.OwnerID, workspace.Personal, workspace.CreatedAt); err != nil {
+			return err
+		}
+
+		return r.insertOwner(ctx, tx, workspace)
+	})
+	if err != nil {
+		r.logger.Error("failed to create workspace", slog.String("op", "CreateWorkspace"), slog.String("error", err.Error()))
+		return err
+	}
+
+	r.logger.Info("workspace created", slog.String("op", "CreateWorkspace"), slog.String("workspace_id", workspace.ID.String()))
+	return nil
+}
+
+// CreatePersonalWorkspace creates the owner's personal workspace unless one already exists
+func (r *WorkspaceRepository) CreatePersonalWorkspace(ctx context.Context, workspace *workspaceEntity.WorkspaceEntity) error {
+	err := pgx.BeginFunc(ctx, r.pgPool, func(tx pgx.Tx) error {
+		query := fmt.Sprintf(`INSERT INTO %s (id, name, owner_id, personal, created_at) VALUES ($1, $2, $3, TRUE, $4)
+			ON CONFLICT (owner_id) WHERE personal DO NOTHING`, workspaceEntity.WorkspaceTable)
+		cmd, err := tx.Exec(ctx, query, workspace.ID, workspace.Name, workspace.OwnerID, workspace.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() == 0 {
+			return nil
+		}
+
+		return r.insertOwner(ctx, tx, workspace)
+	})
+	if err != nil {
+		r.logger.Error("failed to create personal workspace", slog.String("op", "CreatePersonalWorkspace"), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (r *WorkspaceRepository) insertOwner(ctx context.Context, tx pgx.Tx, workspace *workspaceEntity.WorkspaceEntity) error {
+	query := fmt.Sprintf(`INSERT INTO %s (workspace_id, user_id, role, created_at) VALUES ($1, $2, $3, $4)`, workspaceEntity.WorkspaceMemberTable)
+	_, err := tx.Exec(ctx, query, workspace.ID, workspace.OwnerID, workspaceEntity.RoleOwner, workspace.CreatedAt)
+	return err
+}
+
+func (r *WorkspaceRepository) GetWorkspaceByID(ctx context.Context, workspaceID uuid.UUID) (*workspaceEntity.WorkspaceEntity, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, workspaceColumns, workspaceEntity.WorkspaceTable)
+
+	workspace, err := scanWorkspace(r.pgPool.QueryRow(ctx, query, workspaceID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get workspace by id", slog.String("op", "GetWorkspaceByID"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+func (r *WorkspaceRepository) GetPersonalWorkspace(ctx context.Context, ownerID uuid.UUID) (*workspaceEntity.WorkspaceEntity, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE owner_id = $1 AND personal`, workspaceColumns, workspaceEntity.WorkspaceTable)
+
+	workspace, err := scanWorkspace(r.pgPool.QueryRow(ctx, query, ownerID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get personal workspace", slog.String("op", "GetPersonalWorkspace"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+// ListWorkspacesByUserID returns every workspace the user is a member of, personal workspace first
+func (r *WorkspaceRepository) ListWorkspacesByUserID(ctx context.Context, userID uuid.UUID) ([]workspaceEntity.WorkspaceWithRole, error) {
+	query := fmt.Sprintf(`
+		SELECT w.id, w.name, w.owner_id, w.personal, w.created_at, w.updated_at, m.role
+		FROM %s w
+		JOIN %s m ON m.workspace_id = w.id
+		WHERE m.user_id = $1
+		ORDER BY w.personal DESC, w.created_at
+	`, workspaceEntity.WorkspaceTable, workspaceEntity.WorkspaceMemberTable)
+
+	rows, err := r.pgPool.Query(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to query workspaces", slog.String("op", "ListWorkspacesByUserID"), slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workspaces []workspaceEntity.WorkspaceWithRole
+	for rows.Next() {
+		var workspace workspaceEntity.WorkspaceWithRole
+		err := rows.Scan(
+			&workspace.ID,
+			&workspace.Name,
+			&workspace.OwnerID,
+			&workspace.Personal,
+			&workspace.CreatedAt,
+			&workspace.UpdatedAt,
+			&workspace.Role,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan workspace row", slog.String("op", "ListWorkspacesByUserID"), slog.String("error", err.Error()))
+			return nil, err
+		}
+
+		workspaces = append(workspaces, workspace)
+	}
+
+	return workspaces, rows.Err()
+}
+
+func (r *WorkspaceRepository) UpdateWorkspaceName(ctx context.Context, workspaceID uuid.UUID, name string) error {
+	query := fmt.Sprintf(`UPDATE %s SET name = $1 WHERE id = $2`, workspaceEntity.WorkspaceTable)
+
+	if _, err := r.pgPool.Exec(ctx, query, name, workspaceID); err != nil {
+		r.logger.Error("failed to update workspace name", slog.String("op", "UpdateWorkspaceName"), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteWorkspace removes the workspace, its memberships and notes, and any pending invitations
+func (r *WorkspaceRepository) DeleteWorkspace(ctx context.Context, workspaceID uuid.UUID) error {
+	err := pgx.BeginFunc(ctx, r.pgPool, func(tx pgx.Tx) error {
+		invitationQuery := fmt.Sprintf(`DELETE FROM %s WHERE subject = $1 AND metadata @> jsonb_build_object('workspace_id', $2::text)`, authEntity.OneTimeTokenTable)
+		if _, err := tx.Exec(ctx, invitationQuery, authEntity.OneTimeTokenSubjectWorkspaceInvite, workspaceID.String()); err != nil {
+			return err
+		}
+
+		workspaceQuery := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, workspaceEntity.WorkspaceTable)
+		_, err := tx.Exec(ctx, workspaceQuery, workspaceID)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("failed to delete workspace", slog.String("op", "DeleteWorkspace"), slog.String("error", err.Error()))
+		return err
+	}
+
+	r.logger.Info("workspace deleted", slog.String("op", "DeleteWorkspace"), slog.String("workspace_id", workspaceID.String()))
+	return nil
+}
+
+func (r *WorkspaceRepository) GetMember(ctx context.Context, workspaceID, userID uuid.UUID) (*workspaceEntity.WorkspaceMemberEntity, error) {
+	query := fmt.Sprintf(`SELECT workspace_id, user_id, role, created_at, updated_at FROM %s WHERE workspace_id = $1 AND user_id = $2`, workspaceEntity.WorkspaceMemberTable)
+
+	var member workspaceEntity.WorkspaceMemberEntity
+	err := r.pgPool.QueryRow(ctx, query, workspaceID, userID).Scan(
+		&member.WorkspaceID,
+		&member.UserID,
+		&member.Role,
+		&member.CreatedAt,
+		&member.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get workspace member", slog.String("op", "GetMember"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+func (r *WorkspaceRepository) ListMembers(ctx context.Context, workspaceID uuid.UUID) ([]workspaceEntity.WorkspaceMemberDetail, error) {
+	query := fmt.Sprintf(`
+		SELECT m.workspace_id, m.user_id, m.role, m.created_at, m.updated_at, u.display_name, u.username, u.email
+		FROM %s m
+		JOIN %s u ON u.id = m.user_id
+		WHERE m.workspace_id = $1
+		ORDER BY m.created_at
+	`, workspaceEntity.WorkspaceMemberTable, userEntity.UserTable)
+
+	rows, err := r.pgPool.Query(ctx, query, workspaceID)
+	if err != nil {
+		r.logger.Error("failed to query workspace members", slog.String("op", "ListMembers"), slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []workspaceEntity.WorkspaceMemberDetail
+	for rows.Next() {
+		var member workspaceEntity.WorkspaceMemberDetail
+		err := rows.Scan(
+			&member.WorkspaceID,
+			&member.UserID,
+			&member.Role,
+			&member.CreatedAt,
+			&member.UpdatedAt,
+			&member.DisplayName,
+			&member.Username,
+			&member.Email,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan workspace member row", slog.String("op", "ListMembers"), slog.String("error", err.Error()))
+			return nil, err
+		}
+
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+func (r *WorkspaceRepository) AddMember(ctx context.Context, member *workspaceEntity.WorkspaceMemberEntity) error {
+	query := fmt.Sprintf(`INSERT INTO %s (workspace_id, user_id, role, created_at) VALUES ($1, $2, $3, $4)`, workspaceEntity.WorkspaceMemberTable)
+
+	_, err := r.pgPool.Exec(ctx, query, member.WorkspaceID, member.UserID, member.Role, member.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return ErrAlreadyMember
+		}
+		r.logger.Error("failed to add workspace member", slog.String("op", "AddMember"), slog.String("error", err.Error()))
+		return err
+	}
+
+	r.logger.Info("workspace member added", slog.String("op", "AddMember"), slog.String("workspace_id", member.WorkspaceID.String()), slog.String("user_id", member.UserID.String()))
+	return nil
+}
+
+func (r *WorkspaceRepository) UpdateMemberRole(ctx context.Context, workspaceID, userID uuid.UUID, role string) error {
+	query := fmt.Sprintf(`UPDATE %s SET role = $1 WHERE workspace_id = $2 AND user_id = $3`, workspaceEntity.WorkspaceMemberTable)
+
+	if _, err := r.pgPool.Exec(ctx, query, role, workspaceID, userID); err != nil {
+		r.logger.Error("failed to update workspace member role", slog.String("op", "UpdateMemberRole"), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (r *WorkspaceRepository) RemoveMember(ctx context.Context, workspaceID, userID uuid.UUID) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE workspace_id = $1 AND user_id = $2`, workspaceEntity.WorkspaceMemberTable)
+
+	if _, err := r.pgPool.Exec(ctx, query, workspaceID, userID); err != nil {
+		r.logger.Error("failed to remove workspace member", slog.String("op", "RemoveMember"), slog.String("error", err.Error()))
+		return err
+	}
+
+	r.logger.Info("workspace member removed", slog.String("op", "RemoveMember"), slog.String("workspace_id", workspaceID.String()), slog.String("user_id", userID.String()))
+	return nil
+}
+
+// TransferOwnership makes toUserID, who must be a member, the owner of the workspace and
+// fromUserID an admin. Returns false when fromUserID no longer owns the workspace.
+func (r *WorkspaceRepository) TransferOwnership(ctx context.Context, workspaceID, fromUserID, toUserID uuid.UUID) (bool, error) {
+	transferred := false
+	err := pgx.BeginFunc(ctx, r.pgPool, func(tx pgx.Tx) error {
+		ownerQuery := fmt.Sprintf(`UPDATE %s SET owner_id = $1 WHERE id = $2 AND owner_id = $3 AND NOT personal`, workspaceEntity.WorkspaceTable)
+		cmd, err := tx.Exec(ctx, ownerQuery, toUserID, workspaceID, fromUserID)
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() == 0 {
+			return nil
+		}
+
+		roleQuery := fmt.Sprintf(`
+			UPDATE %s SET role = CASE WHEN user_id = $2 THEN $4 ELSE $5 END
+			WHERE workspace_id = $1 AND user_id IN ($2, $3)`, workspaceEntity.WorkspaceMemberTable)
+		cmd, err = tx.Exec(ctx, roleQuery, workspaceID, toUserID, fromUserID, workspaceEntity.RoleOwner, workspaceEntity.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() != 2 {
+			return fmt.Errorf("user %s is not a member of workspace %s", toUserID.String(), workspaceID.String())
+		}
+
+		transferred = true
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("failed to transfer workspace ownership", slog.String("op", "TransferOwnership"), slog.String("error", err.Error()))
+		return false, err
+	}
+
+	if transferred {
+		r.logger.Info("workspace ownership transferred", slog.String("op", "TransferOwnership"), slog.String("workspace_id", workspaceID.String()), slog.String("user_id", toUserID.String()))
+	}
+	return transferred, nil
+}
+
+// CreateInvitation stores an invitation as a one-time token. Invitations have no user yet,
+// the workspace, role and inviter are kept in the token metadata.
+func (r *WorkspaceRepository) CreateInvitation(ctx context.Context, token *authEntity.OneTimeToken) error {
+	query := fmt.Sprintf(`INSERT INTO %s (id, user_id, subject, token_hash, relates_to, metadata, created_at, expires_at, last_sent_at) 
+	VALUES ($1, NULL, $2, $3, $4, $5, $6, $7, $8)`, authEntity.OneTimeTokenTable)
+
+	_, err := r.pgPool.Exec(ctx, query,
+		token.ID,
+		authEntity.OneTimeTokenSubjectWorkspaceInvite,
+		token.TokenHash,
+		token.RelatesTo,
+		token.Metadata,
+		token.CreatedAt,
+		token.ExpiresAt,
+		token.LastSentAt,
+	)
+	if err != nil {
+		r.logger.Error("failed to create workspace invitation", slog.String("op", "CreateInvitation"), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (r *WorkspaceRepository) GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*authEntity.OneTimeToken, error) {
+	query := fmt.Sprintf(`SELECT id, relates_to, metadata, created_at, expires_at FROM %s WHERE token_hash = $1 AND subject = $2`, authEntity.OneTimeTokenTable)
+
+	invitation, err := scanInvitation(r.pgPool.QueryRow(ctx, query, tokenHash, authEntity.OneTimeTokenSubjectWorkspaceInvite))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get workspace invitation", slog.String("op", "GetInvitationByTokenHash"), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// ListInvitations returns the unexpired invitations of the workspace, newest first
+func (r *WorkspaceRepository) ListInvitations(ctx context.Context, workspaceID uuid.UUID) ([]authEntity.OneTimeToken, error) {
+	query := fmt.Sprintf(`
+		SELECT id, relates_to, metadata, created_at, expires_at FROM %s
+		WHERE subject = $1 AND metadata @> jsonb_build_object('workspace_id', $2::text) AND expires_at > $3
+		ORDER BY created_at DESC
+	`, authEntity.OneTimeTokenTable)
+
+	rows, err := r.pgPool.Query(ctx, query, authEntity.OneTimeTokenSubjectWorkspaceInvite, workspaceID.String(), time.Now())
+	if err != nil {
+		r.logger.Error("failed to query workspace invitations", slog.String("op", "ListInvitations"), slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []authEntity.OneTimeToken
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			r.logger.Error("failed to scan workspace invitation row", slog.String("op", "ListInvitations"), slog.String("error", err.Error()))
+			return nil, err
+		}
+
+		invitations = append(invitations, *invitation)
+	}
+
+	return invitations, rows.Err()
+}
+
+// DeleteInvitation removes an invitation of the workspace, reporting whether it existed
+func (r *WorkspaceRepository) DeleteInvitation(ctx context.Context, workspaceID, invitationID uuid.UUID) (bool, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1 AND subject = $2 AND metadata @> jsonb_build_object('workspace_id', $3::text)`, authEntity.OneTimeTokenTable)
+
+	cmd, err := r.pgPool.Exec(ctx, query, invitationID, authEntity.OneTimeTokenSubjectWorkspaceInvite, workspaceID.String())
+	if err != nil {
+		r.logger.Error("failed to delete workspace invitation", slog.String("op", "DeleteInvitation"), slog.String("error", err.Error()))
+		return false, err
+	}
+
+	return cmd.RowsAffected() > 0, nil
+}
+
+func (r *WorkspaceRepository) DeleteInvitationsForEmail(ctx context.Context, workspaceID uuid.UUID, email string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE subject = $1 AND metadata @> jsonb_build_object('workspace_id', $2::text) AND lower(relates_to) = lower($3)`, authEntity.OneTimeTokenTable)
+
+	if _, err := r.pgPool.Exec(ctx, query, authEntity.OneTimeTokenSubjectWorkspaceInvite, workspaceID.String(), email); err != nil {
+		r.logger.Error("failed to delete workspace invitations", slog.String("op", "DeleteInvitationsForEmail"), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func scanWorkspace(row pgx.Row) (*workspaceEntity.WorkspaceEntity, error) {
+	var workspace workspaceEntity.WorkspaceEntity
+	err := row.Scan(
+		&workspace.ID,
+		&workspace.Name,
+		&workspace.OwnerID,
+		&workspace.Personal,
+		&workspace.CreatedAt,
+		&workspace.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+func scanInvitation(row pgx.Row) (*authEntity.OneTimeToken, error) {
+	invitation := authEntity.OneTimeToken{Subject: authEntity.OneTimeTokenSubjectWorkspaceInvite}
+	err := row.Scan(
+		&invitation.ID,
+		&invitation.RelatesTo,
+		&invitation.Metadata,
+		&invitation.CreatedAt,
+		&invitation.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
@@ -0,0 +1,69 @@
+package workspace
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rayhan889/neatspace/internal/application/services"
+	"github.com/rayhan889/neatspace/internal/audit"
+	"github.com/rayhan889/neatspace/internal/domain/workspace/repositories"
+	"github.com/rayhan889/neatspace/internal/notification"
+)
+
+type Options struct {
+	PgPool        *pgxpool.Pool                 // PostgreSQL connection pool (required)
+	UserService   services.UserServiceInterface // User service, resolves invited accounts (required)
+	AuditRecorder audit.RecorderInterface       // Audit trail for membership changes (required)
+	Logger        *slog.Logger                  // Slog logger instance (optional)
+	Mailer        *notification.Mailer          // Mailer for invitation emails (optional)
+	BaseURL       string                        // Base URL used in invitation links (optional)
+}
+
+type WorkspaceDomain struct {
+	logger           *slog.Logger
+	workspaceService *services.WorkspaceService
+}
+
+func NewWorkspaceDomain(opts *Options) *WorkspaceDomain {
+	if err := opts.validate(); err != nil {
+		panic("invalid workspace module options: " + err.Error())
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{}))
+	}
+
+	workspaceService := services.NewWorkspaceService(services.WorkspaceServiceOpts{
+		WorkspaceRepo: repositories.NewWorkspaceRepository(opts.PgPool, logger),
+		UserService:   opts.UserService,
+		AuditRecorder: opts.AuditRecorder,
+		Logger:        logger,
+		Mailer:        opts.Mailer,
+		BaseURL:       opts.BaseURL,
+	})
+
+	return &WorkspaceDomain{
+		logger:           logger,
+		workspaceService: workspaceService,
+	}
+}
+
+func (d *WorkspaceDomain) GetWorkspaceService() services.WorkspaceServiceInterface {
+	return d.workspaceService
+}
+
+func (opts *Options) validate() error {
+	if opts.PgPool == nil {
+		return errors.New("pgPool is required")
+	}
+	if opts.UserService == nil {
+		return errors.New("userService is required")
+	}
+	if opts.AuditRecorder == nil {
+		return errors.New("auditRecorder is required")
+	}
+	return nil
+}
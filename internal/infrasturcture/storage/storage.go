@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned when no object is stored under a key
+var ErrNotFound = errors.New("storage object not found")
+
+// Storage keeps binary objects, e.g. note attachments, under slash separated keys
+type Storage interface {
+	// Put stores size bytes read from r under key, replacing any existing object.
+	// A reader returning an error leaves no partial object behind.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the object stored under key, ErrNotFound if there is none
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key, deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// cleanKey rejects keys that would escape the storage root
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != key || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rayhan889/neatspace/pkg/testutils"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// testStorage checks the behaviour every backend has to share
+func testStorage(t *testing.T, s Storage) {
+	ctx := context.Background()
+
+	t.Run("PutOpenDelete", func(t *testing.T) {
+		require.NoError(t, s.Put(ctx, "attachments/ab/file", strings.NewReader("hello"), 5, "text/plain"))
+
+		r, err := s.Open(ctx, "attachments/ab/file")
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, "hello", string(data))
+
+		require.NoError(t, s.Delete(ctx, "attachments/ab/file"))
+		_, err = s.Open(ctx, "attachments/ab/file")
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		require.NoError(t, s.Put(ctx, "replace", strings.NewReader("one"), 3, "text/plain"))
+		require.NoError(t, s.Put(ctx, "replace", strings.NewReader("two"), 3, "text/plain"))
+
+		r, err := s.Open(ctx, "replace")
+		require.NoError(t, err)
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "two", string(data))
+	})
+
+	t.Run("FailedReadLeavesNothing", func(t *testing.T) {
+		r := io.MultiReader(strings.NewReader("partial"), errReader{})
+		require.Error(t, s.Put(ctx, "failed", r, 100, "text/plain"))
+
+		_, err := s.Open(ctx, "failed")
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("DeleteMissing", func(t *testing.T) {
+		require.NoError(t, s.Delete(ctx, "missing"))
+	})
+
+	t.Run("InvalidKeys", func(t *testing.T) {
+		for _, key := range []string{"", "../escape", "a/../../b", "/absolute", "a//b", `a\b`} {
+			require.Error(t, s.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"), key)
+			_, err := s.Open(ctx, key)
+			require.Error(t, err, key)
+		}
+	})
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestLocalStorage(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	testStorage(t, s)
+}
+
+func TestS3Storage(t *testing.T) {
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	addr, err := testutils.NewTestEnv(t).SetupMinIO()
+	require.NoError(t, err)
+
+	s, err := NewS3Storage(context.Background(), S3Config{
+		Endpoint:        addr,
+		Region:          "us-east-1",
+		Bucket:          "attachments",
+		AccessKeyID:     testutils.MinIOAccessKey,
+		SecretAccessKey: testutils.MinIOSecretKey,
+	})
+	require.NoError(t, err)
+
+	testStorage(t, s)
+}
@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// JobFunc is a unit of background work, it should return once ctx is cancelled
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	fn       JobFunc
+}
+
+// Runner runs registered jobs periodically until stopped. Jobs must be safe to run
+// on several instances at once, e.g. by claiming rows with FOR UPDATE SKIP LOCKED.
+type Runner struct {
+	logger *slog.Logger
+	jobs   []job
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRunner(logger *slog.Logger) *Runner {
+	return &Runner{
+		logger: logger,
+	}
+}
+
+// Every registers fn to run once at start and then every interval. Must be called before Start.
+func (r *Runner) Every(name string, interval time.Duration, fn JobFunc) {
+	r.jobs = append(r.jobs, job{name: name, interval: interval, fn: fn})
+}
+
+// Start launches all registered jobs in the background
+func (r *Runner) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go func(j job) {
+			defer r.wg.Done()
+			r.loop(ctx, j)
+		}(j)
+	}
+
+	r.logger.Info("Background jobs started", "count", len(r.jobs))
+}
+
+// Stop cancels all jobs and waits for running ones to return
+func (r *Runner) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+}
+
+func (r *Runner) loop(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		r.run(ctx, j)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) run(ctx context.Context, j job) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.logger.Error("background job panicked", slog.String("job", j.name), slog.Any("panic", rec))
+		}
+	}()
+
+	start := time.Now()
+	if err := j.fn(ctx); err != nil && ctx.Err() == nil {
+		r.logger.Error("background job failed", slog.String("job", j.name), slog.String("error", err.Error()))
+		return
+	}
+
+	r.logger.Debug("background job finished", slog.String("job", j.name), slog.Duration("took", time.Since(start)))
+}
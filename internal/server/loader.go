@@ -1,19 +1,34 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rayhan889/neatspace/internal/application/handler"
 	"github.com/rayhan889/neatspace/internal/application/middlewares"
+	"github.com/rayhan889/neatspace/internal/audit"
+	"github.com/rayhan889/neatspace/internal/collab"
 	"github.com/rayhan889/neatspace/internal/config"
+	adminDomain "github.com/rayhan889/neatspace/internal/domain/admin"
+	attachmentDomain "github.com/rayhan889/neatspace/internal/domain/attachment"
 	authDomain "github.com/rayhan889/neatspace/internal/domain/auth"
+	exportDomain "github.com/rayhan889/neatspace/internal/domain/export"
 	noteDomain "github.com/rayhan889/neatspace/internal/domain/note"
+	reminderDomain "github.com/rayhan889/neatspace/internal/domain/reminder"
+	templateDomain "github.com/rayhan889/neatspace/internal/domain/template"
 	userDomain "github.com/rayhan889/neatspace/internal/domain/user"
+	workspaceDomain "github.com/rayhan889/neatspace/internal/domain/workspace"
+	"github.com/rayhan889/neatspace/internal/infrasturcture/storage"
+	"github.com/rayhan889/neatspace/internal/jobs"
 	"github.com/rayhan889/neatspace/internal/notification"
+	"github.com/rayhan889/neatspace/pkg/apputils"
 )
 
 // Initialize application modules : containing services, repositories, etc.
-func (s *HTTPServer) initializeApplication(cfg *config.Config, pgPool *pgxpool.Pool, mailer *notification.Mailer, fiberApp *fiber.App) error {
+func (s *HTTPServer) initializeApplication(cfg *config.Config, pgPool *pgxpool.Pool, mailer *notification.Mailer, fiberApp *fiber.App, jobRunner *jobs.Runner) error {
 	fiberApp.Use(middlewares.CORSMiddleware(cfg))
 	fiberApp.Use(middlewares.RateLimitMiddleware(
 		cfg.App.RateLimitRequests, cfg.App.RateLimitBurstSize,
@@ -22,45 +37,339 @@ func (s *HTTPServer) initializeApplication(cfg *config.Config, pgPool *pgxpool.P
 	// Create api v1 group
 	apiV1Route := fiberApp.Group("/api/v1")
 
+	passwordPolicy, err := s.newPasswordPolicy(cfg)
+	if err != nil {
+		return err
+	}
+
+	attachmentStorage, err := s.newAttachmentStorage(cfg)
+	if err != nil {
+		return err
+	}
+	urlSigner := apputils.NewURLSigner(cfg.App.JWTSecretKey)
+
+	auditRecorder := audit.NewRecorder(pgPool, s.logger)
+	passwordHasher := apputils.NewPasswordHasherWithParams(apputils.Argon2Params{
+		Memory:      uint32(cfg.Security.Argon2Memory),
+		Iterations:  uint32(cfg.Security.Argon2Iterations),
+		Parallelism: uint8(cfg.Security.Argon2Parallelism),
+		SaltLength:  uint32(cfg.Security.Argon2SaltLength),
+		KeyLength:   uint32(cfg.Security.Argon2KeyLength),
+	})
+
 	// Load domain application
 	userDomain := userDomain.NewUserDomain(&userDomain.Options{
 		PgPool: pgPool,
 		Logger: s.logger,
 	})
 	authDomain := authDomain.NewAuthDomain(&authDomain.Options{
-		PgPool:       pgPool,
-		UserService:  userDomain.GetUserService(),
-		Logger:       s.logger,
-		Mailer:       mailer,
-		BaseURL:      cfg.GetAppBaseURL(),
-		JWTSecretKey: []byte(cfg.App.JWTSecretKey),
+		PgPool:                     pgPool,
+		UserService:                userDomain.GetUserService(),
+		AuditRecorder:              auditRecorder,
+		Logger:                     s.logger,
+		Mailer:                     mailer,
+		BaseURL:                    cfg.GetAppBaseURL(),
+		JWTSecretKey:               []byte(cfg.App.JWTSecretKey),
+		PasswordPolicy:             passwordPolicy,
+		AccountDeletionGracePeriod: time.Duration(cfg.Security.AccountDeletionGraceDays) * 24 * time.Hour,
+		ImpersonationExpiry:        time.Duration(cfg.Security.ImpersonationMinutes) * time.Minute,
+		PasswordHasher:             passwordHasher,
+	})
+	workspaceDomain := workspaceDomain.NewWorkspaceDomain(&workspaceDomain.Options{
+		PgPool:        pgPool,
+		UserService:   userDomain.GetUserService(),
+		AuditRecorder: auditRecorder,
+		Logger:        s.logger,
+		Mailer:        mailer,
+		BaseURL:       cfg.GetAppBaseURL(),
 	})
 	noteDomain := noteDomain.NewNoteDomain(&noteDomain.Options{
+		PgPool:             pgPool,
+		Logger:             s.logger,
+		UserService:        userDomain.GetUserService(),
+		WorkspaceService:   workspaceDomain.GetWorkspaceService(),
+		AuditRecorder:      auditRecorder,
+		Mailer:             mailer,
+		BaseURL:            cfg.GetAppBaseURL(),
+		PasswordHasher:     passwordHasher,
+		TombstoneRetention: time.Duration(cfg.Storage.NoteTombstoneRetentionDays) * 24 * time.Hour,
+	})
+	adminDomain := adminDomain.NewAdminDomain(&adminDomain.Options{
+		AuditRecorder: auditRecorder,
+		UserService:   userDomain.GetUserService(),
+		AuthService:   authDomain.GetAuthService(),
+		NoteService:   noteDomain.GetNoteService(),
+		Logger:        s.logger,
+	})
+	exportDomain := exportDomain.NewExportDomain(&exportDomain.Options{
+		PgPool:      pgPool,
+		UserService: userDomain.GetUserService(),
+		AuthService: authDomain.GetAuthService(),
+		NoteService: noteDomain.GetNoteService(),
+		Logger:      s.logger,
+		Mailer:      mailer,
+		BaseURL:     cfg.GetAppBaseURL(),
+		StoragePath: cfg.Storage.Path,
+		URLSigner:   urlSigner,
+		Expiry:      time.Duration(cfg.Storage.DataExportExpiryHours) * time.Hour,
+	})
+	attachmentDomain := attachmentDomain.NewAttachmentDomain(&attachmentDomain.Options{
 		PgPool:      pgPool,
+		NoteService: noteDomain.GetNoteService(),
+		Storage:     attachmentStorage,
 		Logger:      s.logger,
+		BaseURL:     cfg.GetAppBaseURL(),
+		URLSigner:   urlSigner,
+		URLExpiry:   time.Duration(cfg.Storage.AttachmentURLExpiryMinutes) * time.Minute,
+		MaxSize:     int64(cfg.Storage.AttachmentMaxSizeMB) << 20,
+		Quota:       int64(cfg.Storage.AttachmentQuotaMB) << 20,
+		OrphanGrace: time.Duration(cfg.Storage.AttachmentOrphanGraceHours) * time.Hour,
+	})
+	templateDomain := templateDomain.NewTemplateDomain(&templateDomain.Options{
+		PgPool:           pgPool,
+		NoteService:      noteDomain.GetNoteService(),
+		UserService:      userDomain.GetUserService(),
+		WorkspaceService: workspaceDomain.GetWorkspaceService(),
+		Logger:           s.logger,
+	})
+	reminderDomain := reminderDomain.NewReminderDomain(&reminderDomain.Options{
+		PgPool:      pgPool,
+		NoteService: noteDomain.GetNoteService(),
 		UserService: userDomain.GetUserService(),
+		Logger:      s.logger,
+		Mailer:      mailer,
+		BaseURL:     cfg.GetAppBaseURL(),
 	})
 
+	collabHub := collab.NewHub(collab.HubOpts{
+		Store:    noteDomain.GetNoteService(),
+		Sessions: authDomain.GetAuthService(),
+		Logger:   s.logger,
+	})
+
+	// Every account starts with a personal workspace for its notes
+	userDomain.GetUserService().OnUserCreated(workspaceDomain.GetWorkspaceService().CreatePersonalWorkspace)
+	// Notes shared with an email before its account existed
+	userDomain.GetUserService().OnUserCreated(noteDomain.GetNoteService().ResolvePendingShares)
+
+	// Shared workspaces outlive their owner, another member takes them over
+	userDomain.GetUserService().OnUserPurging(workspaceDomain.GetWorkspaceService().HandOverWorkspaces)
+
+	// Images in rendered notes load through signed links, pages can't send a session
+	noteDomain.GetNoteService().SignImagesWith(attachmentDomain.GetAttachmentService().ImageSigner)
+
+	// Archives of purged accounts live outside the database, remove them too
+	authDomain.GetAuthService().OnAccountPurged(exportDomain.GetExportService().RemoveUserExports)
+
+	reauthWindow := time.Duration(cfg.Security.ReauthWindowMinutes) * time.Minute
+
 	handler.NewAuthHandler(handler.AuthHandlerOpts{
 		RouteGroup:   apiV1Route,
 		AuthService:  authDomain.GetAuthService(),
 		JWTSecretKey: authDomain.GetJWTSecretKey(),
 		SigningAlg:   authDomain.GetSigningAlgo(),
+		Sessions:     authDomain.GetAuthService(),
+		ReauthWindow: reauthWindow,
 	})
 	handler.NewUserHandler(handler.UserHandlerOpts{
-		RouteGroup:  apiV1Route,
-		UserService: userDomain.GetUserService(),
+		RouteGroup:   apiV1Route,
+		UserService:  userDomain.GetUserService(),
+		AuthService:  authDomain.GetAuthService(),
+		JWTSecretKey: authDomain.GetJWTSecretKey(),
+		SigningAlg:   authDomain.GetSigningAlgo(),
+		Sessions:     authDomain.GetAuthService(),
+		ReauthWindow: reauthWindow,
+	})
+	handler.NewAdminHandler(handler.AdminHandlerOpts{
+		RouteGroup:   apiV1Route,
+		UserService:  userDomain.GetUserService(),
+		AuthService:  authDomain.GetAuthService(),
+		AdminService: adminDomain.GetAdminService(),
+		JWTSecretKey: authDomain.GetJWTSecretKey(),
+		SigningAlg:   authDomain.GetSigningAlgo(),
+		Sessions:     authDomain.GetAuthService(),
 	})
 	handler.NewNoteHandler(handler.NoteHandlerOpts{
 		RouteGroup:   apiV1Route,
 		NoteService:  noteDomain.GetNoteService(),
 		JWTSecretKey: authDomain.GetJWTSecretKey(),
 		SigningAlg:   authDomain.GetSigningAlgo(),
+		Sessions:     authDomain.GetAuthService(),
+	})
+	handler.NewAttachmentHandler(handler.AttachmentHandlerOpts{
+		RouteGroup:        apiV1Route,
+		AttachmentService: attachmentDomain.GetAttachmentService(),
+		JWTSecretKey:      authDomain.GetJWTSecretKey(),
+		SigningAlg:        authDomain.GetSigningAlgo(),
+		Sessions:          authDomain.GetAuthService(),
+	})
+	handler.NewTaskHandler(handler.TaskHandlerOpts{
+		RouteGroup:   apiV1Route,
+		NoteService:  noteDomain.GetNoteService(),
+		JWTSecretKey: authDomain.GetJWTSecretKey(),
+		SigningAlg:   authDomain.GetSigningAlgo(),
+		Sessions:     authDomain.GetAuthService(),
+	})
+	handler.NewTemplateHandler(handler.TemplateHandlerOpts{
+		RouteGroup:      apiV1Route,
+		TemplateService: templateDomain.GetTemplateService(),
+		JWTSecretKey:    authDomain.GetJWTSecretKey(),
+		SigningAlg:      authDomain.GetSigningAlgo(),
+		Sessions:        authDomain.GetAuthService(),
+	})
+	handler.NewReminderHandler(handler.ReminderHandlerOpts{
+		RouteGroup:      apiV1Route,
+		ReminderService: reminderDomain.GetReminderService(),
+		JWTSecretKey:    authDomain.GetJWTSecretKey(),
+		SigningAlg:      authDomain.GetSigningAlgo(),
+		Sessions:        authDomain.GetAuthService(),
+	})
+	handler.NewCollabHandler(handler.CollabHandlerOpts{
+		RouteGroup:   apiV1Route,
+		Hub:          collabHub,
+		NoteService:  noteDomain.GetNoteService(),
+		UserService:  userDomain.GetUserService(),
+		JWTSecretKey: authDomain.GetJWTSecretKey(),
+		SigningAlg:   authDomain.GetSigningAlgo(),
+		Sessions:     authDomain.GetAuthService(),
+	})
+	handler.NewSyncHandler(handler.SyncHandlerOpts{
+		RouteGroup:   apiV1Route,
+		NoteService:  noteDomain.GetNoteService(),
+		JWTSecretKey: authDomain.GetJWTSecretKey(),
+		SigningAlg:   authDomain.GetSigningAlgo(),
+		Sessions:     authDomain.GetAuthService(),
+	})
+	handler.NewWorkspaceHandler(handler.WorkspaceHandlerOpts{
+		RouteGroup:       apiV1Route,
+		WorkspaceService: workspaceDomain.GetWorkspaceService(),
+		JWTSecretKey:     authDomain.GetJWTSecretKey(),
+		SigningAlg:       authDomain.GetSigningAlgo(),
+		Sessions:         authDomain.GetAuthService(),
+	})
+	handler.NewExportHandler(handler.ExportHandlerOpts{
+		RouteGroup:    apiV1Route,
+		ExportService: exportDomain.GetExportService(),
+		JWTSecretKey:  authDomain.GetJWTSecretKey(),
+		SigningAlg:    authDomain.GetSigningAlgo(),
+		Sessions:      authDomain.GetAuthService(),
+	})
+
+	// Register background jobs
+	jobRunner.Every("purge-deleted-accounts", time.Duration(cfg.Jobs.AccountPurgeIntervalMinutes)*time.Minute, func(ctx context.Context) error {
+		purged, err := authDomain.GetAuthService().PurgeDeletedAccounts(ctx)
+		if purged > 0 {
+			s.logger.Info("Deleted accounts purged", "count", purged)
+		}
+		return err
+	})
+	jobRunner.Every("process-data-exports", time.Duration(cfg.Jobs.DataExportIntervalMinutes)*time.Minute, func(ctx context.Context) error {
+		processed, err := exportDomain.GetExportService().ProcessPendingExports(ctx)
+		if processed > 0 {
+			s.logger.Info("Data exports processed", "count", processed)
+		}
+		if err != nil {
+			return err
+		}
+
+		purged, err := exportDomain.GetExportService().PurgeExpiredExports(ctx)
+		if purged > 0 {
+			s.logger.Info("Expired data exports removed", "count", purged)
+		}
+		return err
+	})
+	jobRunner.Every("purge-audit-events", time.Duration(cfg.Jobs.AuditPurgeIntervalMinutes)*time.Minute, func(ctx context.Context) error {
+		retention := time.Duration(cfg.Security.AuditRetentionDays) * 24 * time.Hour
+		purged, err := auditRecorder.Purge(ctx, time.Now().Add(-retention))
+		if purged > 0 {
+			s.logger.Info("Expired audit events purged", "count", purged)
+		}
+		return err
+	})
+	jobRunner.Every("purge-deleted-notes", time.Duration(cfg.Jobs.NotePurgeIntervalMinutes)*time.Minute, func(ctx context.Context) error {
+		purged, err := noteDomain.GetNoteService().PurgeDeletedNotes(ctx)
+		if purged > 0 {
+			s.logger.Info("Deleted notes purged", "count", purged)
+		}
+		return err
+	})
+
+	jobRunner.Every("collect-orphaned-attachments", time.Duration(cfg.Jobs.AttachmentGCIntervalMinutes)*time.Minute, func(ctx context.Context) error {
+		collected, err := attachmentDomain.GetAttachmentService().CollectOrphanedAttachments(ctx)
+		if collected > 0 {
+			s.logger.Info("Orphaned attachments removed", "count", collected)
+		}
+		return err
+	})
+
+	jobRunner.Every("send-due-reminders", time.Duration(cfg.Jobs.ReminderIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		sent, err := reminderDomain.GetReminderService().ProcessDueReminders(ctx)
+		if sent > 0 {
+			s.logger.Info("Reminders sent", "count", sent)
+		}
+		return err
+	})
+
+	jobRunner.Every("persist-collab-snapshots", time.Duration(cfg.Jobs.CollabSnapshotIntervalSeconds)*time.Second, collabHub.Flush)
+
+	// Live editing sessions outlive the HTTP shutdown, save them and disconnect their peers
+	fiberApp.Hooks().OnShutdown(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return collabHub.Close(ctx)
 	})
 
 	// Register main application routes
-	serverHandler := handler.NewServerHandler(pgPool, s.logger)
+	serverHandler := handler.NewServerHandler(pgPool, s.logger, noteDomain.GetNoteService())
 	serverHandler.RegisterRoutes(fiberApp)
 
 	return nil
 }
+
+// Connect the storage attachments are kept in, S3 compatible or a directory below the storage path
+func (s *HTTPServer) newAttachmentStorage(cfg *config.Config) (storage.Storage, error) {
+	if cfg.Storage.Driver != config.StorageDriverS3 {
+		return storage.NewLocalStorage(cfg.Storage.Path)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s3, err := storage.NewS3Storage(ctx, storage.S3Config{
+		Endpoint:        cfg.Storage.S3Endpoint,
+		Region:          cfg.Storage.S3Region,
+		Bucket:          cfg.Storage.S3Bucket,
+		AccessKeyID:     cfg.Storage.S3AccessKeyID,
+		SecretAccessKey: cfg.Storage.S3SecretAccessKey,
+		UseSSL:          cfg.Storage.S3UseSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect attachment storage: %w", err)
+	}
+	s.logger.Info("S3 attachment storage connected", "endpoint", cfg.Storage.S3Endpoint, "bucket", cfg.Storage.S3Bucket)
+	return s3, nil
+}
+
+// Build the password policy from configuration, loading the breached password list if configured
+func (s *HTTPServer) newPasswordPolicy(cfg *config.Config) (*apputils.PasswordPolicy, error) {
+	policy := &apputils.PasswordPolicy{
+		MinLength:        cfg.Security.PasswordMinLength,
+		MaxLength:        cfg.Security.PasswordMaxLength,
+		RequireUpper:     cfg.Security.PasswordRequireUpper,
+		RequireLower:     cfg.Security.PasswordRequireLower,
+		RequireDigit:     cfg.Security.PasswordRequireDigit,
+		RequireSymbol:    cfg.Security.PasswordRequireSymbol,
+		DisallowIdentity: cfg.Security.PasswordDisallowIdentity,
+	}
+
+	if path := cfg.Security.BreachedPasswordsFile; path != "" {
+		list, err := apputils.LoadBreachedPasswordList(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load breached password list: %w", err)
+		}
+		policy.BreachedList = list
+		s.logger.Info("Breached password list loaded", "path", path, "hashes", list.Size())
+	}
+
+	return policy, nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/rayhan889/neatspace/internal/application/middlewares"
 	"github.com/rayhan889/neatspace/internal/config"
 	"github.com/rayhan889/neatspace/internal/infrasturcture/database"
+	"github.com/rayhan889/neatspace/internal/jobs"
 	"github.com/rayhan889/neatspace/internal/notification"
 	templateFS "github.com/rayhan889/neatspace/templates"
 )
@@ -68,6 +69,8 @@ func (s *HTTPServer) Run() error {
 		AppName:           fmt.Sprintf("Neatspace Backend App %s", application.Version),
 		EnablePrintRoutes: true,
 		ErrorHandler:      handler.Error,
+		// Room for the largest attachment plus the rest of the multipart form, never below the default
+		BodyLimit: max(fiber.DefaultBodyLimit, (cfg.Storage.AttachmentMaxSizeMB+1)<<20),
 	})
 
 	fiberApp.Use(logger.New())
@@ -75,8 +78,10 @@ func (s *HTTPServer) Run() error {
 	fiberApp.Use(middlewares.SecurityHeadersMiddleware())
 	fiberApp.Use(middlewares.LoggerMiddleware(s.logger))
 
+	jobRunner := jobs.NewRunner(s.logger)
+
 	// Initializing application modules
-	if err := s.initializeApplication(cfg, pg.Pool, mailer, fiberApp); err != nil {
+	if err := s.initializeApplication(cfg, pg.Pool, mailer, fiberApp, jobRunner); err != nil {
 		s.logger.Error("Failed to initialize application", "err", err)
 		return err
 	}
@@ -87,6 +92,9 @@ func (s *HTTPServer) Run() error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
 
+	// Start background jobs, they stop with the server
+	jobRunner.Start(ctx)
+
 	// Start server in background
 	serverErrCh := make(chan error, 1)
 	go func() {
@@ -120,6 +128,10 @@ func (s *HTTPServer) Run() error {
 		s.logger.Error("failed to shutdown HTTP server gracefully", "err", err)
 	}
 
+	// Wait for running background jobs before closing their resources
+	s.logger.Info("Stopping background jobs")
+	jobRunner.Stop()
+
 	// Close DB pool
 	s.logger.Info("Closing database connections")
 	pg.Close()
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Track when and how a session last proved the user's identity
+-- auth_time is refreshed on sign in and re-authentication, amr lists the
+-- authentication methods used (RFC 8176 values such as pwd, otp)
+-- ============================================================================
+ALTER TABLE public.sessions
+    ADD COLUMN IF NOT EXISTS auth_time TIMESTAMPTZ DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS amr TEXT[] NOT NULL DEFAULT '{}';
+
+-- Existing sessions authenticated when they were created
+UPDATE public.sessions SET auth_time = created_at WHERE auth_time IS NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE public.sessions
+    DROP COLUMN IF EXISTS amr,
+    DROP COLUMN IF EXISTS auth_time;
+
+-- +goose StatementEnd
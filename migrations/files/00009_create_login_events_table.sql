@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Create login events table and indexes
+-- One row per sign-in attempt, successful or not. user_id is NULL when the
+-- attempted identifier doesn't belong to any account.
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.login_events (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES public.users(id) ON DELETE CASCADE,
+    identifier TEXT NOT NULL, -- email (or other handle) used for the attempt
+    method TEXT NOT NULL, -- email_password, etc.
+    success BOOLEAN NOT NULL,
+    failure_reason TEXT DEFAULT NULL, -- unknown_user, invalid_password, email_not_verified, etc.
+    ip_address INET DEFAULT NULL,
+    user_agent TEXT DEFAULT NULL, -- summarized user agent, e.g. "Chrome v120.0 on macOS 10_15_7"
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_login_events_user_id_created_at ON public.login_events (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_login_events_created_at ON public.login_events (created_at);
+CREATE INDEX IF NOT EXISTS idx_login_events_ip_address ON public.login_events (ip_address) WHERE ip_address IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_login_events_ip_address;
+DROP INDEX IF EXISTS idx_login_events_created_at;
+DROP INDEX IF EXISTS idx_login_events_user_id_created_at;
+DROP TABLE IF EXISTS public.login_events;
+
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Account deletion with grace period
+-- deactivated_at blocks sign in as soon as deletion is requested, the account
+-- is hard deleted by a background job once deletion_scheduled_at has passed.
+-- ============================================================================
+ALTER TABLE public.users
+    ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMPTZ DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMPTZ DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_at ON public.users (deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_users_deletion_scheduled_at;
+ALTER TABLE public.users
+    DROP COLUMN IF EXISTS deletion_scheduled_at,
+    DROP COLUMN IF EXISTS deactivated_at;
+
+-- +goose StatementEnd
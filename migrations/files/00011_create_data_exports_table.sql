@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Create data exports table and indexes
+-- A personal data export is requested by the user, built asynchronously by a
+-- background job into a ZIP archive and removed once expires_at has passed.
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.data_exports (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, processing, completed, failed
+    file_path TEXT DEFAULT NULL, -- relative to the storage root
+    file_size BIGINT DEFAULT NULL,
+    error TEXT DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMPTZ DEFAULT NULL,
+    completed_at TIMESTAMPTZ DEFAULT NULL,
+    expires_at TIMESTAMPTZ DEFAULT NULL,
+    CONSTRAINT data_exports_status_check CHECK (status IN ('pending', 'processing', 'completed', 'failed'))
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id_created_at ON public.data_exports (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_data_exports_pending ON public.data_exports (created_at) WHERE status = 'pending';
+-- At most one export in progress per user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_exports_user_id_active ON public.data_exports (user_id) WHERE status IN ('pending', 'processing');
+CREATE INDEX IF NOT EXISTS idx_data_exports_expires_at ON public.data_exports (expires_at) WHERE expires_at IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_data_exports_expires_at;
+DROP INDEX IF EXISTS idx_data_exports_user_id_active;
+DROP INDEX IF EXISTS idx_data_exports_pending;
+DROP INDEX IF EXISTS idx_data_exports_user_id_created_at;
+DROP TABLE IF EXISTS public.data_exports;
+
+-- +goose StatementEnd
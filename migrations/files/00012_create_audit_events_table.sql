@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Create audit events table and indexes
+-- One row per audited action, starting with the actions administrators perform
+-- on user accounts. Actor and target have no foreign keys so the trail
+-- survives accounts being purged.
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.audit_events (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID DEFAULT NULL, -- user who performed the action
+    action TEXT NOT NULL, -- user.role_update, user.deactivate, user.sessions_revoke, etc.
+    target_type TEXT DEFAULT NULL, -- user
+    target_id UUID DEFAULT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}'::jsonb, -- action specific details, e.g. previous and new role
+    ip_address INET DEFAULT NULL,
+    user_agent TEXT DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON public.audit_events (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON public.audit_events (actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON public.audit_events (target_type, target_id, created_at DESC);
+
+-- ============================================================================
+-- Forced password reset
+-- While reset_required_at is set the password can't be used to sign in.
+-- ============================================================================
+ALTER TABLE public.user_passwords
+    ADD COLUMN IF NOT EXISTS reset_required_at TIMESTAMPTZ DEFAULT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE public.user_passwords
+    DROP COLUMN IF EXISTS reset_required_at;
+
+DROP INDEX IF EXISTS idx_audit_events_target;
+DROP INDEX IF EXISTS idx_audit_events_actor_id;
+DROP INDEX IF EXISTS idx_audit_events_created_at;
+DROP TABLE IF EXISTS public.audit_events;
+
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Sessions started by an admin impersonating the user
+-- impersonator_id is the admin acting as the user, NULL for regular sessions
+-- ============================================================================
+ALTER TABLE public.sessions
+    ADD COLUMN IF NOT EXISTS impersonator_id UUID DEFAULT NULL REFERENCES public.users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_impersonator_id ON public.sessions (impersonator_id) WHERE impersonator_id IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_sessions_impersonator_id;
+
+ALTER TABLE public.sessions
+    DROP COLUMN IF EXISTS impersonator_id;
+
+-- +goose StatementEnd
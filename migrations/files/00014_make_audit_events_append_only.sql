@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Extend audit events to every security relevant event
+-- Sign-ins, credential changes and deletions join the admin actions. Events
+-- can be recorded without an actor, e.g. failed sign-ins of unknown emails,
+-- and by an admin impersonating the actor.
+-- ============================================================================
+ALTER TABLE public.audit_events
+    ADD COLUMN IF NOT EXISTS impersonator_id UUID DEFAULT NULL; -- admin acting as the actor, if impersonating
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON public.audit_events (action, created_at DESC);
+
+-- Events can't be changed. Deleting is reserved for the retention purge, which
+-- opts in with SET LOCAL audit.allow_purge = 'on'.
+CREATE OR REPLACE FUNCTION fn_audit_events_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('audit.allow_purge', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_audit_events_append_only BEFORE UPDATE OR DELETE ON public.audit_events FOR EACH ROW EXECUTE FUNCTION fn_audit_events_append_only();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS trg_audit_events_append_only ON public.audit_events;
+DROP FUNCTION IF EXISTS fn_audit_events_append_only();
+DROP INDEX IF EXISTS idx_audit_events_action;
+
+ALTER TABLE public.audit_events
+    DROP COLUMN IF EXISTS impersonator_id;
+
+-- +goose StatementEnd
@@ -0,0 +1,101 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Create workspaces table and indexes
+-- Every user owns exactly one personal workspace, which can't be shared or
+-- deleted. Shared workspaces are handed over to another member when their
+-- owner's account is purged, and removed with it when nobody else is left.
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.workspaces (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    owner_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    personal BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_workspaces_owner_id ON public.workspaces (owner_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspaces_owner_id_personal ON public.workspaces (owner_id) WHERE personal;
+CREATE TRIGGER trg_workspaces_updated_at BEFORE UPDATE ON public.workspaces FOR EACH ROW EXECUTE FUNCTION fn_updated_at_value();
+
+-- ============================================================================
+-- Create workspace members table and indexes
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.workspace_members (
+    workspace_id UUID NOT NULL REFERENCES public.workspaces(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'admin', 'editor', 'viewer')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT NULL,
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON public.workspace_members (user_id);
+CREATE TRIGGER trg_workspace_members_updated_at BEFORE UPDATE ON public.workspace_members FOR EACH ROW EXECUTE FUNCTION fn_updated_at_value();
+
+-- ============================================================================
+-- Personal workspaces for existing users
+-- ============================================================================
+INSERT INTO public.workspaces (name, owner_id, personal, created_at)
+SELECT 'Personal', id, TRUE, created_at FROM public.users
+ON CONFLICT DO NOTHING;
+
+INSERT INTO public.workspace_members (workspace_id, user_id, role, created_at)
+SELECT id, owner_id, 'owner', created_at FROM public.workspaces WHERE personal
+ON CONFLICT DO NOTHING;
+
+-- ============================================================================
+-- Scope notes to a workspace, user_id stays as the author
+-- Notes without an author were never reachable and are removed.
+-- ============================================================================
+ALTER TABLE public.notes
+    ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES public.workspaces(id) ON DELETE CASCADE;
+
+UPDATE public.notes n SET workspace_id = w.id
+FROM public.workspaces w
+WHERE w.owner_id = n.user_id AND w.personal AND n.workspace_id IS NULL;
+
+DELETE FROM public.notes WHERE workspace_id IS NULL;
+
+ALTER TABLE public.notes
+    ALTER COLUMN workspace_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notes_workspace_id ON public.notes (workspace_id, created_at DESC);
+
+-- Notes belong to their workspace now. Purging an account removes its personal
+-- workspace with the notes in it, notes it wrote in workspaces of others stay
+-- there without an author.
+ALTER TABLE public.notes
+    DROP CONSTRAINT IF EXISTS notes_user_id_fkey,
+    ADD CONSTRAINT notes_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE SET NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM public.one_time_tokens WHERE subject = 'workspace_invitation';
+
+ALTER TABLE public.notes
+    DROP CONSTRAINT IF EXISTS notes_user_id_fkey,
+    ADD CONSTRAINT notes_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_notes_workspace_id;
+
+ALTER TABLE public.notes
+    DROP COLUMN IF EXISTS workspace_id;
+
+DROP TRIGGER IF EXISTS trg_workspace_members_updated_at ON public.workspace_members;
+DROP INDEX IF EXISTS idx_workspace_members_user_id;
+DROP TABLE IF EXISTS public.workspace_members;
+
+DROP TRIGGER IF EXISTS trg_workspaces_updated_at ON public.workspaces;
+DROP INDEX IF EXISTS idx_workspaces_owner_id_personal;
+DROP INDEX IF EXISTS idx_workspaces_owner_id;
+DROP TABLE IF EXISTS public.workspaces;
+
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Create note shares table and indexes
+-- A share grants one user access to a single note outside of its workspace.
+-- Shares to an email without an account stay pending (user_id NULL) until
+-- someone signs up with that email.
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.note_shares (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    note_id UUID NOT NULL REFERENCES public.notes(id) ON DELETE CASCADE,
+    user_id UUID DEFAULT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    email TEXT DEFAULT NULL, -- lowercased invitee email while the share is pending
+    permission TEXT NOT NULL CHECK (permission IN ('view', 'comment', 'edit')),
+    shared_by UUID DEFAULT NULL REFERENCES public.users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT NULL,
+    CONSTRAINT chk_note_shares_grantee CHECK ((user_id IS NULL) <> (email IS NULL))
+);
+
+-- Indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_note_shares_note_id_user_id ON public.note_shares (note_id, user_id) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_note_shares_note_id_email ON public.note_shares (note_id, email) WHERE email IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_note_shares_user_id ON public.note_shares (user_id) WHERE user_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_note_shares_email ON public.note_shares (email) WHERE email IS NOT NULL;
+CREATE TRIGGER trg_note_shares_updated_at BEFORE UPDATE ON public.note_shares FOR EACH ROW EXECUTE FUNCTION fn_updated_at_value();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS trg_note_shares_updated_at ON public.note_shares;
+DROP INDEX IF EXISTS idx_note_shares_email;
+DROP INDEX IF EXISTS idx_note_shares_user_id;
+DROP INDEX IF EXISTS idx_note_shares_note_id_email;
+DROP INDEX IF EXISTS idx_note_shares_note_id_user_id;
+DROP TABLE IF EXISTS public.note_shares;
+
+-- +goose StatementEnd
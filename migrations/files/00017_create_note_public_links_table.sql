@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Create note public links table and indexes
+-- A public link publishes one note read-only to anyone who knows its slug,
+-- optionally behind a password and until an expiry.
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.note_public_links (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    note_id UUID NOT NULL REFERENCES public.notes(id) ON DELETE CASCADE,
+    slug TEXT NOT NULL,
+    password_hash TEXT DEFAULT NULL, -- Argon2id hash, NULL when the link is not protected
+    expires_at TIMESTAMPTZ DEFAULT NULL, -- NULL when the link never expires
+    view_count BIGINT NOT NULL DEFAULT 0,
+    created_by UUID DEFAULT NULL REFERENCES public.users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_note_public_links_note_id ON public.note_public_links (note_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_note_public_links_slug ON public.note_public_links (slug);
+CREATE TRIGGER trg_note_public_links_updated_at BEFORE UPDATE ON public.note_public_links FOR EACH ROW EXECUTE FUNCTION fn_updated_at_value();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS trg_note_public_links_updated_at ON public.note_public_links;
+DROP INDEX IF EXISTS idx_note_public_links_slug;
+DROP INDEX IF EXISTS idx_note_public_links_note_id;
+DROP TABLE IF EXISTS public.note_public_links;
+
+-- +goose StatementEnd
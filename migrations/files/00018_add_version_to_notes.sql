@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Optimistic concurrency for notes
+-- version starts at 1 and is bumped by every update, clients send the version
+-- they edited so a stale save is rejected instead of overwriting newer changes.
+-- ============================================================================
+ALTER TABLE public.notes
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION fn_notes_version_value()
+RETURNS TRIGGER AS $$ BEGIN NEW.version = OLD.version + 1; RETURN NEW; END; $$
+LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_notes_version BEFORE UPDATE ON public.notes FOR EACH ROW EXECUTE FUNCTION fn_notes_version_value();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS trg_notes_version ON public.notes;
+DROP FUNCTION IF EXISTS fn_notes_version_value();
+ALTER TABLE public.notes
+    DROP COLUMN IF EXISTS version;
+
+-- +goose StatementEnd
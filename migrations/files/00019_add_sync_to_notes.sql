@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Offline sync of notes
+-- Deleted notes are kept as tombstones (deleted_at set) so syncing clients
+-- learn about the deletion, they are purged after a retention period.
+-- change_xid is the id of the transaction that last wrote the note. Syncing
+-- clients page through changes in change_xid order and only up to the oldest
+-- running transaction, so a change committed late can't be skipped.
+-- ============================================================================
+ALTER TABLE public.notes
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS change_xid XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX IF NOT EXISTS idx_notes_change_xid ON public.notes (change_xid, id);
+CREATE INDEX IF NOT EXISTS idx_notes_deleted_at ON public.notes (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION fn_notes_change_xid_value()
+RETURNS TRIGGER AS $$ BEGIN NEW.change_xid = pg_current_xact_id(); RETURN NEW; END; $$
+LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_notes_change_xid BEFORE UPDATE ON public.notes FOR EACH ROW EXECUTE FUNCTION fn_notes_change_xid_value();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS trg_notes_change_xid ON public.notes;
+DROP FUNCTION IF EXISTS fn_notes_change_xid_value();
+DROP INDEX IF EXISTS idx_notes_deleted_at;
+DROP INDEX IF EXISTS idx_notes_change_xid;
+DELETE FROM public.notes WHERE deleted_at IS NOT NULL;
+ALTER TABLE public.notes
+    DROP COLUMN IF EXISTS change_xid,
+    DROP COLUMN IF EXISTS deleted_at;
+
+-- +goose StatementEnd
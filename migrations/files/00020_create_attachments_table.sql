@@ -0,0 +1,78 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Create attachments table and note references
+-- An attachment is a file uploaded to a note, the object itself lives in the
+-- configured storage under storage_key. Notes refer to attachments through the
+-- src of their image nodes, note_attachment_refs tracks those references and is
+-- kept up to date by a trigger on every write of the note content.
+-- Attachments without references are garbage collected after a grace period,
+-- counted from the upload or the moment the last reference went away.
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.attachments (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID DEFAULT NULL REFERENCES public.users(id) ON DELETE SET NULL, -- Uploader, the size counts against their quota
+    note_id UUID DEFAULT NULL REFERENCES public.notes(id) ON DELETE SET NULL, -- Note the file was uploaded to
+    storage_key TEXT NOT NULL,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL, -- Sniffed from the content, not taken from the client
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    unreferenced_at TIMESTAMPTZ DEFAULT NULL -- When the last note referring to it dropped the reference
+);
+
+CREATE TABLE IF NOT EXISTS public.note_attachment_refs (
+    note_id UUID NOT NULL REFERENCES public.notes(id) ON DELETE CASCADE,
+    attachment_id UUID NOT NULL REFERENCES public.attachments(id) ON DELETE CASCADE,
+    PRIMARY KEY (note_id, attachment_id)
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_attachments_user_id ON public.attachments (user_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_note_id ON public.attachments (note_id);
+CREATE INDEX IF NOT EXISTS idx_note_attachment_refs_attachment_id ON public.note_attachment_refs (attachment_id);
+
+-- Image nodes refer to attachments by their download path /api/v1/attachments/<id>/content
+CREATE OR REPLACE FUNCTION fn_notes_attachment_refs()
+RETURNS TRIGGER AS $$
+BEGIN
+    WITH current_refs AS (
+        SELECT DISTINCT a.id
+        FROM jsonb_path_query(NEW.content, 'strict $.** ? (@.type == "image")') AS node
+        JOIN public.attachments a
+            ON a.id::text = lower(substring(node -> 'attrs' ->> 'src' FROM '/api/v1/attachments/([0-9a-fA-F-]{36})/'))
+    ),
+    dropped AS (
+        DELETE FROM public.note_attachment_refs r
+        WHERE r.note_id = NEW.id AND r.attachment_id NOT IN (SELECT id FROM current_refs)
+        RETURNING r.attachment_id
+    ),
+    added AS (
+        INSERT INTO public.note_attachment_refs (note_id, attachment_id)
+        SELECT NEW.id, id FROM current_refs
+        ON CONFLICT DO NOTHING
+    )
+    UPDATE public.attachments SET unreferenced_at = CURRENT_TIMESTAMP
+    WHERE id IN (SELECT attachment_id FROM dropped);
+
+    RETURN NULL;
+END; $$
+LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_notes_attachment_refs AFTER INSERT OR UPDATE OF content ON public.notes FOR EACH ROW EXECUTE FUNCTION fn_notes_attachment_refs();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS trg_notes_attachment_refs ON public.notes;
+DROP FUNCTION IF EXISTS fn_notes_attachment_refs();
+DROP INDEX IF EXISTS idx_note_attachment_refs_attachment_id;
+DROP INDEX IF EXISTS idx_attachments_note_id;
+DROP INDEX IF EXISTS idx_attachments_user_id;
+DROP TABLE IF EXISTS public.note_attachment_refs;
+DROP TABLE IF EXISTS public.attachments;
+
+-- +goose StatementEnd
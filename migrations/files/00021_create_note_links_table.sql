@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Create note links table and indexes
+-- A note links to another one through a [[Note Title]] mention or a link to the
+-- note's page. Links are extracted from the content whenever a note is saved.
+-- The target is deliberately not a foreign key, a link to a deleted note is
+-- kept and marked dangling so backlinks and outgoing links can still show it.
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.note_links (
+    source_note_id UUID NOT NULL REFERENCES public.notes(id) ON DELETE CASCADE,
+    target_note_id UUID NOT NULL,
+    target_title TEXT NOT NULL, -- Title shown by the link, kept in sync when the target is renamed
+    dangling BOOLEAN NOT NULL DEFAULT FALSE, -- The target doesn't exist or was deleted
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source_note_id, target_note_id)
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_note_links_target_note_id ON public.note_links (target_note_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_note_links_target_note_id;
+DROP TABLE IF EXISTS public.note_links;
+
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Create note templates table and indexes
+-- A template is a Tiptap document notes are created from, with placeholders
+-- like {{date}} filled in at that moment. It belongs either to a user, who
+-- alone sees it, or to a workspace, whose members all can use it.
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.note_templates (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID DEFAULT NULL REFERENCES public.users(id) ON DELETE CASCADE, -- Owner of a personal template
+    workspace_id UUID DEFAULT NULL REFERENCES public.workspaces(id) ON DELETE CASCADE, -- Workspace of a shared template
+    created_by UUID DEFAULT NULL REFERENCES public.users(id) ON DELETE SET NULL,
+    name TEXT NOT NULL,
+    description TEXT DEFAULT NULL,
+    title TEXT NOT NULL, -- Title of created notes, may hold placeholders
+    content JSONB NOT NULL,
+    prompts JSONB NOT NULL DEFAULT '[]'::JSONB, -- Values asked for when creating a note, see {{prompt.<name>}}
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT NULL,
+    CONSTRAINT chk_note_templates_owner CHECK ((user_id IS NULL) <> (workspace_id IS NULL))
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_note_templates_user_id ON public.note_templates (user_id);
+CREATE INDEX IF NOT EXISTS idx_note_templates_workspace_id ON public.note_templates (workspace_id);
+CREATE TRIGGER trg_note_templates_updated_at BEFORE UPDATE ON public.note_templates FOR EACH ROW EXECUTE FUNCTION fn_updated_at_value();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS trg_note_templates_updated_at ON public.note_templates;
+DROP INDEX IF EXISTS idx_note_templates_workspace_id;
+DROP INDEX IF EXISTS idx_note_templates_user_id;
+DROP TABLE IF EXISTS public.note_templates;
+
+-- +goose StatementEnd
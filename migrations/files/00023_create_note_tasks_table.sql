@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Create note tasks table and indexes
+-- Checklist items of notes, extracted from the taskItem nodes of the content
+-- whenever a note is saved, for a to-do view across notes. Task items have no
+-- id of their own, a task is its position among the note's task items.
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.note_tasks (
+    note_id UUID NOT NULL REFERENCES public.notes(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL, -- Index among the note's task items in document order
+    text TEXT NOT NULL,
+    checked BOOLEAN NOT NULL DEFAULT FALSE,
+    due_date DATE DEFAULT NULL, -- From the dueDate attribute of the task item
+    PRIMARY KEY (note_id, position)
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_note_tasks_open_due_date ON public.note_tasks (due_date) WHERE NOT checked;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_note_tasks_open_due_date;
+DROP TABLE IF EXISTS public.note_tasks;
+
+-- +goose StatementEnd
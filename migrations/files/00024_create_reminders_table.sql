@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Create reminders table and indexes
+-- A reminder emails its user about a note at fire_at, then again at every
+-- occurrence of its recurrence rule counted in the reminder's time zone.
+-- Due reminders are claimed by one instance at a time, a claim older than
+-- the lease is taken over by another instance.
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.reminders (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    note_id UUID NOT NULL REFERENCES public.notes(id) ON DELETE CASCADE,
+    fire_at TIMESTAMPTZ NOT NULL, -- First occurrence, recurrences are counted from it
+    timezone TEXT NOT NULL DEFAULT 'UTC', -- IANA zone occurrences are computed in
+    recurrence TEXT DEFAULT NULL, -- RFC 5545 RRULE subset, e.g. FREQ=WEEKLY;INTERVAL=2
+    next_fire_at TIMESTAMPTZ DEFAULT NULL, -- NULL once the last occurrence was sent
+    last_sent_at TIMESTAMPTZ DEFAULT NULL,
+    claim_id UUID DEFAULT NULL, -- Set while an instance is sending the reminder
+    claimed_at TIMESTAMPTZ DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT NULL
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_reminders_user_id ON public.reminders (user_id);
+CREATE INDEX IF NOT EXISTS idx_reminders_note_id ON public.reminders (note_id);
+CREATE INDEX IF NOT EXISTS idx_reminders_next_fire_at ON public.reminders (next_fire_at) WHERE next_fire_at IS NOT NULL;
+CREATE TRIGGER trg_reminders_updated_at BEFORE UPDATE ON public.reminders FOR EACH ROW EXECUTE FUNCTION fn_updated_at_value();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS trg_reminders_updated_at ON public.reminders;
+DROP INDEX IF EXISTS idx_reminders_next_fire_at;
+DROP INDEX IF EXISTS idx_reminders_note_id;
+DROP INDEX IF EXISTS idx_reminders_user_id;
+DROP TABLE IF EXISTS public.reminders;
+
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Count verification attempts of one-time tokens
+-- Short codes such as the reauthentication OTP are guessable, every check
+-- of a code uses up one attempt and the token is burned after the last one.
+-- ============================================================================
+ALTER TABLE public.one_time_tokens ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE public.one_time_tokens DROP COLUMN IF EXISTS attempts;
+
+-- +goose StatementEnd
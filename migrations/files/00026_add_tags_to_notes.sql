@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Add tags to notes
+-- Free-form labels without the leading #, set by the user or taken from the
+-- front matter of imported files. Repeats differing only in case are dropped
+-- before saving.
+-- ============================================================================
+ALTER TABLE public.notes ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- GIN index for finding the notes with a tag
+CREATE INDEX IF NOT EXISTS idx_notes_tags_gin ON public.notes USING GIN (tags);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_notes_tags_gin;
+ALTER TABLE public.notes DROP COLUMN IF EXISTS tags;
+
+-- +goose StatementEnd
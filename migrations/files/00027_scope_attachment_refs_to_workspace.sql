@@ -0,0 +1,90 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Scope attachment references to the workspace of the upload
+-- An attachment belongs to the workspace of the note it was uploaded to. A
+-- note only refers to attachments of its own workspace, an image pointing at
+-- another workspace's attachment is neither tracked nor signed, so knowing an
+-- attachment id doesn't give access to it.
+-- ============================================================================
+ALTER TABLE public.attachments ADD COLUMN IF NOT EXISTS workspace_id UUID DEFAULT NULL REFERENCES public.workspaces(id) ON DELETE SET NULL;
+
+UPDATE public.attachments a SET workspace_id = n.workspace_id
+FROM public.notes n
+WHERE n.id = a.note_id AND a.workspace_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_attachments_workspace_id ON public.attachments (workspace_id);
+
+-- Drop the references recorded across workspaces before the check existed
+WITH dropped AS (
+    DELETE FROM public.note_attachment_refs r
+    USING public.attachments a, public.notes n
+    WHERE a.id = r.attachment_id AND n.id = r.note_id AND a.workspace_id IS DISTINCT FROM n.workspace_id
+    RETURNING r.attachment_id
+)
+UPDATE public.attachments SET unreferenced_at = CURRENT_TIMESTAMP
+WHERE id IN (SELECT attachment_id FROM dropped);
+
+CREATE OR REPLACE FUNCTION fn_notes_attachment_refs()
+RETURNS TRIGGER AS $$
+BEGIN
+    WITH current_refs AS (
+        SELECT DISTINCT a.id
+        FROM jsonb_path_query(NEW.content, 'strict $.** ? (@.type == "image")') AS node
+        JOIN public.attachments a
+            ON a.id::text = lower(substring(node -> 'attrs' ->> 'src' FROM '/api/v1/attachments/([0-9a-fA-F-]{36})/'))
+            AND a.workspace_id = NEW.workspace_id
+    ),
+    dropped AS (
+        DELETE FROM public.note_attachment_refs r
+        WHERE r.note_id = NEW.id AND r.attachment_id NOT IN (SELECT id FROM current_refs)
+        RETURNING r.attachment_id
+    ),
+    added AS (
+        INSERT INTO public.note_attachment_refs (note_id, attachment_id)
+        SELECT NEW.id, id FROM current_refs
+        ON CONFLICT DO NOTHING
+    )
+    UPDATE public.attachments SET unreferenced_at = CURRENT_TIMESTAMP
+    WHERE id IN (SELECT attachment_id FROM dropped);
+
+    RETURN NULL;
+END; $$
+LANGUAGE plpgsql;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+CREATE OR REPLACE FUNCTION fn_notes_attachment_refs()
+RETURNS TRIGGER AS $$
+BEGIN
+    WITH current_refs AS (
+        SELECT DISTINCT a.id
+        FROM jsonb_path_query(NEW.content, 'strict $.** ? (@.type == "image")') AS node
+        JOIN public.attachments a
+            ON a.id::text = lower(substring(node -> 'attrs' ->> 'src' FROM '/api/v1/attachments/([0-9a-fA-F-]{36})/'))
+    ),
+    dropped AS (
+        DELETE FROM public.note_attachment_refs r
+        WHERE r.note_id = NEW.id AND r.attachment_id NOT IN (SELECT id FROM current_refs)
+        RETURNING r.attachment_id
+    ),
+    added AS (
+        INSERT INTO public.note_attachment_refs (note_id, attachment_id)
+        SELECT NEW.id, id FROM current_refs
+        ON CONFLICT DO NOTHING
+    )
+    UPDATE public.attachments SET unreferenced_at = CURRENT_TIMESTAMP
+    WHERE id IN (SELECT attachment_id FROM dropped);
+
+    RETURN NULL;
+END; $$
+LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_attachments_workspace_id;
+ALTER TABLE public.attachments DROP COLUMN IF EXISTS workspace_id;
+
+-- +goose StatementEnd
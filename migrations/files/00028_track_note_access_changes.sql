@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Access changes for offline sync
+-- The change feed of notes only follows writes to notes. When a user joins or
+-- leaves a workspace, changes role, or gets or loses a share, notes they can
+-- access change without being written. change_xid is the id of the last
+-- transaction that changed the user's access, sync positions older than it are
+-- expired and the client starts a full sync again. Rows of purged users are
+-- left behind, nothing syncs for them anymore.
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.note_access_changes (
+    user_id UUID PRIMARY KEY,
+    change_xid XID8 NOT NULL DEFAULT pg_current_xact_id()
+);
+
+CREATE OR REPLACE FUNCTION fn_note_access_changed()
+RETURNS TRIGGER AS $$
+DECLARE
+    affected UUID[];
+BEGIN
+    CASE TG_OP
+        WHEN 'INSERT' THEN affected := ARRAY[NEW.user_id];
+        WHEN 'DELETE' THEN affected := ARRAY[OLD.user_id];
+        ELSE affected := ARRAY[OLD.user_id, NEW.user_id];
+    END CASE;
+
+    INSERT INTO public.note_access_changes (user_id, change_xid)
+    SELECT u.id, pg_current_xact_id() FROM public.users u WHERE u.id = ANY(affected)
+    ON CONFLICT (user_id) DO UPDATE SET change_xid = EXCLUDED.change_xid;
+
+    RETURN NULL;
+END; $$
+LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_workspace_members_access_changed AFTER INSERT OR UPDATE OR DELETE ON public.workspace_members FOR EACH ROW EXECUTE FUNCTION fn_note_access_changed();
+CREATE TRIGGER trg_note_shares_access_changed AFTER INSERT OR UPDATE OR DELETE ON public.note_shares FOR EACH ROW EXECUTE FUNCTION fn_note_access_changed();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS trg_note_shares_access_changed ON public.note_shares;
+DROP TRIGGER IF EXISTS trg_workspace_members_access_changed ON public.workspace_members;
+DROP FUNCTION IF EXISTS fn_note_access_changed();
+DROP TABLE IF EXISTS public.note_access_changes;
+
+-- +goose StatementEnd
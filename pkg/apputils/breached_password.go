@@ -0,0 +1,97 @@
+package apputils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	sha1HexLength     = 40
+	breachedPrefixLen = 5
+)
+
+// BreachedPasswordList is an in-memory set of SHA-1 password hashes, bucketed by
+// their 5 character prefix the same way as the k-anonymity range API does.
+type BreachedPasswordList struct {
+	ranges map[string]map[string]struct{}
+	size   int
+}
+
+// LoadBreachedPasswordList reads a list of SHA-1 hashes from the given file.
+// Each line holds an uppercase or lowercase hex SHA-1, optionally followed by ":count"
+// (the format of the downloadable HIBP Pwned Passwords corpus). Blank lines and lines
+// starting with '#' are ignored.
+func LoadBreachedPasswordList(path string) (*BreachedPasswordList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open breached password list: %w", err)
+	}
+	defer f.Close()
+
+	list := &BreachedPasswordList{ranges: make(map[string]map[string]struct{})}
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(strings.TrimSpace(hash))
+		if len(hash) != sha1HexLength {
+			return nil, fmt.Errorf("invalid SHA-1 hash on line %d", lineNo)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("invalid SHA-1 hash on line %d: %w", lineNo, err)
+		}
+		list.add(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breached password list: %w", err)
+	}
+
+	return list, nil
+}
+
+// Contains reports whether the plain password is present in the list.
+func (l *BreachedPasswordList) Contains(password string) bool {
+	if l == nil {
+		return false
+	}
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, ok := l.ranges[hash[:breachedPrefixLen]]
+	if !ok {
+		return false
+	}
+	_, found := suffixes[hash[breachedPrefixLen:]]
+	return found
+}
+
+// Size returns the number of hashes loaded.
+func (l *BreachedPasswordList) Size() int {
+	if l == nil {
+		return 0
+	}
+	return l.size
+}
+
+func (l *BreachedPasswordList) add(hash string) {
+	prefix, suffix := hash[:breachedPrefixLen], hash[breachedPrefixLen:]
+	bucket, ok := l.ranges[prefix]
+	if !ok {
+		bucket = make(map[string]struct{})
+		l.ranges[prefix] = bucket
+	}
+	if _, exists := bucket[suffix]; !exists {
+		bucket[suffix] = struct{}{}
+		l.size++
+	}
+}
@@ -0,0 +1,50 @@
+package apputils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBreachedPasswordList(t *testing.T) {
+	writeList := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "pwned.txt")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("Load_And_Lookup", func(t *testing.T) {
+		path := writeList(t, "# comment\n"+
+			"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\n"+ // password
+			"\n"+
+			"7c4a8d09ca3762af61e59520943dc26494f8941b\n", // 123456 (lowercase, no count)
+		)
+
+		list, err := LoadBreachedPasswordList(path)
+		require.NoError(t, err)
+		assert.Equal(t, 2, list.Size())
+		assert.True(t, list.Contains("password"))
+		assert.True(t, list.Contains("123456"))
+		assert.False(t, list.Contains("Password"))
+	})
+
+	t.Run("InvalidLine_ReturnsError", func(t *testing.T) {
+		path := writeList(t, "not-a-hash\n")
+		_, err := LoadBreachedPasswordList(path)
+		require.Error(t, err)
+	})
+
+	t.Run("MissingFile_ReturnsError", func(t *testing.T) {
+		_, err := LoadBreachedPasswordList(filepath.Join(t.TempDir(), "missing.txt"))
+		require.Error(t, err)
+	})
+
+	t.Run("NilList_ContainsNothing", func(t *testing.T) {
+		var list *BreachedPasswordList
+		assert.False(t, list.Contains("password"))
+		assert.Equal(t, 0, list.Size())
+	})
+}
@@ -0,0 +1,36 @@
+package apputils
+
+import (
+	"context"
+	"net"
+)
+
+const ClientInfoContextKey ContextKey = "neatspace.client_info"
+
+// ClientInfo describes the client that issued the current request
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+
+	ImpersonatorID string // Admin acting as the signed-in user, empty unless impersonating
+}
+
+// WithClientInfo returns a copy of ctx carrying the client info
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, ClientInfoContextKey, info)
+}
+
+// ClientInfoFromContext returns the client info stored in ctx, or an empty value
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(ClientInfoContextKey).(ClientInfo)
+	return info
+}
+
+// IP parses the client IP address, returning nil when missing or invalid
+func (i ClientInfo) IP() *net.IP {
+	ip := net.ParseIP(i.IPAddress)
+	if ip == nil {
+		return nil
+	}
+	return &ip
+}
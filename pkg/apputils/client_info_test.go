@@ -0,0 +1,31 @@
+package apputils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientInfo(t *testing.T) {
+	t.Run("RoundTrip", func(t *testing.T) {
+		ctx := WithClientInfo(context.Background(), ClientInfo{IPAddress: "203.0.113.7", UserAgent: "curl/8.0"})
+
+		info := ClientInfoFromContext(ctx)
+		require.Equal(t, "203.0.113.7", info.IPAddress)
+		require.Equal(t, "curl/8.0", info.UserAgent)
+		require.NotNil(t, info.IP())
+		require.Equal(t, "203.0.113.7", info.IP().String())
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		info := ClientInfoFromContext(context.Background())
+		require.Empty(t, info.IPAddress)
+		require.Nil(t, info.IP())
+	})
+
+	t.Run("InvalidIP", func(t *testing.T) {
+		info := ClientInfo{IPAddress: "not-an-ip"}
+		require.Nil(t, info.IP())
+	})
+}
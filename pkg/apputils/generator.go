@@ -1,9 +1,10 @@
 package apputils
 
 import (
+	crand "crypto/rand"
 	"encoding/base64"
 	"fmt"
-	"math/rand"
+	"math/big"
 	"strings"
 	"time"
 )
@@ -29,7 +30,7 @@ func GenerateURLSafeToken(length int) (string, error) {
 			byteLen = 1
 		}
 		b := make([]byte, byteLen)
-		_, err := rand.Read(b)
+		_, err := crand.Read(b)
 		if err != nil {
 			return "", fmt.Errorf("failed to generate secure random token: %w", err)
 		}
@@ -51,3 +52,19 @@ func GenerateURLSafeToken(length int) (string, error) {
 	// Append the current unix timestamp (10 digits)
 	return fmt.Sprintf("%s%d", token, time.Now().Unix()), nil
 }
+
+// GenerateNumericOTP generates a cryptographically secure numeric code with exactly
+// 'digits' digits, keeping leading zeros. Returns an error if digits is not within 1-18.
+func GenerateNumericOTP(digits int) (string, error) {
+	if digits < 1 || digits > 18 {
+		return "", fmt.Errorf("invalid otp length: %d", digits)
+	}
+
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := crand.Int(crand.Reader, max)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate secure random otp: %w", err)
+	}
+
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
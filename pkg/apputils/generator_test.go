@@ -48,3 +48,23 @@ func TestGenerateURLSafeToken(t *testing.T) {
 		require.Empty(t, tok, "token should be empty when generation fails")
 	})
 }
+
+func TestGenerateNumericOTP(t *testing.T) {
+	t.Run("ValidLength", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			otp, err := GenerateNumericOTP(6)
+			require.NoError(t, err)
+			require.Regexp(t, `^[0-9]{6}$`, otp, "otp must be exactly 6 digits including leading zeros")
+		}
+	})
+
+	t.Run("InvalidLength", func(t *testing.T) {
+		otp, err := GenerateNumericOTP(0)
+		require.Error(t, err)
+		require.Empty(t, otp)
+
+		otp, err = GenerateNumericOTP(19)
+		require.Error(t, err)
+		require.Empty(t, otp)
+	})
+}
@@ -159,4 +159,25 @@ func TestJWTGenerator(t *testing.T) {
 		assert.Equal(t, expected, gen.GetHash(input))
 	})
 
+	t.Run("NestedActorClaim", func(t *testing.T) {
+		gen := NewJWTGenerator(JWTConfig{
+			SecretKey:         []byte("actor-secret"),
+			AccessTokenExpiry: time.Minute,
+		})
+
+		payload := map[string]any{
+			"sid": "session-1",
+			"act": map[string]any{"sub": "admin-1"},
+		}
+		tokenStr, err := gen.Sign(ctx, payload, "user-1")
+		require.NoError(t, err)
+
+		claims, err := gen.ParseAndValidate(ctx, tokenStr)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", claims[jwt.SubjectKey])
+
+		act, ok := claims["act"].(map[string]any)
+		require.True(t, ok, "act claim should decode as an object")
+		assert.Equal(t, "admin-1", act["sub"])
+	})
 }
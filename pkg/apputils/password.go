@@ -1,13 +1,14 @@
 package apputils
 
 import (
+	"crypto/rand"
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"math/rand"
 	"strings"
 
 	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Argon2Params holds the parameters for argon2id hashing
@@ -27,10 +28,10 @@ type PasswordHasher struct {
 // DefaultArgon2Params returns recommended default parameters
 func DefaultArgon2Params() Argon2Params {
 	return Argon2Params{
-		Memory:      16384, // 16 MB
-		Iterations:  4,     // 4 iterations
+		Memory:      65536, // 64 MB
+		Iterations:  3,     // 3 iterations
 		Parallelism: 2,     // 2 threads
-		SaltLength:  8,     // 8 bytes salt
+		SaltLength:  16,    // 16 bytes salt
 		KeyLength:   32,    // 32 bytes key length
 	}
 }
@@ -45,6 +46,11 @@ func NewPasswordHasherWithParams(params Argon2Params) *PasswordHasher {
 	return &PasswordHasher{params: params}
 }
 
+// Params returns the parameters used for new hashes
+func (h *PasswordHasher) Params() Argon2Params {
+	return h.params
+}
+
 // Hash hashes the password using argon2id and returns a PHC string
 func (h *PasswordHasher) Hash(password string) (string, error) {
 	salt := make([]byte, h.params.SaltLength)
@@ -53,8 +59,8 @@ func (h *PasswordHasher) Hash(password string) (string, error) {
 	}
 
 	hash := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
-	phc := fmt.Sprintf("$argon2id$v=19$m=%d,t=%d,p=%d$%s$%s",
-		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+	phc := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.params.Memory, h.params.Iterations, h.params.Parallelism,
 		base64.RawStdEncoding.EncodeToString(salt),
 		base64.RawStdEncoding.EncodeToString(hash),
 	)
@@ -62,35 +68,93 @@ func (h *PasswordHasher) Hash(password string) (string, error) {
 	return phc, nil
 }
 
-// Validate compares a plain password with a PHC argon2id hash
+// Validate compares a plain password with a PHC argon2id hash.
+// Legacy bcrypt hashes ($2a$, $2b$, $2y$) are accepted as well.
 func (h *PasswordHasher) Validate(password, hash string) (bool, error) {
+	if isBcryptHash(hash) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	params, salt, expectedHash, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	computedHash := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return subtleCompare(computedHash, expectedHash), nil
+}
+
+// NeedsRehash reports whether the stored hash should be replaced by a new one
+// using the current params, either because it is a legacy bcrypt hash or because
+// any of its argon2 params is weaker than the configured ones.
+func (h *PasswordHasher) NeedsRehash(hash string) bool {
+	if isBcryptHash(hash) {
+		return true
+	}
+
+	params, salt, _, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return false
+	}
+
+	return params.Memory < h.params.Memory ||
+		params.Iterations < h.params.Iterations ||
+		params.Parallelism < h.params.Parallelism ||
+		params.KeyLength < h.params.KeyLength ||
+		uint32(len(salt)) < h.params.SaltLength
+}
+
+// decodeArgon2Hash parses a PHC argon2id string into its params, salt and key
+func decodeArgon2Hash(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
 	parts := strings.Split(hash, "$")
 	if len(parts) != 6 {
-		return false, errors.New("invalid hash format")
+		return params, nil, nil, errors.New("invalid hash format")
+	}
+	if parts[1] != "argon2id" {
+		return params, nil, nil, fmt.Errorf("unsupported hash algorithm: %s", parts[1])
 	}
 
-	var memory uint32
-	var iterations uint32
-	var parallelism uint8
-	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism)
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, err
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version: %d", version)
+	}
+
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
 	if err != nil {
-		return false, err
+		return params, nil, nil, err
 	}
 
 	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
 	if err != nil {
-		return false, err
+		return params, nil, nil, err
 	}
 
-	expectedHash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
 	if err != nil {
-		return false, err
+		return params, nil, nil, err
 	}
 
-	keyLen := uint32(len(expectedHash))
-	computedHash := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, keyLen)
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
 
-	return subtleCompare(computedHash, expectedHash), nil
+	return params, salt, key, nil
+}
+
+func isBcryptHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
 }
 
 // subtleCompare does a constant-time comparison of two byte slices
@@ -0,0 +1,105 @@
+package apputils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy describes the rules a new password must satisfy
+type PasswordPolicy struct {
+	MinLength        int
+	MaxLength        int
+	RequireUpper     bool
+	RequireLower     bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	DisallowIdentity bool                  // Reject passwords containing the user's email or username
+	BreachedList     *BreachedPasswordList // Optional list of known breached passwords
+}
+
+// DefaultPasswordPolicy returns a length-based policy without character class requirements
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:        8,
+		MaxLength:        128,
+		DisallowIdentity: true,
+	}
+}
+
+// Validate checks the password against the policy and returns every rule it violates.
+// Identities are values that must not appear in the password (e.g. email, username).
+func (p *PasswordPolicy) Validate(password string, identities ...string) []ErrorValidation {
+	var errs []ErrorValidation
+	addErr := func(message string) {
+		errs = append(errs, ErrorValidation{Key: "password", Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		addErr(fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		addErr(fmt.Sprintf("password must be at most %d characters long", p.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		addErr("password must contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		addErr("password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		addErr("password must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		addErr("password must contain a symbol")
+	}
+
+	if p.DisallowIdentity && containsIdentity(password, identities) {
+		addErr("password must not contain your email or username")
+	}
+
+	if p.BreachedList != nil && p.BreachedList.Contains(password) {
+		addErr("password has appeared in a data breach, please choose another one")
+	}
+
+	return errs
+}
+
+// containsIdentity reports whether the password contains any identity value.
+// Emails are checked both as a whole and by their local part.
+func containsIdentity(password string, identities []string) bool {
+	lowered := strings.ToLower(password)
+	for _, identity := range identities {
+		identity = strings.ToLower(strings.TrimSpace(identity))
+		candidates := []string{identity}
+		if local, _, found := strings.Cut(identity, "@"); found {
+			candidates = append(candidates, local)
+		}
+		for _, c := range candidates {
+			// Very short values would match too many passwords to be meaningful
+			if utf8.RuneCountInString(c) < 3 {
+				continue
+			}
+			if strings.Contains(lowered, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package apputils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	t.Run("DefaultPolicy_AllowsLongPassphrase", func(t *testing.T) {
+		policy := DefaultPasswordPolicy()
+		errs := policy.Validate("correct horse battery staple and then some more words", "jake@example.com")
+		assert.Empty(t, errs)
+	})
+
+	t.Run("Length_Limits", func(t *testing.T) {
+		policy := &PasswordPolicy{MinLength: 10, MaxLength: 12}
+
+		errs := policy.Validate("short")
+		require.Len(t, errs, 1)
+		assert.Equal(t, "password", errs[0].Key)
+		assert.Contains(t, errs[0].Message, "at least 10")
+
+		errs = policy.Validate("this-is-way-too-long")
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, "at most 12")
+	})
+
+	t.Run("Length_CountsRunes", func(t *testing.T) {
+		policy := &PasswordPolicy{MinLength: 4, MaxLength: 4}
+		assert.Empty(t, policy.Validate("ñöüé"))
+	})
+
+	t.Run("CharacterClasses", func(t *testing.T) {
+		policy := &PasswordPolicy{
+			RequireUpper:  true,
+			RequireLower:  true,
+			RequireDigit:  true,
+			RequireSymbol: true,
+		}
+		assert.Len(t, policy.Validate("abcdefgh"), 3)
+		assert.Empty(t, policy.Validate("Abcdef1!"))
+	})
+
+	t.Run("DisallowIdentity", func(t *testing.T) {
+		policy := &PasswordPolicy{DisallowIdentity: true}
+
+		assert.NotEmpty(t, policy.Validate("my-JakeDoe-password", "jakedoe@example.com"))
+		assert.NotEmpty(t, policy.Validate("xx_the_user_xx", "someone@example.com", "the_user"))
+		assert.Empty(t, policy.Validate("unrelated-secret", "jakedoe@example.com", "jd"))
+
+		policy.DisallowIdentity = false
+		assert.Empty(t, policy.Validate("my-JakeDoe-password", "jakedoe@example.com"))
+	})
+
+	t.Run("BreachedList", func(t *testing.T) {
+		list := &BreachedPasswordList{ranges: make(map[string]map[string]struct{})}
+		// SHA-1 of "password"
+		list.add("5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8")
+
+		policy := &PasswordPolicy{BreachedList: list}
+		errs := policy.Validate("password")
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, "breach")
+		assert.Empty(t, policy.Validate("not-in-the-list"))
+	})
+}
@@ -3,6 +3,8 @@ package apputils
 import (
 	"testing"
 
+	"golang.org/x/crypto/bcrypt"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -56,4 +58,47 @@ func TestPasswordHasher(t *testing.T) {
 		require.NoError(t, err)
 		assert.True(t, ok, "expected validation to succeed with custom params")
 	})
+
+	t.Run("NeedsRehash_WeakerParams", func(t *testing.T) {
+		weak := NewPasswordHasherWithParams(Argon2Params{
+			Memory:      16384,
+			Iterations:  2,
+			Parallelism: 2,
+			SaltLength:  8,
+			KeyLength:   32,
+		})
+		hash, err := weak.Hash("legacy-secret")
+		require.NoError(t, err)
+
+		assert.False(t, weak.NeedsRehash(hash), "hash created with current params should not need rehash")
+		assert.True(t, NewPasswordHasher().NeedsRehash(hash), "hash with weaker params should need rehash")
+
+		// Stronger stored params are kept as is
+		strong, err := NewPasswordHasher().Hash("strong-secret")
+		require.NoError(t, err)
+		assert.False(t, weak.NeedsRehash(strong))
+	})
+
+	t.Run("Bcrypt_Legacy_Validate_And_NeedsRehash", func(t *testing.T) {
+		legacy, err := bcrypt.GenerateFromPassword([]byte("old-system-pass"), bcrypt.MinCost)
+		require.NoError(t, err)
+
+		hasher := NewPasswordHasher()
+		ok, err := hasher.Validate("old-system-pass", string(legacy))
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = hasher.Validate("wrong-pass", string(legacy))
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		assert.True(t, hasher.NeedsRehash(string(legacy)))
+	})
+
+	t.Run("UnsupportedAlgorithm_ReturnsError", func(t *testing.T) {
+		hasher := NewPasswordHasher()
+		_, err := hasher.Validate("any", "$argon2i$v=19$m=16,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5a2V5a2V5")
+		require.Error(t, err)
+		assert.False(t, hasher.NeedsRehash("not-a-valid-phc"))
+	})
 }
@@ -50,3 +50,22 @@ func ErrorValidationResponse(statusCode int, errors []ErrorValidation, errorMess
 		Version:      application.Version,
 	})
 }
+
+// ValidationError is returned by services when a request is well-formed but
+// violates business rules. It carries per-field messages for the client.
+type ValidationError struct {
+	Message string
+	Errors  []ErrorValidation
+}
+
+// NewValidationError creates a ValidationError with the given message and field errors.
+func NewValidationError(message string, errors []ErrorValidation) *ValidationError {
+	return &ValidationError{
+		Message: message,
+		Errors:  errors,
+	}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
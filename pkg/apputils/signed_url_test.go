@@ -0,0 +1,46 @@
+package apputils
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner("test-secret")
+	path := "/api/v1/exports/123/download"
+
+	t.Run("Valid", func(t *testing.T) {
+		q := signer.Sign(path, time.Now().Add(time.Hour))
+		require.NoError(t, signer.Verify(path, q.Get("expires"), q.Get("signature")))
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		q := signer.Sign(path, time.Now().Add(-time.Minute))
+		require.ErrorIs(t, signer.Verify(path, q.Get("expires"), q.Get("signature")), ErrSignatureExpired)
+	})
+
+	t.Run("TamperedPath", func(t *testing.T) {
+		q := signer.Sign(path, time.Now().Add(time.Hour))
+		require.ErrorIs(t, signer.Verify("/api/v1/exports/456/download", q.Get("expires"), q.Get("signature")), ErrSignatureInvalid)
+	})
+
+	t.Run("TamperedExpiry", func(t *testing.T) {
+		q := signer.Sign(path, time.Now().Add(time.Hour))
+		later := time.Now().Add(48 * time.Hour).Unix()
+		require.ErrorIs(t, signer.Verify(path, strconv.FormatInt(later, 10), q.Get("signature")), ErrSignatureInvalid)
+	})
+
+	t.Run("DifferentSecret", func(t *testing.T) {
+		q := signer.Sign(path, time.Now().Add(time.Hour))
+		other := NewURLSigner("other-secret")
+		require.ErrorIs(t, other.Verify(path, q.Get("expires"), q.Get("signature")), ErrSignatureInvalid)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		require.ErrorIs(t, signer.Verify(path, "soon", "abc"), ErrSignatureInvalid)
+		require.ErrorIs(t, signer.Verify(path, "123", ""), ErrSignatureInvalid)
+	})
+}
@@ -25,11 +25,19 @@ type TestEnv struct {
 	RedisClient *redis.Client
 	PGURL       string
 	RedisAddr   string
+	MinIOAddr   string
 
 	postgresC testcontainers.Container
 	redisC    testcontainers.Container
+	minioC    testcontainers.Container
 }
 
+// Credentials of the MinIO container started by SetupMinIO
+const (
+	MinIOAccessKey = "testuser"
+	MinIOSecretKey = "testpass123"
+)
+
 // NewTestEnv returns a new TestEnv.
 func NewTestEnv(t *testing.T) *TestEnv {
 	return &TestEnv{
@@ -136,6 +144,40 @@ func (te *TestEnv) SetupRedis() (*redis.Client, string, error) {
 	return redisClient, redisAddr, nil
 }
 
+// SetupMinIO starts a MinIO server as a stand-in for S3 and returns its host:port,
+// authenticate with MinIOAccessKey and MinIOSecretKey
+func (te *TestEnv) SetupMinIO() (string, error) {
+	t := te.T
+	ctx := te.Ctx
+
+	var err error
+	te.minioC, err = testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "minio/minio:latest",
+			ExposedPorts: []string{"9000/tcp"},
+			Env: map[string]string{
+				"MINIO_ROOT_USER":     MinIOAccessKey,
+				"MINIO_ROOT_PASSWORD": MinIOSecretKey,
+			},
+			Cmd:        []string{"server", "/data"},
+			WaitingFor: wait.ForHTTP("/minio/health/live").WithPort("9000/tcp").WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("start minio container: %w", err)
+	}
+	t.Cleanup(func() { _ = te.minioC.Terminate(ctx) })
+
+	minioEndpoint, err := te.minioC.Endpoint(ctx, "")
+	if err != nil {
+		return "", fmt.Errorf("get minio endpoint: %w", err)
+	}
+
+	te.MinIOAddr = strings.TrimPrefix(minioEndpoint, "tcp://")
+	return te.MinIOAddr, nil
+}
+
 func (te *TestEnv) SetupConfig() {
 	if te.PGURL != "" {
 		if err := os.Setenv("DATABASE_URL", te.PGURL); err != nil {
@@ -5,7 +5,8 @@ import "embed"
 // TemplateDir embeds all files matching the *html pattern into the binary.
 // This is not limited to email HTML templates — any file placed under the
 // emails/ directory and matching the embed pattern will be included at build
-// time and available at runtime via the embed.FS.
+// time and available at runtime via the embed.FS. Server rendered pages live
+// under pages/.
 //
-//go:embed emails/*.html
+//go:embed emails/*.html pages/*.html
 var TemplateDir embed.FS